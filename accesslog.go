@@ -0,0 +1,132 @@
+package rfc5424
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAccessLogSDID      = "http@32473"
+	defaultAccessLogMessageID = "access"
+)
+
+// AccessLog is net/http middleware writing a message for each request
+// served to Writer, usually the application's Logger, so that access logs
+// go through the same rules, routes and outputs as application logs. The
+// message has the MSGID "access", text such as "GET /index.html 200", and an
+// SD element, SDID, with the "method", "path", "status", "latency" (as
+// parsed by time.ParseDuration), "remote" address and response "bytes".
+//
+//	http.ListenAndServe(":8080", (&AccessLog{Writer: logger}).Handler(mux))
+type AccessLog struct {
+	Writer MessageWriter
+
+	// SDID is the ID of the request's SD element. If empty, "http@32473"
+	// is used.
+	SDID string
+
+	// Severity, if set, returns the severity of the message for a request
+	// answered with status. By default server errors (5xx) are Error and
+	// the others Info.
+	Severity func(status int) Severity
+
+	// Sample, if set, decides whether a request is logged, e.g. to log all
+	// the errors but only some of the successful requests. If nil, all
+	// requests are logged.
+	Sample func(r *http.Request, status int, latency time.Duration) bool
+
+	// Error, if set, is called with the errors returned by Writer
+	Error func(err error)
+}
+
+// Handler returns a handler serving requests with h and logging them
+func (a *AccessLog) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := TimeNow()
+		rw := &accessLogResponseWriter{ResponseWriter: w}
+		h.ServeHTTP(rw, r)
+		latency := TimeNow().Sub(start)
+		status := rw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if a.Sample != nil && !a.Sample(r, status, latency) {
+			return
+		}
+		if err := a.Writer.WriteMessage(a.message(r, status, latency, rw.bytes)); err != nil && a.Error != nil {
+			a.Error(err)
+		}
+	})
+}
+
+// message returns the message for a request
+func (a *AccessLog) message(r *http.Request, status int, latency time.Duration, bytes int64) Message {
+	sdID := a.SDID
+	if sdID == "" {
+		sdID = defaultAccessLogSDID
+	}
+	severity := Severity(Info)
+	if a.Severity != nil {
+		severity = a.Severity(status)
+	} else if status >= 500 {
+		severity = Error
+	}
+	path := strings.ToValidUTF8(r.URL.Path, "\ufffd")
+	m := defaultMessage(severity)
+	m.MessageID = defaultAccessLogMessageID
+	m.SetTextMessage(r.Method + " " + path + " " + strconv.Itoa(status))
+	m.AddDatum(sdID, "method", r.Method)
+	m.AddDatum(sdID, "path", path)
+	m.AddDatum(sdID, "status", strconv.Itoa(status))
+	m.AddDatum(sdID, "latency", latency.String())
+	m.AddDatum(sdID, "remote", r.RemoteAddr)
+	m.AddDatum(sdID, "bytes", strconv.FormatInt(bytes, 10))
+	return m
+}
+
+// accessLogResponseWriter records the status and length of a response
+type accessLogResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessLogResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher, for streamed responses
+func (w *accessLogResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, for websockets
+func (w *accessLogResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("rfc5424: the response writer cannot be hijacked")
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
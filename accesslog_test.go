@@ -0,0 +1,75 @@
+package rfc5424
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&AccessLogTest{})
+
+type AccessLogTest struct {
+}
+
+func (s *AccessLogTest) TestHandler(c *C) {
+	t := &recordingTransport{}
+	a := &AccessLog{Writer: TransportWriter(t)}
+	h := a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, "oops", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+
+	r := httptest.NewRequest("GET", "/index.html?q=1", nil)
+	r.RemoteAddr = "192.0.2.1:5555"
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/missing", nil))
+	c.Assert(t.sent, HasLen, 2)
+
+	m := t.sent[0]
+	c.Assert(m.Severity(), Equals, Severity(Info))
+	c.Assert(m.MessageID, Equals, "access")
+	c.Assert(m.TextMessage(), Equals, "GET /index.html 200")
+	c.Assert(m.StructuredData, HasLen, 1)
+	params := Params(m.StructuredData[0].Parameters)
+	c.Assert(m.StructuredData[0].ID, Equals, "http@32473")
+	c.Assert(params.Names(), DeepEquals, []string{"method", "path", "status", "latency", "remote", "bytes"})
+	c.Assert(params.Get("method"), Equals, "GET")
+	c.Assert(params.Get("path"), Equals, "/index.html")
+	c.Assert(params.Get("status"), Equals, "200")
+	c.Assert(params.Get("remote"), Equals, "192.0.2.1:5555")
+	c.Assert(params.Get("bytes"), Equals, "5")
+	_, err := m.GetDuration("http@32473", "latency")
+	c.Assert(err, IsNil)
+	c.Assert(m.assertValid(), IsNil)
+
+	m = t.sent[1]
+	c.Assert(m.Severity(), Equals, Severity(Error))
+	c.Assert(m.TextMessage(), Equals, "POST /missing 500")
+}
+
+func (s *AccessLogTest) TestSample(c *C) {
+	t := &recordingTransport{}
+	a := &AccessLog{
+		Writer:   TransportWriter(t),
+		SDID:     "req@32473",
+		Severity: func(status int) Severity { return Notice },
+		Sample: func(r *http.Request, status int, latency time.Duration) bool {
+			return status >= 400
+		},
+	}
+	h := a.Handler(http.NotFoundHandler())
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	c.Assert(t.sent, HasLen, 1)
+	c.Assert(t.sent[0].Severity(), Equals, Severity(Notice))
+	status, _ := t.sent[0].SDParam("req@32473", "status")
+	c.Assert(status, Equals, "404")
+
+	a.Sample = func(r *http.Request, status int, latency time.Duration) bool { return false }
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	c.Assert(t.sent, HasLen, 1)
+}
@@ -0,0 +1,95 @@
+package rfc5424
+
+import (
+	"net"
+	"strings"
+)
+
+// AccessList restricts the addresses a Server accepts messages from. An
+// address is allowed if it is in none of the Deny networks and, when Allow is
+// not empty, in at least one of the Allow networks.
+//
+// Only IP addresses are checked: messages received on Unix sockets are
+// always allowed. Other addresses without an IP address, such as those of
+// custom listeners, are denied when Allow is not empty.
+type AccessList struct {
+	Allow []*net.IPNet
+	Deny  []*net.IPNet
+}
+
+// ParseAccessList returns an AccessList from lists of networks in CIDR
+// notation (e.g. "10.0.0.0/8"). Single addresses are also accepted.
+func ParseAccessList(allow, deny []string) (*AccessList, error) {
+	al := &AccessList{}
+	var err error
+	if al.Allow, err = parseNetworks(allow); err != nil {
+		return nil, err
+	}
+	if al.Deny, err = parseNetworks(deny); err != nil {
+		return nil, err
+	}
+	return al, nil
+}
+
+func parseNetworks(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: cidr}
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Allows reports whether messages from addr are accepted
+func (al *AccessList) Allows(addr net.Addr) bool {
+	if _, ok := addr.(*net.UnixAddr); ok {
+		return true
+	}
+	ip := addrIP(addr)
+	if ip == nil {
+		// an allow-list fails closed
+		return len(al.Allow) == 0
+	}
+	for _, network := range al.Deny {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	if len(al.Allow) == 0 {
+		return true
+	}
+	for _, network := range al.Allow {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// addrIP returns the IP address of addr, or nil if it doesn't have one
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+	return nil
+}
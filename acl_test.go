@@ -0,0 +1,89 @@
+package rfc5424
+
+import (
+	"io/ioutil"
+	"log"
+	"net"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&AccessListTest{})
+
+type AccessListTest struct {
+}
+
+// fakeAddr is an address of a custom listener
+type fakeAddr string
+
+func (a fakeAddr) Network() string { return "fake" }
+func (a fakeAddr) String() string  { return string(a) }
+
+func (s *AccessListTest) TestAllows(c *C) {
+	al, err := ParseAccessList([]string{"10.0.0.0/8", "2001:db8::/32", "192.0.2.1"}, []string{"10.1.0.0/16"})
+	c.Assert(err, IsNil)
+
+	tcp := func(ip string) net.Addr { return &net.TCPAddr{IP: net.ParseIP(ip), Port: 514} }
+	c.Assert(al.Allows(tcp("10.2.3.4")), Equals, true)
+	c.Assert(al.Allows(tcp("10.1.3.4")), Equals, false)
+	c.Assert(al.Allows(tcp("2001:db8::1")), Equals, true)
+	c.Assert(al.Allows(&net.UDPAddr{IP: net.ParseIP("192.0.2.1")}), Equals, true)
+	c.Assert(al.Allows(&net.UDPAddr{IP: net.ParseIP("192.0.2.2")}), Equals, false)
+	c.Assert(al.Allows(&net.UnixAddr{Name: "/dev/log", Net: "unixgram"}), Equals, true)
+	// addresses of other kinds fail closed
+	c.Assert(al.Allows(fakeAddr("192.0.2.1")), Equals, false)
+	c.Assert(al.Allows(nil), Equals, false)
+
+	// With no Allow list everything not denied is allowed
+	al, err = ParseAccessList(nil, []string{"10.1.0.0/16"})
+	c.Assert(err, IsNil)
+	c.Assert(al.Allows(tcp("192.0.2.2")), Equals, true)
+	c.Assert(al.Allows(tcp("10.1.0.1")), Equals, false)
+	c.Assert(al.Allows(fakeAddr("192.0.2.1")), Equals, true)
+
+	_, err = ParseAccessList([]string{"10.0.0.0/33"}, nil)
+	c.Assert(err, NotNil)
+	_, err = ParseAccessList(nil, []string{"example.com"})
+	c.Assert(err, NotNil)
+}
+
+func (s *AccessListTest) TestServerRejectsSources(c *C) {
+	al, err := ParseAccessList([]string{"10.0.0.0/8"}, nil)
+	c.Assert(err, IsNil)
+	rejected := make(chan Source, 10)
+	h := make(chanHandler, 10)
+	srv := Server{
+		Handler:    h,
+		AccessList: al,
+		Rejected:   func(src Source) { rejected <- src },
+		ErrorLog:   log.New(ioutil.Discard, "", 0),
+	}
+	defer srv.Close()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	go srv.ServeUDP(conn)
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	c.Assert(err, IsNil)
+	defer client.Close()
+	_, err = client.Write([]byte("<34>1 0000-12-31T00:00:00Z - - - - - hello"))
+	c.Assert(err, IsNil)
+	src := <-rejected
+	c.Assert(src.Network, Equals, "udp")
+	c.Assert(src.RemoteAddr.String(), Equals, client.LocalAddr().String())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	go srv.ServeTCP(l)
+	stream, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	defer stream.Close()
+	c.Assert((<-rejected).Network, Equals, "tcp")
+
+	// The connection is closed without anything being read from it
+	stream.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = ioutil.ReadAll(stream)
+	c.Assert(err, IsNil)
+	c.Assert(len(h), Equals, 0)
+}
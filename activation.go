@@ -0,0 +1,142 @@
+package rfc5424
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket
+// activation, SD_LISTEN_FDS_START
+const listenFDsStart = 3
+
+// ErrNoActivatedSockets is returned by ServeActivated when the process was
+// not passed any sockets
+var ErrNoActivatedSockets = errors.New("rfc5424: no sockets passed by socket activation")
+
+// activationFiles returns the sockets passed to the process by systemd
+// socket activation (see sd_listen_fds(3)), or nil if there are none. The
+// environment variables are unset so that child processes do not take the
+// sockets to be theirs.
+func activationFiles() []*os.File {
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+	defer os.Unsetenv("LISTEN_FDNAMES")
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	files := make([]*os.File, n)
+	for i := range files {
+		fd := listenFDsStart + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		files[i] = os.NewFile(uintptr(fd), name)
+	}
+	return files
+}
+
+// ServeActivated serves the sockets passed to the process by systemd socket
+// activation, so that the server can be started on demand. Stream sockets
+// are served as by ServeTCP, or ServeTLS if TLSConfig has a certificate, and
+// datagram sockets as by ServeUDP. Once they are all being served,
+// readiness is reported (see NotifyReady).
+//
+// ServeActivated returns once all the sockets have stopped being served,
+// usually because Close was called. The error is the first one other than
+// ErrServerClosed, if any.
+func (srv *Server) ServeActivated() error {
+	files := activationFiles()
+	if len(files) == 0 {
+		return ErrNoActivatedSockets
+	}
+	return srv.serveFiles(files)
+}
+
+// serveFiles serves each of files, which are closed
+func (srv *Server) serveFiles(files []*os.File) error {
+	serves := make([]func() error, 0, len(files))
+	closers := make([]func() error, 0, len(files))
+	var err error
+	for _, f := range files {
+		var serve, closer func() error
+		serve, closer, err = srv.fileServer(f)
+		f.Close()
+		if err != nil {
+			break
+		}
+		serves = append(serves, serve)
+		closers = append(closers, closer)
+	}
+	if err != nil {
+		for _, closer := range closers {
+			closer()
+		}
+		return err
+	}
+
+	errs := make(chan error, len(serves))
+	for _, serve := range serves {
+		go func(serve func() error) { errs <- serve() }(serve)
+	}
+	if err := NotifyReady(); err != nil {
+		srv.logf("rfc5424: cannot notify readiness: %s", err)
+	}
+
+	err = ErrServerClosed
+	for range serves {
+		if serveErr := <-errs; serveErr != ErrServerClosed && err == ErrServerClosed {
+			err = serveErr
+		}
+	}
+	return err
+}
+
+// fileServer returns a function serving the socket f, and one closing it if
+// it is not served
+func (srv *Server) fileServer(f *os.File) (serve, closer func() error, err error) {
+	if l, err := net.FileListener(f); err == nil {
+		serve = func() error { return srv.ServeTCP(l) }
+		switch {
+		case srv.TLSConfig != nil && (len(srv.TLSConfig.Certificates) > 0 || srv.TLSConfig.GetCertificate != nil):
+			serve = func() error { return srv.ServeTLS(l, "", "") }
+		case l.Addr().Network() == "unix":
+			serve = func() error { return srv.ServeUnix(l) }
+		}
+		return serve, l.Close, nil
+	}
+	conn, err := net.FilePacketConn(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	network := conn.LocalAddr().Network()
+	return func() error { return srv.servePacket(conn, network) }, conn.Close, nil
+}
+
+// NotifyReady tells the service manager that started the process that it is
+// ready, as sd_notify(3) does with "READY=1". It does nothing if the process
+// was not started by a service manager expecting the notification, i.e.
+// NOTIFY_SOCKET is not set.
+func NotifyReady() error {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return nil
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte("READY=1"))
+	return err
+}
@@ -0,0 +1,74 @@
+package rfc5424
+
+import (
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&ActivationTest{})
+
+type ActivationTest struct {
+}
+
+func (s *ActivationTest) TestActivationFiles(c *C) {
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	os.Setenv("LISTEN_FDS", "2")
+	c.Assert(activationFiles(), IsNil)
+	c.Assert(os.Getenv("LISTEN_FDS"), Equals, "")
+
+	srv := Server{Handler: make(chanHandler)}
+	c.Assert(srv.ServeActivated(), Equals, ErrNoActivatedSockets)
+}
+
+func (s *ActivationTest) TestServeFiles(c *C) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	lf, err := l.(*net.TCPListener).File()
+	c.Assert(err, IsNil)
+	pf, err := pc.(*net.UDPConn).File()
+	c.Assert(err, IsNil)
+	l.Close()
+	pc.Close()
+
+	notify, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: c.MkDir() + "/notify", Net: "unixgram"})
+	c.Assert(err, IsNil)
+	defer notify.Close()
+	defer os.Unsetenv("NOTIFY_SOCKET")
+	os.Setenv("NOTIFY_SOCKET", notify.LocalAddr().String())
+
+	h := make(chanHandler, 10)
+	srv := Server{Handler: h, ErrorLog: log.New(ioutil.Discard, "", 0)}
+	done := make(chan error)
+	go func() { done <- srv.serveFiles([]*os.File{lf, pf}) }()
+
+	buf := make([]byte, 64)
+	notify.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := notify.Read(buf)
+	c.Assert(err, IsNil)
+	c.Assert(string(buf[:n]), Equals, "READY=1")
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	defer conn.Close()
+	_, err = conn.Write(octetCounted("<34>1 - - a - - -"))
+	c.Assert(err, IsNil)
+	c.Assert(receive(c, h).Message.AppName, Equals, "a")
+
+	client, err := net.Dial("udp", pc.LocalAddr().String())
+	c.Assert(err, IsNil)
+	defer client.Close()
+	_, err = client.Write([]byte("<34>1 - - b - - -"))
+	c.Assert(err, IsNil)
+	c.Assert(receive(c, h).Message.AppName, Equals, "b")
+
+	c.Assert(srv.Close(), IsNil)
+	c.Assert(<-done, Equals, ErrServerClosed)
+}
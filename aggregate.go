@@ -0,0 +1,235 @@
+package rfc5424
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/secureworks/errset"
+)
+
+const (
+	defaultAggregateWindow = time.Minute
+	defaultAggregateSDID   = "aggregate@32473"
+)
+
+// SDParamRef names a parameter of an SD element
+type SDParamRef struct {
+	ID   string `json:"id" yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+}
+
+// value returns the value of the first matching parameter of m
+func (ref SDParamRef) value(m Message) (string, bool) {
+	return m.SDParam(ref.ID, ref.Name)
+}
+
+// aggregateGroup holds the messages of one group of an Aggregator
+type aggregateGroup struct {
+	first      Message
+	count      int
+	start, end time.Time
+	min, max   float64
+	sum        float64
+	measured   int
+	timer      *time.Timer
+}
+
+// Aggregator is a MessageWriter that replaces repetitive messages, such as
+// authentication failures, by a summary per time window. Messages with the
+// same MSGID and the same values of the GroupBy parameters are grouped;
+// once Window has passed since the first of a group, a summary is written
+// to Writer. A group of a single message is written as it is.
+//
+// The summary is the first message of the group with an SD element, SDID,
+// holding the "count" of messages and the timestamps of the "first" and
+// "last". If Measure is set, the "min", "max" and "sum" of its numeric
+// values are added too.
+type Aggregator struct {
+	Writer MessageWriter
+
+	// Window is how long messages are grouped. If zero, one minute is
+	// used.
+	Window time.Duration
+
+	// MessageIDs lists the MSGIDs of the messages aggregated. Other
+	// messages are written immediately. If empty, all messages are
+	// aggregated.
+	MessageIDs []string
+
+	// GroupBy lists the parameters whose values, besides the MSGID, tell
+	// groups apart, e.g. the user of an authentication failure
+	GroupBy []SDParamRef
+
+	// Measure, if set, is a numeric parameter summarized by its minimum,
+	// maximum and sum
+	Measure *SDParamRef
+
+	// SDID is the ID of the summary's SD element. If empty,
+	// "aggregate@32473" is used.
+	SDID string
+
+	// Error, if set, is called with errors returned by Writer when a
+	// window ends
+	Error func(err error)
+
+	mu     sync.Mutex
+	groups map[string]*aggregateGroup
+	closed bool
+}
+
+// aggregates reports whether m is aggregated
+func (a *Aggregator) aggregates(m Message) bool {
+	if len(a.MessageIDs) == 0 {
+		return true
+	}
+	for _, id := range a.MessageIDs {
+		if id == m.MessageID {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the key of the group of m
+func (a *Aggregator) key(m Message) string {
+	parts := make([]string, 0, 1+len(a.GroupBy))
+	parts = append(parts, m.MessageID)
+	for _, ref := range a.GroupBy {
+		value, _ := ref.value(m)
+		parts = append(parts, value)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// WriteMessage adds m to its group, or writes it if it is not aggregated
+func (a *Aggregator) WriteMessage(m Message) error {
+	if !a.aggregates(m) {
+		return a.Writer.WriteMessage(m)
+	}
+	t := m.Timestamp
+	if t.IsZero() {
+		t = TimeNow()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return a.Writer.WriteMessage(m)
+	}
+	if a.groups == nil {
+		a.groups = map[string]*aggregateGroup{}
+	}
+	key := a.key(m)
+	g, ok := a.groups[key]
+	if !ok {
+		window := a.Window
+		if window <= 0 {
+			window = defaultAggregateWindow
+		}
+		g = &aggregateGroup{first: m.Clone(), start: t, end: t}
+		g.timer = time.AfterFunc(window, func() { a.flushGroup(key, g) })
+		a.groups[key] = g
+	}
+	g.count++
+	if t.Before(g.start) {
+		g.start = t
+	}
+	if t.After(g.end) {
+		g.end = t
+	}
+	if a.Measure != nil {
+		if s, ok := a.Measure.value(m); ok {
+			if v, err := strconv.ParseFloat(s, 64); err == nil {
+				if g.measured == 0 || v < g.min {
+					g.min = v
+				}
+				if g.measured == 0 || v > g.max {
+					g.max = v
+				}
+				g.sum += v
+				g.measured++
+			}
+		}
+	}
+	return nil
+}
+
+// flushGroup writes the summary of g when its window ends, unless it was
+// already flushed
+func (a *Aggregator) flushGroup(key string, g *aggregateGroup) {
+	a.mu.Lock()
+	if a.groups[key] != g {
+		a.mu.Unlock()
+		return
+	}
+	delete(a.groups, key)
+	a.mu.Unlock()
+
+	if err := a.Writer.WriteMessage(a.summary(g)); err != nil && a.Error != nil {
+		a.Error(err)
+	}
+}
+
+// summary returns the message written for g
+func (a *Aggregator) summary(g *aggregateGroup) Message {
+	if g.count == 1 {
+		return g.first
+	}
+	sdID := a.SDID
+	if sdID == "" {
+		sdID = defaultAggregateSDID
+	}
+	m := g.first
+	m.Timestamp = g.end
+	m.AddDatum(sdID, "count", strconv.Itoa(g.count))
+	m.AddDatum(sdID, "first", g.start.UTC().Format(time.RFC3339Nano))
+	m.AddDatum(sdID, "last", g.end.UTC().Format(time.RFC3339Nano))
+	if g.measured > 0 {
+		m.AddDatum(sdID, "min", strconv.FormatFloat(g.min, 'g', -1, 64))
+		m.AddDatum(sdID, "max", strconv.FormatFloat(g.max, 'g', -1, 64))
+		m.AddDatum(sdID, "sum", strconv.FormatFloat(g.sum, 'g', -1, 64))
+	}
+	return m
+}
+
+// Flush writes the summaries of all the groups, oldest first, without
+// waiting for their windows to end
+func (a *Aggregator) Flush() error {
+	a.mu.Lock()
+	groups := make([]*aggregateGroup, 0, len(a.groups))
+	for _, g := range a.groups {
+		groups = append(groups, g)
+	}
+	a.groups = nil
+	a.mu.Unlock()
+	sort.Slice(groups, func(i, j int) bool { return groups[i].start.Before(groups[j].start) })
+
+	errs := errset.ErrSet{}
+	for _, g := range groups {
+		g.timer.Stop()
+		if err := a.Writer.WriteMessage(a.summary(g)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.ReturnValue()
+}
+
+// Close flushes the groups and closes Writer. Messages written afterwards
+// are passed to Writer as they are.
+func (a *Aggregator) Close() error {
+	a.mu.Lock()
+	a.closed = true
+	a.mu.Unlock()
+
+	errs := errset.ErrSet{}
+	if err := a.Flush(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := a.Writer.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	return errs.ReturnValue()
+}
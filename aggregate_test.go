@@ -0,0 +1,81 @@
+package rfc5424
+
+import (
+	"bytes"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&AggregateTest{})
+
+type AggregateTest struct {
+}
+
+func authFailure(ts, user, attempts string) Message {
+	m := Message{Priority: 84, Timestamp: T(ts), Hostname: "host", AppName: "sshd", MessageID: "AUTHFAIL"}
+	m.AddDatum("auth@32473", "user", user)
+	m.AddDatum("auth@32473", "attempts", attempts)
+	m.SetTextMessage("authentication failure")
+	return m
+}
+
+func (s *AggregateTest) TestAggregate(c *C) {
+	buf := &bytes.Buffer{}
+	a := &Aggregator{
+		Writer:     &StreamWriter{Writer: buf, Framing: NonTransparentFraming},
+		Window:     time.Hour,
+		MessageIDs: []string{"AUTHFAIL"},
+		GroupBy:    []SDParamRef{{ID: "auth@32473", Name: "user"}},
+		Measure:    &SDParamRef{ID: "auth@32473", Name: "attempts"},
+	}
+	c.Assert(a.WriteMessage(authFailure("2003-10-11T22:14:15Z", "root", "3")), IsNil)
+	c.Assert(a.WriteMessage(authFailure("2003-10-11T22:14:16Z", "root", "1")), IsNil)
+	c.Assert(a.WriteMessage(Message{Priority: 14, MessageID: "OTHER"}), IsNil)
+	c.Assert(a.WriteMessage(authFailure("2003-10-11T22:14:17Z", "root", "5")), IsNil)
+	c.Assert(a.WriteMessage(authFailure("2003-10-11T22:14:18Z", "admin", "1")), IsNil)
+	c.Assert(buf.String(), Equals, "<14>1 - - - - OTHER -\n")
+
+	buf.Reset()
+	c.Assert(a.Flush(), IsNil)
+	c.Assert(buf.String(), Equals,
+		`<84>1 2003-10-11T22:14:17Z host sshd - AUTHFAIL [auth@32473 user="root" attempts="3"]`+
+			`[aggregate@32473 count="3" first="2003-10-11T22:14:15Z" last="2003-10-11T22:14:17Z" min="1" max="5" sum="9"] `+
+			"\ufeffauthentication failure\n"+
+			`<84>1 2003-10-11T22:14:18Z host sshd - AUTHFAIL [auth@32473 user="admin" attempts="1"] `+
+			"\ufeffauthentication failure\n")
+
+	buf.Reset()
+	c.Assert(a.Flush(), IsNil)
+	c.Assert(buf.String(), Equals, "")
+}
+
+func (s *AggregateTest) TestWindow(c *C) {
+	fw := &chanWriter{messages: make(chan Message, 1)}
+	a := &Aggregator{Writer: fw, Window: 10 * time.Millisecond, SDID: "summary@32473"}
+	c.Assert(a.WriteMessage(Message{MessageID: "X", Timestamp: T("2003-10-11T22:14:15Z")}), IsNil)
+	c.Assert(a.WriteMessage(Message{MessageID: "X", Timestamp: T("2003-10-11T22:14:16Z")}), IsNil)
+
+	select {
+	case m := <-fw.messages:
+		count, _ := m.SDParam("summary@32473", "count")
+		c.Assert(count, Equals, "2")
+	case <-time.After(5 * time.Second):
+		c.Fatal("no summary written")
+	}
+	c.Assert(a.Close(), IsNil)
+}
+
+// chanWriter is a MessageWriter sending messages to a channel
+type chanWriter struct {
+	messages chan Message
+}
+
+func (w *chanWriter) WriteMessage(m Message) error {
+	w.messages <- m
+	return nil
+}
+
+func (w *chanWriter) Close() error {
+	return nil
+}
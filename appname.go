@@ -0,0 +1,124 @@
+package rfc5424
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+const defaultAppNameEnv = "SYSLOG_APP_NAME"
+
+// DefaultAppNameResolver chooses the APP-NAME of messages encoded from
+// structs without an AppName field, when no APP-NAME is set with
+// WithAppName or an Encoder's AppName.
+var DefaultAppNameResolver = &AppNameResolver{}
+
+// AppNameSource is where an AppNameResolver found the APP-NAME
+type AppNameSource int
+
+const (
+	// AppNameUnresolved means no APP-NAME was found, or none was resolved
+	// yet
+	AppNameUnresolved AppNameSource = iota
+	// AppNameFromOverride is the Override of the resolver
+	AppNameFromOverride
+	// AppNameFromEnv is the value of an environment variable
+	AppNameFromEnv
+	// AppNameFromBuildInfo is the name of the main package recorded in the
+	// executable's build information
+	AppNameFromBuildInfo
+	// AppNameFromExecutable is the name of the executable
+	AppNameFromExecutable
+)
+
+var appNameSourceNames = []string{"unresolved", "override", "env", "buildinfo", "executable"}
+
+func (s AppNameSource) String() string {
+	if s >= 0 && int(s) < len(appNameSourceNames) {
+		return appNameSourceNames[s]
+	}
+	return fmt.Sprintf("AppNameSource(%d)", int(s))
+}
+
+// AppNameResolver chooses the APP-NAME of emitted messages, in order of
+// preference: Override, the environment variable Env, the last element of
+// the main package's import path as recorded by the Go toolchain, then the
+// base name of the executable. The build information gives the right name
+// under "go run", whose executables are named after the source file, and in
+// test binaries, whose ".test" suffix is removed. The name is resolved when
+// first needed, then cached.
+type AppNameResolver struct {
+	// Override, if set, is always used
+	Override string
+
+	// Env is the environment variable checked. If empty, SYSLOG_APP_NAME
+	// is used.
+	Env string
+
+	// LookupEnv, ReadBuildInfo and Args default to the functions and
+	// variables of the os and runtime/debug packages.
+	LookupEnv     func(key string) (string, bool)
+	ReadBuildInfo func() (*debug.BuildInfo, bool)
+	Args          []string
+
+	once   sync.Once
+	name   string
+	source AppNameSource
+}
+
+// Resolve returns the APP-NAME to use, or "" if nothing is known, in which
+// case the NILVALUE is sent.
+func (ar *AppNameResolver) Resolve() string {
+	name, _ := ar.Resolved()
+	return name
+}
+
+// Resolved returns the APP-NAME to use and where it was found, e.g. to log
+// it at startup.
+func (ar *AppNameResolver) Resolved() (string, AppNameSource) {
+	if ar.Override != "" {
+		return ar.Override, AppNameFromOverride
+	}
+	ar.once.Do(func() { ar.name, ar.source = ar.resolve() })
+	return ar.name, ar.source
+}
+
+// resolve chooses the APP-NAME, other than the override
+func (ar *AppNameResolver) resolve() (string, AppNameSource) {
+	lookupEnv, readBuildInfo, args := ar.LookupEnv, ar.ReadBuildInfo, ar.Args
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	if readBuildInfo == nil {
+		readBuildInfo = debug.ReadBuildInfo
+	}
+	if args == nil {
+		args = os.Args
+	}
+	env := ar.Env
+	if env == "" {
+		env = defaultAppNameEnv
+	}
+
+	if name, ok := lookupEnv(env); ok && name != "" {
+		return name, AppNameFromEnv
+	}
+	if info, ok := readBuildInfo(); ok {
+		// "go run file.go" builds a package without an import path
+		pkg := info.Path
+		if pkg == "command-line-arguments" {
+			pkg = info.Main.Path
+		}
+		if name := strings.TrimSuffix(path.Base(pkg), ".test"); pkg != "" && name != "" {
+			return name, AppNameFromBuildInfo
+		}
+	}
+	if len(args) > 0 && args[0] != "" {
+		return filepath.Base(args[0]), AppNameFromExecutable
+	}
+	return "", AppNameUnresolved
+}
@@ -0,0 +1,80 @@
+package rfc5424
+
+import (
+	"runtime/debug"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&AppNameTest{})
+
+type AppNameTest struct {
+}
+
+func fakeEnv(env map[string]string) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func fakeBuildInfo(pkg, module string) func() (*debug.BuildInfo, bool) {
+	return func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Path: pkg, Main: debug.Module{Path: module}}, true
+	}
+}
+
+func (s *AppNameTest) TestOrder(c *C) {
+	env := map[string]string{"SYSLOG_APP_NAME": "fromenv", "APP": "other"}
+	build := fakeBuildInfo("example.com/mod/cmd/tool", "example.com/mod")
+	args := []string{"/tmp/go-build1/b001/exe/main"}
+
+	ar := &AppNameResolver{Override: "forced", LookupEnv: fakeEnv(env), ReadBuildInfo: build, Args: args}
+	name, source := ar.Resolved()
+	c.Assert(name, Equals, "forced")
+	c.Assert(source, Equals, AppNameFromOverride)
+
+	ar = &AppNameResolver{LookupEnv: fakeEnv(env), ReadBuildInfo: build, Args: args}
+	name, source = ar.Resolved()
+	c.Assert(name, Equals, "fromenv")
+	c.Assert(source, Equals, AppNameFromEnv)
+
+	ar = &AppNameResolver{Env: "APP", LookupEnv: fakeEnv(env), ReadBuildInfo: build, Args: args}
+	c.Assert(ar.Resolve(), Equals, "other")
+
+	ar = &AppNameResolver{LookupEnv: fakeEnv(nil), ReadBuildInfo: build, Args: args}
+	name, source = ar.Resolved()
+	c.Assert(name, Equals, "tool")
+	c.Assert(source, Equals, AppNameFromBuildInfo)
+
+	noBuildInfo := func() (*debug.BuildInfo, bool) { return nil, false }
+	ar = &AppNameResolver{LookupEnv: fakeEnv(nil), ReadBuildInfo: noBuildInfo, Args: args}
+	name, source = ar.Resolved()
+	c.Assert(name, Equals, "main")
+	c.Assert(source, Equals, AppNameFromExecutable)
+
+	ar = &AppNameResolver{LookupEnv: fakeEnv(nil), ReadBuildInfo: noBuildInfo, Args: []string{}}
+	name, source = ar.Resolved()
+	c.Assert(name, Equals, "")
+	c.Assert(source, Equals, AppNameUnresolved)
+}
+
+func (s *AppNameTest) TestBuildInfo(c *C) {
+	for _, t := range []struct{ pkg, module, expected string }{
+		{"example.com/mod/cmd/tool", "example.com/mod", "tool"},
+		{"example.com/mod.test", "example.com/mod", "mod"},
+		{"command-line-arguments", "example.com/mod", "mod"},
+		{"command-line-arguments", "", "main"},
+	} {
+		ar := &AppNameResolver{LookupEnv: fakeEnv(nil), ReadBuildInfo: fakeBuildInfo(t.pkg, t.module), Args: []string{"main"}}
+		c.Assert(ar.Resolve(), Equals, t.expected, Commentf("%s", t.pkg))
+	}
+}
+
+func (s *AppNameTest) TestCached(c *C) {
+	env := map[string]string{"SYSLOG_APP_NAME": "first"}
+	ar := &AppNameResolver{LookupEnv: fakeEnv(env)}
+	c.Assert(ar.Resolve(), Equals, "first")
+	env["SYSLOG_APP_NAME"] = "second"
+	c.Assert(ar.Resolve(), Equals, "first")
+}
@@ -0,0 +1,130 @@
+package rfc5424
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AuditSDID is the ID of the SD element of audit events (see
+// DocumentationEnterpriseNumber). It should be the same for all of an
+// organization's applications, so that their audit events can be found and
+// checked the same way.
+var AuditSDID = "audit@32473"
+
+// The outcomes of audited actions
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// auditParams are the parameters of the audit SD element, all required
+var auditParams = []string{"actor", "action", "target", "outcome", "reason"}
+
+// AuditSchema returns the declaration of the audit SD element, AuditSDID,
+// so that a SchemaRegistry checks that every audit event logged has all of
+// its parameters
+func AuditSchema() SDSchema {
+	return SDSchema{
+		ID: AuditSDID,
+		Params: map[string]ParamType{
+			"actor":   ParamString,
+			"action":  ParamString,
+			"target":  ParamString,
+			"outcome": ParamString,
+			"reason":  ParamString,
+		},
+		Required: auditParams,
+	}
+}
+
+// AuditEvent is a security-relevant action, such as a login or a change of
+// permissions, reported by the audit SD element. Actor, Action, Target and
+// Outcome are required; Reason may be empty but is always sent, so that
+// every audit element has the same parameters.
+type AuditEvent struct {
+	// Actor is who acted, e.g. a user or service account
+	Actor string
+
+	// Action is what was done, e.g. "login" or "grant"
+	Action string
+
+	// Target is what was acted on, e.g. a resource or another account
+	Target string
+
+	// Outcome is AuditSuccess or AuditFailure
+	Outcome string
+
+	// Reason explains the outcome, e.g. why access was denied
+	Reason string
+}
+
+// Validate returns an error if a required field is empty or Outcome is not
+// AuditSuccess or AuditFailure
+func (e AuditEvent) Validate() error {
+	for i, value := range []string{e.Actor, e.Action, e.Target, e.Outcome} {
+		if value == "" {
+			return fmt.Errorf("rfc5424: audit event has no %s", auditParams[i])
+		}
+	}
+	if e.Outcome != AuditSuccess && e.Outcome != AuditFailure {
+		return fmt.Errorf("rfc5424: audit outcome must be %q or %q: %q", AuditSuccess, AuditFailure, e.Outcome)
+	}
+	return nil
+}
+
+// StructuredData returns the audit SD element
+func (e AuditEvent) StructuredData() StructuredData {
+	sd := StructuredData{ID: AuditSDID}
+	for i, value := range []string{e.Actor, e.Action, e.Target, e.Outcome, e.Reason} {
+		sd.AddParam(auditParams[i], value)
+	}
+	return sd
+}
+
+// Message returns a message reporting e, with the facility Audit, the MSGID
+// "audit" and the same defaults as NewMessage otherwise. Its severity is
+// Notice for successes and Warning for failures. It returns an error if e
+// is not valid.
+func (e AuditEvent) Message() (Message, error) {
+	if err := e.Validate(); err != nil {
+		return Message{}, err
+	}
+	severity := Severity(Notice)
+	if e.Outcome == AuditFailure {
+		severity = Warning
+	}
+	m := defaultMessage(severity)
+	m.Priority = int(severity-Emergency) | (int(Audit-Kernel) << 3)
+	m.MessageID = "audit"
+	text := e.Actor + " " + e.Action + " " + e.Target + ": " + e.Outcome
+	if e.Reason != "" {
+		text += " (" + e.Reason + ")"
+	}
+	m.SetTextMessage(text)
+	m.StructuredData = append(m.StructuredData, e.StructuredData())
+	return m, nil
+}
+
+// ErrNoAuditEvent is returned by ParseAuditEvent for messages without an
+// audit SD element
+var ErrNoAuditEvent = errors.New("rfc5424: the message has no audit event")
+
+// ParseAuditEvent returns the audit event reported by m. It returns an
+// error if m has no audit SD element, or if the element is missing a
+// parameter or is not valid.
+func ParseAuditEvent(m Message) (AuditEvent, error) {
+	sd := m.SD(AuditSDID)
+	if sd == nil {
+		return AuditEvent{}, ErrNoAuditEvent
+	}
+	values := make([]string, len(auditParams))
+	for i, name := range auditParams {
+		v := Params(sd.Parameters).Values(name)
+		if v == nil {
+			return AuditEvent{}, fmt.Errorf("rfc5424: audit event has no %s", name)
+		}
+		values[i] = v[0]
+	}
+	e := AuditEvent{Actor: values[0], Action: values[1], Target: values[2], Outcome: values[3], Reason: values[4]}
+	return e, e.Validate()
+}
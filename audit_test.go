@@ -0,0 +1,78 @@
+package rfc5424
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&AuditTest{})
+
+type AuditTest struct {
+}
+
+func (s *AuditTest) TestMessage(c *C) {
+	e := AuditEvent{Actor: "alice", Action: "delete", Target: "bucket/logs", Outcome: AuditFailure, Reason: "not an owner"}
+	m, err := e.Message()
+	c.Assert(err, IsNil)
+	c.Assert(m.Severity(), Equals, Severity(Warning))
+	c.Assert(m.Facility(), Equals, Facility(Audit))
+	c.Assert(m.MessageID, Equals, "audit")
+	c.Assert(m.TextMessage(), Equals, "alice delete bucket/logs: failure (not an owner)")
+	c.Assert(m.StructuredData, DeepEquals, []StructuredData{{ID: "audit@32473", Parameters: []SDParam{
+		{Name: "actor", Value: "alice"},
+		{Name: "action", Value: "delete"},
+		{Name: "target", Value: "bucket/logs"},
+		{Name: "outcome", Value: "failure"},
+		{Name: "reason", Value: "not an owner"},
+	}}})
+
+	parsed, err := ParseAuditEvent(m)
+	c.Assert(err, IsNil)
+	c.Assert(parsed, Equals, e)
+
+	e = AuditEvent{Actor: "alice", Action: "login", Target: "console", Outcome: AuditSuccess}
+	m, err = e.Message()
+	c.Assert(err, IsNil)
+	c.Assert(m.Severity(), Equals, Severity(Notice))
+	c.Assert(m.TextMessage(), Equals, "alice login console: success")
+	reason, ok := m.SDParam(AuditSDID, "reason")
+	c.Assert(ok, Equals, true)
+	c.Assert(reason, Equals, "")
+}
+
+func (s *AuditTest) TestInvalid(c *C) {
+	_, err := AuditEvent{Actor: "alice", Action: "login", Outcome: AuditSuccess}.Message()
+	c.Assert(err, ErrorMatches, "rfc5424: audit event has no target")
+	_, err = AuditEvent{Actor: "alice", Action: "login", Target: "console", Outcome: "ok"}.Message()
+	c.Assert(err, ErrorMatches, `rfc5424: audit outcome must be "success" or "failure": "ok"`)
+
+	_, err = ParseAuditEvent(Message{})
+	c.Assert(err, Equals, ErrNoAuditEvent)
+	m := Message{}
+	m.AddDatum(AuditSDID, "actor", "alice")
+	_, err = ParseAuditEvent(m)
+	c.Assert(err, ErrorMatches, "rfc5424: audit event has no action")
+}
+
+func (s *AuditTest) TestSchema(c *C) {
+	r := &SchemaRegistry{Policy: SchemaError}
+	c.Assert(r.Declare(AuditSchema()), IsNil)
+
+	m, err := AuditEvent{Actor: "alice", Action: "login", Target: "console", Outcome: AuditSuccess}.Message()
+	c.Assert(err, IsNil)
+	c.Assert(r.Check(m), IsNil)
+
+	m.StructuredData[0].Parameters = m.StructuredData[0].Parameters[:4]
+	c.Assert(r.Check(m), ErrorMatches, `rfc5424: audit@32473 is missing required parameter "reason"`)
+}
+
+func (s *AuditTest) TestSDID(c *C) {
+	defer func(id string) { AuditSDID = id }(AuditSDID)
+	AuditSDID = "audit@99999"
+
+	m, err := AuditEvent{Actor: "alice", Action: "login", Target: "console", Outcome: AuditSuccess}.Message()
+	c.Assert(err, IsNil)
+	c.Assert(m.SD("audit@99999"), NotNil)
+	_, err = ParseAuditEvent(m)
+	c.Assert(err, IsNil)
+	c.Assert(AuditSchema().ID, Equals, "audit@99999")
+}
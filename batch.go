@@ -0,0 +1,49 @@
+package rfc5424
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// MarshalBatch marshals msgs on up to workers goroutines, for bulk jobs
+// such as exporting or replaying stored messages. The result holds the
+// marshaled form of each message in the order of msgs. If workers is zero
+// or less, GOMAXPROCS goroutines are used.
+//
+// If any message cannot be marshaled, MarshalBatch returns the error of the
+// first such message in msgs.
+func MarshalBatch(msgs []Message, workers int) ([][]byte, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(msgs) {
+		workers = len(msgs)
+	}
+
+	bs := make([][]byte, len(msgs))
+	errs := make([]error, len(msgs))
+	var next int64 = -1
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(msgs) {
+					return
+				}
+				bs[i], errs[i] = msgs[i].MarshalBinary()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bs, nil
+}
@@ -0,0 +1,67 @@
+package rfc5424
+
+import (
+	"bytes"
+	"fmt"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&BatchTest{})
+
+type BatchTest struct {
+}
+
+func (s *BatchTest) TestMarshalBatchKeepsOrder(c *C) {
+	msgs := make([]Message, 100)
+	for i := range msgs {
+		msgs[i] = Message{Priority: i % 192, Message: []byte(fmt.Sprint(i))}
+		if i%2 == 0 {
+			msgs[i].AddDatum("x@32473", "i", fmt.Sprint(i))
+		}
+	}
+	for _, workers := range []int{0, 1, 3, 200} {
+		bs, err := MarshalBatch(msgs, workers)
+		c.Assert(err, IsNil)
+		c.Assert(bs, HasLen, len(msgs))
+		for i, b := range bs {
+			expected, _ := msgs[i].MarshalBinary()
+			c.Assert(string(b), Equals, string(expected))
+		}
+	}
+
+	bs, err := MarshalBatch(nil, 4)
+	c.Assert(err, IsNil)
+	c.Assert(bs, HasLen, 0)
+}
+
+func (s *BatchTest) TestMarshalBatchReportsFirstError(c *C) {
+	msgs := []Message{{}, {Hostname: "bad host"}, {}, {AppName: "bad app"}}
+	bs, err := MarshalBatch(msgs, 4)
+	c.Assert(bs, IsNil)
+	c.Assert(err, ErrorMatches, ".*Hostname is invalid.*")
+}
+
+func (s *BatchTest) TestEncodeBatch(c *C) {
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	evs := []interface{}{
+		encoderEvent{Timestamp: T("2003-10-11T22:14:15.003Z"), Hostname: "host", AppName: "app", ProcessID: "1", MessageID: "ID", Message: []byte("one")},
+		encoderEvent{Timestamp: T("2003-10-11T22:14:15.003Z"), Hostname: "host", AppName: "app", ProcessID: "1", MessageID: "ID", Message: []byte("two")},
+	}
+	c.Assert(e.EncodeBatch(evs, 2), IsNil)
+	c.Assert(buf.String(), Equals, "51 <134>1 2003-10-11T22:14:15.003Z host app 1 ID - one"+
+		"51 <134>1 2003-10-11T22:14:15.003Z host app 1 ID - two")
+
+	buf.Reset()
+	e.Profile = &SplunkProfile
+	c.Assert(e.EncodeBatch(evs, 2), IsNil)
+	c.Assert(buf.String(), Equals, "<134>1 2003-10-11T22:14:15.003Z host app 1 ID - one\n"+
+		"<134>1 2003-10-11T22:14:15.003Z host app 1 ID - two\n")
+
+	// nothing is written when a message is invalid
+	buf.Reset()
+	evs = append(evs, encoderEvent{Hostname: "bad host"})
+	c.Assert(e.EncodeBatch(evs, 2), NotNil)
+	c.Assert(buf.Len(), Equals, 0)
+}
@@ -0,0 +1,163 @@
+package rfc5424
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+// MessageBuilder builds a Message one field at a time, checking each value
+// as it is set. It is an alternative to struct tags (see Encode) and to
+// Message literals:
+//
+//	m, err := NewMessage().Severity(Error).AppName("api").
+//		SD("exampleSDID@32473", "iut", "3").Msg("boom").Build()
+//
+// The first invalid value is reported by Build; later calls are ignored.
+type MessageBuilder struct {
+	m        Message
+	severity Severity
+	facility Facility
+	err      error
+}
+
+// NewMessage returns a MessageBuilder for a message with the same defaults
+// as Encode: severity Info, facility Local0, the current time, the HOSTNAME
+// chosen by DefaultHostnameResolver, the APP-NAME chosen by
+// DefaultAppNameResolver and the PROCID returned by DefaultProcessID.
+func NewMessage() *MessageBuilder {
+	return &MessageBuilder{
+		m: Message{
+			Timestamp: TimeNow().UTC(),
+			Hostname:  DefaultHostnameResolver.Resolve(),
+			AppName:   DefaultAppNameResolver.Resolve(),
+			ProcessID: DefaultProcessID(),
+		},
+		severity: defaultSeverity,
+		facility: defaultFacility,
+	}
+}
+
+// defaultMessage returns a message with severity and the defaults of
+// NewMessage
+func defaultMessage(severity Severity) Message {
+	return Message{
+		Priority:  int(severity-Emergency) | (int(defaultFacility-Kernel) << 3),
+		Timestamp: TimeNow().UTC(),
+		Hostname:  DefaultHostnameResolver.Resolve(),
+		AppName:   DefaultAppNameResolver.Resolve(),
+		ProcessID: DefaultProcessID(),
+	}
+}
+
+// check records err unless an earlier value was invalid
+func (b *MessageBuilder) check(err error) *MessageBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// headerField checks a HOSTNAME, APP-NAME, PROCID or MSGID, which may be
+// empty for the NILVALUE
+func headerField(property, value string, maxLength int) error {
+	if len(value) > maxLength || !isPrintableUsASCII(value) {
+		return InvalidValue(property, value)
+	}
+	return nil
+}
+
+// Severity sets the severity of the PRI
+func (b *MessageBuilder) Severity(severity Severity) *MessageBuilder {
+	if severity < Emergency || severity > Debug {
+		return b.check(InvalidValue("Severity", severity))
+	}
+	b.severity = severity
+	return b
+}
+
+// Facility sets the facility of the PRI
+func (b *MessageBuilder) Facility(facility Facility) *MessageBuilder {
+	if facility < Kernel || facility > Local7 {
+		return b.check(InvalidValue("Facility", facility))
+	}
+	b.facility = facility
+	return b
+}
+
+// Timestamp sets the TIMESTAMP. The zero time sends the NILVALUE.
+func (b *MessageBuilder) Timestamp(t time.Time) *MessageBuilder {
+	if year := t.Year(); !t.IsZero() && (year < 0 || year > 9999) {
+		return b.check(errorInvalidValue{Property: "Timestamp", Value: t,
+			reason: "the year must have four digits"})
+	}
+	b.m.Timestamp = t
+	return b
+}
+
+// Hostname sets the HOSTNAME. An empty string sends the NILVALUE.
+func (b *MessageBuilder) Hostname(hostname string) *MessageBuilder {
+	b.m.Hostname = hostname
+	return b.check(headerField("Hostname", hostname, 255))
+}
+
+// AppName sets the APP-NAME. An empty string sends the NILVALUE.
+func (b *MessageBuilder) AppName(appName string) *MessageBuilder {
+	b.m.AppName = appName
+	return b.check(headerField("AppName", appName, 48))
+}
+
+// ProcessID sets the PROCID. An empty string sends the NILVALUE.
+func (b *MessageBuilder) ProcessID(processID string) *MessageBuilder {
+	b.m.ProcessID = processID
+	return b.check(headerField("ProcessID", processID, 128))
+}
+
+// MessageID sets the MSGID. An empty string sends the NILVALUE.
+func (b *MessageBuilder) MessageID(messageID string) *MessageBuilder {
+	b.m.MessageID = messageID
+	return b.check(headerField("MessageID", messageID, 32))
+}
+
+// SD adds a parameter to the SD element with the given ID, which is added
+// to the message if it does not have it yet
+func (b *MessageBuilder) SD(id, name, value string) *MessageBuilder {
+	switch {
+	case id == "" || !isValidSdName(id):
+		return b.check(InvalidValue("StructuredData/ID", id))
+	case name == "" || !isValidSdName(name):
+		return b.check(InvalidValue("StructuredData["+id+"]/Name", name))
+	case !utf8.ValidString(value):
+		return b.check(InvalidValue("StructuredData["+id+"]/"+name, value))
+	}
+	b.m.AddDatum(id, name, value)
+	return b
+}
+
+// Msg sets the MSG to s, as opaque bytes (MSG-ANY)
+func (b *MessageBuilder) Msg(s string) *MessageBuilder {
+	b.m.Message = []byte(s)
+	return b
+}
+
+// Text sets the MSG to s, declared to be UTF-8 text with a BOM (MSG-UTF8)
+func (b *MessageBuilder) Text(s string) *MessageBuilder {
+	if !utf8.ValidString(s) {
+		return b.check(errorInvalidValue{Property: "Message", Value: s,
+			reason: "a message starting with a BOM must be UTF-8"})
+	}
+	b.m.SetTextMessage(s)
+	return b
+}
+
+// Build returns the message, or the error for the first invalid value set
+func (b *MessageBuilder) Build() (Message, error) {
+	if b.err != nil {
+		return Message{}, b.err
+	}
+	m := b.m.Clone() // later calls must not change the message built
+	m.Priority = int(b.severity-Emergency) | (int(b.facility-Kernel) << 3)
+	if err := m.assertValid(); err != nil {
+		return Message{}, err
+	}
+	return m, nil
+}
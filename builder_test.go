@@ -0,0 +1,62 @@
+package rfc5424
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&BuilderTest{})
+
+type BuilderTest struct {
+}
+
+func (s *BuilderTest) TestBuild(c *C) {
+	now := T("2003-10-11T22:14:15.003Z")
+	TimeNow = func() time.Time { return now }
+	defer func() { TimeNow = time.Now }()
+
+	b := NewMessage().Severity(Error).Facility(Auth).Hostname("host").AppName("api").MessageID("ID47").
+		SD("exampleSDID@32473", "iut", "3").SD("exampleSDID@32473", "eventSource", "Application").Msg("boom")
+	m, err := b.Build()
+	c.Assert(err, IsNil)
+	c.Assert(m.Severity(), Equals, Severity(Error))
+	c.Assert(m.Facility(), Equals, Facility(Auth))
+	raw, err := m.MarshalBinary()
+	c.Assert(err, IsNil)
+	c.Assert(string(raw), Equals, `<35>1 2003-10-11T22:14:15.003Z host api `+processPID+
+		` ID47 [exampleSDID@32473 iut="3" eventSource="Application"] boom`)
+
+	// Building more does not change messages already built
+	b.SD("exampleSDID@32473", "more", "1")
+	c.Assert(m.StructuredData[0].Parameters, HasLen, 2)
+
+	m, err = NewMessage().Hostname("").Text("café").Build()
+	c.Assert(err, IsNil)
+	c.Assert(m.Hostname, Equals, "")
+	c.Assert(m.TextMessage(), Equals, "café")
+	c.Assert(m.Severity(), Equals, Severity(Info))
+	c.Assert(m.Facility(), Equals, Facility(Local0))
+}
+
+func (s *BuilderTest) TestReportsFirstInvalidValue(c *C) {
+	for _, t := range []struct {
+		b   *MessageBuilder
+		err string
+	}{
+		{NewMessage().Severity(0), ".*Severity is invalid.*"},
+		{NewMessage().Facility(25), ".*Facility is invalid.*"},
+		{NewMessage().Timestamp(time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)), ".*Timestamp is invalid.*"},
+		{NewMessage().Hostname("my host"), ".*Hostname is invalid.*"},
+		{NewMessage().AppName("a\tb"), ".*AppName is invalid.*"},
+		{NewMessage().MessageID("0123456789012345678901234567890123"), ".*MessageID is invalid.*"},
+		{NewMessage().SD("bad id", "x", "1"), `.*StructuredData/ID is invalid.*`},
+		{NewMessage().SD("x@1", "a=b", "1"), `.*StructuredData\[x@1\]/Name is invalid.*`},
+		{NewMessage().SD("x@1", "v", "\xff"), `.*StructuredData\[x@1\]/v is invalid.*`},
+		{NewMessage().Text("\xff"), ".*Message is invalid.*"},
+		{NewMessage().Hostname("my host").AppName("a b"), ".*Hostname is invalid.*"},
+	} {
+		_, err := t.b.Build()
+		c.Assert(err, ErrorMatches, t.err)
+	}
+}
@@ -0,0 +1,31 @@
+package rfc5424
+
+import "sort"
+
+// CanonicalBytes returns a normalized serialization of the message, for
+// hashing in audit trails. Messages that differ only in how they were
+// written produce the same bytes:
+//
+//   - the timestamp is in UTC, or NILVALUE if it is zero
+//   - SD elements are sorted by ID, and their parameters by name, keeping
+//     the order of repeated names
+//   - empty fields and structured data are written as NILVALUE, and an
+//     empty MSG is omitted
+//
+// The MSG is kept exactly, including any BOM.
+func (m Message) CanonicalBytes() ([]byte, error) {
+	c := m
+	c.Timestamp = m.Timestamp.UTC()
+	c.StructuredData = make([]StructuredData, len(m.StructuredData))
+	for i, sd := range m.StructuredData {
+		params := append([]SDParam{}, sd.Parameters...)
+		sort.SliceStable(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+		c.StructuredData[i] = StructuredData{ID: sd.ID, Parameters: params}
+	}
+	sort.Slice(c.StructuredData, func(i, j int) bool { return c.StructuredData[i].ID < c.StructuredData[j].ID })
+
+	if len(c.Message) == 0 {
+		c.Message = nil
+	}
+	return c.MarshalBinary()
+}
@@ -0,0 +1,46 @@
+package rfc5424
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&CanonicalTest{})
+
+type CanonicalTest struct {
+}
+
+func (s *CanonicalTest) TestCanonicalBytes(c *C) {
+	a := Message{
+		Priority:  165,
+		Timestamp: T("2003-08-24T05:14:15.000003-07:00"),
+		Hostname:  "host",
+		StructuredData: []StructuredData{
+			{ID: "b@32473", Parameters: []SDParam{{Name: "y", Value: "1"}, {Name: "x", Value: "2"}, {Name: "y", Value: "3"}}},
+			{ID: "a@32473"},
+		},
+		Message: []byte("hello"),
+	}
+	b := a
+	b.Timestamp = a.Timestamp.UTC()
+	b.StructuredData = []StructuredData{
+		{ID: "a@32473"},
+		{ID: "b@32473", Parameters: []SDParam{{Name: "x", Value: "2"}, {Name: "y", Value: "1"}, {Name: "y", Value: "3"}}},
+	}
+
+	ca, err := a.CanonicalBytes()
+	c.Assert(err, IsNil)
+	cb, err := b.CanonicalBytes()
+	c.Assert(err, IsNil)
+	c.Assert(string(ca), Equals, string(cb))
+	c.Assert(string(ca), Equals, `<165>1 2003-08-24T12:14:15.000003Z host - - - [a@32473][b@32473 x="2" y="1" y="3"] hello`)
+
+	// The message itself is unchanged
+	c.Assert(a.StructuredData[0].ID, Equals, "b@32473")
+	c.Assert(a.StructuredData[0].Parameters[0].Name, Equals, "y")
+
+	ca, err = Message{Timestamp: time.Time{}, StructuredData: []StructuredData{}}.CanonicalBytes()
+	c.Assert(err, IsNil)
+	c.Assert(string(ca), Equals, "<0>1 - - - - - -")
+}
@@ -0,0 +1,164 @@
+package rfc5424
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCircuitThreshold = 5
+	defaultCircuitCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned by CircuitBreaker.WriteMessage when the circuit is
+// open and no Fallback writer has been configured.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+const (
+	// CircuitClosed means messages are passed through to the underlying writer
+	CircuitClosed CircuitState = iota
+	// CircuitOpen means messages fail fast without touching the underlying writer
+	CircuitOpen
+	// CircuitHalfOpen means a single probe message is being sent to the
+	// underlying writer to find out if it has recovered
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker is a MessageWriter that protects callers from a failing
+// Writer. After Threshold consecutive failures the circuit opens and messages
+// fail fast (or are handed to Fallback, if set) instead of waiting on the
+// broken backend. Once Cooldown has elapsed a single message is let through to
+// probe the Writer: if it succeeds the circuit closes, otherwise it opens again.
+type CircuitBreaker struct {
+	Writer   MessageWriter
+	Fallback MessageWriter
+
+	// Threshold is the number of consecutive failures that opens the circuit.
+	// If zero, 5 is used.
+	Threshold int
+
+	// Cooldown is how long the circuit stays open before probing. If zero, 30
+	// seconds is used.
+	Cooldown time.Duration
+
+	mu         sync.Mutex
+	state      CircuitState
+	generation uint64
+	failures   int
+	openedAt   time.Time
+}
+
+// State returns the current state of the circuit.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == CircuitOpen && cb.cooledDown() {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+func (cb *CircuitBreaker) threshold() int {
+	if cb.Threshold <= 0 {
+		return defaultCircuitThreshold
+	}
+	return cb.Threshold
+}
+
+func (cb *CircuitBreaker) cooledDown() bool {
+	cooldown := cb.Cooldown
+	if cooldown <= 0 {
+		cooldown = defaultCircuitCooldown
+	}
+	return TimeNow().Sub(cb.openedAt) >= cooldown
+}
+
+// allow reports whether a message may be sent to the underlying writer. When
+// the cooldown has expired the caller becomes the (only) probe. The returned
+// generation must be passed to record with the outcome of the write.
+func (cb *CircuitBreaker) allow() (uint64, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case CircuitClosed:
+		return cb.generation, true
+	case CircuitOpen:
+		if cb.cooledDown() {
+			cb.setState(CircuitHalfOpen)
+			return cb.generation, true
+		}
+	}
+	return 0, false
+}
+
+// setState changes the state of the circuit and starts a new generation so
+// that writes allowed under the previous state can no longer affect it. The
+// caller must hold cb.mu.
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	cb.state = state
+	cb.generation++
+	if state == CircuitOpen {
+		cb.openedAt = TimeNow()
+	}
+}
+
+// record updates the circuit with the outcome of a write that was allowed in
+// generation `generation`. Outcomes of writes that started before the last
+// state change (e.g. a slow write that succeeds after the circuit has opened)
+// are ignored.
+func (cb *CircuitBreaker) record(generation uint64, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if generation != cb.generation {
+		return
+	}
+	if err == nil {
+		cb.failures = 0
+		if cb.state != CircuitClosed {
+			cb.setState(CircuitClosed)
+		}
+		return
+	}
+	cb.failures++
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.threshold() {
+		cb.failures = 0
+		cb.setState(CircuitOpen)
+	}
+}
+
+// WriteMessage writes `m` to Writer unless the circuit is open, in which case
+// the message goes to Fallback or ErrCircuitOpen is returned.
+func (cb *CircuitBreaker) WriteMessage(m Message) error {
+	generation, ok := cb.allow()
+	if !ok {
+		if cb.Fallback != nil {
+			return cb.Fallback.WriteMessage(m)
+		}
+		return ErrCircuitOpen
+	}
+	err := cb.Writer.WriteMessage(m)
+	cb.record(generation, err)
+	return err
+}
+
+// Close closes the underlying Writer. The Fallback writer is not closed since
+// it is often shared.
+func (cb *CircuitBreaker) Close() error {
+	return cb.Writer.Close()
+}
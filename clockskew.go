@@ -0,0 +1,88 @@
+package rfc5424
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultMaxFutureSkew = 10 * time.Minute
+	defaultMaxPastSkew   = 24 * time.Hour
+	defaultClockSkewSDID = "clockskew@32473"
+)
+
+// ClockSkewAction is what a ClockSkewGuard does with a message whose
+// TIMESTAMP is too far from the time it was received
+type ClockSkewAction int
+
+const (
+	// AnnotateClockSkew keeps the TIMESTAMP and adds an SD element
+	// recording the skew.
+	AnnotateClockSkew ClockSkewAction = iota
+	// CorrectClockSkew replaces the TIMESTAMP with the time the message
+	// was received, and adds an SD element recording the original
+	// TIMESTAMP and the skew.
+	CorrectClockSkew
+)
+
+// ClockSkewGuard checks the TIMESTAMP of received messages against the time
+// they were received, to keep devices with drifting clocks from corrupting
+// time-ordered stores and indexes. Messages without a TIMESTAMP are not
+// checked. A ClockSkewGuard is used as Middleware via its Wrap method.
+type ClockSkewGuard struct {
+	Action ClockSkewAction
+
+	// MaxFuture and MaxPast are how far ahead of and behind the time of
+	// receipt a TIMESTAMP may be. If zero, ten minutes and 24 hours are
+	// used; the latter allows for messages buffered by relays during
+	// outages.
+	MaxFuture time.Duration
+	MaxPast   time.Duration
+
+	// SDID is the ID of the SD element added to skewed messages. It has
+	// "original" and "skew" parameters: the TIMESTAMP received, and how far
+	// it was from the time of receipt, negative if in the past. If empty,
+	// "clockskew@32473" is used (see DocumentationEnterpriseNumber).
+	SDID string
+}
+
+// skew returns how far t is from now, and whether that is too far
+func (g *ClockSkewGuard) skew(t, now time.Time) (time.Duration, bool) {
+	maxFuture, maxPast := g.MaxFuture, g.MaxPast
+	if maxFuture <= 0 {
+		maxFuture = defaultMaxFutureSkew
+	}
+	if maxPast <= 0 {
+		maxPast = defaultMaxPastSkew
+	}
+	skew := t.Sub(now)
+	return skew, skew > maxFuture || skew < -maxPast
+}
+
+// Wrap returns a Handler that checks the TIMESTAMP of each message before
+// passing it to next.
+func (g *ClockSkewGuard) Wrap(next Handler) Handler {
+	return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+		if m.Timestamp.IsZero() {
+			next.Handle(ctx, m, src)
+			return
+		}
+		now := TimeNow()
+		skew, skewed := g.skew(m.Timestamp, now)
+		if !skewed {
+			next.Handle(ctx, m, src)
+			return
+		}
+
+		sdid := g.SDID
+		if sdid == "" {
+			sdid = defaultClockSkewSDID
+		}
+		m.AddDatum(sdid, "original", m.Timestamp.Format(time.RFC3339Nano))
+		m.AddDatum(sdid, "skew", skew.String())
+		if g.Action == CorrectClockSkew {
+			m.Timestamp = now.UTC()
+		}
+		next.Handle(ctx, m, src)
+	})
+}
@@ -0,0 +1,54 @@
+package rfc5424
+
+import (
+	"context"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&ClockSkewGuardTest{})
+
+type ClockSkewGuardTest struct {
+}
+
+func (s *ClockSkewGuardTest) TestAnnotate(c *C) {
+	now := T("2003-10-11T22:14:15Z")
+	TimeNow = func() time.Time { return now }
+	defer func() { TimeNow = time.Now }()
+
+	h := make(chanHandler, 10)
+	guarded := Chain(h, (&ClockSkewGuard{}).Wrap)
+	for _, ts := range []string{"2003-10-11T22:20:00Z", "2003-10-11T00:00:00Z", ""} {
+		m := Message{}
+		if ts != "" {
+			m.Timestamp = T(ts)
+		}
+		guarded.Handle(context.Background(), m, Source{})
+		c.Assert(receive(c, h).Message.StructuredData, IsNil, Commentf(ts))
+	}
+
+	guarded.Handle(context.Background(), Message{Timestamp: T("2003-10-11T23:14:15Z")}, Source{})
+	rm := receive(c, h)
+	c.Assert(rm.Message.Timestamp.Equal(T("2003-10-11T23:14:15Z")), Equals, true)
+	c.Assert(rm.Message.StructuredData, DeepEquals, []StructuredData{{
+		ID:         "clockskew@32473",
+		Parameters: []SDParam{{Name: "original", Value: "2003-10-11T23:14:15Z"}, {Name: "skew", Value: "1h0m0s"}},
+	}})
+}
+
+func (s *ClockSkewGuardTest) TestCorrect(c *C) {
+	now := T("2003-10-11T22:14:15Z")
+	TimeNow = func() time.Time { return now }
+	defer func() { TimeNow = time.Now }()
+
+	h := make(chanHandler, 10)
+	g := &ClockSkewGuard{Action: CorrectClockSkew, MaxPast: time.Hour, SDID: "skew@32473"}
+	g.Wrap(h).Handle(context.Background(), Message{Timestamp: T("2003-10-11T20:14:15Z")}, Source{})
+	rm := receive(c, h)
+	c.Assert(rm.Message.Timestamp.Equal(now), Equals, true)
+	original, _ := rm.Message.SDParam("skew@32473", "original")
+	c.Assert(original, Equals, "2003-10-11T20:14:15Z")
+	skew, _ := rm.Message.SDParam("skew@32473", "skew")
+	c.Assert(skew, Equals, "-2h0m0s")
+}
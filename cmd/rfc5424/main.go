@@ -0,0 +1,436 @@
+// Command rfc5424 parses, generates, validates, sends and receives RFC-5424
+// syslog messages, for debugging interoperability with collectors.
+//
+// Usage:
+//
+//	rfc5424 parse [-json] [file ...]
+//	rfc5424 validate [-strict] [file ...]
+//	rfc5424 generate [message flags] [text ...]
+//	rfc5424 send -addr host:port [-network udp|tcp|tls] [-profile name] [message flags] [text ...]
+//	rfc5424 receive -addr :port [-network udp|tcp|tls|relp] [-json]
+//	rfc5424 replay -addr host:port [-network udp|tcp|tls] [-profile name] [-rate n] [-timestamps keep|now|shift] [-store dir [-start time] [-end time] | file ...]
+//
+// parse and validate read one message per line from the files, or from
+// standard input. send sends the message described by its flags, or if no
+// text is given each message read from standard input, so that
+//
+//	rfc5424 generate -severity err boom | rfc5424 send -addr localhost:514
+//
+// sends the generated message unchanged. replay sends again the messages
+// of a FileStore directory, in the range of RFC 3339 times given, or of
+// files of framed messages, such as its segment ".log" files, or standard
+// input.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/secureworks/rfc5424"
+)
+
+var commands = map[string]func(args []string) error{
+	"parse":    parse,
+	"validate": validate,
+	"generate": generate,
+	"send":     send,
+	"receive":  receive,
+	"replay":   replay,
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rfc5424 parse|validate|generate|send|receive|replay [flags] [args]")
+	fmt.Fprintln(os.Stderr, "Run rfc5424 <command> -h for the flags of a command.")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+	if err := command(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "rfc5424:", err)
+		os.Exit(1)
+	}
+}
+
+// eachLine calls fn with each non-empty line of the named files, or of
+// standard input if there are none
+func eachLine(files []string, fn func(name string, n int, line []byte) error) error {
+	if len(files) == 0 {
+		return scanLines("<stdin>", os.Stdin, fn)
+	}
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		err = scanLines(name, f, fn)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanLines(name string, r io.Reader, fn func(name string, n int, line []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := []byte(strings.TrimRight(scanner.Text(), "\r"))
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(name, n, line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// jsonMessage is how messages are printed with -json
+type jsonMessage struct {
+	Facility       rfc5424.Facility             `json:"facility"`
+	Severity       rfc5424.Severity             `json:"severity"`
+	Timestamp      *time.Time                   `json:"timestamp,omitempty"`
+	Hostname       string                       `json:"hostname,omitempty"`
+	AppName        string                       `json:"appName,omitempty"`
+	ProcessID      string                       `json:"procID,omitempty"`
+	MessageID      string                       `json:"msgID,omitempty"`
+	StructuredData map[string]map[string]string `json:"sd,omitempty"`
+	Message        string                       `json:"msg,omitempty"`
+}
+
+// printMessage writes m to w, as JSON or as one field per line
+func printMessage(w io.Writer, m rfc5424.Message, asJSON bool) error {
+	if asJSON {
+		jm := jsonMessage{
+			Facility:  m.Facility(),
+			Severity:  m.Severity(),
+			Hostname:  m.Hostname,
+			AppName:   m.AppName,
+			ProcessID: m.ProcessID,
+			MessageID: m.MessageID,
+			Message:   m.TextMessage(),
+		}
+		if !m.Timestamp.IsZero() {
+			jm.Timestamp = &m.Timestamp
+		}
+		for _, sd := range m.StructuredData {
+			if jm.StructuredData == nil {
+				jm.StructuredData = map[string]map[string]string{}
+			}
+			params := map[string]string{}
+			for _, param := range sd.Parameters {
+				params[param.Name] = param.Value
+			}
+			jm.StructuredData[sd.ID] = params
+		}
+		return json.NewEncoder(w).Encode(jm)
+	}
+
+	fmt.Fprintf(w, "priority:  %s.%s (%d)\n", m.Facility(), m.Severity(), m.Priority)
+	if !m.Timestamp.IsZero() {
+		fmt.Fprintf(w, "timestamp: %s\n", m.Timestamp.Format(time.RFC3339Nano))
+	}
+	for _, field := range []struct{ name, value string }{
+		{"hostname", m.Hostname},
+		{"app-name", m.AppName},
+		{"procid", m.ProcessID},
+		{"msgid", m.MessageID},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(w, "%-10s %s\n", field.name+":", field.value)
+		}
+	}
+	for _, sd := range m.StructuredData {
+		fmt.Fprintf(w, "sd:        [%s]\n", sd.ID)
+		for _, param := range sd.Parameters {
+			fmt.Fprintf(w, "             %s=%q\n", param.Name, param.Value)
+		}
+	}
+	if len(m.Message) > 0 {
+		fmt.Fprintf(w, "msg:       %s\n", m.TextMessage())
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func parse(args []string) error {
+	fs := flag.NewFlagSet("parse", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print each message as a JSON object")
+	fs.Parse(args)
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	return eachLine(fs.Args(), func(name string, n int, line []byte) error {
+		m := rfc5424.Message{}
+		if err := m.UnmarshalBinary(line); err != nil {
+			return fmt.Errorf("%s:%d: %s", name, n, err)
+		}
+		return printMessage(out, m, *asJSON)
+	})
+}
+
+func validate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	strict := fs.Bool("strict", false, "check SD-IDs and parameter names against the full RFC-5424 grammar")
+	fs.Parse(args)
+	rfc5424.StrictSDIDs = *strict
+
+	invalid := 0
+	err := eachLine(fs.Args(), func(name string, n int, line []byte) error {
+		m := rfc5424.Message{}
+		err := m.UnmarshalBinary(line)
+		if err == nil {
+			_, err = m.MarshalBinary()
+		}
+		if err != nil {
+			invalid++
+			fmt.Printf("%s:%d: %s\n", name, n, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d invalid messages", invalid)
+	}
+	return nil
+}
+
+// sdFlags collects -sd flags of the form "ID name=value"
+type sdFlags []rfc5424.SDTag
+
+func (f *sdFlags) String() string {
+	return ""
+}
+
+func (f *sdFlags) Set(s string) error {
+	i := strings.IndexByte(s, ' ')
+	j := strings.IndexByte(s, '=')
+	if i <= 0 || j < i {
+		return fmt.Errorf("%q is not of the form \"ID name=value\"", s)
+	}
+	*f = append(*f, rfc5424.SDTag{ID: s[:i], Name: s[i+1 : j], Value: s[j+1:]})
+	return nil
+}
+
+// messageFlags are the flags describing the message to generate or send
+type messageFlags struct {
+	severity  rfc5424.Severity
+	facility  rfc5424.Facility
+	hostname  string
+	appName   string
+	processID string
+	messageID string
+	sd        sdFlags
+}
+
+func (mf *messageFlags) register(fs *flag.FlagSet) {
+	mf.severity = rfc5424.Info
+	mf.facility = rfc5424.Local0
+	fs.Var(&mf.severity, "severity", "severity, e.g. err or warning")
+	fs.Var(&mf.facility, "facility", "facility, e.g. auth or local3")
+	fs.StringVar(&mf.hostname, "hostname", "", "HOSTNAME (default this host's name)")
+	fs.StringVar(&mf.appName, "app-name", "", "APP-NAME (default the program's name)")
+	fs.StringVar(&mf.processID, "procid", "", "PROCID (default this process's ID)")
+	fs.StringVar(&mf.messageID, "msgid", "", "MSGID")
+	fs.Var(&mf.sd, "sd", "an SD parameter of the form \"ID name=value\"; may be repeated")
+}
+
+func (mf *messageFlags) build(text string) (rfc5424.Message, error) {
+	b := rfc5424.NewMessage().Severity(mf.severity).Facility(mf.facility).MessageID(mf.messageID)
+	if mf.hostname != "" {
+		b.Hostname(mf.hostname)
+	}
+	if mf.appName != "" {
+		b.AppName(mf.appName)
+	}
+	if mf.processID != "" {
+		b.ProcessID(mf.processID)
+	}
+	for _, tag := range mf.sd {
+		b.SD(tag.ID, tag.Name, tag.Value)
+	}
+	if text != "" {
+		b.Text(text)
+	}
+	return b.Build()
+}
+
+func generate(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	mf := messageFlags{}
+	mf.register(fs)
+	fs.Parse(args)
+
+	m, err := mf.build(strings.Join(fs.Args(), " "))
+	if err != nil {
+		return err
+	}
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", b)
+	return nil
+}
+
+func send(args []string) error {
+	fs := flag.NewFlagSet("send", flag.ExitOnError)
+	output := rfc5424.OutputConfig{}
+	outputFlags(fs, &output)
+	mf := messageFlags{}
+	mf.register(fs)
+	fs.Parse(args)
+	if output.Address == "" {
+		return fmt.Errorf("send: -addr is required")
+	}
+
+	logger, err := rfc5424.Config{Outputs: []rfc5424.OutputConfig{output}}.Build()
+	if err != nil {
+		return err
+	}
+	defer logger.Close()
+
+	if fs.NArg() > 0 {
+		m, err := mf.build(strings.Join(fs.Args(), " "))
+		if err != nil {
+			return err
+		}
+		return logger.WriteMessage(m)
+	}
+	return eachLine(nil, func(name string, n int, line []byte) error {
+		m := rfc5424.Message{}
+		if err := m.UnmarshalBinary(line); err != nil {
+			return fmt.Errorf("%s:%d: %s", name, n, err)
+		}
+		return logger.WriteMessage(m)
+	})
+}
+
+// outputFlags registers the flags of the output to send messages to
+func outputFlags(fs *flag.FlagSet, output *rfc5424.OutputConfig) {
+	fs.StringVar(&output.Network, "network", "udp", "udp, tcp or tls")
+	fs.StringVar(&output.Address, "addr", "", "address of the collector, host:port")
+	fs.StringVar(&output.Profile, "profile", "", "collector profile, e.g. rsyslog or splunk")
+	fs.Var(&output.Framing, "framing", "octet-counting or non-transparent, for tcp and tls")
+	fs.StringVar(&output.CAFile, "ca", "", "PEM file of the CAs trusted to verify a tls collector")
+}
+
+func replay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	output := rfc5424.OutputConfig{}
+	outputFlags(fs, &output)
+	replayer := rfc5424.Replayer{}
+	fs.Float64Var(&replayer.Rate, "rate", 0, "most messages sent per second, if set")
+	fs.Var(&replayer.Timestamps, "timestamps", "keep, now or shift the timestamps")
+	dir := fs.String("store", "", "FileStore directory to replay")
+	start := fs.String("start", "", "RFC 3339 time of the first message replayed from -store")
+	end := fs.String("end", "", "RFC 3339 time after the last message replayed from -store")
+	fs.Parse(args)
+	if output.Address == "" {
+		return fmt.Errorf("replay: -addr is required")
+	}
+	startTime, endTime := time.Time{}, time.Unix(1<<62, 0)
+	for _, t := range []struct {
+		value string
+		time  *time.Time
+	}{{*start, &startTime}, {*end, &endTime}} {
+		if t.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, t.value)
+		if err != nil {
+			return fmt.Errorf("replay: %s", err)
+		}
+		*t.time = parsed
+	}
+
+	logger, err := rfc5424.Config{Outputs: []rfc5424.OutputConfig{output}}.Build()
+	if err != nil {
+		return err
+	}
+	defer logger.Close()
+	replayer.Writer = logger
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	total := 0
+	defer func() { fmt.Fprintf(os.Stderr, "%d messages replayed\n", total) }()
+	if *dir != "" {
+		store, err := rfc5424.OpenFileStore(*dir)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		total, err = replayer.ReplayStore(ctx, store, startTime, endTime)
+		return err
+	}
+	if fs.NArg() == 0 {
+		total, err = replayer.ReplayFrames(ctx, os.Stdin)
+		return err
+	}
+	for _, name := range fs.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		n, err := replayer.ReplayFrames(ctx, f)
+		f.Close()
+		total += n
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+func receive(args []string) error {
+	fs := flag.NewFlagSet("receive", flag.ExitOnError)
+	network := fs.String("network", "udp", "udp, tcp, tls or relp")
+	addr := fs.String("addr", ":514", "address to listen on")
+	certFile := fs.String("cert", "", "PEM certificate file, for tls")
+	keyFile := fs.String("key", "", "PEM key file, for tls")
+	asJSON := fs.Bool("json", false, "print each message as a JSON object")
+	rfc3164 := fs.Bool("rfc3164", false, "also accept BSD syslog (RFC-3164) messages")
+	fs.Parse(args)
+
+	out := make(chan rfc5424.Message, 64)
+	go func() {
+		for m := range out {
+			printMessage(os.Stdout, m, *asJSON)
+		}
+	}()
+	srv := rfc5424.NewServer(rfc5424.HandlerFunc(func(ctx context.Context, m rfc5424.Message, src rfc5424.Source) {
+		out <- m.Clone()
+	}))
+	srv.AcceptRFC3164 = *rfc3164
+
+	switch *network {
+	case "udp":
+		return srv.ListenAndServeUDP(*addr)
+	case "tcp":
+		return srv.ListenAndServeTCP(*addr)
+	case "tls":
+		return srv.ListenAndServeTLS(*addr, *certFile, *keyFile)
+	case "relp":
+		return srv.ListenAndServeRELP(*addr)
+	}
+	return fmt.Errorf("receive: unknown network %q", *network)
+}
@@ -0,0 +1,112 @@
+package rfc5424
+
+import (
+	"bufio"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// commandLineLength is the longest line of output in a single message.
+// Longer lines are split.
+const commandLineLength = 4096
+
+// CommandOutput captures the output of child processes, such as the
+// programs run by a supervisor, into messages written to Writer: a message
+// for each line, at Info for standard output and Error for standard error.
+// The APP-NAME of the messages is the name of the program and the PROCID is
+// the ID of the child process.
+type CommandOutput struct {
+	Writer MessageWriter
+
+	// AppName, if set, is the APP-NAME of the messages, instead of the base
+	// name of the program run
+	AppName string
+
+	// Facility is the facility of the messages. If zero, Local0 is used.
+	Facility Facility
+
+	// Error, if set, is called with the errors returned by Writer. Output
+	// keeps being read, so that the child does not block.
+	Error func(err error)
+
+	// mu serializes the writes of the lines of both outputs
+	mu sync.Mutex
+}
+
+// Run starts cmd with its standard output and error captured, and waits for
+// it to exit, like cmd.Run
+func (co *CommandOutput) Run(cmd *exec.Cmd) error {
+	wait, err := co.Start(cmd)
+	if err != nil {
+		return err
+	}
+	return wait()
+}
+
+// Start starts cmd with its standard output and error captured. cmd.Stdout
+// and cmd.Stderr must be nil. wait reads the rest of the output and waits
+// for cmd to exit; it must be called instead of cmd.Wait.
+func (co *CommandOutput) Start(cmd *exec.Cmd) (wait func() error, err error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	appName := co.AppName
+	if appName == "" {
+		appName = filepath.Base(cmd.Path)
+	}
+	processID := strconv.Itoa(cmd.Process.Pid)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		co.capture(stdout, Info, appName, processID)
+	}()
+	go func() {
+		defer wg.Done()
+		co.capture(stderr, Error, appName, processID)
+	}()
+	return func() error {
+		wg.Wait()
+		return cmd.Wait()
+	}, nil
+}
+
+// capture writes the lines read from r until the end of the output
+func (co *CommandOutput) capture(r io.Reader, severity Severity, appName, processID string) {
+	facility := co.Facility
+	if facility == DefaultFacility {
+		facility = defaultFacility
+	}
+	br := bufio.NewReaderSize(r, commandLineLength)
+	for {
+		line, _, err := br.ReadLine()
+		if len(line) > 0 {
+			m := defaultMessage(severity)
+			m.Priority = int(severity-Emergency) | (int(facility-Kernel) << 3)
+			m.AppName, m.ProcessID = appName, processID
+			m.SetTextMessage(strings.ToValidUTF8(string(line), "\ufffd"))
+			co.mu.Lock()
+			writeErr := co.Writer.WriteMessage(m)
+			co.mu.Unlock()
+			if writeErr != nil && co.Error != nil {
+				co.Error(writeErr)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package rfc5424
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&CommandOutputTest{})
+
+type CommandOutputTest struct {
+}
+
+func (s *CommandOutputTest) TestRun(c *C) {
+	w := &chanWriter{messages: make(chan Message, 10)}
+	co := &CommandOutput{Writer: w, Facility: Daemon}
+	cmd := exec.Command("sh", "-c", "echo started; echo failed >&2; echo; printf done")
+	c.Assert(co.Run(cmd), IsNil)
+	close(w.messages)
+
+	var stdout, stderr []string
+	for m := range w.messages {
+		c.Assert(m.AppName, Equals, "sh")
+		c.Assert(m.ProcessID, Equals, strconv.Itoa(cmd.Process.Pid))
+		c.Assert(m.Facility(), Equals, Facility(Daemon))
+		if m.Severity() == Error {
+			stderr = append(stderr, m.TextMessage())
+		} else {
+			c.Assert(m.Severity(), Equals, Severity(Info))
+			stdout = append(stdout, m.TextMessage())
+		}
+	}
+	c.Assert(stdout, DeepEquals, []string{"started", "done"})
+	c.Assert(stderr, DeepEquals, []string{"failed"})
+}
+
+func (s *CommandOutputTest) TestLongLines(c *C) {
+	w := &chanWriter{messages: make(chan Message, 10)}
+	co := &CommandOutput{Writer: w, AppName: "job"}
+	cmd := exec.Command("sh", "-c", "printf '%5000s\\n' x")
+	c.Assert(co.Run(cmd), IsNil)
+	close(w.messages)
+
+	var lines []string
+	for m := range w.messages {
+		c.Assert(m.AppName, Equals, "job")
+		lines = append(lines, m.TextMessage())
+	}
+	c.Assert(lines, HasLen, 2)
+	c.Assert(len(lines[0]), Equals, 4096)
+	c.Assert(strings.Join(lines, ""), Equals, strings.Repeat(" ", 4999)+"x")
+}
+
+func (s *CommandOutputTest) TestExitStatus(c *C) {
+	w := &chanWriter{messages: make(chan Message, 10)}
+	co := &CommandOutput{Writer: w}
+	c.Assert(co.Run(exec.Command("sh", "-c", "exit 3")), ErrorMatches, "exit status 3")
+
+	cmd := exec.Command("sh")
+	cmd.Stdout = &strings.Builder{}
+	_, err := co.Start(cmd)
+	c.Assert(err, ErrorMatches, ".*Stdout already set")
+}
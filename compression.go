@@ -0,0 +1,64 @@
+package rfc5424
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Compression identifies the compression applied to a stream of messages.
+//
+// Only gzip is provided, since it is available in the standard library.
+// Syslog text typically compresses very well, which matters on
+// bandwidth-constrained links to central collectors.
+type Compression int
+
+const (
+	// NoCompression sends messages as-is
+	NoCompression Compression = iota
+	// GzipCompression wraps the stream in gzip (RFC-1952)
+	GzipCompression
+)
+
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case GzipCompression:
+		return "gzip"
+	}
+	return fmt.Sprintf("Compression(%d)", int(c))
+}
+
+type compressWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+func newCompressWriter(w io.Writer, c Compression) (compressWriter, error) {
+	switch c {
+	case GzipCompression:
+		return gzip.NewWriter(w), nil
+	}
+	return nil, fmt.Errorf("unsupported compression %s", c)
+}
+
+// NewStreamReader returns a reader that decompresses a stream written by a
+// StreamWriter configured with the same Compression. The result can be passed
+// to NewDecoder or Message.ReadFrom.
+func NewStreamReader(r io.Reader, c Compression) (io.Reader, error) {
+	switch c {
+	case NoCompression:
+		return r, nil
+	case GzipCompression:
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		// ReadFrom scans the length prefix with fmt, which over-reads by a
+		// rune unless the reader implements io.RuneScanner.
+		return bufio.NewReader(zr), nil
+	}
+	return nil, fmt.Errorf("unsupported compression %s", c)
+}
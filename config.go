@@ -0,0 +1,234 @@
+package rfc5424
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+)
+
+// Config describes a Logger declaratively, so that where messages are sent
+// and how they are filtered can be managed in configuration files rather
+// than code. It can be loaded with encoding/json, or a YAML package using
+// the "yaml" tags:
+//
+//	appName: api
+//	outputs:
+//	- network: tls
+//	  address: logs.example.com:6514
+//	  profile: rsyslog
+//	filters:
+//	  rules:
+//	  - maxSeverity: debug
+//	    action: drop
+type Config struct {
+	// Hostname, AppName and Facility are used for the messages built by
+	// Logger.Log (see Logger).
+	Hostname string   `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	AppName  string   `json:"appName,omitempty" yaml:"appName,omitempty"`
+	Facility Facility `json:"facility,omitempty" yaml:"facility,omitempty"`
+
+	// StrictSDIDs makes the Logger refuse messages that do not follow the
+	// full RFC-5424 grammar for SD-IDs and parameter names.
+	StrictSDIDs bool `json:"strictSDIDs,omitempty" yaml:"strictSDIDs,omitempty"`
+
+	// Outputs lists where messages are sent. Messages go to every output
+	// without a Route.
+	Outputs []OutputConfig `json:"outputs" yaml:"outputs"`
+
+	// Filters drops, tags and routes messages before they are sent
+	Filters RuleSet `json:"filters,omitempty" yaml:"filters,omitempty"`
+
+	// StructuredData lists the parameters added to every message
+	StructuredData []SDTag `json:"structuredData,omitempty" yaml:"structuredData,omitempty"`
+
+	// MaxLength is the longest message sent to outputs that do not set
+	// their own MaxLength or use a Profile that does. Longer messages are
+	// truncated. If zero, there is no limit.
+	MaxLength int `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+}
+
+// OutputConfig describes a destination of a Config
+type OutputConfig struct {
+	// Network is "tcp", "tls" or "udp"
+	Network string `json:"network" yaml:"network"`
+	Address string `json:"address" yaml:"address"`
+
+	// Route, if set, makes the output only receive the messages routed to
+	// it by filter rules with the route action.
+	Route string `json:"route,omitempty" yaml:"route,omitempty"`
+
+	// Profile, if set, names the collector profile (see LookupProfile) the
+	// messages are adapted to. It also sets the framing and length limit.
+	Profile string `json:"profile,omitempty" yaml:"profile,omitempty"`
+
+	// Framing is how messages are delimited on tcp and tls outputs without
+	// a Profile. By default they are octet-counted.
+	Framing Framing `json:"framing,omitempty" yaml:"framing,omitempty"`
+
+	// MaxLength, if set, is the longest message sent to the output
+	MaxLength int `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+
+	// CAFile, if set, is a PEM file of the certificate authorities trusted
+	// to verify a tls output. By default the system's are used.
+	CAFile string `json:"caFile,omitempty" yaml:"caFile,omitempty"`
+
+	// Rewrite, if set, changes the fields of the messages sent to the
+	// output, before they are adapted to its Profile
+	Rewrite *FieldRewrite `json:"rewrite,omitempty" yaml:"rewrite,omitempty"`
+}
+
+// packetWriter is a MessageWriter and Transport that sends each message in
+// a single datagram, as described in RFC-5426
+type packetWriter struct {
+	counters transportCounters
+	conn     net.Conn
+}
+
+func (pw *packetWriter) WriteMessage(m Message) error {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		pw.counters.record(0, err)
+		return err
+	}
+	return pw.writeMarshaled(m, b)
+}
+
+func (pw *packetWriter) writeMarshaled(m Message, b []byte) error {
+	_, err := pw.conn.Write(b)
+	pw.counters.record(int64(len(b)), err)
+	return err
+}
+
+func (pw *packetWriter) Send(m Message) error {
+	return pw.WriteMessage(m)
+}
+
+// Flush does nothing, datagrams being sent as they are written
+func (pw *packetWriter) Flush() error {
+	return nil
+}
+
+func (pw *packetWriter) Stats() TransportStats {
+	return pw.counters.stats()
+}
+
+func (pw *packetWriter) Close() error {
+	return pw.conn.Close()
+}
+
+// tlsConfig returns the configuration used to connect to a tls output
+func (o OutputConfig) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{}
+	if o.CAFile == "" {
+		return config, nil
+	}
+	pem, err := ioutil.ReadFile(o.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	config.RootCAs = x509.NewCertPool()
+	if !config.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", o.CAFile)
+	}
+	return config, nil
+}
+
+// writer connects to the output. maxLength is the limit used if neither
+// the output nor its profile sets one.
+func (o OutputConfig) writer(maxLength int) (MessageWriter, error) {
+	profile := Profile{Framing: o.Framing}
+	if o.Profile != "" {
+		p, ok := LookupProfile(o.Profile)
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q", o.Profile)
+		}
+		profile = p
+	}
+	if o.MaxLength > 0 {
+		profile.MaxLength = o.MaxLength
+	} else if profile.MaxLength == 0 {
+		profile.MaxLength = maxLength
+	}
+
+	switch o.Network {
+	case "tcp":
+		conn, err := net.Dial("tcp", o.Address)
+		if err != nil {
+			return nil, err
+		}
+		return profile.StreamWriter(conn), nil
+	case "tls":
+		config, err := o.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		conn, err := tls.Dial("tcp", o.Address, config)
+		if err != nil {
+			return nil, err
+		}
+		return profile.StreamWriter(conn), nil
+	case "udp":
+		conn, err := net.Dial("udp", o.Address)
+		if err != nil {
+			return nil, err
+		}
+		return profile.Writer(&packetWriter{conn: conn}), nil
+	}
+	return nil, fmt.Errorf("unknown network %q", o.Network)
+}
+
+// Build connects to the outputs and returns a Logger sending messages to
+// them. If any output cannot be reached, the connections already made are
+// closed and the error is returned.
+func (c Config) Build() (*Logger, error) {
+	l := &Logger{
+		Facility:    c.Facility,
+		Hostname:    c.Hostname,
+		AppName:     c.AppName,
+		StrictSDIDs: c.StrictSDIDs,
+		Rules:       c.Filters,
+	}
+	sd := Message{}
+	for _, tag := range c.StructuredData {
+		sd.AddDatum(tag.ID, tag.Name, tag.Value)
+	}
+	l.StructuredData = sd.StructuredData
+
+	var writers, opened []MessageWriter
+	fail := func(i int, err error) (*Logger, error) {
+		for _, w := range opened {
+			w.Close()
+		}
+		return nil, fmt.Errorf("rfc5424: output %d: %s", i, err)
+	}
+	for i, output := range c.Outputs {
+		if _, used := l.Routes[output.Route]; used {
+			return fail(i, fmt.Errorf("route %q is used twice", output.Route))
+		}
+		w, err := output.writer(c.MaxLength)
+		if err != nil {
+			return fail(i, err)
+		}
+		opened = append(opened, w)
+		if output.Rewrite != nil {
+			w = output.Rewrite.Writer(w)
+		}
+		if output.Route == "" {
+			writers = append(writers, w)
+			continue
+		}
+		if l.Routes == nil {
+			l.Routes = map[string]MessageWriter{}
+		}
+		l.Routes[output.Route] = w
+	}
+
+	if len(writers) == 1 {
+		l.Writer = writers[0]
+	} else {
+		l.Writer = MultiMessageWriter{Writers: writers}
+	}
+	return l, nil
+}
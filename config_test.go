@@ -0,0 +1,90 @@
+package rfc5424
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&ConfigTest{})
+
+type ConfigTest struct {
+}
+
+func (s *ConfigTest) TestBuild(c *C) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer l.Close()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer pc.Close()
+
+	config := Config{}
+	c.Assert(json.Unmarshal([]byte(`{
+		"hostname": "host",
+		"appName": "app",
+		"facility": "auth",
+		"outputs": [
+			{"network": "tcp", "address": "`+l.Addr().String()+`", "framing": "non-transparent"},
+			{"network": "udp", "address": "`+pc.LocalAddr().String()+`", "route": "alerts"}
+		],
+		"filters": {"rules": [
+			{"maxSeverity": "debug", "action": "drop"},
+			{"minSeverity": "crit", "action": "route", "route": "alerts"}
+		]},
+		"structuredData": [{"id": "env@32473", "name": "dc", "value": "eu"}]
+	}`), &config), IsNil)
+
+	logger, err := config.Build()
+	c.Assert(err, IsNil)
+	conn, err := l.Accept()
+	c.Assert(err, IsNil)
+	defer conn.Close()
+	defer func() { TimeNow = time.Now }()
+	TimeNow = func() time.Time { return T("2003-10-11T22:14:15.003Z") }
+
+	c.Assert(logger.Log(Debug, "dropped"), IsNil)
+	c.Assert(logger.Log(Warning, "disk filling up"), IsNil)
+	c.Assert(logger.Log(Critical, "disk full"), IsNil)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	c.Assert(err, IsNil)
+	c.Assert(line, Equals, "<36>1 2003-10-11T22:14:15.003Z host app "+processPID+" - [env@32473 dc=\"eu\"] \ufeffdisk filling up\n")
+
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	c.Assert(err, IsNil)
+	c.Assert(string(buf[:n]), Equals, "<34>1 2003-10-11T22:14:15.003Z host app "+processPID+" - [env@32473 dc=\"eu\"] \ufeffdisk full")
+
+	c.Assert(logger.Close(), IsNil)
+}
+
+func (s *ConfigTest) TestBuildErrors(c *C) {
+	_, err := Config{Outputs: []OutputConfig{{Network: "carrier-pigeon"}}}.Build()
+	c.Assert(err, ErrorMatches, `rfc5424: output 0: unknown network "carrier-pigeon"`)
+
+	_, err = Config{Outputs: []OutputConfig{{Network: "udp", Address: "127.0.0.1:514", Profile: "nope"}}}.Build()
+	c.Assert(err, ErrorMatches, `rfc5424: output 0: unknown profile "nope"`)
+
+	_, err = Config{Outputs: []OutputConfig{
+		{Network: "udp", Address: "127.0.0.1:514", Route: "a"},
+		{Network: "udp", Address: "127.0.0.1:514", Route: "a"},
+	}}.Build()
+	c.Assert(err, ErrorMatches, `rfc5424: output 1: route "a" is used twice`)
+}
+
+func (s *ConfigTest) TestFramingText(c *C) {
+	b, err := json.Marshal(NonTransparentFraming)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `"non-transparent"`)
+
+	var f Framing
+	c.Assert(json.Unmarshal([]byte(`"octet-counting"`), &f), IsNil)
+	c.Assert(f, Equals, OctetCounting)
+	c.Assert(json.Unmarshal([]byte(`"smoke-signals"`), &f), ErrorMatches, ".*Framing is invalid: smoke-signals")
+}
@@ -0,0 +1,213 @@
+package rfc5424
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+const defaultContainerSDID = "container@32473"
+
+// ContainerLogFormat is the format of a container runtime's log files
+type ContainerLogFormat int
+
+const (
+	// DetectContainerLogFormat chooses the format from the first line
+	DetectContainerLogFormat ContainerLogFormat = iota
+	// DockerJSONLogFormat is Docker's json-file format, one JSON object
+	// per line: {"log":"...\n","stream":"stdout","time":"..."}
+	DockerJSONLogFormat
+	// CRILogFormat is the format of the Kubernetes Container Runtime
+	// Interface, as written by containerd and CRI-O: "TIME STREAM TAG LOG",
+	// where TAG is P for a partial line and F for the end of one.
+	CRILogFormat
+)
+
+// containerRecord is one line of a container log
+type containerRecord struct {
+	time    time.Time
+	stream  string
+	log     []byte
+	partial bool
+}
+
+// ContainerLogReader converts a container's log stream into Messages, for
+// sidecars shipping container logs to a collector. Lines split by the
+// runtime are joined again. Each message has an SD element with the
+// "container_id" and the "stream" (stdout or stderr) it was written to;
+// lines written to stderr have severity Error and the others Info.
+type ContainerLogReader struct {
+	Format      ContainerLogFormat
+	ContainerID string
+
+	// Hostname and AppName are the HOSTNAME and APP-NAME of the messages.
+	// By default the HOSTNAME is chosen by DefaultHostnameResolver and the
+	// APP-NAME is the first 12 characters of ContainerID, as shown by
+	// docker ps.
+	Hostname string
+	AppName  string
+
+	// Facility is the facility of the messages. If zero, Local0 is used.
+	Facility Facility
+
+	// SDID is the ID of the SD element added to each message. If empty,
+	// "container@32473" is used (see DocumentationEnterpriseNumber).
+	SDID string
+
+	r       *bufio.Reader
+	pending map[string]*containerRecord
+}
+
+// NewContainerLogReader returns a ContainerLogReader reading the log of the
+// container with the given ID from r
+func NewContainerLogReader(r io.Reader, containerID string) *ContainerLogReader {
+	return &ContainerLogReader{ContainerID: containerID, r: bufio.NewReader(r)}
+}
+
+// parseDockerJSON parses a line of a json-file log
+func parseDockerJSON(line []byte) (containerRecord, error) {
+	var entry struct {
+		Log    string    `json:"log"`
+		Stream string    `json:"stream"`
+		Time   time.Time `json:"time"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return containerRecord{}, err
+	}
+	rec := containerRecord{time: entry.Time, stream: entry.Stream, log: []byte(entry.Log)}
+	rec.partial = !bytes.HasSuffix(rec.log, []byte{'\n'})
+	rec.log = bytes.TrimSuffix(rec.log, []byte{'\n'})
+	return rec, nil
+}
+
+// parseCRI parses a line of a CRI log
+func parseCRI(line []byte) (containerRecord, error) {
+	fields := bytes.SplitN(line, []byte{' '}, 4)
+	if len(fields) < 3 {
+		return containerRecord{}, fmt.Errorf("missing fields")
+	}
+	t, err := time.Parse(time.RFC3339Nano, string(fields[0]))
+	if err != nil {
+		return containerRecord{}, err
+	}
+	rec := containerRecord{time: t, stream: string(fields[1])}
+	switch string(fields[2]) {
+	case "P":
+		rec.partial = true
+	case "F":
+	default:
+		return containerRecord{}, fmt.Errorf("unknown tag %q", fields[2])
+	}
+	if len(fields) == 4 {
+		rec.log = fields[3]
+	}
+	return rec, nil
+}
+
+// readRecord returns the next line of the log
+func (cr *ContainerLogReader) readRecord() (containerRecord, error) {
+	line, err := cr.r.ReadBytes('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	if err != nil {
+		return containerRecord{}, err
+	}
+	line = bytes.TrimRight(line, "\r\n")
+
+	if cr.Format == DetectContainerLogFormat {
+		cr.Format = CRILogFormat
+		if bytes.HasPrefix(line, []byte{'{'}) {
+			cr.Format = DockerJSONLogFormat
+		}
+	}
+	var rec containerRecord
+	if cr.Format == DockerJSONLogFormat {
+		rec, err = parseDockerJSON(line)
+	} else {
+		rec, err = parseCRI(line)
+	}
+	if err != nil {
+		return rec, fmt.Errorf("rfc5424: malformed container log line %q: %s", line, err)
+	}
+	return rec, nil
+}
+
+// ReadMessage returns the message for the next complete line of the log, or
+// io.EOF at the end of the stream. Partial lines left at the end of the
+// stream are returned as they are.
+func (cr *ContainerLogReader) ReadMessage() (Message, error) {
+	if cr.pending == nil {
+		cr.pending = map[string]*containerRecord{}
+	}
+	for {
+		rec, err := cr.readRecord()
+		if err == io.EOF {
+			for stream, pending := range cr.pending {
+				delete(cr.pending, stream)
+				return cr.message(*pending), nil
+			}
+		}
+		if err != nil {
+			return Message{}, err
+		}
+
+		if pending, ok := cr.pending[rec.stream]; ok {
+			pending.log = append(pending.log, rec.log...)
+			if rec.partial {
+				continue
+			}
+			delete(cr.pending, rec.stream)
+			return cr.message(*pending), nil
+		}
+		if rec.partial {
+			rec.log = append([]byte(nil), rec.log...)
+			cr.pending[rec.stream] = &rec
+			continue
+		}
+		return cr.message(rec), nil
+	}
+}
+
+// message returns the Message for a complete line
+func (cr *ContainerLogReader) message(rec containerRecord) Message {
+	severity := Severity(Info)
+	if rec.stream == "stderr" {
+		severity = Error
+	}
+	facility := cr.Facility
+	if facility == DefaultFacility {
+		facility = defaultFacility
+	}
+	m := Message{
+		Priority:  int(severity-Emergency) | int(facility-Kernel)<<3,
+		Timestamp: rec.time.UTC(),
+		Hostname:  cr.Hostname,
+		AppName:   cr.AppName,
+	}
+	if m.Hostname == "" {
+		m.Hostname = DefaultHostnameResolver.Resolve()
+	}
+	if m.AppName == "" {
+		m.AppName = cr.ContainerID
+		if len(m.AppName) > 12 {
+			m.AppName = m.AppName[:12]
+		}
+	}
+	sdID := cr.SDID
+	if sdID == "" {
+		sdID = defaultContainerSDID
+	}
+	m.AddDatum(sdID, "container_id", cr.ContainerID)
+	m.AddDatum(sdID, "stream", rec.stream)
+	if utf8.Valid(rec.log) {
+		m.SetTextMessage(string(rec.log))
+	} else {
+		m.SetBinaryMessage(rec.log)
+	}
+	return m
+}
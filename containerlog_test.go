@@ -0,0 +1,64 @@
+package rfc5424
+
+import (
+	"io"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&ContainerLogTest{})
+
+type ContainerLogTest struct {
+}
+
+const testContainerID = "4f66ad9a0b2e9c8f7e1d3b5a6c7d8e9f0a1b2c3d4e5f60718293a4b5c6d7e8f9"
+
+func readContainerLog(c *C, log string) []string {
+	cr := NewContainerLogReader(strings.NewReader(log), testContainerID)
+	cr.Hostname = "node1"
+	var lines []string
+	for {
+		m, err := cr.ReadMessage()
+		if err == io.EOF {
+			return lines
+		}
+		c.Assert(err, IsNil)
+		b, err := m.MarshalBinary()
+		c.Assert(err, IsNil)
+		lines = append(lines, string(b))
+	}
+}
+
+func (s *ContainerLogTest) TestDockerJSON(c *C) {
+	lines := readContainerLog(c, `{"log":"started\n","stream":"stdout","time":"2019-04-30T12:00:00.123456789Z"}
+{"log":"a very ","stream":"stderr","time":"2019-04-30T12:00:01Z"}
+{"log":"other\n","stream":"stdout","time":"2019-04-30T12:00:02Z"}
+{"log":"long line\n","stream":"stderr","time":"2019-04-30T12:00:03Z"}
+`)
+	c.Assert(lines, DeepEquals, []string{
+		`<134>1 2019-04-30T12:00:00.123456Z node1 4f66ad9a0b2e - - [container@32473 container_id="` + testContainerID + `" stream="stdout"] ` + "\ufeffstarted",
+		`<134>1 2019-04-30T12:00:02Z node1 4f66ad9a0b2e - - [container@32473 container_id="` + testContainerID + `" stream="stdout"] ` + "\ufeffother",
+		`<131>1 2019-04-30T12:00:01Z node1 4f66ad9a0b2e - - [container@32473 container_id="` + testContainerID + `" stream="stderr"] ` + "\ufeffa very long line",
+	})
+}
+
+func (s *ContainerLogTest) TestCRI(c *C) {
+	lines := readContainerLog(c, "2016-10-06T00:17:09.669794202Z stdout F hello\n"+
+		"2016-10-06T00:17:10Z stdout P split \n"+
+		"2016-10-06T00:17:10Z stdout F across lines\n"+
+		"2016-10-06T00:17:11Z stderr F \n"+
+		"2016-10-06T00:17:12Z stderr P unterminated")
+	c.Assert(lines, DeepEquals, []string{
+		`<134>1 2016-10-06T00:17:09.669794Z node1 4f66ad9a0b2e - - [container@32473 container_id="` + testContainerID + `" stream="stdout"] ` + "\ufeffhello",
+		`<134>1 2016-10-06T00:17:10Z node1 4f66ad9a0b2e - - [container@32473 container_id="` + testContainerID + `" stream="stdout"] ` + "\ufeffsplit across lines",
+		`<131>1 2016-10-06T00:17:11Z node1 4f66ad9a0b2e - - [container@32473 container_id="` + testContainerID + `" stream="stderr"] ` + "\ufeff",
+		`<131>1 2016-10-06T00:17:12Z node1 4f66ad9a0b2e - - [container@32473 container_id="` + testContainerID + `" stream="stderr"] ` + "\ufeffunterminated",
+	})
+}
+
+func (s *ContainerLogTest) TestMalformed(c *C) {
+	cr := NewContainerLogReader(strings.NewReader("2016-10-06T00:17:09Z stdout X hello\n"), testContainerID)
+	_, err := cr.ReadMessage()
+	c.Assert(err, ErrorMatches, `rfc5424: malformed container log line .*: unknown tag "X"`)
+}
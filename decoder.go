@@ -1,8 +1,11 @@
 package rfc5424
 
 import (
+	"fmt"
 	"io"
+	"io/ioutil"
 	"reflect"
+	"strconv"
 )
 
 type Decoder struct {
@@ -13,12 +16,95 @@ func NewDecoder(r io.Reader) *Decoder {
 	return &Decoder{Reader: r}
 }
 
+// decoderMessages holds the messages read by Decoders, which are only used
+// until their fields are copied out
+var decoderMessages MessagePool
+
 func (d Decoder) Decode(ob interface{}) error {
-	m := Message{}
+	m := decoderMessages.Get()
+	defer decoderMessages.Put(m)
 	if _, err := m.ReadFrom(d.Reader); err != nil {
 		return err
 	}
-	return d.decode(&m, ob)
+	return d.decode(m, ob)
+}
+
+// DecodeMessage reads the next message from Reader into m. m is Reset first,
+// so the StructuredData slices of the previous message read into it are
+// reused rather than allocated again; they must not be kept by the caller
+// from one call to the next.
+func (d Decoder) DecodeMessage(m *Message) error {
+	m.Reset()
+	_, err := m.ReadFrom(d.Reader)
+	return err
+}
+
+// Read reads the next message from Reader into p, octet-counted, making a
+// Decoder an io.Reader. Like Decode, it reads octet-counted frames of at
+// most 8192 octets, and returns io.EOF at the end of the stream. Frames are
+// read whole, so p must have room for the next one, or io.ErrShortBuffer is
+// returned and the message skipped; 8197 octets are always enough.
+// io.Copy uses WriteTo instead.
+func (d Decoder) Read(p []byte) (int, error) {
+	var length int
+	if _, err := fmt.Fscanf(d.Reader, "%d ", &length); err != nil {
+		return 0, err
+	}
+	if length <= 0 {
+		return 0, BadFormat("MSG-LEN")
+	}
+	if length > defaultMaxMessageLength {
+		return 0, ErrMessageTooLong
+	}
+	header := strconv.AppendInt(nil, int64(length), 10)
+	header = append(header, ' ')
+	if len(header)+length > len(p) {
+		io.CopyN(ioutil.Discard, d.Reader, int64(length))
+		return 0, io.ErrShortBuffer
+	}
+	n := copy(p, header)
+	if _, err := io.ReadFull(d.Reader, p[n:n+length]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	m := Message{}
+	if err := m.UnmarshalBinary(p[n : n+length]); err != nil {
+		return 0, err
+	}
+	return n + length, nil
+}
+
+// WriteTo copies the messages read from Reader to w until the end of the
+// stream, implementing io.WriterTo, so that io.Copy from a Decoder to an
+// Encoder relays the messages without marshaling them again. The frames read may be octet-counted or
+// non-transparent, and are limited to 8192 octets. Each is parsed to check
+// that it holds a message, then written to w octet-counted as it was read,
+// without being marshaled again. It returns the number of bytes written.
+func (d Decoder) WriteTo(w io.Writer) (int64, error) {
+	fr := newFrameReader(d.Reader, defaultMaxMessageLength)
+	m := Message{}
+	var frame []byte
+	var written int64
+	for {
+		b, err := fr.ReadFrame()
+		if err == io.EOF {
+			return written, nil
+		} else if err != nil {
+			return written, err
+		}
+		m.Reset()
+		if err := m.UnmarshalBinary(b); err != nil {
+			return written, err
+		}
+		frame, _ = OctetCounting.appendFrame(frame[:0], b, m)
+		n, err := w.Write(frame)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
 }
 
 func (d Decoder) decode(m *Message, ob interface{}) error {
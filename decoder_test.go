@@ -0,0 +1,111 @@
+package rfc5424
+
+import (
+	"bytes"
+	"io"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&DecoderTest{})
+
+type DecoderTest struct {
+}
+
+func (s *DecoderTest) TestDecodeMessageReusesMessage(c *C) {
+	r := bytes.NewBuffer(octetCounted(
+		`<34>1 - host - - - [a@1 x="1" y="2"] one`,
+		`<34>1 - - - - - [b@1 z="3"]`,
+		`<34>1 - - app - - - three`))
+	d := NewDecoder(r)
+
+	m := Message{}
+	c.Assert(d.DecodeMessage(&m), IsNil)
+	c.Assert(m.Hostname, Equals, "host")
+	c.Assert(string(m.Message), Equals, "one")
+	sd := &m.StructuredData[0]
+	params := &m.StructuredData[0].Parameters[0]
+
+	c.Assert(d.DecodeMessage(&m), IsNil)
+	c.Assert(m.Hostname, Equals, "")
+	c.Assert(m.Message, IsNil)
+	c.Assert(m.StructuredData, DeepEquals, []StructuredData{{ID: "b@1", Parameters: []SDParam{{Name: "z", Value: "3"}}}})
+	c.Assert(&m.StructuredData[0], Equals, sd)
+	c.Assert(&m.StructuredData[0].Parameters[0], Equals, params)
+
+	c.Assert(d.DecodeMessage(&m), IsNil)
+	c.Assert(m.AppName, Equals, "app")
+	c.Assert(m.StructuredData, HasLen, 0)
+	c.Assert(string(m.Message), Equals, "three")
+
+	c.Assert(d.DecodeMessage(&m), Equals, io.EOF)
+}
+
+func (s *DecoderTest) TestWriteToAndReadFrom(c *C) {
+	stream := "<34>1 - host - - - [a@1 x=\"1\"] one\n<34>1 2003-10-11T22:14:15.00Z - - - - - two\n"
+
+	out := &bytes.Buffer{}
+	n, err := NewDecoder(bytes.NewBufferString(stream)).WriteTo(out)
+	c.Assert(err, IsNil)
+	c.Assert(out.String(), Equals, "34 <34>1 - host - - - [a@1 x=\"1\"] one"+
+		"43 <34>1 2003-10-11T22:14:15.00Z - - - - - two") // not marshaled again
+	c.Assert(n, Equals, int64(out.Len()))
+
+	relayed := &bytes.Buffer{}
+	n, err = NewEncoder(relayed).ReadFrom(bytes.NewBufferString(out.String()))
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(out.Len()))
+	c.Assert(relayed.String(), Equals, out.String())
+
+	// with a Profile each message is adapted and marshaled again
+	relayed.Reset()
+	e := NewEncoder(relayed)
+	e.Profile = &GraylogProfile
+	_, err = e.ReadFrom(bytes.NewBufferString(stream))
+	c.Assert(err, IsNil)
+	c.Assert(relayed.String(), Equals, "<34>1 - host - - - [a@1 x=\"1\"] one\n<34>1 2003-10-11T22:14:15Z - - - - - two\n")
+
+	// invalid messages stop the copy
+	out.Reset()
+	_, err = NewDecoder(bytes.NewBufferString("<34>1 - - - - - - ok\nnot syslog\n")).WriteTo(out)
+	c.Assert(err, NotNil)
+	c.Assert(out.String(), Equals, "20 <34>1 - - - - - - ok")
+
+	var _ io.WriterTo = Decoder{}
+	var _ io.ReaderFrom = Encoder{}
+}
+
+func (s *DecoderTest) TestCopy(c *C) {
+	stream := "34 <34>1 - host - - - [a@1 x=\"1\"] one" + "21 <34>1 - - - - - - two"
+
+	// io.Copy uses WriteTo
+	out := &bytes.Buffer{}
+	n, err := io.Copy(NewEncoder(out), NewDecoder(bytes.NewBufferString(stream)))
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(len(stream)))
+	c.Assert(out.String(), Equals, stream)
+
+	// and ReadFrom for other sources
+	out.Reset()
+	_, err = io.Copy(NewEncoder(out), struct{ io.Reader }{bytes.NewBufferString(stream)})
+	c.Assert(err, IsNil)
+	c.Assert(out.String(), Equals, stream)
+
+	// Read and Write without the fast paths
+	out.Reset()
+	n, err = io.Copy(struct{ io.Writer }{NewEncoder(out)}, struct{ io.Reader }{NewDecoder(bytes.NewBufferString(stream))})
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(len(stream)))
+	c.Assert(out.String(), Equals, stream)
+
+	// a short buffer skips the message
+	d := NewDecoder(bytes.NewBufferString(stream))
+	_, err = d.Read(make([]byte, 10))
+	c.Assert(err, Equals, io.ErrShortBuffer)
+	p := make([]byte, 100)
+	n2, err := d.Read(p)
+	c.Assert(err, IsNil)
+	c.Assert(string(p[:n2]), Equals, "21 <34>1 - - - - - - two")
+	_, err = d.Read(p)
+	c.Assert(err, Equals, io.EOF)
+}
@@ -0,0 +1,44 @@
+package rfc5424
+
+// DestinationSDID is the ID of the SD element holding the destination of a
+// message (see SetDestination and DocumentationEnterpriseNumber). It must be
+// the same for the senders and the Loggers and relays routing their
+// messages.
+var DestinationSDID = "route@32473"
+
+// SetDestination asks Loggers to write m to the output of the route name,
+// e.g. a security channel, whatever route their rules choose. The hint is
+// an SD element, DestinationSDID, so that it travels with m through chained
+// Loggers and relays; the Logger that routes m removes it. An empty name
+// removes the hint.
+func (m *Message) SetDestination(name string) {
+	if name == "" {
+		m.DeleteSD(DestinationSDID)
+		return
+	}
+	m.SetSDParam(DestinationSDID, "name", name)
+}
+
+// Destination returns the route set by SetDestination, or ""
+func (m Message) Destination() string {
+	name, _ := m.SDParam(DestinationSDID, "name")
+	return name
+}
+
+// Destination sets the route the message is written to by Loggers (see
+// Message.SetDestination)
+func (b *MessageBuilder) Destination(name string) *MessageBuilder {
+	b.m.SetDestination(name)
+	return b
+}
+
+// LogTo writes a message with the given severity and text to the output of
+// the route destination, as if routed there by Rules. Rules can still drop
+// it. If there is no such route, it is written to Writer.
+func (l *Logger) LogTo(destination string, severity Severity, text string) error {
+	m, err := l.builder(severity).Destination(destination).Text(text).Build()
+	if err != nil {
+		return err
+	}
+	return l.WriteMessage(m)
+}
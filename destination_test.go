@@ -0,0 +1,55 @@
+package rfc5424
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&DestinationTest{})
+
+type DestinationTest struct {
+}
+
+func (s *DestinationTest) TestSetDestination(c *C) {
+	m := Message{}
+	c.Assert(m.Destination(), Equals, "")
+	m.SetDestination("security")
+	m.SetDestination("audit")
+	c.Assert(m.Destination(), Equals, "audit")
+	c.Assert(m.StructuredData, DeepEquals, []StructuredData{{ID: "route@32473", Parameters: []SDParam{{Name: "name", Value: "audit"}}}})
+	m.SetDestination("")
+	c.Assert(m.StructuredData, HasLen, 0)
+
+	defer func(id string) { DestinationSDID = id }(DestinationSDID)
+	DestinationSDID = "route@99999"
+	m.SetDestination("audit")
+	c.Assert(m.SD("route@99999"), NotNil)
+	c.Assert(m.Destination(), Equals, "audit")
+}
+
+func (s *DestinationTest) TestLogger(c *C) {
+	general, security := &recordingTransport{}, &recordingTransport{}
+	l := &Logger{
+		Writer: TransportWriter(general),
+		Rules:  RuleSet{Rules: []Rule{{MaxSeverity: Debug, Action: ActionDrop}}},
+		Routes: map[string]MessageWriter{"security": TransportWriter(security)},
+	}
+
+	c.Assert(l.LogTo("security", Warning, "login failed"), IsNil)
+	c.Assert(l.LogTo("security", Debug, "dropped by the rules"), IsNil)
+	c.Assert(l.LogTo("unknown", Info, "to the default output"), IsNil)
+	c.Assert(l.Log(Info, "general"), IsNil)
+
+	m, err := NewMessage().Destination("security").Text("relayed").Build()
+	c.Assert(err, IsNil)
+	c.Assert(l.WriteMessage(m), IsNil)
+	c.Assert(m.Destination(), Equals, "security")
+
+	c.Assert(security.sent, HasLen, 2)
+	c.Assert(security.sent[0].TextMessage(), Equals, "login failed")
+	c.Assert(security.sent[0].StructuredData, HasLen, 0)
+	c.Assert(security.sent[1].TextMessage(), Equals, "relayed")
+	c.Assert(security.sent[1].Destination(), Equals, "")
+	c.Assert(general.sent, HasLen, 2)
+	c.Assert(general.sent[0].TextMessage(), Equals, "to the default output")
+	c.Assert(general.sent[1].TextMessage(), Equals, "general")
+}
@@ -1,6 +1,7 @@
 package rfc5424
 
 import (
+	"bytes"
 	"io"
 	"reflect"
 	"time"
@@ -9,46 +10,69 @@ import (
 var TimeNow = time.Now
 
 func Encode(ob interface{}) *Message {
+	m, _ := encode(ob, options{})
+	return m
+}
+
+// encode is Encode with the defaults set by o, where they are not zero.
+// Fields tagged encrypt are encrypted with o.encrypter; without one they
+// are omitted and errNoEncrypter is returned with the message.
+func encode(ob interface{}, o options) (*Message, error) {
 	mt := reflect.TypeOf(ob)
 	mv := reflect.ValueOf(ob)
 
 	reflection := Reflect(mt)
+	formatter := o.formatter
+	if formatter == nil {
+		formatter = DefaultFormatter
+	}
 
 	m := Message{}
+	var err error
 
 	severity := reflection.SeverityDefault
 	if reflection.SeverityFieldIndex >= 0 {
-		severity = mv.Field(reflection.SeverityFieldIndex).Interface().(Severity)
+		severity = Severity(mv.Field(reflection.SeverityFieldIndex).Int())
 	}
 
 	facility := reflection.FacilityDefault
 	if reflection.FacilityFieldIndex >= 0 {
-		facility = mv.Field(reflection.FacilityFieldIndex).Interface().(Facility)
+		facility = Facility(mv.Field(reflection.FacilityFieldIndex).Int())
 	}
 	m.Priority = int(severity-Emergency) | (int(facility-Kernel) << 3)
 
 	if reflection.TimestampFieldIndex >= 0 {
 		m.Timestamp = mv.Field(reflection.TimestampFieldIndex).Interface().(time.Time)
+	} else if o.clock != nil {
+		m.Timestamp = o.clock().UTC()
 	} else {
 		m.Timestamp = TimeNow().UTC()
 	}
 
 	if reflection.HostnameFieldIndex >= 0 {
-		m.Hostname = mv.Field(reflection.HostnameFieldIndex).Interface().(string)
+		m.Hostname = mv.Field(reflection.HostnameFieldIndex).String()
+	} else if o.hostname != "" {
+		m.Hostname = o.hostname
 	} else {
-		m.Hostname = defaultHostname
+		m.Hostname = DefaultHostnameResolver.Resolve()
 	}
 
 	if reflection.AppNameFieldIndex >= 0 {
-		m.AppName = mv.Field(reflection.AppNameFieldIndex).Interface().(string)
-	} else {
+		m.AppName = mv.Field(reflection.AppNameFieldIndex).String()
+	} else if o.appName != "" {
+		m.AppName = o.appName
+	} else if reflection.AppNameDefault != "" {
 		m.AppName = reflection.AppNameDefault
+	} else {
+		m.AppName = DefaultAppNameResolver.Resolve()
 	}
 
 	if reflection.ProcessIDFieldIndex >= 0 {
-		m.ProcessID = mv.Field(reflection.ProcessIDFieldIndex).String()
+		m.ProcessID = formatter.format(reflection.ProcessIDFormat, mv.Field(reflection.ProcessIDFieldIndex))
+	} else if o.processID != nil {
+		m.ProcessID = o.processID()
 	} else {
-		m.ProcessID = defaultProcessID
+		m.ProcessID = DefaultProcessID()
 	}
 
 	if reflection.MessageIDFieldIndex >= 0 {
@@ -60,31 +84,244 @@ func Encode(ob interface{}) *Message {
 
 	for _, fieldReflection := range reflection.StructuredDataFieldReflections {
 		v := mv.Field(fieldReflection.FieldIndex)
-		if fieldReflection.OmitEmpty {
-			zeroValue := reflect.Zero(mt.Field(fieldReflection.FieldIndex).Type)
-			if zeroValue.Interface() == v.Interface() {
+		if fieldReflection.OmitEmpty && v.IsZero() {
+			continue
+		}
+		sdID := fieldReflection.SdID
+		if sdID == "" {
+			sdID = DefaultSDID
+		}
+		value := formatter.format(fieldReflection.Format, v)
+		if fieldReflection.Encrypt {
+			if o.encrypter == nil {
+				err = errNoEncrypter
+				continue
+			}
+			var encryptErr error
+			if value, encryptErr = o.encrypter.Encrypt(sdID, fieldReflection.FieldName, value); encryptErr != nil {
+				err = encryptErr
 				continue
 			}
 		}
-		m.AddDatum(fieldReflection.SdID, fieldReflection.FieldName, v.String())
+		m.AddDatum(sdID, fieldReflection.FieldName, value)
 	}
 
 	if reflection.MessageFieldIndex >= 0 {
-		m.Message = mv.Field(reflection.MessageFieldIndex).Interface().([]byte)
+		m.Message = mv.Field(reflection.MessageFieldIndex).Bytes()
 	}
-	return &m
+	return &m, err
 }
 
+// DuplicateSDPolicy is what an Encoder does when a message would contain
+// several SD elements with the same ID, which RFC-5424 forbids. This happens
+// when a struct field is tagged with the ID of an SD element the Encoder
+// adds, e.g. "origin".
+type DuplicateSDPolicy int
+
+const (
+	// MergeDuplicateSD combines the elements into one, keeping all of their
+	// parameters in order.
+	MergeDuplicateSD DuplicateSDPolicy = iota
+	// RejectDuplicateSD makes Encode return an error.
+	RejectDuplicateSD
+)
+
 type Encoder struct {
 	Writer io.Writer
+
+	// Hostname and AppName, if set, are the HOSTNAME and APP-NAME of
+	// messages encoded from structs without the corresponding field. By
+	// default they are chosen by DefaultHostnameResolver and
+	// DefaultAppNameResolver.
+	Hostname string
+	AppName  string
+
+	// Clock, if set, reports the TIMESTAMP of messages encoded from structs
+	// without a Timestamp field. If nil, TimeNow is used.
+	Clock func() time.Time
+
+	// Encrypter, if set, encrypts the values of the fields tagged encrypt.
+	// Without it, such fields cannot be encoded.
+	Encrypter *ParamEncrypter
+
+	// ProcessID, if set, chooses the PROCID of messages encoded from
+	// structs without a ProcessID field. If nil, DefaultProcessID is used.
+	ProcessID ProcessIDStrategy
+
+	// StrictSDIDs makes Encode check SD-IDs and parameter names against the
+	// full RFC-5424 grammar, as the package-level StrictSDIDs does.
+	StrictSDIDs bool
+
+	// Formatter, if set, formats the values of struct fields that are not
+	// strings. If nil, DefaultFormatter is used.
+	Formatter *Formatter
+
+	// TimeQuality, if set, is called for each message and the result is
+	// attached as the timeQuality SD element, as recommended by RFC-5424
+	// section 7.1. It should report the current state of the clock.
+	TimeQuality func() TimeQuality
+
+	// Origin, if set, is attached to each message as the origin SD element
+	// so that receivers can identify the emitting software.
+	Origin *Origin
+
+	// Meta, if set, numbers the messages written by the Encoder using the
+	// meta SD element.
+	Meta *Meta
+
+	DuplicateSD DuplicateSDPolicy
+
+	// Profile, if set, adapts each message to the destination, e.g.
+	// SplunkProfile, and chooses its framing. By default messages are
+	// octet-counted.
+	Profile *Profile
 }
 
-func NewEncoder(w io.Writer) *Encoder {
-	return &Encoder{Writer: w}
+// NewEncoder returns a new Encoder writing to w. WithFraming and WithMaxLen
+// set its Profile.
+func NewEncoder(w io.Writer, opts ...Option) *Encoder {
+	o := newOptions(opts)
+	e := &Encoder{
+		Writer:      w,
+		Hostname:    o.hostname,
+		AppName:     o.appName,
+		Clock:       o.clock,
+		StrictSDIDs: o.strict,
+		Formatter:   o.formatter,
+		ProcessID:   o.processID,
+	}
+	if o.framing != OctetCounting || o.maxLength > 0 {
+		e.Profile = &Profile{Framing: o.framing, MaxLength: o.maxLength}
+	}
+	return e
 }
 
 func (e Encoder) Encode(ob interface{}) error {
-	m := Encode(ob)
-	_, err := m.WriteTo(e.Writer)
+	m, err := e.message(ob)
+	if err != nil {
+		return err
+	}
+	_, err = e.framing().writeMessage(e.Writer, m, nil)
+	return err
+}
+
+// EncodeBatch encodes each of obs in turn, like Encode, but marshals them
+// in parallel on up to workers goroutines (see MarshalBatch) and writes
+// them to Writer at once, in order. Nothing is written if any of them
+// cannot be encoded.
+func (e Encoder) EncodeBatch(obs []interface{}, workers int) error {
+	msgs := make([]Message, len(obs))
+	for i, ob := range obs {
+		m, err := e.message(ob)
+		if err != nil {
+			return err
+		}
+		msgs[i] = m
+	}
+	bs, err := MarshalBatch(msgs, workers)
+	if err != nil {
+		return err
+	}
+
+	framing := e.framing()
+	var frames []byte
+	for i, b := range bs {
+		if frames, err = framing.appendFrame(frames, b, msgs[i]); err != nil {
+			return err
+		}
+	}
+	_, err = e.Writer.Write(frames)
 	return err
 }
+
+// Write relays the framed messages in p to Writer, as ReadFrom does, making
+// an Encoder an io.Writer. p must hold whole frames, such as those returned
+// by Decoder.Read.
+func (e Encoder) Write(p []byte) (int, error) {
+	if _, err := e.ReadFrom(bytes.NewReader(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// ReadFrom relays the messages read from r to Writer until the end of the
+// stream, implementing io.ReaderFrom, which io.Copy uses unless the source
+// is a Decoder. The frames read may be octet-counted
+// or non-transparent, and are limited to 8192 octets. Each is parsed, and
+// adapted to the Encoder's Profile if it has one; otherwise it is written
+// as it was read, without being marshaled again. The SD elements the
+// Encoder adds to encoded structs are not added. It returns the number of
+// bytes read.
+func (e Encoder) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	fr := newFrameReader(cr, defaultMaxMessageLength)
+	framing := e.framing()
+	m := Message{}
+	var frame []byte
+	for {
+		b, err := fr.ReadFrame()
+		if err == io.EOF {
+			return cr.n, nil
+		} else if err != nil {
+			return cr.n, err
+		}
+		m.Reset()
+		if err := m.UnmarshalBinary(b); err != nil {
+			return cr.n, err
+		}
+		applied := m
+		if e.Profile != nil {
+			if applied, err = e.Profile.Apply(m); err != nil {
+				return cr.n, err
+			}
+			if b, err = applied.MarshalBinary(); err != nil {
+				return cr.n, err
+			}
+		}
+		if frame, err = framing.appendFrame(frame[:0], b, applied); err != nil {
+			return cr.n, err
+		}
+		if _, err := e.Writer.Write(frame); err != nil {
+			return cr.n, err
+		}
+	}
+}
+
+// message returns the message for ob, with the SD elements added by the
+// Encoder and adapted to its Profile
+func (e Encoder) message(ob interface{}) (Message, error) {
+	m, err := encode(ob, options{hostname: e.Hostname, appName: e.AppName, clock: e.Clock, formatter: e.Formatter,
+		processID: e.ProcessID, encrypter: e.Encrypter})
+	if err != nil {
+		return *m, err
+	}
+	if e.TimeQuality != nil {
+		m.StructuredData = append(m.StructuredData, e.TimeQuality().StructuredData())
+	}
+	if e.Origin != nil {
+		m.StructuredData = append(m.StructuredData, e.Origin.StructuredData())
+	}
+	if e.Meta != nil {
+		m.StructuredData = append(m.StructuredData, e.Meta.Next())
+	}
+	if e.DuplicateSD == MergeDuplicateSD {
+		m.MergeStructuredData()
+	}
+	if e.StrictSDIDs {
+		if err := m.validate(true); err != nil {
+			return *m, err
+		}
+	}
+	if e.Profile == nil {
+		return *m, nil
+	}
+	return e.Profile.Apply(*m)
+}
+
+// framing returns how the Encoder delimits messages
+func (e Encoder) framing() Framing {
+	if e.Profile == nil {
+		return OctetCounting
+	}
+	return e.Profile.Framing
+}
@@ -0,0 +1,198 @@
+package rfc5424
+
+import (
+	"bytes"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&EncoderTest{})
+
+type EncoderTest struct {
+}
+
+type encoderEvent struct {
+	Timestamp time.Time
+	Hostname  string
+	AppName   string
+	ProcessID string
+	MessageID string
+	Message   []byte
+}
+
+func (s *EncoderTest) TestTimeQuality(c *C) {
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	tq := TimeQuality{TZKnown: true, IsSynced: true, SyncAccuracy: 1000}
+	e.TimeQuality = func() TimeQuality { return tq }
+
+	ev := encoderEvent{Timestamp: T("2003-10-11T22:14:15.003Z"), Hostname: "host", AppName: "app", ProcessID: "1", MessageID: "ID"}
+	c.Assert(e.Encode(ev), IsNil)
+	c.Assert(buf.String(), Equals, `104 <134>1 2003-10-11T22:14:15.003Z host app 1 ID [timeQuality tzKnown="1" isSynced="1" syncAccuracy="1000"]`)
+
+	// syncAccuracy is only sent for synchronized clocks
+	buf.Reset()
+	tq = TimeQuality{SyncAccuracy: 1000}
+	c.Assert(e.Encode(ev), IsNil)
+	c.Assert(buf.String(), Equals, `84 <134>1 2003-10-11T22:14:15.003Z host app 1 ID [timeQuality tzKnown="0" isSynced="0"]`)
+}
+
+func (s *EncoderTest) TestOrigin(c *C) {
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.Origin = &Origin{IP: []string{"192.0.2.1", "2001:db8::1"}, Software: "myapp", SWVersion: "1.2"}
+
+	ev := encoderEvent{Timestamp: T("2003-10-11T22:14:15.003Z"), Hostname: "host", AppName: "app", ProcessID: "1", MessageID: "ID"}
+	c.Assert(e.Encode(ev), IsNil)
+	c.Assert(buf.String(), Equals, `119 <134>1 2003-10-11T22:14:15.003Z host app 1 ID [origin ip="192.0.2.1" ip="2001:db8::1" software="myapp" swVersion="1.2"]`)
+
+	c.Assert(Origin{EnterpriseID: "32473.1"}.StructuredData(), DeepEquals, StructuredData{
+		ID:         "origin",
+		Parameters: []SDParam{{Name: "enterpriseId", Value: "32473.1"}},
+	})
+}
+
+func (s *EncoderTest) TestMeta(c *C) {
+	now := T("2003-10-11T22:14:15.003Z")
+	TimeNow = func() time.Time { return now }
+	defer func() { TimeNow = time.Now }()
+
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.Meta = &Meta{Language: "en"}
+	ev := encoderEvent{Timestamp: now, Hostname: "host", AppName: "app", ProcessID: "1", MessageID: "ID"}
+	c.Assert(e.Encode(ev), IsNil)
+	now = now.Add(1500 * time.Millisecond)
+	buf.Reset()
+	c.Assert(e.Encode(ev), IsNil)
+	c.Assert(buf.String(), Equals, `97 <134>1 2003-10-11T22:14:15.003Z host app 1 ID [meta sequenceId="2" sysUpTime="150" language="en"]`)
+
+	// sequenceId wraps to 1
+	meta := &Meta{sequence: maxSequenceID}
+	c.Assert(meta.Next().Parameters[0], Equals, SDParam{Name: "sequenceId", Value: "1"})
+}
+
+func (s *EncoderTest) TestDuplicateSD(c *C) {
+	type event struct {
+		Timestamp time.Time
+		Hostname  string
+		AppName   string
+		ProcessID string
+		MessageID string
+		SDID      string `log:"origin"`
+		Software  string
+	}
+	ev := event{Timestamp: T("2003-10-11T22:14:15.003Z"), Hostname: "host", AppName: "app", ProcessID: "1", MessageID: "ID",
+		Software: "x"}
+
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.Origin = &Origin{Software: "y"}
+	c.Assert(e.Encode(ev), IsNil)
+	c.Assert(buf.String(), Equals, `80 <134>1 2003-10-11T22:14:15.003Z host app 1 ID [origin software="x" software="y"]`)
+
+	buf.Reset()
+	e.DuplicateSD = RejectDuplicateSD
+	c.Assert(e.Encode(ev), ErrorMatches, `.*StructuredData\[1\]/ID is invalid: origin \(an SD-ID may only appear once\)`)
+	c.Assert(buf.Len(), Equals, 0)
+}
+
+func (s *EncoderTest) TestProfile(c *C) {
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.Profile = &GraylogProfile
+
+	ev := encoderEvent{Timestamp: T("2003-10-11T22:14:15.003456Z"), Hostname: "host", AppName: "app", ProcessID: "1", MessageID: "ID"}
+	c.Assert(e.Encode(ev), IsNil)
+	c.Assert(buf.String(), Equals, "<134>1 2003-10-11T22:14:15.003Z host app 1 ID -\n")
+}
+
+type bareEvent struct {
+	ProcessID string
+	Count     int `log:"count"`
+	Message   []byte
+}
+
+type strictEvent struct {
+	Count int `log:"a_parameter_name_of_33_characters"`
+}
+
+func (s *EncoderTest) TestOptions(c *C) {
+	buf := &bytes.Buffer{}
+	clock := func() time.Time { return T("2003-10-11T22:14:15.003Z") }
+	e := NewEncoder(buf, WithHostname("host"), WithAppName("app"), WithClock(clock),
+		WithFraming(NonTransparentFraming), WithMaxLen(82))
+	c.Assert(e.Encode(bareEvent{ProcessID: "1", Count: 5, Message: []byte("hello world")}), IsNil)
+	c.Assert(buf.String(), Equals, "<134>1 2003-10-11T22:14:15.003Z host app 1 bareEvent [local@32473 count=\"5\"] hello\n")
+
+	buf.Reset()
+	e = NewEncoder(buf, WithStrictness(true))
+	c.Assert(e.Encode(strictEvent{}), ErrorMatches, ".*names must be 1 to 32 characters.*")
+	c.Assert(buf.Len(), Equals, 0)
+}
+
+type typedEvent struct {
+	Timestamp time.Time
+	Hostname  string
+	AppName   string
+	ProcessID int
+	Count     int      `log:"x@32473 count"`
+	Ratio     float64  `log:"x@32473 ratio"`
+	OK        bool     `log:"x@32473 ok"`
+	Level     Severity `log:"x@32473 level"`
+	Skipped   uint     `log:"x@32473 skipped,omitempty"`
+}
+
+func (s *EncoderTest) TestFieldTypes(c *C) {
+	ev := typedEvent{Timestamp: T("2003-10-11T22:14:15.003Z"), Hostname: "host", AppName: "app", ProcessID: 42,
+		Count: -3, Ratio: 0.5, OK: true, Level: Warning}
+	m := Encode(ev)
+	c.Assert(m.ProcessID, Equals, "42")
+	c.Assert(m.StructuredData, DeepEquals, []StructuredData{{ID: "x@32473", Parameters: []SDParam{
+		{Name: "count", Value: "-3"},
+		{Name: "ratio", Value: "0.5"},
+		{Name: "ok", Value: "true"},
+		{Name: "level", Value: "warning"},
+	}}})
+
+	ev.Skipped = 7
+	c.Assert(Encode(ev).StructuredData[0].Parameters[4], Equals, SDParam{Name: "skipped", Value: "7"})
+}
+
+type formattedEvent struct {
+	Ratio   float64   `log:"x@32473 ratio"`
+	Small   float32   `log:"x@32473 small"`
+	OK      bool      `log:"x@32473 ok"`
+	Count   *int      `log:"x@32473 count"`
+	Missing *int      `log:"x@32473 missing"`
+	Level   *Severity `log:"x@32473 level"`
+}
+
+func (s *EncoderTest) TestFormatter(c *C) {
+	count, level := 3, Severity(Error)
+	ev := formattedEvent{Ratio: 2.5, Small: 0.1, OK: true, Count: &count, Level: &level}
+	params := func(m *Message) []SDParam { return m.StructuredData[0].Parameters }
+
+	c.Assert(params(Encode(ev)), DeepEquals, []SDParam{
+		{Name: "ratio", Value: "2.5"},
+		{Name: "small", Value: "0.1"},
+		{Name: "ok", Value: "true"},
+		{Name: "count", Value: "3"},
+		{Name: "missing", Value: ""},
+		{Name: "level", Value: "err"},
+	})
+
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf, WithFormatter(&Formatter{FloatFormat: 'f', FloatPrecision: 3, NumericBools: true, Nil: "-"}))
+	m, err := e.message(ev)
+	c.Assert(err, IsNil)
+	c.Assert(params(&m), DeepEquals, []SDParam{
+		{Name: "ratio", Value: "2.500"},
+		{Name: "small", Value: "0.100"},
+		{Name: "ok", Value: "1"},
+		{Name: "count", Value: "3"},
+		{Name: "missing", Value: "-"},
+		{Name: "level", Value: "err"},
+	})
+}
@@ -0,0 +1,151 @@
+package rfc5424
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ParamEncrypter encrypts the values of sensitive SD parameters, such as
+// personal data, so that only the consumers holding the key can read them.
+// Values are encrypted with AES-GCM and sent as the base64 of a random
+// nonce followed by the ciphertext. The SD-ID and name of the parameter are
+// authenticated with the value, so that an encrypted value cannot be moved
+// to another parameter.
+//
+// The parameters encrypted are those listed in Params, by Apply and the
+// Writer, and the struct fields tagged "encrypt" by an Encoder with the
+// ParamEncrypter, e.g.
+//
+//	SSN string `log:"user@32473 ssn,encrypt"`
+//
+// Encoders without a ParamEncrypter refuse such structs, and Encode omits
+// the fields.
+type ParamEncrypter struct {
+	// Key is the AES key: 16, 24 or 32 bytes for AES-128, AES-192 or
+	// AES-256
+	Key []byte
+
+	// Params lists the parameters encrypted by Apply and decrypted by
+	// DecryptMessage
+	Params []SDParamRef
+}
+
+// aead returns the AES-GCM cipher of Key
+func (pe *ParamEncrypter) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pe.Key)
+	if err != nil {
+		return nil, fmt.Errorf("rfc5424: %s", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt returns the encrypted value of the parameter name of the SD
+// element id
+func (pe *ParamEncrypter) Encrypt(id, name, value string) (string, error) {
+	aead, err := pe.aead()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(id+" "+name))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the value of the parameter name of the SD element id,
+// encrypted by Encrypt. It returns an error if the value was not encrypted
+// with Key for that parameter, or was changed.
+func (pe *ParamEncrypter) Decrypt(id, name, encrypted string) (string, error) {
+	aead, err := pe.aead()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("rfc5424: parameter %q of %s is not encrypted", name, id)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	value, err := aead.Open(nil, nonce, ciphertext, []byte(id+" "+name))
+	if err != nil {
+		return "", fmt.Errorf("rfc5424: parameter %q of %s cannot be decrypted", name, id)
+	}
+	return string(value), nil
+}
+
+// Apply returns m with the values of the parameters listed in Params
+// encrypted. m itself is not modified.
+func (pe *ParamEncrypter) Apply(m Message) (Message, error) {
+	return pe.transform(m, pe.Encrypt)
+}
+
+// DecryptMessage returns m with the values of the parameters listed in
+// Params decrypted. m itself is not modified.
+func (pe *ParamEncrypter) DecryptMessage(m Message) (Message, error) {
+	return pe.transform(m, pe.Decrypt)
+}
+
+// transform returns m with f applied to the values of the parameters listed
+// in Params
+func (pe *ParamEncrypter) transform(m Message, f func(id, name, value string) (string, error)) (Message, error) {
+	if len(pe.Params) == 0 {
+		return m, nil
+	}
+	m = m.Clone()
+	for i := range m.StructuredData {
+		sd := &m.StructuredData[i]
+		for j := range sd.Parameters {
+			param := &sd.Parameters[j]
+			if !pe.listed(sd.ID, param.Name) {
+				continue
+			}
+			value, err := f(sd.ID, param.Name, param.Value)
+			if err != nil {
+				return Message{}, err
+			}
+			param.Value = value
+		}
+	}
+	return m, nil
+}
+
+func (pe *ParamEncrypter) listed(id, name string) bool {
+	for _, ref := range pe.Params {
+		if ref.ID == id && ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// errNoEncrypter is returned by Encoders asked to encrypt fields without a
+// ParamEncrypter
+var errNoEncrypter = errors.New("rfc5424: a field is tagged encrypt but the Encoder has no Encrypter")
+
+// Writer returns a MessageWriter encrypting the parameters listed in Params
+// of the messages written to w
+func (pe *ParamEncrypter) Writer(w MessageWriter) MessageWriter {
+	return encryptWriter{pe: pe, w: w}
+}
+
+type encryptWriter struct {
+	pe *ParamEncrypter
+	w  MessageWriter
+}
+
+func (ew encryptWriter) WriteMessage(m Message) error {
+	m, err := ew.pe.Apply(m)
+	if err != nil {
+		return err
+	}
+	return ew.w.WriteMessage(m)
+}
+
+func (ew encryptWriter) Close() error {
+	return ew.w.Close()
+}
@@ -0,0 +1,84 @@
+package rfc5424
+
+import (
+	"bytes"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&EncryptTest{})
+
+type EncryptTest struct {
+}
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+type signupEvent struct {
+	User  string `log:"user@32473 name"`
+	SSN   string `log:"user@32473 ssn,encrypt"`
+	Phone string `log:"user@32473 phone,omitempty,encrypt"`
+}
+
+func (s *EncryptTest) TestEncryptDecrypt(c *C) {
+	pe := &ParamEncrypter{Key: testKey}
+	encrypted, err := pe.Encrypt("user@32473", "ssn", "078-05-1120")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(encrypted, "078"), Equals, false)
+	again, _ := pe.Encrypt("user@32473", "ssn", "078-05-1120")
+	c.Assert(again, Not(Equals), encrypted)
+
+	value, err := pe.Decrypt("user@32473", "ssn", encrypted)
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, "078-05-1120")
+
+	_, err = pe.Decrypt("user@32473", "name", encrypted)
+	c.Assert(err, ErrorMatches, `rfc5424: parameter "name" of user@32473 cannot be decrypted`)
+	_, err = (&ParamEncrypter{Key: []byte("fedcba9876543210")}).Decrypt("user@32473", "ssn", encrypted)
+	c.Assert(err, NotNil)
+	_, err = pe.Decrypt("user@32473", "ssn", "plain")
+	c.Assert(err, ErrorMatches, `rfc5424: parameter "ssn" of user@32473 is not encrypted`)
+	_, err = (&ParamEncrypter{Key: []byte("short")}).Encrypt("user@32473", "ssn", "x")
+	c.Assert(err, ErrorMatches, "rfc5424: crypto/aes: invalid key size 5")
+}
+
+func (s *EncryptTest) TestApply(c *C) {
+	pe := &ParamEncrypter{Key: testKey, Params: []SDParamRef{{ID: "user@32473", Name: "ssn"}}}
+	m := Message{}
+	m.AddDatum("user@32473", "name", "alice")
+	m.AddDatum("user@32473", "ssn", "078-05-1120")
+
+	encrypted, err := pe.Apply(m)
+	c.Assert(err, IsNil)
+	c.Assert(m.StructuredData[0].Parameters[1].Value, Equals, "078-05-1120")
+	c.Assert(encrypted.StructuredData[0].Parameters[0].Value, Equals, "alice")
+	c.Assert(encrypted.StructuredData[0].Parameters[1].Value, Not(Equals), "078-05-1120")
+
+	decrypted, err := pe.DecryptMessage(encrypted)
+	c.Assert(err, IsNil)
+	c.Assert(decrypted.StructuredData, DeepEquals, m.StructuredData)
+
+	w := &chanWriter{messages: make(chan Message, 1)}
+	c.Assert(pe.Writer(w).WriteMessage(m), IsNil)
+	written := <-w.messages
+	value, err := pe.Decrypt("user@32473", "ssn", written.StructuredData[0].Parameters[1].Value)
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, "078-05-1120")
+}
+
+func (s *EncryptTest) TestEncoder(c *C) {
+	pe := &ParamEncrypter{Key: testKey, Params: []SDParamRef{{ID: "user@32473", Name: "ssn"}}}
+	e := &Encoder{Writer: &bytes.Buffer{}, Encrypter: pe}
+	m, err := e.message(signupEvent{User: "alice", SSN: "078-05-1120"})
+	c.Assert(err, IsNil)
+	c.Assert(Params(m.StructuredData[0].Parameters).Names(), DeepEquals, []string{"name", "ssn"})
+	ssn, _ := m.SDParam("user@32473", "ssn")
+	value, err := pe.Decrypt("user@32473", "ssn", ssn)
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, "078-05-1120")
+
+	e.Encrypter = nil
+	c.Assert(e.Encode(signupEvent{User: "alice", SSN: "078-05-1120"}), ErrorMatches, ".*tagged encrypt.*")
+	c.Assert(Params(Encode(signupEvent{User: "alice", SSN: "078-05-1120"}).StructuredData[0].Parameters).Names(),
+		DeepEquals, []string{"name"})
+}
@@ -0,0 +1,98 @@
+package rfc5424
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// ErrorSDID is the ID of the SD element added by FromError (see
+// DocumentationEnterpriseNumber).
+var ErrorSDID = "error@32473"
+
+// FromError returns a message reporting err, which must not be nil, with
+// severity sev and the same defaults as NewMessage. The MSG is the text of
+// err, and an SD element, ErrorSDID, describes it so that errors can be
+// searched the same way across services:
+//
+//	type     the Go type of err, e.g. "*fs.PathError"
+//	message  the text of err
+//	chain    the types of the errors wrapped by err, outermost first,
+//	         separated by " > ", if it wraps any
+//	cause    the text of the innermost error, if err wraps any
+//	stack    the stack of the first error of the chain that recorded one,
+//	         as "function file:line" frames separated by "; "
+//
+// Stacks are those recorded by packages such as github.com/pkg/errors, whose
+// errors have a StackTrace method returning program counters, or by errors
+// with a Callers() []uintptr method.
+func FromError(err error, sev Severity) Message {
+	m := defaultMessage(sev)
+	text := strings.ToValidUTF8(err.Error(), "\ufffd")
+	m.SetTextMessage(text)
+	m.AddDatum(ErrorSDID, "type", fmt.Sprintf("%T", err))
+	m.AddDatum(ErrorSDID, "message", text)
+
+	var chain []string
+	var stack []uintptr
+	cause := err
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if e != err {
+			chain = append(chain, fmt.Sprintf("%T", e))
+			cause = e
+		}
+		if stack == nil {
+			stack = errorStack(e)
+		}
+	}
+	if len(chain) > 0 {
+		m.AddDatum(ErrorSDID, "chain", strings.Join(chain, " > "))
+		m.AddDatum(ErrorSDID, "cause", strings.ToValidUTF8(cause.Error(), "\ufffd"))
+	}
+	if len(stack) > 0 {
+		m.AddDatum(ErrorSDID, "stack", formatStack(stack))
+	}
+	return m
+}
+
+// errorStack returns the program counters of the stack recorded by err, or
+// nil. The StackTrace method of github.com/pkg/errors returns a slice of a
+// uintptr type, which is found by reflection so as not to depend on it.
+func errorStack(err error) []uintptr {
+	if c, ok := err.(interface{ Callers() []uintptr }); ok {
+		return c.Callers()
+	}
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() {
+		return nil
+	}
+	t := method.Type()
+	if t.NumIn() != 0 || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Slice ||
+		t.Out(0).Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	frames := method.Call(nil)[0]
+	pcs := make([]uintptr, frames.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(frames.Index(i).Uint())
+	}
+	return pcs
+}
+
+// formatStack returns the frames of the program counters pcs
+func formatStack(pcs []uintptr) string {
+	var parts []string
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" {
+			parts = append(parts, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.Join(parts, "; ")
+}
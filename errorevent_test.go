@@ -0,0 +1,69 @@
+package rfc5424
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&ErrorEventTest{})
+
+type ErrorEventTest struct {
+}
+
+// wrappedError wraps err, with a stack like those of github.com/pkg/errors
+type wrappedError struct {
+	msg   string
+	err   error
+	stack stackTrace
+}
+
+type frame uintptr
+
+type stackTrace []frame
+
+func wrap(err error, msg string) error {
+	pcs := make([]uintptr, 8)
+	n := runtime.Callers(2, pcs)
+	stack := make(stackTrace, n)
+	for i, pc := range pcs[:n] {
+		stack[i] = frame(pc)
+	}
+	return &wrappedError{msg: msg, err: err, stack: stack}
+}
+
+func (e *wrappedError) Error() string          { return e.msg + ": " + e.err.Error() }
+func (e *wrappedError) Unwrap() error          { return e.err }
+func (e *wrappedError) StackTrace() stackTrace { return e.stack }
+
+func (s *ErrorEventTest) TestFromError(c *C) {
+	m := FromError(errors.New("disk full"), Error)
+	c.Assert(m.Priority, Equals, 131)
+	c.Assert(m.TextMessage(), Equals, "disk full")
+	c.Assert(m.StructuredData, DeepEquals, []StructuredData{{ID: "error@32473", Parameters: []SDParam{
+		{Name: "type", Value: "*errors.errorString"},
+		{Name: "message", Value: "disk full"},
+	}}})
+	c.Assert(m.assertValid(), IsNil)
+}
+
+func (s *ErrorEventTest) TestChainAndStack(c *C) {
+	err := wrap(wrap(errors.New("disk full"), "writing"), "saving")
+	m := FromError(err, Warning)
+	c.Assert(m.TextMessage(), Equals, "saving: writing: disk full")
+
+	message, _ := m.SDParam("error@32473", "message")
+	c.Assert(message, Equals, "saving: writing: disk full")
+	chain, _ := m.SDParam("error@32473", "chain")
+	c.Assert(chain, Equals, "*rfc5424.wrappedError > *errors.errorString")
+	cause, _ := m.SDParam("error@32473", "cause")
+	c.Assert(cause, Equals, "disk full")
+
+	stack, ok := m.SDParam("error@32473", "stack")
+	c.Assert(ok, Equals, true)
+	first := strings.Split(stack, "; ")[0]
+	c.Assert(first, Matches, `.*rfc5424.\(\*ErrorEventTest\).TestChainAndStack .*errorevent_test.go:[0-9]+`)
+	c.Assert(m.assertValid(), IsNil)
+}
@@ -0,0 +1,97 @@
+package rfc5424
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventLevel is the level of a Windows event
+type EventLevel int
+
+const (
+	EventCritical EventLevel = 1 + iota
+	EventError
+	EventWarning
+	EventInformation
+	EventVerbose
+)
+
+// EventLevelOf returns the Windows event level matching severity (see
+// WindowsEventLevels)
+func EventLevelOf(severity Severity) EventLevel {
+	return EventLevel(WindowsEventLevels.Level(severity))
+}
+
+// SeverityOfEventLevel returns the Severity matching a Windows event level
+// (see WindowsEventLevels). Unknown levels, such as LogAlways (0), are taken
+// to be Info.
+func SeverityOfEventLevel(level EventLevel) Severity {
+	if level < EventCritical || level > EventVerbose {
+		return Info
+	}
+	return WindowsEventLevels.Severity(int(level))
+}
+
+// Event is an entry of a Windows event log, as written by an EventLogWriter
+// and read by an EventLogReader on Windows.
+type Event struct {
+	Source   string
+	Computer string
+	ID       uint32
+	Level    EventLevel
+	Time     time.Time
+
+	// Message is the first string of the event, and Data the others. The
+	// parameters of SD elements are sent as strings of the form
+	// "SD-ID PARAM-NAME=PARAM-VALUE".
+	Message string
+	Data    []string
+}
+
+// MessageToEvent returns the event for m. The APP-NAME is the source, the
+// HOSTNAME the computer, and a numeric MSGID the event ID.
+func MessageToEvent(m Message) Event {
+	e := Event{
+		Source:   m.AppName,
+		Computer: m.Hostname,
+		Level:    EventLevelOf(m.Severity()),
+		Time:     m.Timestamp,
+		Message:  m.TextMessage(),
+	}
+	if id, err := strconv.ParseUint(m.MessageID, 10, 32); err == nil {
+		e.ID = uint32(id)
+	}
+	for _, sd := range m.StructuredData {
+		for _, param := range sd.Parameters {
+			e.Data = append(e.Data, sd.ID+" "+param.Name+"="+param.Value)
+		}
+	}
+	return e
+}
+
+// EventToMessage returns the message for e, with the given facility. It
+// reverses MessageToEvent. Data strings that are not SD parameters become
+// "data" parameters of the DefaultSDID element.
+func EventToMessage(e Event, facility Facility) Message {
+	m := Message{
+		Priority:  int(SeverityOfEventLevel(e.Level)-Emergency) | int(facility-Kernel)<<3,
+		Timestamp: e.Time,
+		Hostname:  e.Computer,
+		AppName:   e.Source,
+		MessageID: strconv.FormatUint(uint64(e.ID), 10),
+	}
+	if e.Message != "" {
+		m.SetTextMessage(e.Message)
+	}
+	for _, s := range e.Data {
+		i := strings.IndexByte(s, ' ')
+		j := strings.IndexByte(s, '=')
+		if i <= 0 || j < i || !isValidSdName(s[:i]) || !isValidSdName(s[i+1:j]) {
+			m.AddDatum(DefaultSDID, "data", s)
+			continue
+		}
+		m.AddDatum(s[:i], s[i+1:j], s[j+1:])
+	}
+	return m
+}
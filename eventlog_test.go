@@ -0,0 +1,51 @@
+package rfc5424
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&EventLogTest{})
+
+type EventLogTest struct {
+}
+
+func (s *EventLogTest) TestEventLevels(c *C) {
+	c.Assert(EventLevelOf(Alert), Equals, EventCritical)
+	c.Assert(EventLevelOf(Error), Equals, EventError)
+	c.Assert(EventLevelOf(Notice), Equals, EventInformation)
+	c.Assert(EventLevelOf(Debug), Equals, EventVerbose)
+	for _, level := range []EventLevel{EventCritical, EventError, EventWarning, EventInformation, EventVerbose} {
+		c.Assert(EventLevelOf(SeverityOfEventLevel(level)), Equals, level)
+	}
+	c.Assert(SeverityOfEventLevel(0), Equals, Severity(Info))
+}
+
+func (s *EventLogTest) TestEventConversion(c *C) {
+	m := Message{
+		Priority:  27,
+		Timestamp: T("2003-10-11T22:14:15Z"),
+		Hostname:  "WEB01",
+		AppName:   "api",
+		MessageID: "1001",
+		StructuredData: []StructuredData{
+			{ID: "exampleSDID@32473", Parameters: []SDParam{{Name: "iut", Value: "3"}, {Name: "note", Value: "a=b c"}}},
+		},
+	}
+	m.SetTextMessage("disk full")
+
+	e := MessageToEvent(m)
+	c.Assert(e, DeepEquals, Event{
+		Source:   "api",
+		Computer: "WEB01",
+		ID:       1001,
+		Level:    EventError,
+		Time:     T("2003-10-11T22:14:15Z"),
+		Message:  "disk full",
+		Data:     []string{"exampleSDID@32473 iut=3", "exampleSDID@32473 note=a=b c"},
+	})
+	c.Assert(EventToMessage(e, Daemon), DeepEquals, m)
+
+	e.Data = append(e.Data, "not a parameter")
+	c.Assert(EventToMessage(e, Daemon).StructuredData[1], DeepEquals,
+		StructuredData{ID: DefaultSDID, Parameters: []SDParam{{Name: "data", Value: "not a parameter"}}})
+}
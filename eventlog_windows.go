@@ -0,0 +1,248 @@
+//go:build windows
+// +build windows
+
+package rfc5424
+
+import (
+	"errors"
+	"io"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSourceW  = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEventW          = advapi32.NewProc("ReportEventW")
+	procOpenEventLogW         = advapi32.NewProc("OpenEventLogW")
+	procReadEventLogW         = advapi32.NewProc("ReadEventLogW")
+	procCloseEventLog         = advapi32.NewProc("CloseEventLog")
+)
+
+// Event types of ReportEvent and EVENTLOGRECORD
+const (
+	eventlogErrorType       = 0x0001
+	eventlogWarningType     = 0x0002
+	eventlogInformationType = 0x0004
+	eventlogAuditFailure    = 0x0010
+
+	eventlogSequentialRead = 0x0001
+	eventlogForwardsRead   = 0x0004
+
+	errorHandleEOF          = syscall.Errno(38)
+	errorInsufficientBuffer = syscall.Errno(122)
+)
+
+// ErrEventLogClosed is returned when an EventLogWriter or EventLogReader is
+// used after it was closed
+var ErrEventLogClosed = errors.New("rfc5424: event log closed")
+
+// EventLogWriter is a MessageWriter that reports each message to the
+// Windows event log as an event of Source (see MessageToEvent). The source
+// should be registered, e.g. with New-EventLog, for the events to be
+// displayed without warnings.
+type EventLogWriter struct {
+	handle syscall.Handle
+}
+
+// NewEventLogWriter returns an EventLogWriter reporting events of source on
+// the local computer
+func NewEventLogWriter(source string) (*EventLogWriter, error) {
+	name, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := procRegisterEventSourceW.Call(0, uintptr(unsafe.Pointer(name)))
+	if h == 0 {
+		return nil, err
+	}
+	return &EventLogWriter{handle: syscall.Handle(h)}, nil
+}
+
+// WriteMessage reports m as an event
+func (w *EventLogWriter) WriteMessage(m Message) error {
+	if w.handle == 0 {
+		return ErrEventLogClosed
+	}
+	e := MessageToEvent(m)
+	eventType := eventlogInformationType
+	switch e.Level {
+	case EventCritical, EventError:
+		eventType = eventlogErrorType
+	case EventWarning:
+		eventType = eventlogWarningType
+	}
+
+	strs := make([]*uint16, 0, 1+len(e.Data))
+	for _, s := range append([]string{e.Message}, e.Data...) {
+		p, err := syscall.UTF16PtrFromString(s)
+		if err != nil {
+			return err
+		}
+		strs = append(strs, p)
+	}
+	ok, _, err := procReportEventW.Call(uintptr(w.handle), uintptr(eventType), 0, uintptr(e.ID), 0,
+		uintptr(len(strs)), 0, uintptr(unsafe.Pointer(&strs[0])), 0)
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// Close deregisters the event source
+func (w *EventLogWriter) Close() error {
+	if w.handle == 0 {
+		return ErrEventLogClosed
+	}
+	ok, _, err := procDeregisterEventSource.Call(uintptr(w.handle))
+	w.handle = 0
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// eventLogRecord is the fixed part of an EVENTLOGRECORD
+type eventLogRecord struct {
+	Length              uint32
+	Reserved            uint32
+	RecordNumber        uint32
+	TimeGenerated       uint32
+	TimeWritten         uint32
+	EventID             uint32
+	EventType           uint16
+	NumStrings          uint16
+	EventCategory       uint16
+	ReservedFlags       uint16
+	ClosingRecordNumber uint32
+	StringOffset        uint32
+	UserSidLength       uint32
+	UserSidOffset       uint32
+	DataLength          uint32
+	DataOffset          uint32
+}
+
+// EventLogReader reads the events of a Windows event log, oldest first, so
+// that they can be forwarded as Messages (see EventToMessage).
+type EventLogReader struct {
+	handle syscall.Handle
+	buf    []byte
+	events []Event
+}
+
+// NewEventLogReader opens the named event log of the local computer, e.g.
+// "Application" or "System"
+func NewEventLogReader(log string) (*EventLogReader, error) {
+	name, err := syscall.UTF16PtrFromString(log)
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := procOpenEventLogW.Call(0, uintptr(unsafe.Pointer(name)))
+	if h == 0 {
+		return nil, err
+	}
+	return &EventLogReader{handle: syscall.Handle(h), buf: make([]byte, 64*1024)}, nil
+}
+
+// ReadEvent returns the next event, or io.EOF once all the events in the log
+// have been read. Events written later can be read by calling it again.
+func (r *EventLogReader) ReadEvent() (Event, error) {
+	if r.handle == 0 {
+		return Event{}, ErrEventLogClosed
+	}
+	for len(r.events) == 0 {
+		var read, needed uint32
+		ok, _, err := procReadEventLogW.Call(uintptr(r.handle), eventlogSequentialRead|eventlogForwardsRead, 0,
+			uintptr(unsafe.Pointer(&r.buf[0])), uintptr(len(r.buf)),
+			uintptr(unsafe.Pointer(&read)), uintptr(unsafe.Pointer(&needed)))
+		if ok == 0 {
+			switch err {
+			case errorHandleEOF:
+				return Event{}, io.EOF
+			case errorInsufficientBuffer:
+				r.buf = make([]byte, needed)
+				continue
+			}
+			return Event{}, err
+		}
+		r.events = parseEventLogRecords(r.buf[:read])
+	}
+	e := r.events[0]
+	r.events = r.events[1:]
+	return e, nil
+}
+
+// Close closes the event log
+func (r *EventLogReader) Close() error {
+	if r.handle == 0 {
+		return ErrEventLogClosed
+	}
+	ok, _, err := procCloseEventLog.Call(uintptr(r.handle))
+	r.handle = 0
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// utf16String returns the NUL-terminated UTF-16 string at the start of b,
+// and the number of bytes it occupies
+func utf16String(b []byte) (string, int) {
+	var u []uint16
+	for i := 0; i+1 < len(b); i += 2 {
+		c := uint16(b[i]) | uint16(b[i+1])<<8
+		if c == 0 {
+			return syscall.UTF16ToString(u), i + 2
+		}
+		u = append(u, c)
+	}
+	return syscall.UTF16ToString(u), len(b)
+}
+
+// parseEventLogRecords parses the EVENTLOGRECORDs returned by ReadEventLog
+func parseEventLogRecords(b []byte) []Event {
+	var events []Event
+	for len(b) >= int(unsafe.Sizeof(eventLogRecord{})) {
+		rec := (*eventLogRecord)(unsafe.Pointer(&b[0]))
+		if rec.Length < uint32(unsafe.Sizeof(*rec)) || int(rec.Length) > len(b) {
+			break
+		}
+		record := b[:rec.Length]
+		e := Event{
+			ID:   rec.EventID & 0xffff,
+			Time: time.Unix(int64(rec.TimeGenerated), 0).UTC(),
+		}
+		switch rec.EventType {
+		case eventlogErrorType:
+			e.Level = EventError
+		case eventlogWarningType, eventlogAuditFailure:
+			e.Level = EventWarning
+		default:
+			e.Level = EventInformation
+		}
+
+		var n int
+		names := record[unsafe.Sizeof(*rec):]
+		e.Source, n = utf16String(names)
+		e.Computer, _ = utf16String(names[n:])
+
+		var strs []byte
+		if int(rec.StringOffset) < len(record) {
+			strs = record[rec.StringOffset:]
+		}
+		for i := 0; i < int(rec.NumStrings) && len(strs) > 0; i++ {
+			s, n := utf16String(strs)
+			if i == 0 {
+				e.Message = s
+			} else {
+				e.Data = append(e.Data, s)
+			}
+			strs = strs[n:]
+		}
+		events = append(events, e)
+		b = b[rec.Length:]
+	}
+	return events
+}
@@ -0,0 +1,460 @@
+package rfc5424
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSegmentSize = 64 << 20
+
+	// indexEntrySize is the size of an index entry: the timestamp as seconds
+	// (8 bytes) and nanoseconds (4 bytes), then the offset of the message in
+	// the segment's log file (8 bytes). All are big-endian.
+	indexEntrySize = 20
+
+	segmentLogSuffix   = ".log"
+	segmentIndexSuffix = ".idx"
+)
+
+// segment is a pair of files holding consecutive messages: a log of
+// RFC-5425 framed messages and an index of their timestamps and offsets.
+type segment struct {
+	id      uint64
+	minTime time.Time
+	maxTime time.Time
+	count   int
+	size    int64
+}
+
+type indexEntry struct {
+	Timestamp time.Time
+	Offset    int64
+}
+
+func (e indexEntry) appendTo(b []byte) []byte {
+	var buf [indexEntrySize]byte
+	binary.BigEndian.PutUint64(buf[0:8], uint64(e.Timestamp.Unix()))
+	binary.BigEndian.PutUint32(buf[8:12], uint32(e.Timestamp.Nanosecond()))
+	binary.BigEndian.PutUint64(buf[12:20], uint64(e.Offset))
+	return append(b, buf[:]...)
+}
+
+func parseIndexEntry(b []byte) indexEntry {
+	return indexEntry{
+		Timestamp: time.Unix(int64(binary.BigEndian.Uint64(b[0:8])),
+			int64(binary.BigEndian.Uint32(b[8:12]))).UTC(),
+		Offset: int64(binary.BigEndian.Uint64(b[12:20])),
+	}
+}
+
+// FileStore is a Store that appends messages to segment files in Dir. Each
+// segment has an index of message timestamps so that queries only read the
+// segments and messages in the requested time range. It has no dependencies
+// beyond the file system, making it suitable for small collectors.
+type FileStore struct {
+	Dir string
+
+	// SegmentSize is the size at which the current segment is closed and a
+	// new one started. If zero, 64 MiB is used.
+	SegmentSize int64
+
+	// Retention is how long messages are kept. Segments whose newest
+	// message is older than Retention are deleted when a new segment is
+	// started, or when Compact is called. If zero, messages are kept forever.
+	Retention time.Duration
+
+	mu       sync.Mutex
+	segments []*segment
+	log      *os.File
+	index    *os.File
+}
+
+// OpenFileStore opens the store in dir, creating the directory if needed.
+// If the index of the newest segment does not cover its whole log, as after
+// a crash while appending, the index is rebuilt and a partially written
+// final message is discarded.
+func OpenFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	fs := &FileStore{Dir: dir}
+
+	names, err := filepath.Glob(filepath.Join(dir, "*"+segmentLogSuffix))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		id, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), segmentLogSuffix), 10, 64)
+		if err != nil {
+			continue // not one of ours
+		}
+		fs.segments = append(fs.segments, &segment{id: id})
+	}
+	sort.Slice(fs.segments, func(i, j int) bool { return fs.segments[i].id < fs.segments[j].id })
+
+	for i, seg := range fs.segments {
+		if i == len(fs.segments)-1 {
+			err = fs.loadNewest(seg)
+		} else {
+			err = fs.loadIndex(seg)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fs, nil
+}
+
+func (fs *FileStore) path(seg *segment, suffix string) string {
+	return filepath.Join(fs.Dir, fmt.Sprintf("%020d%s", seg.id, suffix))
+}
+
+func (fs *FileStore) readIndex(seg *segment) ([]indexEntry, error) {
+	b, err := ioutil.ReadFile(fs.path(seg, segmentIndexSuffix))
+	if err != nil {
+		return nil, err
+	}
+	// a trailing partial entry is from a write in progress, so ignore it
+	entries := make([]indexEntry, 0, len(b)/indexEntrySize)
+	for len(b) >= indexEntrySize {
+		entries = append(entries, parseIndexEntry(b[:indexEntrySize]))
+		b = b[indexEntrySize:]
+	}
+	return entries, nil
+}
+
+// loadIndex reads the time range and size of a segment from its index
+func (fs *FileStore) loadIndex(seg *segment) error {
+	entries, err := fs.readIndex(seg)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		seg.add(e.Timestamp)
+	}
+	fi, err := os.Stat(fs.path(seg, segmentLogSuffix))
+	if err != nil {
+		return err
+	}
+	seg.size = fi.Size()
+	return nil
+}
+
+func (seg *segment) add(t time.Time) {
+	if seg.count == 0 || t.Before(seg.minTime) {
+		seg.minTime = t
+	}
+	if seg.count == 0 || t.After(seg.maxTime) {
+		seg.maxTime = t
+	}
+	seg.count++
+}
+
+// loadNewest loads the index of the newest segment, rebuilding it if it
+// does not cover the whole log
+func (fs *FileStore) loadNewest(seg *segment) error {
+	complete, err := fs.indexComplete(seg)
+	if err != nil {
+		return err
+	}
+	if complete {
+		return fs.loadIndex(seg)
+	}
+	return fs.recover(seg)
+}
+
+// indexComplete reports whether the index of seg is whole and its last entry
+// refers to the last message of the log, as it does unless appending was
+// interrupted
+func (fs *FileStore) indexComplete(seg *segment) (bool, error) {
+	logInfo, err := os.Stat(fs.path(seg, segmentLogSuffix))
+	if err != nil {
+		return false, err
+	}
+	index, err := ioutil.ReadFile(fs.path(seg, segmentIndexSuffix))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if len(index)%indexEntrySize != 0 {
+		return false, nil
+	}
+	if len(index) == 0 {
+		return logInfo.Size() == 0, nil
+	}
+
+	last := parseIndexEntry(index[len(index)-indexEntrySize:])
+	f, err := os.Open(fs.path(seg, segmentLogSuffix))
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	cr := &countingReader{r: io.NewSectionReader(f, last.Offset, logInfo.Size()-last.Offset)}
+	fr := newFrameReader(cr, math.MaxInt32)
+	if _, err := fr.ReadFrame(); err != nil {
+		return false, nil
+	}
+	end := last.Offset + cr.n - int64(fr.r.Buffered())
+	return end == logInfo.Size(), nil
+}
+
+// recover rebuilds the index of seg from its log, truncating a partially
+// written final message. Messages that cannot be parsed are kept in the log
+// but not indexed, and a log that is not framed correctly before its end is
+// an error, so that stored messages are never discarded.
+func (fs *FileStore) recover(seg *segment) error {
+	f, err := os.Open(fs.path(seg, segmentLogSuffix))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cr := &countingReader{r: f}
+	fr := newFrameReader(cr, math.MaxInt32)
+	index := []byte{}
+	*seg = segment{id: seg.id}
+	for {
+		offset := cr.n - int64(fr.r.Buffered())
+		frame, err := fr.ReadFrame()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("rfc5424: segment %s is corrupt at offset %d: %s",
+				fs.path(seg, segmentLogSuffix), offset, err)
+		}
+		seg.size = cr.n - int64(fr.r.Buffered())
+		m := Message{}
+		if err := m.UnmarshalBinary(frame); err != nil {
+			continue
+		}
+		index = indexEntry{Timestamp: m.Timestamp, Offset: offset}.appendTo(index)
+		seg.add(m.Timestamp)
+	}
+
+	if err := os.Truncate(fs.path(seg, segmentLogSuffix), seg.size); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fs.path(seg, segmentIndexSuffix), index, 0644)
+}
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// openCurrent opens the newest segment for appending, starting a new one if
+// there is none or it is full. The caller must hold fs.mu.
+func (fs *FileStore) openCurrent() error {
+	segmentSize := fs.SegmentSize
+	if segmentSize <= 0 {
+		segmentSize = defaultSegmentSize
+	}
+	if fs.log != nil {
+		if fs.segments[len(fs.segments)-1].size < segmentSize {
+			return nil
+		}
+		if err := fs.closeCurrent(); err != nil {
+			return err
+		}
+	}
+
+	if len(fs.segments) == 0 || fs.segments[len(fs.segments)-1].size >= segmentSize {
+		id := uint64(1)
+		if len(fs.segments) > 0 {
+			id = fs.segments[len(fs.segments)-1].id + 1
+		}
+		fs.segments = append(fs.segments, &segment{id: id})
+		if err := fs.compact(); err != nil {
+			return err
+		}
+	}
+
+	seg := fs.segments[len(fs.segments)-1]
+	var err error
+	fs.log, err = os.OpenFile(fs.path(seg, segmentLogSuffix), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	fs.index, err = os.OpenFile(fs.path(seg, segmentIndexSuffix), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		fs.log.Close()
+		fs.log = nil
+		return err
+	}
+	return nil
+}
+
+func (fs *FileStore) closeCurrent() error {
+	if fs.log == nil {
+		return nil
+	}
+	err := fs.log.Close()
+	if indexErr := fs.index.Close(); err == nil {
+		err = indexErr
+	}
+	fs.log, fs.index = nil, nil
+	return err
+}
+
+// Append stores a single message
+func (fs *FileStore) Append(m Message) error {
+	return fs.AppendBatch([]Message{m})
+}
+
+// AppendBatch stores messages, writing the log and index of the current
+// segment once for the whole batch.
+func (fs *FileStore) AppendBatch(messages []Message) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for len(messages) > 0 {
+		if err := fs.openCurrent(); err != nil {
+			return err
+		}
+		seg := fs.segments[len(fs.segments)-1]
+		segmentSize := fs.SegmentSize
+		if segmentSize <= 0 {
+			segmentSize = defaultSegmentSize
+		}
+
+		logBuf := bytes.Buffer{}
+		index := []byte{}
+		n := 0
+		for ; n < len(messages) && seg.size+int64(logBuf.Len()) < segmentSize; n++ {
+			offset := seg.size + int64(logBuf.Len())
+			if _, err := messages[n].WriteTo(&logBuf); err != nil {
+				return err
+			}
+			index = indexEntry{Timestamp: messages[n].Timestamp, Offset: offset}.appendTo(index)
+		}
+
+		// The log is written first, so that the index never refers to a
+		// message that isn't there.
+		if _, err := fs.log.Write(logBuf.Bytes()); err != nil {
+			return err
+		}
+		if _, err := fs.index.Write(index); err != nil {
+			return err
+		}
+		for _, m := range messages[:n] {
+			seg.add(m.Timestamp)
+		}
+		seg.size += int64(logBuf.Len())
+		messages = messages[n:]
+	}
+	return nil
+}
+
+// Query calls fn for each stored message with a Timestamp in [start, end).
+// Segments that cannot contain such messages are skipped using their index.
+func (fs *FileStore) Query(start, end time.Time, fn func(m Message) error) error {
+	fs.mu.Lock()
+	segments := make([]segment, len(fs.segments))
+	for i, seg := range fs.segments {
+		segments[i] = *seg
+	}
+	fs.mu.Unlock()
+
+	for i := range segments {
+		seg := &segments[i]
+		if seg.count == 0 || seg.maxTime.Before(start) || !seg.minTime.Before(end) {
+			continue
+		}
+		if err := fs.querySegment(seg, start, end, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *FileStore) querySegment(seg *segment, start, end time.Time, fn func(m Message) error) error {
+	entries, err := fs.readIndex(seg)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(fs.path(seg, segmentLogSuffix))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, e := range entries {
+		if e.Timestamp.Before(start) || !e.Timestamp.Before(end) {
+			continue
+		}
+		fr := newFrameReader(io.NewSectionReader(f, e.Offset, math.MaxInt64-e.Offset), math.MaxInt32)
+		frame, err := fr.ReadFrame()
+		if err != nil {
+			return err
+		}
+		m := Message{}
+		if err := m.UnmarshalBinary(frame); err != nil {
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Compact deletes the segments whose messages are all older than Retention.
+// The current segment is never deleted.
+func (fs *FileStore) Compact() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.compact()
+}
+
+func (fs *FileStore) compact() error {
+	if fs.Retention <= 0 {
+		return nil
+	}
+	cutoff := TimeNow().Add(-fs.Retention)
+	kept := make([]*segment, 0, len(fs.segments))
+	for i, seg := range fs.segments {
+		if i < len(fs.segments)-1 && seg.maxTime.Before(cutoff) {
+			// the segments already removed are dropped, the others stay
+			if err := os.Remove(fs.path(seg, segmentLogSuffix)); err != nil && !os.IsNotExist(err) {
+				fs.segments = append(kept, fs.segments[i:]...)
+				return err
+			}
+			// without its log the segment is gone, even if its index stays
+			if err := os.Remove(fs.path(seg, segmentIndexSuffix)); err != nil && !os.IsNotExist(err) {
+				fs.segments = append(kept, fs.segments[i+1:]...)
+				return err
+			}
+			continue
+		}
+		kept = append(kept, seg)
+	}
+	fs.segments = kept
+	return nil
+}
+
+// Close closes the current segment
+func (fs *FileStore) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.closeCurrent()
+}
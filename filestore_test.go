@@ -0,0 +1,188 @@
+package rfc5424
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&FileStoreTest{})
+
+type FileStoreTest struct {
+}
+
+func queryTimes(c *C, s Store, start, end time.Time) []time.Time {
+	found := []time.Time{}
+	err := s.Query(start, end, func(m Message) error {
+		found = append(found, m.Timestamp)
+		return nil
+	})
+	c.Assert(err, IsNil)
+	return found
+}
+
+func (testSuite *FileStoreTest) TestAppendAndQuery(c *C) {
+	dir := c.MkDir()
+	store, err := OpenFileStore(dir)
+	c.Assert(err, IsNil)
+	var _ Store = store
+	store.SegmentSize = 100
+
+	t0 := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	msgs := []Message{}
+	for i := 0; i < 10; i++ {
+		msgs = append(msgs, Message{
+			Timestamp: t0.Add(time.Duration(i) * time.Hour),
+			Hostname:  "host",
+			Message:   []byte("hello"),
+		})
+	}
+	c.Assert(store.Append(msgs[0]), IsNil)
+	c.Assert(store.AppendBatch(msgs[1:]), IsNil)
+
+	// The small segment size spreads the messages over several segments
+	logs, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	c.Assert(len(logs) > 1, Equals, true)
+
+	c.Assert(queryTimes(c, store, t0.Add(2*time.Hour), t0.Add(5*time.Hour)), DeepEquals,
+		[]time.Time{t0.Add(2 * time.Hour), t0.Add(3 * time.Hour), t0.Add(4 * time.Hour)})
+	c.Assert(store.Close(), IsNil)
+
+	// Everything is still there after reopening, and appends continue
+	store, err = OpenFileStore(dir)
+	c.Assert(err, IsNil)
+	c.Assert(store.Append(Message{Timestamp: t0.Add(10 * time.Hour)}), IsNil)
+	c.Assert(len(queryTimes(c, store, t0, t0.Add(24*time.Hour))), Equals, 11)
+	c.Assert(store.Close(), IsNil)
+}
+
+func (testSuite *FileStoreTest) TestRecoversTornWrite(c *C) {
+	dir := c.MkDir()
+	store, err := OpenFileStore(dir)
+	c.Assert(err, IsNil)
+	t0 := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.Assert(store.Append(Message{Timestamp: t0, Message: []byte("one")}), IsNil)
+	c.Assert(store.Close(), IsNil)
+
+	// Simulate a crash part way through writing a message
+	logs, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	c.Assert(logs, HasLen, 1)
+	f, err := os.OpenFile(logs[0], os.O_WRONLY|os.O_APPEND, 0644)
+	c.Assert(err, IsNil)
+	f.WriteString("45 <0>1 2015-01-01T")
+	f.Close()
+
+	store, err = OpenFileStore(dir)
+	c.Assert(err, IsNil)
+	c.Assert(store.Append(Message{Timestamp: t0.Add(time.Hour), Message: []byte("two")}), IsNil)
+
+	bodies := []string{}
+	err = store.Query(t0, t0.Add(24*time.Hour), func(m Message) error {
+		bodies = append(bodies, string(m.Message))
+		return nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(bodies, DeepEquals, []string{"one", "two"})
+	c.Assert(store.Close(), IsNil)
+}
+
+func (testSuite *FileStoreTest) TestRecoverKeepsMessages(c *C) {
+	dir := c.MkDir()
+	store, err := OpenFileStore(dir)
+	c.Assert(err, IsNil)
+	t0 := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.Assert(store.Append(Message{Timestamp: t0, Message: []byte("one")}), IsNil)
+	c.Assert(store.Close(), IsNil)
+
+	// A complete frame that does not parse, appended without its index
+	// entry, is kept
+	logs, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	f, err := os.OpenFile(logs[0], os.O_WRONLY|os.O_APPEND, 0644)
+	c.Assert(err, IsNil)
+	f.WriteString("7 garbage")
+	f.Close()
+	store, err = OpenFileStore(dir)
+	c.Assert(err, IsNil)
+	c.Assert(store.Append(Message{Timestamp: t0.Add(time.Hour), Message: []byte("two")}), IsNil)
+	c.Assert(store.Close(), IsNil)
+	b, err := ioutil.ReadFile(logs[0])
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(b), "7 garbage"), Equals, true)
+	c.Assert(len(queryTimes(c, store, t0, t0.Add(24*time.Hour))), Equals, 2)
+
+	// A cleanly closed segment is opened as it is
+	before, err := ioutil.ReadFile(logs[0])
+	c.Assert(err, IsNil)
+	store, err = OpenFileStore(dir)
+	c.Assert(err, IsNil)
+	c.Assert(store.Close(), IsNil)
+	after, err := ioutil.ReadFile(logs[0])
+	c.Assert(err, IsNil)
+	c.Assert(after, DeepEquals, before)
+
+	// A log that is corrupt before its end is not truncated
+	f, err = os.OpenFile(logs[0], os.O_WRONLY|os.O_APPEND, 0644)
+	c.Assert(err, IsNil)
+	f.WriteString("x3 abc")
+	f.Close()
+	_, err = OpenFileStore(dir)
+	c.Assert(err, ErrorMatches, "rfc5424: segment .* is corrupt at offset .*")
+	after, err = ioutil.ReadFile(logs[0])
+	c.Assert(err, IsNil)
+	c.Assert(string(after), Equals, string(before)+"x3 abc")
+}
+
+func (testSuite *FileStoreTest) TestRetention(c *C) {
+	now := time.Date(2015, 1, 10, 0, 0, 0, 0, time.UTC)
+	TimeNow = func() time.Time { return now }
+	defer func() { TimeNow = time.Now }()
+
+	dir := c.MkDir()
+	store, err := OpenFileStore(dir)
+	c.Assert(err, IsNil)
+	store.SegmentSize = 1
+	store.Retention = 24 * time.Hour
+
+	for _, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, time.Hour, 0} {
+		c.Assert(store.Append(Message{Timestamp: now.Add(-age)}), IsNil)
+	}
+	c.Assert(store.Compact(), IsNil)
+
+	c.Assert(queryTimes(c, store, time.Time{}, now.Add(time.Hour)), DeepEquals,
+		[]time.Time{now.Add(-time.Hour), now})
+	files, err := ioutil.ReadDir(dir)
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 4) // two segments, each with a log and an index
+	c.Assert(store.Close(), IsNil)
+}
+
+func (testSuite *FileStoreTest) TestCompactFailure(c *C) {
+	now := time.Date(2015, 1, 10, 0, 0, 0, 0, time.UTC)
+	TimeNow = func() time.Time { return now }
+	defer func() { TimeNow = time.Now }()
+
+	dir := c.MkDir()
+	store, err := OpenFileStore(dir)
+	c.Assert(err, IsNil)
+	store.SegmentSize = 1
+	for _, age := range []time.Duration{0, 72 * time.Hour, time.Hour, 48 * time.Hour, 0} {
+		c.Assert(store.Append(Message{Timestamp: now.Add(-age)}), IsNil)
+	}
+	store.Retention = 24 * time.Hour
+
+	// The log of the fourth segment cannot be removed
+	logs, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	c.Assert(logs, HasLen, 5)
+	c.Assert(os.Remove(logs[3]), IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(logs[3], "busy"), 0755), IsNil)
+	c.Assert(store.Compact(), NotNil)
+
+	// The segments kept are neither lost nor duplicated
+	c.Assert(queryTimes(c, store, now.Add(-2*time.Hour), now.Add(time.Hour)), DeepEquals,
+		[]time.Time{now, now.Add(-time.Hour), now})
+	c.Assert(store.Close(), IsNil)
+}
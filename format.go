@@ -0,0 +1,78 @@
+package rfc5424
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Formatter converts the values of struct fields that are not strings to
+// PROCIDs and PARAM-VALUEs when structs are encoded. Its output does not
+// depend on the locale: numbers are always decimal, with '.' as the decimal
+// point and no digit grouping, so that every service emits values that
+// parse the same way. The zero value formats floats with the fewest digits
+// that represent them exactly, booleans as "true" and "false" and nil
+// values as "".
+type Formatter struct {
+	// FloatFormat and FloatPrecision, if FloatFormat is set, are the format
+	// and precision passed to strconv.FormatFloat, e.g. 'f' and 3 for
+	// milliseconds
+	FloatFormat    byte
+	FloatPrecision int
+
+	// NumericBools formats booleans as "1" and "0"
+	NumericBools bool
+
+	// Nil is the value of nil pointers, interfaces, maps and slices
+	Nil string
+}
+
+// DefaultFormatter is used by Encode and by Encoders without a Formatter
+var DefaultFormatter = &Formatter{}
+
+// WithFormatter sets how the values of struct fields that are not strings
+// are formatted, instead of DefaultFormatter.
+func WithFormatter(f *Formatter) Option {
+	return func(o *options) { o.formatter = f }
+}
+
+// format returns v, whose type was reflected as vf, as a string
+func (f *Formatter) format(vf valueFormat, v reflect.Value) string {
+	switch vf {
+	case formatString:
+		return v.String()
+	case formatInt:
+		return strconv.FormatInt(v.Int(), 10)
+	case formatUint:
+		return strconv.FormatUint(v.Uint(), 10)
+	case formatBool:
+		switch {
+		case !f.NumericBools:
+			return strconv.FormatBool(v.Bool())
+		case v.Bool():
+			return "1"
+		}
+		return "0"
+	case formatFloat:
+		if f.FloatFormat == 0 {
+			return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())
+		}
+		return strconv.FormatFloat(v.Float(), f.FloatFormat, f.FloatPrecision, v.Type().Bits())
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return f.Nil
+		}
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.Type().Implements(stringerType) {
+		// format what is pointed to as if it were the field itself
+		elem := v.Elem()
+		return f.format(formatOf(elem.Type()), elem)
+	}
+	if !v.CanInterface() {
+		// unexported fields can only be read with the typed accessors
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
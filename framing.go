@@ -0,0 +1,201 @@
+package rfc5424
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxFrameLengthDigits bounds the MSG-LEN prefix of an octet-counted frame
+const maxFrameLengthDigits = 10
+
+// ErrMessageTooLong is reported when a connection sends a message longer than
+// Server.MaxMessageLength, and returned by LengthLimiter for messages longer
+// than its MaxLength.
+var ErrMessageTooLong = errors.New("rfc5424: message exceeds maximum length")
+
+// Framing describes how messages are delimited on a stream transport, as
+// described in RFC-6587.
+type Framing int
+
+const (
+	// OctetCounting prefixes each message with its length and a space
+	// (MSG-LEN SP SYSLOG-MSG), as required by RFC-5425.
+	OctetCounting Framing = iota
+	// NonTransparentFraming terminates each message with a line feed. It
+	// cannot carry messages containing a line feed.
+	NonTransparentFraming
+)
+
+func (f Framing) String() string {
+	switch f {
+	case OctetCounting:
+		return "octet-counting"
+	case NonTransparentFraming:
+		return "non-transparent"
+	}
+	return fmt.Sprintf("Framing(%d)", int(f))
+}
+
+// MarshalText returns the name of the framing, e.g. "octet-counting"
+func (f Framing) MarshalText() ([]byte, error) {
+	if f != OctetCounting && f != NonTransparentFraming {
+		return nil, InvalidValue("Framing", int(f))
+	}
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText parses "octet-counting" or "non-transparent", so that the
+// framing can be chosen in configuration files.
+func (f *Framing) UnmarshalText(text []byte) error {
+	for _, framing := range []Framing{OctetCounting, NonTransparentFraming} {
+		if string(text) == framing.String() {
+			*f = framing
+			return nil
+		}
+	}
+	return InvalidValue("Framing", string(text))
+}
+
+// Set implements flag.Value, so that the framing can be a command line flag
+func (f *Framing) Set(name string) error {
+	return f.UnmarshalText([]byte(name))
+}
+
+// writeMessage writes m to w as a single frame. b is the marshaled form of
+// m, or nil if it is not known yet.
+func (f Framing) writeMessage(w io.Writer, m Message, b []byte) (int64, error) {
+	if b == nil {
+		var err error
+		if b, err = m.MarshalBinary(); err != nil {
+			return 0, err
+		}
+	}
+	frame, err := f.appendFrame(nil, b, m)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(frame)
+	return int64(n), err
+}
+
+// appendFrame appends b, the marshaled form of m, to dst as a single frame
+func (f Framing) appendFrame(dst, b []byte, m Message) ([]byte, error) {
+	if f == OctetCounting {
+		dst = strconv.AppendInt(dst, int64(len(b)), 10)
+		dst = append(dst, ' ')
+		return append(dst, b...), nil
+	}
+	if bytes.IndexByte(b, '\n') >= 0 {
+		err := errorInvalidValue{Property: "StructuredData", Value: m.StructuredData}
+		if bytes.IndexByte(m.Message, '\n') >= 0 {
+			err = errorInvalidValue{Property: "Message", Value: string(m.Message)}
+		}
+		err.reason = "a line feed cannot be sent with non-transparent framing"
+		return dst, err
+	}
+	dst = append(dst, b...)
+	return append(dst, '\n'), nil
+}
+
+// frameReader reads frames from a stream. If autodetect is set, the framing is
+// chosen by looking at the first byte of the stream: octet-counted frames
+// start with a digit while non-transparent frames start with the '<' of the
+// PRI. Frames longer than maxLength are rejected before they are buffered.
+type frameReader struct {
+	r          *bufio.Reader
+	framing    Framing
+	autodetect bool
+	maxLength  int
+}
+
+func newFrameReader(r io.Reader, maxLength int) *frameReader {
+	return &frameReader{r: bufio.NewReader(r), autodetect: true, maxLength: maxLength}
+}
+
+// ReadFrame returns the next frame, or io.EOF at the end of the stream.
+func (fr *frameReader) ReadFrame() ([]byte, error) {
+	if fr.autodetect {
+		b, err := fr.r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] >= '0' && b[0] <= '9' {
+			fr.framing = OctetCounting
+		} else {
+			fr.framing = NonTransparentFraming
+		}
+		fr.autodetect = false
+	}
+
+	if fr.framing == OctetCounting {
+		return fr.readOctetCounted()
+	}
+	return fr.readLine()
+}
+
+func (fr *frameReader) readOctetCounted() ([]byte, error) {
+	digits := make([]byte, 0, maxFrameLengthDigits)
+	for {
+		ch, err := fr.r.ReadByte()
+		if err == io.EOF && len(digits) > 0 {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+		if ch == ' ' {
+			break
+		}
+		if ch < '0' || ch > '9' || len(digits) == maxFrameLengthDigits {
+			return nil, BadFormat("MSG-LEN")
+		}
+		digits = append(digits, ch)
+	}
+	length, err := strconv.Atoi(string(digits))
+	if err != nil || length == 0 {
+		return nil, BadFormat("MSG-LEN")
+	}
+	if length > fr.maxLength {
+		return nil, ErrMessageTooLong
+	}
+
+	frame := make([]byte, length)
+	if _, err := io.ReadFull(fr.r, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return frame, nil
+}
+
+func (fr *frameReader) readLine() ([]byte, error) {
+	for {
+		var line []byte
+		for {
+			chunk, err := fr.r.ReadSlice('\n')
+			// allow for the trailing CR LF on top of the message itself
+			if len(line)+len(chunk) > fr.maxLength+2 {
+				return nil, ErrMessageTooLong
+			}
+			line = append(line, chunk...)
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+			if err == io.EOF && len(line) > 0 {
+				break // the final message need not be terminated
+			}
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 0 {
+			return line, nil
+		}
+	}
+}
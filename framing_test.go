@@ -0,0 +1,62 @@
+package rfc5424
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&FramingTest{})
+
+type FramingTest struct {
+}
+
+func (s *FramingTest) TestReadsFrames(c *C) {
+	fr := newFrameReader(strings.NewReader("3 abc2 de"), 10)
+	frame, err := fr.ReadFrame()
+	c.Assert(err, IsNil)
+	c.Assert(string(frame), Equals, "abc")
+	c.Assert(fr.framing, Equals, OctetCounting)
+	frame, err = fr.ReadFrame()
+	c.Assert(err, IsNil)
+	c.Assert(string(frame), Equals, "de")
+	_, err = fr.ReadFrame()
+	c.Assert(err, Equals, io.EOF)
+
+	fr = newFrameReader(strings.NewReader("<abc\n\n<de\r\n<f"), 10)
+	for _, expected := range []string{"<abc", "<de", "<f"} {
+		frame, err = fr.ReadFrame()
+		c.Assert(err, IsNil)
+		c.Assert(string(frame), Equals, expected)
+	}
+	c.Assert(fr.framing, Equals, NonTransparentFraming)
+	_, err = fr.ReadFrame()
+	c.Assert(err, Equals, io.EOF)
+}
+
+func (s *FramingTest) TestRejectsLongFrames(c *C) {
+	// The declared length is rejected before anything is allocated
+	fr := newFrameReader(strings.NewReader("9999999999 x"), 8192)
+	_, err := fr.ReadFrame()
+	c.Assert(err, Equals, ErrMessageTooLong)
+
+	// Lines are rejected even when no line feed ever arrives
+	fr = newFrameReader(bytes.NewReader(bytes.Repeat([]byte("<"), 100000)), 8192)
+	_, err = fr.ReadFrame()
+	c.Assert(err, Equals, ErrMessageTooLong)
+
+	fr = newFrameReader(strings.NewReader("<abc\r\n"), 4)
+	frame, err := fr.ReadFrame()
+	c.Assert(err, IsNil)
+	c.Assert(string(frame), Equals, "<abc")
+}
+
+func (s *FramingTest) TestRejectsBadLength(c *C) {
+	for _, stream := range []string{"0 ", "12345678901 x", "12x abc", "3"} {
+		fr := newFrameReader(strings.NewReader(stream), 8192)
+		_, err := fr.ReadFrame()
+		c.Assert(err, NotNil, Commentf("%q", stream))
+	}
+}
@@ -0,0 +1,21 @@
+//go:build go1.18
+// +build go1.18
+
+package rfc5424_test
+
+import (
+	"testing"
+
+	"github.com/secureworks/rfc5424/rfc5424test"
+)
+
+// The corpora of these fuzz tests are seeded from rfc5424test.Vectors; inputs
+// found by go test -fuzz are kept in testdata/fuzz.
+
+func FuzzUnmarshal(f *testing.F) {
+	rfc5424test.FuzzUnmarshal(f)
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	rfc5424test.FuzzRoundTrip(f)
+}
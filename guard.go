@@ -0,0 +1,157 @@
+package rfc5424
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultGuardWarnRatio     = 0.9
+	defaultGuardMaxParamNames = 1000
+)
+
+// guardBuckets are the upper bounds of the buckets of the size histogram of
+// a MessageGuard. Larger messages are counted in a last bucket.
+var guardBuckets = [...]int{128, 256, 512, 1024, 2048, 4096, 8192, 16384, 32768, 65536}
+
+// SizeBucket is a bucket of a message size histogram: the number of
+// messages of more than the previous bucket's UpTo and at most UpTo octets.
+// UpTo is zero for the last bucket, of the messages larger than all others.
+type SizeBucket struct {
+	UpTo  int
+	Count uint64
+}
+
+// MessageGuard is a MessageWriter that watches the messages written to
+// Writer for signs of trouble before a destination rejects them: messages
+// approaching MaxLength, and an ever growing number of distinct SD
+// parameter names, which usually means values are being used as names by
+// mistake. Problems are passed to Error; the messages are written anyway.
+// It also keeps a histogram of the marshaled sizes of the messages.
+type MessageGuard struct {
+	// sizes is accessed atomically, and comes first to be 64-bit aligned
+	sizes [len(guardBuckets) + 1]uint64
+
+	Writer MessageWriter
+
+	// MaxLength is the largest message the destination accepts. Messages
+	// of at least WarnRatio (by default 0.9) times MaxLength are reported.
+	// If zero, sizes are only counted.
+	MaxLength int
+	WarnRatio float64
+
+	// MaxParamNames is the number of distinct SD-ID and parameter name
+	// pairs above which the cardinality is reported, once. If zero, 1000
+	// is used.
+	MaxParamNames int
+
+	// Error is called with the problems found. If nil, they are ignored.
+	Error func(err error)
+
+	mu         sync.Mutex
+	paramNames map[string]struct{}
+	reported   bool
+}
+
+// WriteMessage checks m and writes it to Writer
+func (g *MessageGuard) WriteMessage(m Message) error {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return g.writeMarshaled(m, b)
+}
+
+func (g *MessageGuard) writeMarshaled(m Message, b []byte) error {
+	g.countSize(len(b))
+	if g.MaxLength > 0 {
+		ratio := g.WarnRatio
+		if ratio <= 0 {
+			ratio = defaultGuardWarnRatio
+		}
+		if float64(len(b)) >= ratio*float64(g.MaxLength) {
+			g.report(fmt.Errorf("rfc5424: message of %d octets from %s is close to the limit of %d",
+				len(b), nilify(m.AppName), g.MaxLength))
+		}
+	}
+	g.checkParamNames(m)
+	return writeMarshaled(g.Writer, m, b)
+}
+
+// countSize adds a message of n octets to the histogram
+func (g *MessageGuard) countSize(n int) {
+	i := 0
+	for i < len(guardBuckets) && n > guardBuckets[i] {
+		i++
+	}
+	atomic.AddUint64(&g.sizes[i], 1)
+}
+
+// checkParamNames adds the parameter names of m to those seen, reporting
+// when there are too many. Once they have been reported, no more names are
+// kept, so that the set stays bounded.
+func (g *MessageGuard) checkParamNames(m Message) {
+	max := g.MaxParamNames
+	if max <= 0 {
+		max = defaultGuardMaxParamNames
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.reported {
+		return
+	}
+	if g.paramNames == nil {
+		g.paramNames = map[string]struct{}{}
+	}
+	for _, sd := range m.StructuredData {
+		for _, param := range sd.Parameters {
+			key := sd.ID + " " + param.Name
+			if _, ok := g.paramNames[key]; ok {
+				continue
+			}
+			g.paramNames[key] = struct{}{}
+			if len(g.paramNames) > max {
+				g.reported, g.paramNames = true, nil
+				g.report(fmt.Errorf("rfc5424: more than %d distinct SD parameter names, the last %s in %s",
+					max, param.Name, sd.ID))
+				return
+			}
+		}
+	}
+}
+
+func (g *MessageGuard) report(err error) {
+	if g.Error != nil {
+		g.Error(err)
+	}
+}
+
+// Sizes returns the histogram of the sizes of the messages written, in
+// octets
+func (g *MessageGuard) Sizes() []SizeBucket {
+	buckets := make([]SizeBucket, len(g.sizes))
+	for i := range g.sizes {
+		if i < len(guardBuckets) {
+			buckets[i].UpTo = guardBuckets[i]
+		}
+		buckets[i].Count = atomic.LoadUint64(&g.sizes[i])
+	}
+	return buckets
+}
+
+// ParamNames returns the number of distinct SD-ID and parameter name pairs
+// seen, or -1 once MaxParamNames was exceeded and they stopped being kept
+func (g *MessageGuard) ParamNames() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.reported {
+		return -1
+	}
+	return len(g.paramNames)
+}
+
+// Close closes Writer
+func (g *MessageGuard) Close() error {
+	return g.Writer.Close()
+}
@@ -0,0 +1,62 @@
+package rfc5424
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&GuardTest{})
+
+type GuardTest struct {
+}
+
+func (s *GuardTest) TestSizes(c *C) {
+	var errs []error
+	buf := &bytes.Buffer{}
+	g := &MessageGuard{
+		Writer:    &StreamWriter{Writer: buf},
+		MaxLength: 200,
+		Error:     func(err error) { errs = append(errs, err) },
+	}
+	small := Message{Priority: 14, AppName: "app"}
+	large := Message{Priority: 14, AppName: "app", Message: []byte(strings.Repeat("x", 170))}
+	c.Assert(g.WriteMessage(small), IsNil)
+	c.Assert(g.WriteMessage(large), IsNil)
+	huge := Message{Priority: 14, Message: []byte(strings.Repeat("x", 100000))}
+	c.Assert(g.WriteMessage(huge), IsNil)
+
+	c.Assert(errs, HasLen, 2)
+	c.Assert(errs[0], ErrorMatches, "rfc5424: message of 190 octets from app is close to the limit of 200")
+	c.Assert(errs[1], ErrorMatches, "rfc5424: message of 100018 octets from - is close to the limit of 200")
+	sizes := g.Sizes()
+	c.Assert(sizes, HasLen, 11)
+	c.Assert(sizes[0], Equals, SizeBucket{UpTo: 128, Count: 1})
+	c.Assert(sizes[1], Equals, SizeBucket{UpTo: 256, Count: 1})
+	c.Assert(sizes[10], Equals, SizeBucket{UpTo: 0, Count: 1})
+	c.Assert(strings.Count(buf.String(), "<14>1"), Equals, 3)
+}
+
+func (s *GuardTest) TestParamNames(c *C) {
+	var errs []error
+	g := &MessageGuard{
+		Writer:        &StreamWriter{Writer: &bytes.Buffer{}},
+		MaxParamNames: 3,
+		Error:         func(err error) { errs = append(errs, err) },
+	}
+	for i := 0; i < 10; i++ {
+		m := Message{}
+		m.AddDatum("req@32473", "status", "200")
+		m.AddDatum("req@32473", "user_"+strconv.Itoa(i), "1")
+		c.Assert(g.WriteMessage(m), IsNil)
+		if i == 1 {
+			c.Assert(g.ParamNames(), Equals, 3)
+		}
+	}
+	c.Assert(errs, HasLen, 1)
+	c.Assert(errs[0], ErrorMatches, "rfc5424: more than 3 distinct SD parameter names, the last user_2 in req@32473")
+	c.Assert(g.ParamNames(), Equals, -1)
+	c.Assert(g.Close(), IsNil)
+}
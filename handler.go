@@ -0,0 +1,59 @@
+package rfc5424
+
+import "context"
+
+// HandlerFunc is an adapter that allows an ordinary function to be used as a
+// Handler.
+type HandlerFunc func(ctx context.Context, m Message, src Source)
+
+// Handle calls f(ctx, m, src)
+func (f HandlerFunc) Handle(ctx context.Context, m Message, src Source) {
+	f(ctx, m, src)
+}
+
+// Middleware wraps a Handler to add behavior before or after it runs, in the
+// same way as net/http middleware.
+type Middleware func(Handler) Handler
+
+// Chain returns h wrapped by each of the middleware. The first middleware is
+// the outermost, so it sees each message first.
+func Chain(h Handler, middleware ...Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// Filter returns a Middleware that only passes on messages for which accept
+// returns true.
+func Filter(accept func(m Message, src Source) bool) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+			if accept(m, src) {
+				next.Handle(ctx, m, src)
+			}
+		})
+	}
+}
+
+// Enrich returns a Middleware that calls modify on each message before
+// passing it on, e.g. to add structured data describing the Source.
+func Enrich(modify func(m *Message, src Source)) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+			modify(&m, src)
+			next.Handle(ctx, m, src)
+		})
+	}
+}
+
+// WriterHandler returns a Handler that writes each message to w, allowing
+// received messages to be forwarded to any MessageWriter. Errors returned by
+// w are passed to onError, if it is not nil.
+func WriterHandler(w MessageWriter, onError func(error)) Handler {
+	return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+		if err := w.WriteMessage(m); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
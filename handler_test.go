@@ -0,0 +1,44 @@
+package rfc5424
+
+import (
+	"context"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&HandlerTest{})
+
+type HandlerTest struct {
+}
+
+func (s *HandlerTest) TestChain(c *C) {
+	order := []string{}
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+				order = append(order, name)
+				next.Handle(ctx, m, src)
+			})
+		}
+	}
+
+	h := make(chanHandler, 10)
+	chained := Chain(h,
+		trace("first"),
+		Filter(func(m Message, src Source) bool { return m.AppName != "noisy" }),
+		Enrich(func(m *Message, src Source) { m.AddDatum("origin@32473", "network", src.Network) }),
+		trace("last"))
+
+	src := Source{Network: "udp"}
+	chained.Handle(context.Background(), Message{AppName: "noisy"}, src)
+	chained.Handle(context.Background(), Message{AppName: "quiet"}, src)
+
+	c.Assert(order, DeepEquals, []string{"first", "first", "last"})
+	rm := <-h
+	c.Assert(rm.Message.AppName, Equals, "quiet")
+	c.Assert(rm.Message.StructuredData, DeepEquals, []StructuredData{{
+		ID:         "origin@32473",
+		Parameters: []SDParam{{Name: "network", Value: "udp"}},
+	}})
+	c.Assert(len(h), Equals, 0)
+}
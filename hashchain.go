@@ -0,0 +1,178 @@
+package rfc5424
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// defaultHashChainSDID is the SD-ID of the hash chain element, unless
+// HashChainWriter.SDID is set
+const defaultHashChainSDID = "chain@32473"
+
+// hashChainLink returns the hash of m that the next message of its chain
+// refers to: the base64 SHA-256 of its canonical form (see CanonicalBytes),
+// which includes m's own chain element
+func hashChainLink(m Message) (string, error) {
+	b, err := m.CanonicalBytes()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// HashChainWriter is a MessageWriter that makes the messages written to
+// Writer tamper-evident, for append-only audit logs. Each message gets an
+// SD element with its sequence number, "seq", and the hash of the message
+// before it, "prev", so that removing, inserting or changing a message
+// breaks the chain (see HashChainVerifier). It is lighter than RFC-5848
+// signing (see Signer) but, having no key, only detects changes made
+// without rewriting the rest of the chain; the last hash should be kept
+// elsewhere to anchor it.
+type HashChainWriter struct {
+	Writer MessageWriter
+
+	// SDID is the ID of the chain's SD element. If empty, "chain@32473" is
+	// used.
+	SDID string
+
+	mu   sync.Mutex
+	seq  uint64
+	prev string
+}
+
+// Resume continues a chain after a restart: the next message has sequence
+// number seq+1 and refers to hash, as returned by Last before the restart.
+func (w *HashChainWriter) Resume(seq uint64, hash string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.seq, w.prev = seq, hash
+}
+
+// Last returns the sequence number and hash of the last message written,
+// to be kept for Resume or to anchor the chain. They are zero and "" before
+// the first message.
+func (w *HashChainWriter) Last() (seq uint64, hash string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.seq, w.prev
+}
+
+func (w *HashChainWriter) sdID() string {
+	if w.SDID == "" {
+		return defaultHashChainSDID
+	}
+	return w.SDID
+}
+
+// WriteMessage adds the chain element to m and writes it. m itself is not
+// modified. Messages are chained and written in the order WriteMessage is
+// called: concurrent calls are serialized, so that the stored order matches
+// the chain. A message Writer fails to write stays in the chain, so that
+// the gap is detected.
+func (w *HashChainWriter) WriteMessage(m Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	m = m.Clone()
+	m.AddDatum(w.sdID(), "seq", strconv.FormatUint(w.seq+1, 10))
+	m.AddDatum(w.sdID(), "prev", w.prev)
+	hash, err := hashChainLink(m)
+	if err != nil {
+		return err
+	}
+	w.seq, w.prev = w.seq+1, hash
+	return w.Writer.WriteMessage(m)
+}
+
+// Close closes Writer
+func (w *HashChainWriter) Close() error {
+	return w.Writer.Close()
+}
+
+// HashChainError describes a break in a hash chain found by a
+// HashChainVerifier
+type HashChainError struct {
+	// Seq is the sequence number of the message where the chain breaks,
+	// or zero if it has none
+	Seq    uint64
+	Reason string
+}
+
+func (e *HashChainError) Error() string {
+	return fmt.Sprintf("rfc5424: hash chain broken at message %d: %s", e.Seq, e.Reason)
+}
+
+// HashChainVerifier checks that messages written by a HashChainWriter were
+// neither removed, inserted, reordered nor changed. Messages are passed to
+// Add in the order they were stored, so that a Store can be checked with
+//
+//	err := store.Query(start, end, verifier.Add)
+//
+// The first message starts the chain, unless Resume was called with the
+// last known link.
+type HashChainVerifier struct {
+	// SDID is the ID of the chain's SD element. If empty, "chain@32473" is
+	// used.
+	SDID string
+
+	mu      sync.Mutex
+	started bool
+	seq     uint64
+	prev    string
+}
+
+// Resume makes the next message checked follow the message with sequence
+// number seq and hash hash, as returned by HashChainWriter.Last
+func (v *HashChainVerifier) Resume(seq uint64, hash string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.started, v.seq, v.prev = true, seq, hash
+}
+
+// Last returns the sequence number and hash of the last message checked,
+// which can be compared with those kept from the HashChainWriter to detect
+// messages removed from the end of the chain
+func (v *HashChainVerifier) Last() (seq uint64, hash string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.seq, v.prev
+}
+
+// Add checks that m follows the previous message. It returns a
+// *HashChainError if it does not; the chain then continues from m, so that
+// the rest of a stream can still be checked.
+func (v *HashChainVerifier) Add(m Message) error {
+	id := v.SDID
+	if id == "" {
+		id = defaultHashChainSDID
+	}
+	seqParam, hasSeq := m.SDParam(id, "seq")
+	prev, hasPrev := m.SDParam(id, "prev")
+	seq, err := strconv.ParseUint(seqParam, 10, 64)
+	if !hasSeq || !hasPrev || err != nil {
+		return &HashChainError{Reason: "the message has no valid chain element"}
+	}
+	hash, err := hashChainLink(m)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	started, expectedSeq, expectedPrev := v.started, v.seq+1, v.prev
+	v.started, v.seq, v.prev = true, seq, hash
+	switch {
+	case !started:
+		return nil
+	case seq > expectedSeq:
+		return &HashChainError{Seq: seq, Reason: fmt.Sprintf("messages %d to %d are missing", expectedSeq, seq-1)}
+	case seq < expectedSeq:
+		return &HashChainError{Seq: seq, Reason: fmt.Sprintf("expected message %d", expectedSeq)}
+	case prev != expectedPrev:
+		return &HashChainError{Seq: seq, Reason: "it does not follow the previous message"}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package rfc5424
+
+import (
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&HashChainTest{})
+
+type HashChainTest struct {
+}
+
+// writeChain returns n messages written by a HashChainWriter, as received
+func writeChain(c *C, w *HashChainWriter, n int) []Message {
+	cw := &chanWriter{messages: make(chan Message, n)}
+	w.Writer = cw
+	var msgs []Message
+	for i := 0; i < n; i++ {
+		m := Message{Priority: 110, Timestamp: T("2003-10-11T22:14:15.003Z"), Hostname: "host", AppName: "audit"}
+		m.SetTextMessage("event")
+		c.Assert(w.WriteMessage(m), IsNil)
+		b, err := (<-cw.messages).MarshalBinary()
+		c.Assert(err, IsNil)
+		received := Message{}
+		c.Assert(received.UnmarshalBinary(b), IsNil)
+		msgs = append(msgs, received)
+	}
+	return msgs
+}
+
+func (s *HashChainTest) TestChain(c *C) {
+	w := &HashChainWriter{}
+	msgs := writeChain(c, w, 4)
+	seq, ok := msgs[0].SDParam("chain@32473", "seq")
+	c.Assert(seq, Equals, "1")
+	c.Assert(ok, Equals, true)
+
+	v := &HashChainVerifier{}
+	for _, m := range msgs {
+		c.Assert(v.Add(m), IsNil)
+	}
+	lastSeq, lastHash := v.Last()
+	writerSeq, writerHash := w.Last()
+	c.Assert(lastSeq, Equals, uint64(4))
+	c.Assert(writerSeq, Equals, uint64(4))
+	c.Assert(lastHash, Equals, writerHash)
+
+	// Resuming continues the chain
+	w2 := &HashChainWriter{}
+	w2.Resume(w.Last())
+	for _, m := range writeChain(c, w2, 2) {
+		c.Assert(v.Add(m), IsNil)
+	}
+}
+
+func (s *HashChainTest) TestConcurrentWrites(c *C) {
+	const n = 50
+	cw := &chanWriter{messages: make(chan Message, n)}
+	w := &HashChainWriter{Writer: cw}
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := Message{Priority: 110, Hostname: "host"}
+			m.SetTextMessage("event")
+			c.Check(w.WriteMessage(m), IsNil)
+		}()
+	}
+	wg.Wait()
+
+	// the messages are stored in chain order
+	v := &HashChainVerifier{}
+	for i := 0; i < n; i++ {
+		b, err := (<-cw.messages).MarshalBinary()
+		c.Assert(err, IsNil)
+		received := Message{}
+		c.Assert(received.UnmarshalBinary(b), IsNil)
+		c.Assert(v.Add(received), IsNil)
+	}
+}
+
+func (s *HashChainTest) TestTampering(c *C) {
+	msgs := writeChain(c, &HashChainWriter{}, 5)
+
+	v := &HashChainVerifier{}
+	c.Assert(v.Add(msgs[0]), IsNil)
+	c.Assert(v.Add(msgs[2]), ErrorMatches, "rfc5424: hash chain broken at message 3: messages 2 to 2 are missing")
+	c.Assert(v.Add(msgs[1]), ErrorMatches, "rfc5424: hash chain broken at message 2: expected message 4")
+
+	changed := msgs[2].Clone()
+	changed.SetTextMessage("nothing happened")
+	v = &HashChainVerifier{}
+	c.Assert(v.Add(msgs[0]), IsNil)
+	c.Assert(v.Add(msgs[1]), IsNil)
+	c.Assert(v.Add(changed), IsNil)
+	err := v.Add(msgs[3])
+	c.Assert(err, ErrorMatches, "rfc5424: hash chain broken at message 4: it does not follow the previous message")
+	c.Assert(err.(*HashChainError).Seq, Equals, uint64(4))
+	c.Assert(v.Add(msgs[4]), IsNil)
+
+	c.Assert(v.Add(Message{}), ErrorMatches, "rfc5424: hash chain broken at message 0: the message has no valid chain element")
+}
@@ -0,0 +1,67 @@
+package rfc5424
+
+import "time"
+
+const (
+	defaultHeartbeatInterval = 20 * time.Minute
+	heartbeatMessageID       = "MARK"
+	heartbeatText            = "-- MARK --"
+)
+
+// Heartbeat writes a message to Writer at regular intervals, like the
+// "-- MARK --" messages of syslogd, so that collectors can tell a silent
+// sender or a broken path from an application with nothing to log. Each
+// heartbeat has the MSGID "MARK" and a meta SD element numbering the
+// heartbeats and giving the time since Start, so that lost heartbeats and
+// restarts can be detected too.
+type Heartbeat struct {
+	Writer MessageWriter
+
+	// Interval is the time between heartbeats. If zero, 20 minutes is
+	// used, as by syslogd.
+	Interval time.Duration
+
+	// Severity is the severity of the heartbeats. If zero, Info is used.
+	Severity Severity
+
+	// Error, if set, is called with the errors returned by Writer
+	Error func(err error)
+
+	meta     Meta
+	periodic periodic
+}
+
+// Beat writes a heartbeat now
+func (h *Heartbeat) Beat() error {
+	severity := h.Severity
+	if severity == DefaultSeverity {
+		severity = Info
+	}
+	m := defaultMessage(severity)
+	m.MessageID = heartbeatMessageID
+	m.SetTextMessage(heartbeatText)
+	m.StructuredData = append(m.StructuredData, h.meta.Next())
+	return h.Writer.WriteMessage(m)
+}
+
+// Start starts writing a heartbeat every Interval, until Close is called.
+// The first is written immediately.
+func (h *Heartbeat) Start() {
+	interval := h.Interval
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+	beat := func() {
+		if err := h.Beat(); err != nil && h.Error != nil {
+			h.Error(err)
+		}
+	}
+	beat()
+	h.periodic.start(interval, beat)
+}
+
+// Close stops the heartbeats and closes Writer
+func (h *Heartbeat) Close() error {
+	h.periodic.stopAndWait()
+	return h.Writer.Close()
+}
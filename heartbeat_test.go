@@ -0,0 +1,51 @@
+package rfc5424
+
+import (
+	"strconv"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&HeartbeatTest{})
+
+type HeartbeatTest struct {
+}
+
+func (s *HeartbeatTest) TestBeat(c *C) {
+	w := &chanWriter{messages: make(chan Message, 2)}
+	h := &Heartbeat{Writer: w, Severity: Notice}
+	c.Assert(h.Beat(), IsNil)
+	c.Assert(h.Beat(), IsNil)
+
+	m := <-w.messages
+	c.Assert(m.Severity(), Equals, Severity(Notice))
+	c.Assert(m.MessageID, Equals, "MARK")
+	c.Assert(m.TextMessage(), Equals, "-- MARK --")
+	seq, _ := m.SDParam(MetaSDID, "sequenceId")
+	c.Assert(seq, Equals, "1")
+	_, ok := m.SDParam(MetaSDID, "sysUpTime")
+	c.Assert(ok, Equals, true)
+
+	m = <-w.messages
+	seq, _ = m.SDParam(MetaSDID, "sequenceId")
+	c.Assert(seq, Equals, "2")
+	c.Assert(m.assertValid(), IsNil)
+}
+
+func (s *HeartbeatTest) TestStart(c *C) {
+	w := &chanWriter{messages: make(chan Message, 10)}
+	h := &Heartbeat{Writer: w, Interval: 10 * time.Millisecond}
+	h.Start()
+	for i := 1; i <= 3; i++ {
+		select {
+		case m := <-w.messages:
+			c.Assert(m.Severity(), Equals, Severity(Info))
+			seq, _ := m.SDParam(MetaSDID, "sequenceId")
+			c.Assert(seq, Equals, strconv.Itoa(i))
+		case <-time.After(time.Second):
+			c.Fatal("no heartbeat")
+		}
+	}
+	c.Assert(h.Close(), IsNil)
+}
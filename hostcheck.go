@@ -0,0 +1,150 @@
+package rfc5424
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHostnameCacheTTL  = 5 * time.Minute
+	defaultHostnameCheckSDID = "hostcheck@32473"
+)
+
+// HostnameMismatchAction is what a HostnameVerifier does with a message
+// whose HOSTNAME doesn't match its sender
+type HostnameMismatchAction int
+
+const (
+	// AnnotateHostnameMismatch keeps the HOSTNAME and adds an SD element
+	// recording the sender's address and name.
+	AnnotateHostnameMismatch HostnameMismatchAction = iota
+	// RewriteHostnameMismatch replaces the HOSTNAME with the sender's name
+	// from reverse DNS, or its IP address if it has none.
+	RewriteHostnameMismatch
+)
+
+type hostnameCacheEntry struct {
+	names   []string
+	expires time.Time
+}
+
+// HostnameVerifier checks the HOSTNAME of received messages against the IP
+// address they came from and its reverse DNS names, to guard against
+// senders claiming to be other hosts. A HOSTNAME matches if it is the IP
+// address, one of the names, or the first label of one of the names.
+//
+// Messages received on Unix sockets, which have no IP address, are not
+// checked. A HostnameVerifier is used as Middleware via its Wrap method.
+type HostnameVerifier struct {
+	Action HostnameMismatchAction
+
+	// SDID is the ID of the SD element added by AnnotateHostnameMismatch.
+	// It has "ip" and "name" parameters. If empty, "hostcheck@32473" is
+	// used (see DocumentationEnterpriseNumber).
+	SDID string
+
+	// LookupAddr returns the names for an address. If nil,
+	// net.DefaultResolver.LookupAddr is used.
+	LookupAddr func(ctx context.Context, addr string) ([]string, error)
+
+	// CacheTTL is how long the names of an address are cached. If zero,
+	// five minutes is used.
+	CacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]hostnameCacheEntry
+}
+
+// names returns the reverse DNS names of ip, without trailing dots. Lookup
+// failures are cached like an address without names.
+func (hv *HostnameVerifier) names(ctx context.Context, ip string) []string {
+	now := TimeNow()
+	hv.mu.Lock()
+	entry, ok := hv.cache[ip]
+	hv.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.names
+	}
+
+	lookup := hv.LookupAddr
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupAddr
+	}
+	names, _ := lookup(ctx, ip)
+	for i, name := range names {
+		names[i] = strings.TrimSuffix(name, ".")
+	}
+
+	ttl := hv.CacheTTL
+	if ttl <= 0 {
+		ttl = defaultHostnameCacheTTL
+	}
+	hv.mu.Lock()
+	defer hv.mu.Unlock()
+	if hv.cache == nil {
+		hv.cache = map[string]hostnameCacheEntry{}
+	}
+	for key, e := range hv.cache {
+		if !now.Before(e.expires) {
+			delete(hv.cache, key)
+		}
+	}
+	hv.cache[ip] = hostnameCacheEntry{names: names, expires: now.Add(ttl)}
+	return names
+}
+
+// hostnameMatches reports whether hostname refers to the host with address
+// ip and reverse DNS names
+func hostnameMatches(hostname, ip string, names []string) bool {
+	if hostname == ip {
+		return true
+	}
+	for _, name := range names {
+		if strings.EqualFold(hostname, name) {
+			return true
+		}
+		if i := strings.IndexByte(name, '.'); i > 0 && strings.EqualFold(hostname, name[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Wrap returns a Handler that checks the HOSTNAME of each message before
+// passing it to next.
+func (hv *HostnameVerifier) Wrap(next Handler) Handler {
+	return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+		ip := sourceIP(src)
+		if ip == "" {
+			next.Handle(ctx, m, src)
+			return
+		}
+		names := hv.names(ctx, ip)
+		if hostnameMatches(m.Hostname, ip, names) {
+			next.Handle(ctx, m, src)
+			return
+		}
+
+		name := ip
+		if len(names) > 0 {
+			name = names[0]
+		}
+		switch hv.Action {
+		case RewriteHostnameMismatch:
+			m.Hostname = name
+		default:
+			sdid := hv.SDID
+			if sdid == "" {
+				sdid = defaultHostnameCheckSDID
+			}
+			m.AddDatum(sdid, "ip", ip)
+			if len(names) > 0 {
+				m.AddDatum(sdid, "name", names[0])
+			}
+		}
+		next.Handle(ctx, m, src)
+	})
+}
@@ -0,0 +1,66 @@
+package rfc5424
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&HostnameVerifierTest{})
+
+type HostnameVerifierTest struct {
+}
+
+func fakeLookup(lookups *int) func(ctx context.Context, addr string) ([]string, error) {
+	return func(ctx context.Context, addr string) ([]string, error) {
+		*lookups++
+		if addr == "192.0.2.1" {
+			return []string{"web1.example.com."}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+}
+
+func (s *HostnameVerifierTest) TestAnnotate(c *C) {
+	lookups := 0
+	hv := &HostnameVerifier{LookupAddr: fakeLookup(&lookups)}
+	h := make(chanHandler, 10)
+	verified := Chain(h, hv.Wrap)
+	src := Source{RemoteAddr: &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 514}}
+
+	for _, hostname := range []string{"web1.example.com", "WEB1", "192.0.2.1"} {
+		verified.Handle(context.Background(), Message{Hostname: hostname}, src)
+		c.Assert(receive(c, h).Message.StructuredData, IsNil, Commentf(hostname))
+	}
+	c.Assert(lookups, Equals, 1)
+
+	verified.Handle(context.Background(), Message{Hostname: "db1"}, src)
+	rm := receive(c, h)
+	c.Assert(rm.Message.Hostname, Equals, "db1")
+	c.Assert(rm.Message.StructuredData, DeepEquals, []StructuredData{{
+		ID:         "hostcheck@32473",
+		Parameters: []SDParam{{Name: "ip", Value: "192.0.2.1"}, {Name: "name", Value: "web1.example.com"}},
+	}})
+
+	// Unix sockets are not checked
+	verified.Handle(context.Background(), Message{Hostname: "db1"}, Source{Network: "unixgram"})
+	c.Assert(receive(c, h).Message.StructuredData, IsNil)
+}
+
+func (s *HostnameVerifierTest) TestRewrite(c *C) {
+	lookups := 0
+	hv := &HostnameVerifier{Action: RewriteHostnameMismatch, LookupAddr: fakeLookup(&lookups)}
+	h := make(chanHandler, 10)
+	verified := hv.Wrap(h)
+
+	verified.Handle(context.Background(), Message{Hostname: "db1"},
+		Source{RemoteAddr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1")}})
+	c.Assert(receive(c, h).Message.Hostname, Equals, "web1.example.com")
+
+	// Without reverse DNS the IP address is used
+	verified.Handle(context.Background(), Message{Hostname: "db1"},
+		Source{RemoteAddr: &net.TCPAddr{IP: net.ParseIP("192.0.2.2")}})
+	c.Assert(receive(c, h).Message.Hostname, Equals, "192.0.2.2")
+}
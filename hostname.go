@@ -0,0 +1,117 @@
+package rfc5424
+
+import (
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultHostnameResolverTTL = 5 * time.Minute
+
+// DefaultHostnameResolver chooses the HOSTNAME of messages encoded from
+// structs without a Hostname field.
+var DefaultHostnameResolver = &HostnameResolver{}
+
+// HostnameResolver chooses the HOSTNAME of emitted messages in the order of
+// preference of RFC-5424 section 6.2.4: the FQDN, a static IP address, the
+// hostname, then a dynamic IP address. The result is cached.
+type HostnameResolver struct {
+	// Override, if set, is always used
+	Override string
+
+	// CacheTTL is how long the chosen name is used before it is resolved
+	// again. If zero, five minutes is used.
+	CacheTTL time.Duration
+
+	// IsDynamic reports whether an address was assigned dynamically, e.g. by
+	// DHCP. If nil, all addresses are treated as static.
+	IsDynamic func(ip net.IP) bool
+
+	// Hostname, LookupHost, LookupAddr and InterfaceAddrs default to the
+	// functions of the os and net packages.
+	Hostname       func() (string, error)
+	LookupHost     func(host string) ([]string, error)
+	LookupAddr     func(addr string) ([]string, error)
+	InterfaceAddrs func() ([]net.Addr, error)
+
+	mu       sync.Mutex
+	name     string
+	resolved time.Time
+}
+
+// Resolve returns the HOSTNAME to use, or "" if nothing is known, in which
+// case the NILVALUE is sent.
+func (hr *HostnameResolver) Resolve() string {
+	if hr.Override != "" {
+		return hr.Override
+	}
+	ttl := hr.CacheTTL
+	if ttl <= 0 {
+		ttl = defaultHostnameResolverTTL
+	}
+	now := TimeNow()
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	if !hr.resolved.IsZero() && now.Sub(hr.resolved) < ttl {
+		return hr.name
+	}
+	hr.name = hr.resolve()
+	hr.resolved = now
+	return hr.name
+}
+
+// resolve chooses the HOSTNAME. The caller must hold hr.mu.
+func (hr *HostnameResolver) resolve() string {
+	hostnameFunc, lookupHost, lookupAddr, interfaceAddrs :=
+		hr.Hostname, hr.LookupHost, hr.LookupAddr, hr.InterfaceAddrs
+	if hostnameFunc == nil {
+		hostnameFunc = os.Hostname
+	}
+	if lookupHost == nil {
+		lookupHost = net.LookupHost
+	}
+	if lookupAddr == nil {
+		lookupAddr = net.LookupAddr
+	}
+	if interfaceAddrs == nil {
+		interfaceAddrs = net.InterfaceAddrs
+	}
+
+	hostname, _ := hostnameFunc()
+	if strings.Contains(hostname, ".") {
+		return strings.TrimSuffix(hostname, ".")
+	}
+	if hostname != "" {
+		addrs, _ := lookupHost(hostname)
+		for _, addr := range addrs {
+			names, _ := lookupAddr(addr)
+			for _, name := range names {
+				name = strings.TrimSuffix(name, ".")
+				if strings.HasPrefix(strings.ToLower(name), strings.ToLower(hostname)+".") {
+					return name
+				}
+			}
+		}
+	}
+
+	var dynamic string
+	addrs, _ := interfaceAddrs()
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || !ipnet.IP.IsGlobalUnicast() {
+			continue
+		}
+		if hr.IsDynamic == nil || !hr.IsDynamic(ipnet.IP) {
+			return ipnet.IP.String()
+		}
+		if dynamic == "" {
+			dynamic = ipnet.IP.String()
+		}
+	}
+	if hostname != "" {
+		return hostname
+	}
+	return dynamic
+}
@@ -0,0 +1,80 @@
+package rfc5424
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&HostnameTest{})
+
+type HostnameTest struct {
+}
+
+func fakeInterfaceAddrs(cidrs ...string) func() ([]net.Addr, error) {
+	return func() ([]net.Addr, error) {
+		addrs := []net.Addr{}
+		for _, cidr := range cidrs {
+			ip, ipnet, _ := net.ParseCIDR(cidr)
+			ipnet.IP = ip
+			addrs = append(addrs, ipnet)
+		}
+		return addrs, nil
+	}
+}
+
+func (s *HostnameTest) TestPreference(c *C) {
+	hostname := "web1"
+	noLookup := func(string) ([]string, error) { return nil, errors.New("no such host") }
+	hr := &HostnameResolver{
+		Hostname:       func() (string, error) { return hostname, nil },
+		LookupHost:     func(string) ([]string, error) { return []string{"192.0.2.10"}, nil },
+		LookupAddr:     func(string) ([]string, error) { return []string{"web1.example.com."}, nil },
+		InterfaceAddrs: fakeInterfaceAddrs("127.0.0.1/8", "192.0.2.10/24", "2001:db8::1/64"),
+		IsDynamic:      func(ip net.IP) bool { return ip.To4() == nil },
+	}
+
+	// The FQDN, either from the hostname or from DNS
+	c.Assert(hr.resolve(), Equals, "web1.example.com")
+	hostname = "web1.example.org"
+	c.Assert(hr.resolve(), Equals, "web1.example.org")
+
+	// A static IP address
+	hostname = "web1"
+	hr.LookupAddr = noLookup
+	c.Assert(hr.resolve(), Equals, "192.0.2.10")
+
+	// The hostname
+	hr.InterfaceAddrs = fakeInterfaceAddrs("127.0.0.1/8", "2001:db8::1/64")
+	c.Assert(hr.resolve(), Equals, "web1")
+
+	// A dynamic IP address
+	hostname = ""
+	c.Assert(hr.resolve(), Equals, "2001:db8::1")
+
+	hr.Override = "override"
+	c.Assert(hr.Resolve(), Equals, "override")
+}
+
+func (s *HostnameTest) TestCache(c *C) {
+	now := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	TimeNow = func() time.Time { return now }
+	defer func() { TimeNow = time.Now }()
+
+	calls := 0
+	hr := &HostnameResolver{
+		Hostname: func() (string, error) {
+			calls++
+			return "host.example.com", nil
+		},
+		CacheTTL: time.Minute,
+	}
+	c.Assert(hr.Resolve(), Equals, "host.example.com")
+	c.Assert(hr.Resolve(), Equals, "host.example.com")
+	c.Assert(calls, Equals, 1)
+	now = now.Add(time.Minute)
+	c.Assert(hr.Resolve(), Equals, "host.example.com")
+	c.Assert(calls, Equals, 2)
+}
@@ -0,0 +1,69 @@
+package rfc5424
+
+import "sync"
+
+const defaultInternerMaxEntries = 10000
+
+// Interner makes identical strings share memory. Collectors that keep the
+// messages they receive see the same HOSTNAMEs, APP-NAMEs, SD-IDs and
+// PARAM-NAMEs over and over; interning them means each is stored once
+// rather than once per message. See Server.Interner.
+//
+// The zero Interner is ready to use and is safe for concurrent use.
+type Interner struct {
+	// MaxEntries bounds the number of distinct strings kept, so that a
+	// sender cannot grow the table without limit. Once it is reached, new
+	// strings are returned as they are. If zero, 10000 is used.
+	MaxEntries int
+
+	mu      sync.RWMutex
+	strings map[string]string
+}
+
+// Intern returns a string equal to s, sharing the memory of earlier strings
+// equal to it
+func (in *Interner) Intern(s string) string {
+	if s == "" {
+		return s
+	}
+	in.mu.RLock()
+	interned, ok := in.strings[s]
+	in.mu.RUnlock()
+	if ok {
+		return interned
+	}
+
+	maxEntries := in.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultInternerMaxEntries
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if interned, ok := in.strings[s]; ok {
+		return interned
+	}
+	if len(in.strings) >= maxEntries {
+		return s
+	}
+	if in.strings == nil {
+		in.strings = map[string]string{}
+	}
+	in.strings[s] = s
+	return s
+}
+
+// InternMessage interns the HOSTNAME, APP-NAME, MSGID, SD-IDs and
+// PARAM-NAMEs of m. PROCIDs and PARAM-VALUEs usually vary too much to be
+// worth interning.
+func (in *Interner) InternMessage(m *Message) {
+	m.Hostname = in.Intern(m.Hostname)
+	m.AppName = in.Intern(m.AppName)
+	m.MessageID = in.Intern(m.MessageID)
+	for i := range m.StructuredData {
+		sd := &m.StructuredData[i]
+		sd.ID = in.Intern(sd.ID)
+		for j := range sd.Parameters {
+			sd.Parameters[j].Name = in.Intern(sd.Parameters[j].Name)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package rfc5424
+
+import (
+	"unsafe"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&InternTest{})
+
+type InternTest struct {
+}
+
+// sameMemory reports whether a and b share their bytes
+func sameMemory(a, b string) bool {
+	return len(a) == len(b) && (len(a) == 0 ||
+		(*[2]uintptr)(unsafe.Pointer(&a))[0] == (*[2]uintptr)(unsafe.Pointer(&b))[0])
+}
+
+func (s *InternTest) TestInternMessage(c *C) {
+	in := &Interner{}
+	raw := []byte(`<34>1 - host app 1234 ID [a@1 x="1"] msg`)
+	var m1, m2 Message
+	c.Assert(m1.UnmarshalBinary(raw), IsNil)
+	c.Assert(m2.UnmarshalBinary(raw), IsNil)
+	c.Assert(sameMemory(m1.Hostname, m2.Hostname), Equals, false)
+
+	in.InternMessage(&m1)
+	in.InternMessage(&m2)
+	c.Assert(m2.Hostname, Equals, "host")
+	c.Assert(sameMemory(m1.Hostname, m2.Hostname), Equals, true)
+	c.Assert(sameMemory(m1.AppName, m2.AppName), Equals, true)
+	c.Assert(sameMemory(m1.MessageID, m2.MessageID), Equals, true)
+	c.Assert(sameMemory(m1.StructuredData[0].ID, m2.StructuredData[0].ID), Equals, true)
+	c.Assert(sameMemory(m1.StructuredData[0].Parameters[0].Name, m2.StructuredData[0].Parameters[0].Name), Equals, true)
+	c.Assert(sameMemory(m1.ProcessID, m2.ProcessID), Equals, false)
+}
+
+func (s *InternTest) TestMaxEntries(c *C) {
+	in := &Interner{MaxEntries: 2}
+	a := in.Intern(string([]byte("aa")))
+	in.Intern("bb")
+	c.Assert(sameMemory(in.Intern(string([]byte("aa"))), a), Equals, true)
+
+	// full: new strings are returned unchanged
+	d1 := string([]byte("dd"))
+	d2 := string([]byte("dd"))
+	c.Assert(sameMemory(in.Intern(d1), d1), Equals, true)
+	c.Assert(sameMemory(in.Intern(d2), d2), Equals, true)
+	c.Assert(in.strings, HasLen, 2)
+}
@@ -0,0 +1,76 @@
+package rfc5424
+
+// SeverityLevel is an entry of a SeverityMap: levels from Level up to the
+// next entry's have severity Severity.
+type SeverityLevel struct {
+	Level    int
+	Severity Severity
+}
+
+// SeverityMap maps the numeric levels of another logging system to
+// Severity. Its entries are sorted by Level. The maps below cover common
+// systems, and the adapters of this package use them rather than tables of
+// their own, so replacing one changes how its adapter maps levels.
+type SeverityMap []SeverityLevel
+
+// Severity returns the severity of level: that of the last entry whose
+// Level is at most level, or of the first entry if level is below them all.
+// An empty map returns Info.
+func (sm SeverityMap) Severity(level int) Severity {
+	if len(sm) == 0 {
+		return defaultSeverity
+	}
+	severity := sm[0].Severity
+	for _, entry := range sm {
+		if entry.Level > level {
+			break
+		}
+		severity = entry.Severity
+	}
+	return severity
+}
+
+// Level returns the level of the first entry with severity s. If there is
+// none, the entry with the closest severity is used, preferring the less
+// severe of two equally close ones. An empty map returns 0.
+func (sm SeverityMap) Level(s Severity) int {
+	best, bestDistance := 0, -1
+	for _, entry := range sm {
+		distance := int(entry.Severity - s)
+		if distance < 0 {
+			distance = -distance*2 + 1 // more severe loses ties
+		} else {
+			distance *= 2
+		}
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = entry.Level, distance
+		}
+	}
+	return best
+}
+
+var (
+	// SlogLevels maps the levels of log/slog
+	SlogLevels = SeverityMap{{-4, Debug}, {0, Info}, {4, Warning}, {8, Error}}
+
+	// ZapLevels maps the levels of go.uber.org/zap: Debug, Info, Warn,
+	// Error, DPanic, Panic and Fatal
+	ZapLevels = SeverityMap{{-1, Debug}, {0, Info}, {1, Warning}, {2, Error}, {3, Critical}, {4, Alert}, {5, Emergency}}
+
+	// PythonLevels maps the levels of Python's logging module
+	PythonLevels = SeverityMap{{0, Debug}, {20, Info}, {30, Warning}, {40, Error}, {50, Critical}}
+
+	// HTTPStatusLevels maps HTTP status codes by class: client errors are
+	// warnings and server errors errors
+	HTTPStatusLevels = SeverityMap{{0, Info}, {400, Warning}, {500, Error}}
+
+	// OTelSeverityNumbers maps the SeverityNumbers of OpenTelemetry log
+	// records, as the OpenTelemetry Collector's syslog receiver does. It is
+	// used by ToLogRecord and FromLogRecord.
+	OTelSeverityNumbers = SeverityMap{{1, Debug}, {9, Info}, {10, Notice}, {13, Warning}, {17, Error},
+		{18, Critical}, {21, Alert}, {22, Emergency}}
+
+	// WindowsEventLevels maps the levels of Windows events. It is used by
+	// EventLevelOf and SeverityOfEventLevel.
+	WindowsEventLevels = SeverityMap{{1, Critical}, {2, Error}, {3, Warning}, {4, Info}, {5, Debug}}
+)
@@ -0,0 +1,45 @@
+package rfc5424
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&LevelsTest{})
+
+type LevelsTest struct {
+}
+
+func (s *LevelsTest) TestSeverity(c *C) {
+	for _, t := range []struct {
+		sm       SeverityMap
+		level    int
+		severity Severity
+	}{
+		{SlogLevels, -8, Debug},
+		{SlogLevels, 0, Info},
+		{SlogLevels, 2, Info},
+		{SlogLevels, 12, Error},
+		{ZapLevels, 3, Critical},
+		{ZapLevels, 5, Emergency},
+		{PythonLevels, 10, Debug},
+		{PythonLevels, 35, Warning},
+		{HTTPStatusLevels, 204, Info},
+		{HTTPStatusLevels, 404, Warning},
+		{HTTPStatusLevels, 503, Error},
+		{OTelSeverityNumbers, 14, Warning},
+		{SeverityMap{}, 3, Info},
+	} {
+		c.Assert(t.sm.Severity(t.level), Equals, t.severity, Commentf("level %d of %v", t.level, t.sm))
+	}
+}
+
+func (s *LevelsTest) TestLevel(c *C) {
+	c.Assert(SlogLevels.Level(Warning), Equals, 4)
+	c.Assert(PythonLevels.Level(Critical), Equals, 50)
+	// Emergency is closest to Critical, and Notice as close to Warning as
+	// to Info, which is less severe
+	c.Assert(PythonLevels.Level(Emergency), Equals, 50)
+	c.Assert(SlogLevels.Level(Notice), Equals, 0)
+	c.Assert(HTTPStatusLevels.Level(Critical), Equals, 500)
+	c.Assert(SeverityMap{}.Level(Error), Equals, 0)
+}
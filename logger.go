@@ -0,0 +1,181 @@
+package rfc5424
+
+import (
+	"time"
+
+	"github.com/secureworks/errset"
+)
+
+// Logger builds messages from a severity and a text and writes them to
+// Writer. Every message, including those passed to WriteMessage, is first
+// evaluated by Rules and enriched with StructuredData. A Logger is usually
+// built from a Config.
+type Logger struct {
+	Writer MessageWriter
+
+	// Facility is the facility of the messages built by Log. If zero,
+	// Local0 is used.
+	Facility Facility
+
+	// Hostname and AppName, if set, are the HOSTNAME and APP-NAME of the
+	// messages built by Log. By default they are chosen by
+	// DefaultHostnameResolver and DefaultAppNameResolver.
+	Hostname string
+	AppName  string
+
+	// Clock, if set, reports the TIMESTAMP of the messages built by Log. If
+	// nil, TimeNow is used.
+	Clock func() time.Time
+
+	// ProcessID, if set, chooses the PROCID of the messages built by Log.
+	// If nil, DefaultProcessID is used.
+	ProcessID ProcessIDStrategy
+
+	// StrictSDIDs makes the Logger refuse messages whose SD-IDs or
+	// parameter names do not follow the full RFC-5424 grammar (see
+	// StrictSDIDs).
+	StrictSDIDs bool
+
+	// Schemas, if set, checks the structured data of every message against
+	// the declared schemas, after StructuredData is added
+	Schemas *SchemaRegistry
+
+	// Rules filters and tags messages. Dropped messages are discarded and
+	// routed messages are written to the matching writer in Routes, or to
+	// Writer if the route is not known. Messages with a destination (see
+	// Message.SetDestination) are routed there instead, unless dropped.
+	Rules  RuleSet
+	Routes map[string]MessageWriter
+
+	// StructuredData is added to every message
+	StructuredData []StructuredData
+
+	// Templates, if set, renders the MSG of messages that have none from
+	// their fields
+	Templates *MessageTemplates
+
+	// Stats, if set, counts the messages written, dropped and failed
+	Stats *LoggerStats
+}
+
+// NewLogger returns a Logger writing to w. WithHostname, WithAppName,
+// WithProcessID, WithClock and WithStrictness set the matching fields.
+func NewLogger(w MessageWriter, opts ...Option) *Logger {
+	o := newOptions(opts)
+	return &Logger{
+		Writer:      w,
+		Hostname:    o.hostname,
+		AppName:     o.appName,
+		Clock:       o.clock,
+		StrictSDIDs: o.strict,
+		ProcessID:   o.processID,
+	}
+}
+
+// Log writes a message with the given severity and text
+func (l *Logger) Log(severity Severity, text string) error {
+	m, err := l.builder(severity).Text(text).Build()
+	if err != nil {
+		return err
+	}
+	return l.WriteMessage(m)
+}
+
+// builder returns a MessageBuilder for a message with the given severity
+// and the Logger's defaults
+func (l *Logger) builder(severity Severity) *MessageBuilder {
+	b := NewMessage().Severity(severity)
+	if l.Facility != DefaultFacility {
+		b.Facility(l.Facility)
+	}
+	if l.Hostname != "" {
+		b.Hostname(l.Hostname)
+	}
+	if l.AppName != "" {
+		b.AppName(l.AppName)
+	}
+	if l.ProcessID != nil {
+		b.ProcessID(l.ProcessID())
+	}
+	if l.Clock != nil {
+		b.Timestamp(l.Clock().UTC())
+	}
+	return b
+}
+
+// now returns the current time, as reported by Clock
+func (l *Logger) now() time.Time {
+	if l.Clock != nil {
+		return l.Clock()
+	}
+	return TimeNow()
+}
+
+// WriteMessage filters and enriches m, then writes it. m itself is not
+// modified. It implements MessageWriter, so Loggers can be chained.
+func (l *Logger) WriteMessage(m Message) error {
+	if len(l.Rules.Rules) > 0 || len(l.StructuredData) > 0 {
+		m = m.Clone()
+	}
+	action, route := l.Rules.Evaluate(&m)
+	if action == ActionDrop {
+		l.Stats.messageDropped(m)
+		return nil
+	}
+	if destination := m.Destination(); destination != "" {
+		m.DeleteSD(DestinationSDID)
+		action, route = ActionRoute, destination
+	}
+	for _, sd := range l.StructuredData {
+		for _, param := range sd.Parameters {
+			m.AddDatum(sd.ID, param.Name, param.Value)
+		}
+	}
+	err := l.write(m, action, route)
+	if err != nil {
+		l.Stats.writeFailed(m, err)
+	} else {
+		l.Stats.messageWritten(m)
+	}
+	return err
+}
+
+// write renders and checks m, and writes it to the writer for action and
+// route
+func (l *Logger) write(m Message, action RuleAction, route string) error {
+	if l.Templates != nil {
+		if err := l.Templates.Render(&m); err != nil {
+			return err
+		}
+	}
+	if l.Schemas != nil {
+		if err := l.Schemas.Check(m); err != nil {
+			return err
+		}
+	}
+	if l.StrictSDIDs {
+		if err := m.validate(true); err != nil {
+			return err
+		}
+	}
+	if action == ActionRoute {
+		if w, ok := l.Routes[route]; ok {
+			return w.WriteMessage(m)
+		}
+	}
+	return l.Writer.WriteMessage(m)
+}
+
+// Close closes Writer and the writers in Routes
+func (l *Logger) Close() error {
+	errs := errset.ErrSet{}
+	if err := l.Writer.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, w := range l.Routes {
+		if err := w.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.ReturnValue()
+}
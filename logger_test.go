@@ -0,0 +1,76 @@
+package rfc5424
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&LoggerTest{})
+
+type LoggerTest struct {
+}
+
+// marshalString returns the text of m, for comparing with what is expected
+func marshalString(c *C, m Message) string {
+	b, err := m.MarshalBinary()
+	c.Assert(err, IsNil)
+	return string(b)
+}
+
+func (s *LoggerTest) TestLog(c *C) {
+	fw := &chanWriter{messages: make(chan Message, 4)}
+	alerts := &chanWriter{messages: make(chan Message, 4)}
+	clock := func() time.Time { return time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC) }
+	l := NewLogger(fw, WithHostname("host"), WithAppName("app"), WithClock(clock))
+	l.Facility = Auth
+	l.Rules = RuleSet{Rules: []Rule{
+		{MaxSeverity: Debug, Action: ActionDrop},
+		{MinSeverity: Critical, Action: ActionRoute, Route: "alerts"},
+	}}
+	l.Routes = map[string]MessageWriter{"alerts": alerts}
+	l.StructuredData = []StructuredData{{ID: "env@32473", Parameters: []SDParam{{Name: "dc", Value: "eu"}}}}
+
+	c.Assert(l.Log(Debug, "dropped"), IsNil)
+	c.Assert(l.Log(Warning, "disk filling up"), IsNil)
+	c.Assert(l.Log(Critical, "disk full"), IsNil)
+
+	defaults, err := NewMessage().Build()
+	c.Assert(err, IsNil)
+	pid := defaults.ProcessID
+	c.Assert(marshalString(c, <-fw.messages), Equals, "<36>1 2003-10-11T22:14:15.003Z host app "+pid+" - [env@32473 dc=\"eu\"] \ufeffdisk filling up")
+	c.Assert(marshalString(c, <-alerts.messages), Equals, "<34>1 2003-10-11T22:14:15.003Z host app "+pid+" - [env@32473 dc=\"eu\"] \ufeffdisk full")
+	c.Assert(fw.messages, HasLen, 0)
+
+	c.Assert(l.Close(), IsNil)
+}
+
+func (s *LoggerTest) TestWriteMessageDoesNotModify(c *C) {
+	fw := &chanWriter{messages: make(chan Message, 4)}
+	l := NewLogger(fw)
+	l.StructuredData = []StructuredData{{ID: "env@32473", Parameters: []SDParam{{Name: "dc", Value: "eu"}}}}
+
+	m := Message{Priority: 14, StructuredData: []StructuredData{
+		{ID: "env@32473", Parameters: make([]SDParam, 0, 4)},
+	}}
+	c.Assert(l.WriteMessage(m), IsNil)
+	c.Assert(marshalString(c, <-fw.messages), Equals, `<14>1 - - - - - [env@32473 dc="eu"]`)
+	c.Assert(m.StructuredData[0].Parameters, HasLen, 0)
+	c.Assert(m.StructuredData[0].Parameters[:1], DeepEquals, []SDParam{{}})
+}
+
+func (s *LoggerTest) TestSchemas(c *C) {
+	fw := &chanWriter{messages: make(chan Message, 4)}
+	l := NewLogger(fw)
+	l.Schemas = &SchemaRegistry{Policy: SchemaError}
+	c.Assert(l.Schemas.Declare(SDSchema{ID: "env@32473", Params: map[string]ParamType{"dc": ParamString}}), IsNil)
+
+	m := Message{Priority: 14}
+	m.AddDatum("env@32473", "dc", "eu")
+	c.Assert(l.WriteMessage(m), IsNil)
+	c.Assert(marshalString(c, <-fw.messages), Equals, `<14>1 - - - - - [env@32473 dc="eu"]`)
+
+	m.AddDatum("env@32473", "rack", "4")
+	c.Assert(l.WriteMessage(m), ErrorMatches, `rfc5424: parameter "rack" of env@32473 is not declared`)
+	c.Assert(fw.messages, HasLen, 0)
+}
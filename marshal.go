@@ -3,6 +3,10 @@ package rfc5424
 import (
 	"bytes"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
 )
@@ -11,12 +15,73 @@ import (
 // of 32-characters. (When true, this violates RFC-5424).
 const allowLongSdNames = true
 
+// StrictSDIDs, if true, makes MarshalBinary check SD-IDs and parameter
+// names against the full RFC-5424 grammar: an SD-ID must be registered with
+// IANA or be of the form "name@enterprise-number", and names must be 1 to
+// 32 characters. By default only the characters are checked, so that
+// messages from other senders can be relayed unchanged.
+var StrictSDIDs = false
+
+// timestampLayout is the TIMESTAMP format of RFC-5424, which allows at most
+// six digits of fractional seconds
+const timestampLayout = "2006-01-02T15:04:05.999999Z07:00"
+
+// timestampCache holds the formatted parts of the last TIMESTAMP written,
+// which only change once per second
+type timestampCache struct {
+	second int64
+	offset int
+	prefix []byte // the date and time, without fractional seconds
+	zone   []byte
+}
+
+var lastTimestamp atomic.Value // of *timestampCache
+
+// appendTimestamp appends t to dst in timestampLayout, truncated to the
+// microsecond. Only the fractional seconds are formatted for each message;
+// the rest is reused from the previous call within the same second.
+func appendTimestamp(dst []byte, t time.Time) []byte {
+	_, offset := t.Zone()
+	second := t.Unix()
+	cache, _ := lastTimestamp.Load().(*timestampCache)
+	if cache == nil || cache.second != second || cache.offset != offset {
+		cache = &timestampCache{
+			second: second,
+			offset: offset,
+			prefix: t.AppendFormat(nil, "2006-01-02T15:04:05"),
+			zone:   t.AppendFormat(nil, "Z07:00"),
+		}
+		lastTimestamp.Store(cache)
+	}
+
+	dst = append(dst, cache.prefix...)
+	if micros := t.Nanosecond() / 1000; micros != 0 {
+		var frac [7]byte
+		frac[0] = '.'
+		for i := 6; i > 0; i-- {
+			frac[i] = byte('0' + micros%10)
+			micros /= 10
+		}
+		n := len(frac)
+		for frac[n-1] == '0' {
+			n--
+		}
+		dst = append(dst, frac[:n]...)
+	}
+	return append(dst, cache.zone...)
+}
+
 type errorInvalidValue struct {
 	Property string
 	Value    interface{}
+	reason   string
 }
 
 func (e errorInvalidValue) Error() string {
+	if e.reason != "" {
+		return fmt.Sprintf("Message cannot be serialized because %s is invalid: %v (%s)",
+			e.Property, e.Value, e.reason)
+	}
 	return fmt.Sprintf("Message cannot be serialized because %s is invalid: %v",
 		e.Property, e.Value)
 }
@@ -33,52 +98,67 @@ func nilify(x string) string {
 	return x
 }
 
-func escapeSDParam(s string) string {
-	escapeCount := 0
-	for i := 0; i < len(s); i++ {
-		switch s[i] {
-		case '\\', '"', ']':
-			escapeCount++
-		}
-	}
-	if escapeCount == 0 {
-		return s
-	}
-
-	t := make([]byte, len(s)+escapeCount)
-	j := 0
+// appendEscapedSDParam appends s to dst, escaping the characters that
+// RFC-5424 requires escaping in a PARAM-VALUE, and returns the extended
+// slice
+func appendEscapedSDParam(dst []byte, s string) []byte {
+	start := 0
 	for i := 0; i < len(s); i++ {
 		switch c := s[i]; c {
 		case '\\', '"', ']':
-			t[j] = '\\'
-			t[j+1] = c
-			j += 2
-		default:
-			t[j] = s[i]
-			j++
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', c)
+			start = i + 1
 		}
 	}
-	return string(t)
+	return append(dst, s[start:]...)
 }
 
+// Masks for checking the eight bytes of a uint64 at once
+const (
+	lowBits  = 0x0101010101010101
+	highBits = 0x8080808080808080
+)
+
+// isPrintableUsASCII reports whether s only holds PRINTUSASCII (%d33-126).
+// Long strings are checked eight bytes at a time.
 func isPrintableUsASCII(s string) bool {
-	for _, ch := range s {
-		if ch < 33 || ch > 126 {
+	i := 0
+	for ; i+8 <= len(s); i += 8 {
+		x := uint64(s[i]) | uint64(s[i+1])<<8 | uint64(s[i+2])<<16 | uint64(s[i+3])<<24 |
+			uint64(s[i+4])<<32 | uint64(s[i+5])<<40 | uint64(s[i+6])<<48 | uint64(s[i+7])<<56
+		// a byte of 127 or more has its high bit set in x or x+1
+		if (x|(x+lowBits))&highBits != 0 {
+			return false
+		}
+		// a byte below 33 borrows its high bit in x-33
+		if (x-33*lowBits)&^x&highBits != 0 {
+			return false
+		}
+	}
+	for ; i < len(s); i++ {
+		if s[i] < 33 || s[i] > 126 {
 			return false
 		}
 	}
 	return true
 }
 
+// sdNameChars holds the characters allowed in an SD-NAME: PRINTUSASCII
+// except '=', SP, ']' and '"'
+var sdNameChars = func() (chars [256]bool) {
+	for ch := 33; ch <= 126; ch++ {
+		chars[ch] = ch != '=' && ch != ']' && ch != '"'
+	}
+	return chars
+}()
+
 func isValidSdName(s string) bool {
 	if !allowLongSdNames && len(s) > 32 {
 		return false
 	}
-	for _, ch := range s {
-		if ch < 33 || ch > 126 {
-			return false
-		}
-		if ch == '=' || ch == ']' || ch == '"' {
+	for i := 0; i < len(s); i++ {
+		if !sdNameChars[s[i]] {
 			return false
 		}
 	}
@@ -86,6 +166,23 @@ func isValidSdName(s string) bool {
 }
 
 func (m Message) assertValid() error {
+	return m.validate(StrictSDIDs)
+}
+
+// validate checks m, and if strict its SD-IDs and parameter names against
+// the full RFC-5424 grammar
+func (m Message) validate(strict bool) error {
+
+	// PRIVAL          = 1*3DIGIT ; range 0 .. 191
+	if m.Priority < 0 || m.Priority > 191 {
+		return InvalidValue("Priority", m.Priority)
+	}
+
+	// DATE-FULLYEAR   = 4DIGIT
+	if year := m.Timestamp.Year(); year < 0 || year > 9999 {
+		return errorInvalidValue{Property: "Timestamp", Value: m.Timestamp,
+			reason: "the year must have four digits"}
+	}
 
 	// HOSTNAME        = NILVALUE / 1*255PRINTUSASCII
 	if !isPrintableUsASCII(m.Hostname) {
@@ -119,52 +216,181 @@ func (m Message) assertValid() error {
 		return InvalidValue("MessageID", m.MessageID)
 	}
 
-	for _, sdElement := range m.StructuredData {
+	if i := m.duplicateSDID(); i >= 0 {
+		return errorInvalidValue{Property: fmt.Sprintf("StructuredData[%d]/ID", i),
+			Value: m.StructuredData[i].ID, reason: "an SD-ID may only appear once"}
+	}
+	// MSG-UTF8        = BOM UTF-8-STRING
+	if m.IsUTF8() && !utf8.Valid(m.Message) {
+		return errorInvalidValue{Property: "Message", Value: m.Message,
+			reason: "a message starting with a BOM must be UTF-8"}
+	}
+
+	// The property names are only formatted on error, to keep valid
+	// messages free of allocations
+	for i, sdElement := range m.StructuredData {
 		if !isValidSdName(sdElement.ID) {
-			return InvalidValue("StructuredData/ID", sdElement.ID)
+			return InvalidValue(fmt.Sprintf("StructuredData[%d]/ID", i), sdElement.ID)
+		}
+		if strict {
+			if _, _, err := ParseSDID(sdElement.ID); err != nil {
+				return errorInvalidValue{Property: fmt.Sprintf("StructuredData[%d]/ID", i), Value: sdElement.ID,
+					reason: strings.TrimPrefix(err.Error(), "rfc5424: ")}
+			}
 		}
 		for _, sdParam := range sdElement.Parameters {
 			if !isValidSdName(sdParam.Name) {
-				return InvalidValue("StructuredData/Name", sdParam.Name)
+				return InvalidValue(fmt.Sprintf("StructuredData[%s]/Name", sdElement.ID), sdParam.Name)
+			}
+			if strict && (sdParam.Name == "" || len(sdParam.Name) > 32) {
+				return errorInvalidValue{Property: fmt.Sprintf("StructuredData[%s]/Name", sdElement.ID), Value: sdParam.Name,
+					reason: "names must be 1 to 32 characters"}
 			}
 			if !utf8.ValidString(sdParam.Value) {
-				return InvalidValue("StructuredData/Value", sdParam.Value)
+				return InvalidValue(fmt.Sprintf("StructuredData[%s]/%s", sdElement.ID, sdParam.Name),
+					sdParam.Value)
 			}
 		}
 	}
 	return nil
 }
 
+// maxPooledBufferSize is the capacity above which marshaling buffers are
+// not returned to the pool, so that one huge message does not pin its
+// buffer forever
+const maxPooledBufferSize = 64 * 1024
+
+// marshalBuffers holds the *bytes.Buffers messages are marshaled into
+var marshalBuffers = sync.Pool{
+	New: func() interface{} { return &bytes.Buffer{} },
+}
+
 // MarshalBinary marshals the message to a byte slice, or returns an error
 func (m Message) MarshalBinary() ([]byte, error) {
+	codec, ok := lookupVersion(m.version())
+	if !ok {
+		return nil, InvalidValue("Version", m.Version)
+	}
+	if _, ok := codec.(rfc5424Codec); ok && len(m.StructuredData) == 0 {
+		return marshalWithoutSD(m)
+	}
+	b := marshalBuffers.Get().(*bytes.Buffer)
+	b.Reset()
+	defer func() {
+		if b.Cap() <= maxPooledBufferSize {
+			marshalBuffers.Put(b)
+		}
+	}()
+
+	var scratch [8]byte
+	b.WriteByte('<')
+	b.Write(strconv.AppendInt(scratch[:0], int64(m.Priority), 10))
+	b.WriteByte('>')
+	b.Write(strconv.AppendInt(scratch[:0], int64(m.version()), 10))
+	if err := codec.Marshal(b, m); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), b.Bytes()...), nil
+}
+
+// maxPrefixLength is the longest PRI VERSION SP TIMESTAMP can be
+const maxPrefixLength = len("<191>999 ") + len(timestampLayout)
+
+// marshalWithoutSD marshals an RFC-5424 message without structured data,
+// which is most of them, by appending its fields to a slice of the right
+// size rather than going through a buffer
+func marshalWithoutSD(m Message) ([]byte, error) {
 	if err := m.assertValid(); err != nil {
 		return nil, err
 	}
+	length := maxPrefixLength + len(m.Hostname) + len(m.AppName) + len(m.ProcessID) + len(m.MessageID) +
+		len(" - - - - -") + 1 + len(m.Message)
+	b := make([]byte, 0, length)
 
-	b := bytes.NewBuffer(nil)
-	fmt.Fprintf(b, "<%d>1 %s %s %s %s %s ",
-		m.Priority,
-		m.Timestamp.Format(time.RFC3339Nano),
-		nilify(m.Hostname),
-		nilify(m.AppName),
-		nilify(m.ProcessID),
-		nilify(m.MessageID))
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(m.Priority), 10)
+	b = append(b, '>')
+	b = strconv.AppendInt(b, int64(m.version()), 10)
+	if m.Timestamp.IsZero() {
+		b = append(b, " -"...) // NILVALUE
+	} else {
+		b = append(b, ' ')
+		b = appendTimestamp(b, m.Timestamp)
+	}
+	b = appendField(b, m.Hostname)
+	b = appendField(b, m.AppName)
+	b = appendField(b, m.ProcessID)
+	b = appendField(b, m.MessageID)
+	b = append(b, " -"...)
+	if m.Message != nil {
+		b = append(b, ' ')
+		b = append(b, m.Message...)
+	}
+	return b, nil
+}
+
+// appendField appends SP followed by a header field, or the NILVALUE if it
+// is empty
+func appendField(b []byte, s string) []byte {
+	b = append(b, ' ')
+	return append(b, nilify(s)...)
+}
+
+// writeField writes SP followed by a header field, or the NILVALUE if it is
+// empty
+func writeField(b *bytes.Buffer, s string) {
+	b.WriteByte(' ')
+	b.WriteString(nilify(s))
+}
+
+// marshalFields writes the fields of an RFC-5424 message that follow the
+// VERSION
+func marshalFields(b *bytes.Buffer, m Message) error {
+	if err := m.assertValid(); err != nil {
+		return err
+	}
+
+	if m.Timestamp.IsZero() {
+		b.WriteString(" -") // NILVALUE
+	} else {
+		var scratch [len(timestampLayout) + 1]byte
+		b.WriteByte(' ')
+		b.Write(appendTimestamp(scratch[:0], m.Timestamp))
+	}
+	writeField(b, m.Hostname)
+	writeField(b, m.AppName)
+	writeField(b, m.ProcessID)
+	writeField(b, m.MessageID)
+	b.WriteByte(' ')
 
 	if len(m.StructuredData) == 0 {
-		fmt.Fprint(b, "-")
+		b.WriteByte('-')
 	}
+	// Escaped values are built in scratch, which only allocates for values
+	// longer than it that need escaping
+	var scratch [256]byte
 	for _, sdElement := range m.StructuredData {
-		fmt.Fprintf(b, "[%s", sdElement.ID)
+		b.WriteByte('[')
+		b.WriteString(sdElement.ID)
 		for _, sdParam := range sdElement.Parameters {
-			fmt.Fprintf(b, " %s=\"%s\"", sdParam.Name,
-				escapeSDParam(sdParam.Value))
+			b.WriteByte(' ')
+			b.WriteString(sdParam.Name)
+			b.WriteString(`="`)
+			if strings.ContainsAny(sdParam.Value, `\"]`) {
+				b.Write(appendEscapedSDParam(scratch[:0], sdParam.Value))
+			} else {
+				b.WriteString(sdParam.Value)
+			}
+			b.WriteByte('"')
 		}
-		fmt.Fprintf(b, "]")
+		b.WriteByte(']')
 	}
 
-	if len(m.Message) > 0 {
-		fmt.Fprint(b, " ")
+	// An empty but non-nil MSG is written as a trailing SP, so that parsed
+	// messages are reproduced exactly
+	if m.Message != nil {
+		b.WriteByte(' ')
 		b.Write(m.Message)
 	}
-	return b.Bytes(), nil
+	return nil
 }
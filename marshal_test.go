@@ -1,7 +1,9 @@
 package rfc5424
 
 import (
+	"bytes"
 	"fmt"
+	"testing"
 	"time"
 
 	. "gopkg.in/check.v1"
@@ -224,6 +226,8 @@ func (s *MarshalTest) TestCannotUnmarshalBrokenStrings(c *C) {
 }
 
 var invalidMessages = []Message{
+	Message{Priority: -6},
+	Message{Priority: 192},
 	Message{Hostname: "\x7f"},
 	Message{Hostname: "\x20"},
 	Message{Hostname: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA" +
@@ -326,9 +330,279 @@ func (s *MarshalTest) TestLogAttributes(c *C) {
 	bin, err := m.MarshalBinary()
 	if allowLongSdNames {
 		c.Assert(err, IsNil)
-		c.Assert(string(bin), Equals, "<0>1 0001-01-01T00:00:00Z - - - - [AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA =\"value\"]")
+		c.Assert(string(bin), Equals, "<0>1 - - - - - [AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA =\"value\"]")
 	} else {
 		c.Assert(err, Not(IsNil))
 		c.Assert(fmt.Sprintf("%s", err), Not(Equals), "")
 	}
 }
+
+func (s *MarshalTest) TestStrictSDIDs(c *C) {
+	defer func() { StrictSDIDs = false }()
+	StrictSDIDs = true
+
+	for _, id := range []string{"exampleSDID@32473", "origin", "meta"} {
+		m := Message{StructuredData: []StructuredData{{ID: id, Parameters: []SDParam{{Name: "x", Value: "y"}}}}}
+		_, err := m.MarshalBinary()
+		c.Assert(err, IsNil, Commentf("%s", id))
+	}
+
+	m := Message{StructuredData: []StructuredData{
+		{ID: "origin"},
+		{ID: "32473@example"},
+	}}
+	_, err := m.MarshalBinary()
+	c.Assert(err, ErrorMatches, `Message cannot be serialized because StructuredData\[1\]/ID is invalid: 32473@example \(invalid SD-ID "32473@example"\)`)
+
+	m = Message{StructuredData: []StructuredData{{ID: "example"}}}
+	_, err = m.MarshalBinary()
+	c.Assert(err, ErrorMatches, `.*StructuredData\[0\]/ID is invalid: example \(SD-ID "example" is neither registered.*`)
+
+	m = Message{StructuredData: []StructuredData{{ID: "x@1", Parameters: []SDParam{{Name: "", Value: "y"}}}}}
+	_, err = m.MarshalBinary()
+	c.Assert(err, ErrorMatches, `.*StructuredData\[x@1\]/Name is invalid.*`)
+
+	// Permissive mode allows them
+	StrictSDIDs = false
+	_, err = m.MarshalBinary()
+	c.Assert(err, IsNil)
+}
+
+func (s *MarshalTest) TestDuplicateSDIDs(c *C) {
+	m := Message{StructuredData: []StructuredData{
+		{ID: "a@1", Parameters: []SDParam{{Name: "x", Value: "1"}, {Name: "x", Value: "2"}}},
+		{ID: "b@1"},
+		{ID: "a@1", Parameters: []SDParam{{Name: "y", Value: "3"}}},
+	}}
+	_, err := m.MarshalBinary()
+	c.Assert(err, ErrorMatches, `.*StructuredData\[2\]/ID is invalid: a@1 .*`)
+
+	m.MergeStructuredData()
+	b, err := m.MarshalBinary()
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `<0>1 - - - - - [a@1 x="1" x="2" y="3"][b@1]`)
+}
+
+func (s *MarshalTest) TestTimestamps(c *C) {
+	// Fractional seconds are truncated to microseconds
+	m := Message{Timestamp: T("2003-10-11T22:14:15.123456789Z")}
+	b, err := m.MarshalBinary()
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, "<0>1 2003-10-11T22:14:15.123456Z - - - - -")
+
+	m = Message{Timestamp: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)}
+	_, err = m.MarshalBinary()
+	c.Assert(err, ErrorMatches, ".*Timestamp is invalid.*four digits.*")
+
+	for _, ts := range []string{
+		"2003-10-11T22:14:15.1234567Z",
+		"2003-10-11T23:59:60Z",
+		"2003-10-11 22:14:15Z",
+		"03-10-11T22:14:15Z",
+	} {
+		err = m.UnmarshalBinary([]byte("<0>1 " + ts + " - - - - -"))
+		c.Assert(err, NotNil, Commentf("%s", ts))
+	}
+
+	defer func() { PermissiveTimestamps = false }()
+	PermissiveTimestamps = true
+	c.Assert(m.UnmarshalBinary([]byte("<0>1 2003-10-11T22:14:15.1234567Z - - - - -")), IsNil)
+	c.Assert(m.Timestamp, Equals, T("2003-10-11T22:14:15.1234567Z"))
+	c.Assert(m.UnmarshalBinary([]byte("<0>1 2003-10-11T23:59:60Z - - - - -")), IsNil)
+	c.Assert(m.Timestamp, Equals, T("2003-10-11T23:59:59.999999999Z"))
+}
+
+func (s *MarshalTest) TestTimestampSyntax(c *C) {
+	for _, ts := range []string{
+		"2003-10-11T22:14:15Z",
+		"2003-10-11T22:14:15.1Z",
+		"2003-10-11T22:14:15.123456Z",
+		"2003-10-11T22:14:15.003-07:00",
+		"2003-10-11T22:14:15+05:30",
+	} {
+		c.Assert(isTimestampSyntax(ts), Equals, true, Commentf("%s", ts))
+	}
+	for _, ts := range []string{
+		"",
+		"2003-10-11T22:14:15",
+		"2003-10-11T22:14:15.Z",
+		"2003-10-11T22:14:15.1234567Z",
+		"2003-10-11T22:14:15z",
+		"2003-10-11T22:14:15+0530",
+		"2003-10-11T22:14:15+05:30x",
+		"2003-1a-11T22:14:15Z",
+		"2003-10-11t22:14:15Z",
+	} {
+		c.Assert(isTimestampSyntax(ts), Equals, false, Commentf("%s", ts))
+	}
+}
+
+func (s *MarshalTest) TestUTF8Message(c *C) {
+	m := Message{Timestamp: T("2003-10-11T22:14:15.003Z")}
+	m.SetTextMessage("héllo")
+	c.Assert(m.IsUTF8(), Equals, true)
+	c.Assert(m.TextMessage(), Equals, "héllo")
+	b, err := m.MarshalBinary()
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, "<0>1 2003-10-11T22:14:15.003Z - - - - - \xef\xbb\xbfhéllo")
+
+	m2 := Message{}
+	c.Assert(m2.UnmarshalBinary(b), IsNil)
+	c.Assert(m2.IsUTF8(), Equals, true)
+	c.Assert(m2.TextMessage(), Equals, "héllo")
+
+	// Opaque bytes need not be UTF-8, but a declared UTF-8 MSG must be
+	m.SetBinaryMessage([]byte("\xff\xfe"))
+	c.Assert(m.IsUTF8(), Equals, false)
+	_, err = m.MarshalBinary()
+	c.Assert(err, IsNil)
+	m.SetTextMessage("\xff\xfe")
+	_, err = m.MarshalBinary()
+	c.Assert(err, ErrorMatches, ".*Message is invalid.*")
+	c.Assert(m2.UnmarshalBinary([]byte("<0>1 2003-10-11T22:14:15.003Z - - - - - \xef\xbb\xbf\xff\xfe")), NotNil)
+}
+
+func (s *MarshalTest) TestMarshaledBytesAreNotReused(c *C) {
+	first, err := Message{Priority: 1, Message: []byte("first")}.MarshalBinary()
+	c.Assert(err, IsNil)
+	second, err := Message{Priority: 2, Message: []byte("second")}.MarshalBinary()
+	c.Assert(err, IsNil)
+	c.Assert(string(first), Equals, "<1>1 - - - - - - first")
+	c.Assert(string(second), Equals, "<2>1 - - - - - - second")
+
+	// An error leaves nothing behind for the next message
+	_, err = Message{Hostname: "bad host", Message: []byte("x")}.MarshalBinary()
+	c.Assert(err, NotNil)
+	third, err := Message{Priority: 3}.MarshalBinary()
+	c.Assert(err, IsNil)
+	c.Assert(string(third), Equals, "<3>1 - - - - - -")
+}
+
+func (s *MarshalTest) TestMarshalAllocations(c *C) {
+	if raceEnabled {
+		c.Skip("allocations are not stable under the race detector")
+	}
+	m := Message{
+		Priority:  165,
+		Timestamp: T("2003-10-11T22:14:15.003Z"),
+		Hostname:  "mymachine.example.com",
+		AppName:   "evntslog",
+		MessageID: "ID47",
+		StructuredData: []StructuredData{
+			{ID: "exampleSDID@32473", Parameters: []SDParam{{Name: "iut", Value: "3"}, {Name: "q", Value: `a "b" [c\]`}}},
+		},
+		Message: []byte("An application event log entry..."),
+	}
+	m.MarshalBinary() // warm up the buffer pool
+	allocs := testing.AllocsPerRun(100, func() { m.MarshalBinary() })
+	c.Assert(allocs, Equals, 1.0) // the returned slice
+}
+
+func (s *MarshalTest) TestAppendEscapedSDParam(c *C) {
+	c.Assert(string(appendEscapedSDParam(nil, "plain")), Equals, "plain")
+	c.Assert(string(appendEscapedSDParam([]byte("x="), `a"b\c]`)), Equals, `x=a\"b\\c\]`)
+	c.Assert(string(appendEscapedSDParam(nil, `"`)), Equals, `\"`)
+	c.Assert(string(appendEscapedSDParam(nil, "")), Equals, "")
+}
+
+func (s *MarshalTest) TestAppendTimestamp(c *C) {
+	zones := []*time.Location{time.UTC, time.FixedZone("", -4*3600), time.FixedZone("", 5*3600+30*60)}
+	t := T("2003-10-11T22:14:15Z")
+	for _, step := range []time.Duration{0, time.Nanosecond, 999 * time.Nanosecond, time.Microsecond,
+		120 * time.Microsecond, 3 * time.Millisecond, 999999 * time.Microsecond, time.Second, time.Hour} {
+		t = t.Add(step)
+		for _, zone := range zones {
+			zoned := t.In(zone)
+			expected := zoned.Truncate(time.Microsecond).Format(timestampLayout)
+			c.Assert(string(appendTimestamp(nil, zoned)), Equals, expected)
+			// and again from the cache
+			c.Assert(string(appendTimestamp(nil, zoned)), Equals, expected)
+		}
+	}
+}
+
+func (s *MarshalTest) TestResetReusesStructuredData(c *C) {
+	m := Message{}
+	c.Assert(m.UnmarshalBinary([]byte(`<34>1 - host - - - [a@1 x="1" y="2"][b@1 z="3"] first`)), IsNil)
+	sd := &m.StructuredData[0]
+
+	m.Reset()
+	c.Assert(m.Hostname, Equals, "")
+	c.Assert(m.Message, IsNil)
+	c.Assert(m.StructuredData, HasLen, 0)
+
+	c.Assert(m.UnmarshalBinary([]byte(`<35>1 - - app - - [c@1 w="4"]`)), IsNil)
+	c.Assert(&m.StructuredData[0], Equals, sd)
+	c.Assert(m, DeepEquals, Message{Priority: 35, AppName: "app", StructuredData: []StructuredData{
+		{ID: "c@1", Parameters: []SDParam{{Name: "w", Value: "4"}}},
+	}})
+
+	// Without Reset nothing is reused
+	c.Assert(m.UnmarshalBinary([]byte(`<35>1 - - app - - [d@1]`)), IsNil)
+	c.Assert(&m.StructuredData[0], Not(Equals), sd)
+
+	var pool *MessagePool
+	c.Assert(pool.Get(), DeepEquals, &Message{})
+	pool = &MessagePool{}
+	pooled := pool.Get()
+	pooled.Hostname = "host"
+	pool.Put(pooled)
+	c.Assert(pool.Get().Hostname, Equals, "")
+}
+
+func (s *MarshalTest) TestMarshalWithoutSD(c *C) {
+	for _, m := range []Message{
+		{},
+		{Priority: 191, Timestamp: T("2003-10-11T22:14:15.123456-07:00"), Hostname: "h", AppName: "a",
+			ProcessID: "p", MessageID: "m", Message: []byte("msg")},
+		{Priority: 13, StructuredData: []StructuredData{}, Message: []byte{}},
+	} {
+		b, err := m.MarshalBinary()
+		c.Assert(err, IsNil)
+
+		// the same as the general serializer
+		buf := &bytes.Buffer{}
+		buf.WriteString("<" + fmt.Sprint(m.Priority) + ">1")
+		c.Assert(marshalFields(buf, m), IsNil)
+		c.Assert(string(b), Equals, buf.String())
+	}
+
+	_, err := Message{AppName: "bad app"}.MarshalBinary()
+	c.Assert(err, ErrorMatches, ".*AppName is invalid.*")
+}
+
+func (s *MarshalTest) TestValidityChecks(c *C) {
+	printable := func(s string) bool {
+		for _, ch := range s {
+			if ch < 33 || ch > 126 {
+				return false
+			}
+		}
+		return true
+	}
+	// every byte, at every offset of a string long enough to be chunked
+	for b := 0; b < 256; b++ {
+		for i := 0; i < 17; i++ {
+			t := []byte("abcdefghijklmnopq")
+			t[i] = byte(b)
+			c.Assert(isPrintableUsASCII(string(t)), Equals, printable(string(t)), Commentf("%d at %d", b, i))
+
+			name := string(t)
+			valid := printable(name) && b != '=' && b != ']' && b != '"'
+			c.Assert(isValidSdName(name), Equals, valid, Commentf("%d at %d", b, i))
+		}
+	}
+	// pairs of edge values, in case one byte masks another
+	edges := []byte{0, 1, 32, 33, 34, 61, 125, 126, 127, 128, 255}
+	for _, b1 := range edges {
+		for _, b2 := range edges {
+			for i := 1; i < 8; i++ {
+				t := []byte("abcdefgh")
+				t[0], t[i] = b1, b2
+				c.Assert(isPrintableUsASCII(string(t)), Equals, printable(string(t)), Commentf("%q", t))
+			}
+		}
+	}
+	c.Assert(isPrintableUsASCII(""), Equals, true)
+	c.Assert(isPrintableUsASCII("café.example.com"), Equals, false)
+}
@@ -0,0 +1,21 @@
+package rfc5424
+
+// marshaledWriter is implemented by the MessageWriters that can write a
+// message that is already marshaled, so that a message written to several
+// of them, or checked before being written, is only marshaled once. The
+// marshaled form is passed alongside the message, rather than kept in it,
+// so that messages stay plain values.
+type marshaledWriter interface {
+	// writeMarshaled writes m, whose marshaled form is b. b is shared and
+	// must not be modified.
+	writeMarshaled(m Message, b []byte) error
+}
+
+// writeMarshaled writes m, whose marshaled form is b, to w, passing b on if
+// w can use it
+func writeMarshaled(w MessageWriter, m Message, b []byte) error {
+	if mw, ok := w.(marshaledWriter); ok {
+		return mw.writeMarshaled(m, b)
+	}
+	return w.WriteMessage(m)
+}
@@ -0,0 +1,62 @@
+package rfc5424
+
+import (
+	"bytes"
+	"reflect"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&MarshalCacheTest{})
+
+type MarshalCacheTest struct {
+}
+
+// marshaledRecorder records the marshaled forms it is given
+type marshaledRecorder struct {
+	chanWriter
+	marshaled chan []byte
+}
+
+func (r *marshaledRecorder) writeMarshaled(m Message, b []byte) error {
+	r.marshaled <- b
+	return r.WriteMessage(m)
+}
+
+func (s *MarshalCacheTest) TestFanOut(c *C) {
+	m := Message{Priority: 34, Hostname: "host", Message: []byte("hello")}
+	m.AddDatum("x@32473", "a", "1")
+
+	first := &marshaledRecorder{chanWriter{make(chan Message, 1)}, make(chan []byte, 1)}
+	second := &marshaledRecorder{chanWriter{make(chan Message, 1)}, make(chan []byte, 1)}
+	plain := &chanWriter{make(chan Message, 1)}
+	mmw := MultiMessageWriter{Writers: []MessageWriter{first, MultiMessageWriter{Writers: []MessageWriter{second}}, plain}}
+	c.Assert(mmw.WriteMessage(m), IsNil)
+
+	// marshaled once, through nested fan-outs
+	b1, b2 := <-first.marshaled, <-second.marshaled
+	c.Assert(&b1[0], Equals, &b2[0])
+	c.Assert(string(b1), Equals, `<34>1 - host - - - [x@32473 a="1"] hello`)
+
+	// and the messages written are plain values
+	for _, w := range []*chanWriter{&first.chanWriter, &second.chanWriter, plain} {
+		c.Assert(reflect.DeepEqual(<-w.messages, m), Equals, true)
+	}
+}
+
+func (s *MarshalCacheTest) TestStreamWriter(c *C) {
+	m := Message{Priority: 34, Hostname: "host", Message: []byte("hello")}
+	b, err := m.MarshalBinary()
+	c.Assert(err, IsNil)
+
+	buf := &bytes.Buffer{}
+	c.Assert(writeMarshaled(NewStreamWriter(buf), m, b), IsNil)
+	c.Assert(buf.String(), Equals, "26 <34>1 - host - - - - hello")
+
+	// messages longer than MaxLength are still truncated
+	buf.Reset()
+	sw := NewStreamWriter(buf)
+	sw.MaxLength = 22
+	c.Assert(writeMarshaled(sw, m, b), IsNil)
+	c.Assert(buf.String(), Equals, "22 <34>1 - host - - - - h")
+}
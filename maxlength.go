@@ -0,0 +1,108 @@
+package rfc5424
+
+import "unicode/utf8"
+
+// Maximum message lengths for the common transports. They are the length of
+// the message itself, excluding any framing.
+const (
+	// MaxLengthUDPIPv4 is the length RFC-5426 recommends not exceeding over
+	// UDP on IPv4 when the path MTU is unknown.
+	MaxLengthUDPIPv4 = 480
+
+	// MaxLengthUDPIPv6 is the length RFC-5426 recommends not exceeding over
+	// UDP on IPv6 when the path MTU is unknown.
+	MaxLengthUDPIPv6 = 1180
+
+	// MaxLengthTLS is the length that RFC-5425 requires all TLS receivers
+	// to accept. Longer messages may be rejected by some collectors.
+	MaxLengthTLS = 2048
+)
+
+// LengthPolicy is what a LengthLimiter does with a message that is too long
+type LengthPolicy int
+
+const (
+	// TruncateLongMessages shortens the MSG part of a long message, as
+	// RFC-5424 section 6.1 recommends. If the message is still too long
+	// without a MSG, it is rejected.
+	TruncateLongMessages LengthPolicy = iota
+	// RejectLongMessages returns ErrMessageTooLong for long messages.
+	RejectLongMessages
+)
+
+// LengthLimiter is a MessageWriter that makes sure the messages written to
+// Writer are no longer than the transport or collector accepts, e.g.
+// MaxLengthUDPIPv4 for a UDP collector.
+type LengthLimiter struct {
+	Writer    MessageWriter
+	MaxLength int
+	Policy    LengthPolicy
+
+	// Truncated, if set, is called with each message that was truncated,
+	// before truncation.
+	Truncated func(m Message)
+}
+
+// truncateMessage returns m with its MSG shortened so that it serializes
+// to at most maxLength octets. A UTF-8 MSG is not cut in the middle of a
+// character.
+func truncateMessage(m Message, maxLength int) (Message, error) {
+	header := m
+	header.Message = nil
+	b, err := header.MarshalBinary()
+	if err != nil {
+		return m, err
+	}
+	if len(b) > maxLength {
+		return m, ErrMessageTooLong
+	}
+	room := maxLength - len(b) - 1 // the SP before MSG
+	if room <= 0 {
+		m.Message = nil
+		return m, nil
+	}
+
+	body := m.Message[:room]
+	if utf8.Valid(m.Message) {
+		for len(body) > 0 && !utf8.Valid(body) {
+			body = body[:len(body)-1]
+		}
+	}
+	m.Message = body
+	return m, nil
+}
+
+// limitLength returns m, truncated according to policy if it serializes to
+// more than maxLength octets. truncated reports whether it was.
+func limitLength(m Message, maxLength int, policy LengthPolicy) (limited Message, truncated bool, err error) {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return m, false, err
+	}
+	if maxLength <= 0 || len(b) <= maxLength {
+		return m, false, nil
+	}
+	if policy == RejectLongMessages {
+		return m, false, ErrMessageTooLong
+	}
+	limited, err = truncateMessage(m, maxLength)
+	return limited, err == nil, err
+}
+
+// WriteMessage writes m to Writer, truncating or rejecting it if it is
+// longer than MaxLength.
+func (ll *LengthLimiter) WriteMessage(m Message) error {
+	limited, truncated, err := limitLength(m, ll.MaxLength, ll.Policy)
+	if err != nil {
+		return err
+	}
+	if truncated && ll.Truncated != nil {
+		ll.Truncated(m)
+	}
+	return ll.Writer.WriteMessage(limited)
+}
+
+// Close closes Writer
+func (ll *LengthLimiter) Close() error {
+	return ll.Writer.Close()
+}
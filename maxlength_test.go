@@ -0,0 +1,46 @@
+package rfc5424
+
+import (
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&LengthLimiterTest{})
+
+type LengthLimiterTest struct {
+}
+
+func (testSuite *LengthLimiterTest) TestTruncate(c *C) {
+	w := &chanWriter{messages: make(chan Message, 4)}
+	truncated := []string{}
+	ll := &LengthLimiter{Writer: w, MaxLength: 21,
+		Truncated: func(m Message) { truncated = append(truncated, string(m.Message)) }}
+
+	// "<0>1 - - - - - -" is 16 octets
+	c.Assert(ll.WriteMessage(Message{Message: []byte("abcd")}), IsNil)
+	c.Assert(marshalString(c, <-w.messages), Equals, "<0>1 - - - - - - abcd")
+	c.Assert(ll.WriteMessage(Message{Message: []byte("abcdefgh")}), IsNil)
+	c.Assert(marshalString(c, <-w.messages), Equals, "<0>1 - - - - - - abcd")
+	c.Assert(truncated, DeepEquals, []string{"abcdefgh"})
+
+	// UTF-8 is not cut in the middle of a character
+	c.Assert(ll.WriteMessage(Message{Message: []byte("abcé")}), IsNil)
+	c.Assert(marshalString(c, <-w.messages), Equals, "<0>1 - - - - - - abc")
+
+	// The header cannot be truncated
+	m := Message{Hostname: strings.Repeat("h", 10), Message: []byte("x")}
+	c.Assert(ll.WriteMessage(m), Equals, ErrMessageTooLong)
+	c.Assert(w.messages, HasLen, 0)
+}
+
+func (testSuite *LengthLimiterTest) TestReject(c *C) {
+	w := &chanWriter{messages: make(chan Message, 4)}
+	ll := &LengthLimiter{Writer: w, MaxLength: MaxLengthUDPIPv4,
+		Policy: RejectLongMessages}
+	c.Assert(ll.WriteMessage(Message{Message: []byte("short")}), IsNil)
+	c.Assert(w.messages, HasLen, 1)
+	long := Message{Message: []byte(strings.Repeat("x", MaxLengthUDPIPv4))}
+	c.Assert(ll.WriteMessage(long), Equals, ErrMessageTooLong)
+	c.Assert(w.messages, HasLen, 1)
+}
@@ -1,11 +1,23 @@
 package rfc5424
 
-import "time"
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// bom is the byte order mark that declares a MSG to be UTF-8 (MSG-UTF8)
+var bom = []byte("\xef\xbb\xbf")
 
 // Message represents a log message as defined by RFC-5424
 // (https://tools.ietf.org/html/rfc5424)
 type Message struct {
-	Priority       int
+	Priority int
+
+	// Version is the VERSION of the message. If zero, 1 is used; messages
+	// parsed with VERSION 1 have a zero Version.
+	Version int
+
 	Timestamp      time.Time
 	Hostname       string
 	AppName        string
@@ -15,6 +27,44 @@ type Message struct {
 	Message        []byte
 }
 
+// Reset clears m so that it can be reused. The StructuredData slice and the
+// Parameters of its elements are kept, emptied, and are filled again when
+// m is next unmarshaled, so the message must not be reused while any of
+// them may still be referenced.
+func (m *Message) Reset() {
+	*m = Message{StructuredData: m.StructuredData[:0]}
+}
+
+// MessagePool is a pool of Messages for receivers that parse many messages,
+// such as a Server with MessagePool set. A message taken from the pool with
+// Get can be unmarshaled into without allocating its StructuredData, once
+// the pool has warmed up. The zero MessagePool is ready to use.
+type MessagePool struct {
+	pool sync.Pool
+}
+
+// Get returns an empty message from the pool, or a new one. A nil pool
+// always returns a new message.
+func (p *MessagePool) Get() *Message {
+	if p == nil {
+		return &Message{}
+	}
+	if m, ok := p.pool.Get().(*Message); ok {
+		return m
+	}
+	return &Message{}
+}
+
+// Put resets m and returns it to the pool. Nothing in m may be used
+// afterwards.
+func (p *MessagePool) Put(m *Message) {
+	if p == nil {
+		return
+	}
+	m.Reset()
+	p.pool.Put(m)
+}
+
 // SDParam represents parameters for structured data
 type SDParam struct {
 	Name  string
@@ -32,7 +82,9 @@ func (sd *StructuredData) AddParam(name, value string) {
 	sd.Parameters = append(sd.Parameters, SDParam{Name: name, Value: value})
 }
 
-// AddDatum adds structured data to a log message
+// AddDatum adds structured data to a log message. If the message already has
+// an SD element with the given ID the parameter is added to it, since an
+// SD-ID may only appear once in a message.
 func (m *Message) AddDatum(ID string, Name string, Value string) {
 	if m.StructuredData == nil {
 		m.StructuredData = []StructuredData{}
@@ -55,3 +107,148 @@ func (m *Message) AddDatum(ID string, Name string, Value string) {
 		},
 	})
 }
+
+// Clone returns a deep copy of m, which shares no StructuredData, parameters
+// or MSG bytes with m, so that either can be changed without affecting the
+// other. Copying a Message by assignment only copies the slices' headers.
+func (m Message) Clone() Message {
+	c := m
+	if m.StructuredData != nil {
+		c.StructuredData = make([]StructuredData, len(m.StructuredData))
+		for i, sd := range m.StructuredData {
+			c.StructuredData[i] = StructuredData{ID: sd.ID}
+			if sd.Parameters != nil {
+				c.StructuredData[i].Parameters = append(make([]SDParam, 0, len(sd.Parameters)), sd.Parameters...)
+			}
+		}
+	}
+	if m.Message != nil {
+		c.Message = append(make([]byte, 0, len(m.Message)), m.Message...)
+	}
+	return c
+}
+
+// SD returns the SD element of m with the given ID, or nil if there is
+// none. Changes made through it change m.
+func (m *Message) SD(id string) *StructuredData {
+	for i := range m.StructuredData {
+		if m.StructuredData[i].ID == id {
+			return &m.StructuredData[i]
+		}
+	}
+	return nil
+}
+
+// SDParam returns the value of the first parameter with the given name in
+// the SD element with the given ID
+func (m Message) SDParam(id, name string) (string, bool) {
+	sd := m.SD(id)
+	if sd == nil {
+		return "", false
+	}
+	for _, param := range sd.Parameters {
+		if param.Name == name {
+			return param.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetSDParam sets the value of the first parameter with the given name in
+// the SD element with the given ID, adding the parameter or the element if
+// needed. Like AddDatum, it changes the StructuredData of m in place, which
+// copies of m may share (see Clone).
+func (m *Message) SetSDParam(id, name, value string) {
+	sd := m.SD(id)
+	if sd == nil {
+		m.AddDatum(id, name, value)
+		return
+	}
+	for i := range sd.Parameters {
+		if sd.Parameters[i].Name == name {
+			sd.Parameters[i].Value = value
+			return
+		}
+	}
+	sd.AddParam(name, value)
+}
+
+// DeleteSD removes the SD elements with the given ID from m. The
+// StructuredData slice is replaced rather than changed in place.
+func (m *Message) DeleteSD(id string) {
+	if m.SD(id) == nil {
+		return
+	}
+	kept := make([]StructuredData, 0, len(m.StructuredData)-1)
+	for _, sd := range m.StructuredData {
+		if sd.ID != id {
+			kept = append(kept, sd)
+		}
+	}
+	m.StructuredData = kept
+}
+
+// MergeStructuredData combines SD elements that have the same ID into the
+// first of them, keeping the order of their parameters. Repeated parameter
+// names are allowed by RFC-5424 and are kept.
+func (m *Message) MergeStructuredData() {
+	merged := make([]StructuredData, 0, len(m.StructuredData))
+	index := map[string]int{}
+	for _, sd := range m.StructuredData {
+		if i, ok := index[sd.ID]; ok {
+			params := append([]SDParam{}, merged[i].Parameters...)
+			merged[i].Parameters = append(params, sd.Parameters...)
+			continue
+		}
+		index[sd.ID] = len(merged)
+		merged = append(merged, sd)
+	}
+	m.StructuredData = merged
+}
+
+// duplicateSDID returns the index of the first SD element whose ID was used
+// by an earlier element, or -1 if there is none
+func (m Message) duplicateSDID() int {
+	for i := 1; i < len(m.StructuredData); i++ {
+		for j := 0; j < i; j++ {
+			if m.StructuredData[i].ID == m.StructuredData[j].ID {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// IsUTF8 reports whether the MSG is declared to be UTF-8 text by starting
+// with a BOM (MSG-UTF8). Otherwise it is opaque bytes (MSG-ANY), which are
+// often, but not necessarily, text.
+func (m Message) IsUTF8() bool {
+	return bytes.HasPrefix(m.Message, bom)
+}
+
+// SetTextMessage sets the MSG to s, declared to be UTF-8 text with a BOM.
+// s must be valid UTF-8 for the message to be marshaled.
+func (m *Message) SetTextMessage(s string) {
+	m.Message = append(append([]byte{}, bom...), s...)
+}
+
+// SetBinaryMessage sets the MSG to b, as opaque bytes. If b itself starts
+// with a BOM, receivers will take it to be UTF-8.
+func (m *Message) SetBinaryMessage(b []byte) {
+	m.Message = b
+}
+
+// TextMessage returns the MSG without its BOM, if it has one
+func (m Message) TextMessage() string {
+	return string(bytes.TrimPrefix(m.Message, bom))
+}
+
+// Severity returns the severity encoded in the message's Priority
+func (m Message) Severity() Severity {
+	return Emergency + Severity(m.Priority&severityMask)
+}
+
+// Facility returns the facility encoded in the message's Priority
+func (m Message) Facility() Facility {
+	return Kernel + Facility((m.Priority&facilityMask)>>3)
+}
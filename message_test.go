@@ -0,0 +1,68 @@
+package rfc5424
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&MessageTest{})
+
+type MessageTest struct {
+}
+
+func (s *MessageTest) TestSDAccessors(c *C) {
+	m := Message{}
+	c.Assert(m.SD("a@1"), IsNil)
+	_, ok := m.SDParam("a@1", "x")
+	c.Assert(ok, Equals, false)
+
+	m.SetSDParam("a@1", "x", "1")
+	m.SetSDParam("b@1", "y", "2")
+	m.SetSDParam("a@1", "z", "3")
+	m.SetSDParam("a@1", "x", "4")
+	c.Assert(m.StructuredData, DeepEquals, []StructuredData{
+		{ID: "a@1", Parameters: []SDParam{{Name: "x", Value: "4"}, {Name: "z", Value: "3"}}},
+		{ID: "b@1", Parameters: []SDParam{{Name: "y", Value: "2"}}},
+	})
+
+	value, ok := m.SDParam("a@1", "z")
+	c.Assert(ok, Equals, true)
+	c.Assert(value, Equals, "3")
+	_, ok = m.SDParam("a@1", "y")
+	c.Assert(ok, Equals, false)
+
+	m.SD("b@1").AddParam("w", "5")
+	c.Assert(m.StructuredData[1].Parameters, HasLen, 2)
+
+	copied := m
+	m.DeleteSD("a@1")
+	c.Assert(m.StructuredData, HasLen, 1)
+	c.Assert(m.StructuredData[0].ID, Equals, "b@1")
+	c.Assert(copied.StructuredData, HasLen, 2)
+	c.Assert(copied.StructuredData[0].ID, Equals, "a@1")
+	m.DeleteSD("missing@1")
+	c.Assert(m.StructuredData, HasLen, 1)
+}
+
+func (s *MessageTest) TestClone(c *C) {
+	m := Message{Hostname: "host", Message: []byte("hello")}
+	m.AddDatum("a@1", "x", "1")
+	m.StructuredData = append(m.StructuredData, StructuredData{ID: "b@1"})
+
+	clone := m.Clone()
+	c.Assert(clone, DeepEquals, m)
+
+	clone.Message[0] = 'j'
+	clone.StructuredData[0].Parameters[0].Value = "2"
+	clone.StructuredData[0].AddParam("y", "3")
+	clone.StructuredData[1].ID = "c@1"
+	c.Assert(string(m.Message), Equals, "hello")
+	c.Assert(m.StructuredData, DeepEquals, []StructuredData{
+		{ID: "a@1", Parameters: []SDParam{{Name: "x", Value: "1"}}},
+		{ID: "b@1"},
+	})
+
+	// nil and empty slices stay as they were, since they marshal differently
+	c.Assert(Message{}.Clone(), DeepEquals, Message{})
+	empty := Message{StructuredData: []StructuredData{}, Message: []byte{}}
+	c.Assert(empty.Clone(), DeepEquals, empty)
+}
@@ -0,0 +1,147 @@
+package rfc5424
+
+import (
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMetricsInterval = time.Minute
+	defaultMetricsSDID     = "metrics@32473"
+)
+
+// runtimeMetrics are the metrics of the runtime/metrics package reported by
+// a MetricsReporter with Runtime set, by parameter name
+var runtimeMetrics = map[string]string{
+	"goroutines": "/sched/goroutines:goroutines",
+	"heapBytes":  "/memory/classes/heap/objects:bytes",
+	"totalBytes": "/memory/classes/total:bytes",
+	"gcCycles":   "/gc/cycles/total:gc-cycles",
+}
+
+// MetricsReporter periodically writes the values of registered counters and
+// gauges to Writer, for environments where syslog is the only telemetry
+// channel. Each report is a message with the MSGID "metrics" and an SD
+// element, SDID, with a parameter for each metric, in order of name.
+// Metric names must be valid SD parameter names.
+type MetricsReporter struct {
+	Writer MessageWriter
+
+	// Interval is the time between reports. If zero, one minute is used.
+	Interval time.Duration
+
+	// SDID is the ID of the metrics SD element. If empty, "metrics@32473"
+	// is used.
+	SDID string
+
+	// Runtime adds some of the Go runtime's metrics: the number of
+	// "goroutines", the "heapBytes" of live and unswept objects, the
+	// "totalBytes" of memory mapped by the runtime and the "gcCycles"
+	// completed.
+	Runtime bool
+
+	// Error, if set, is called with the errors of the periodic reports
+	Error func(err error)
+
+	mu       sync.Mutex
+	counters map[string]func() uint64
+	gauges   map[string]func() float64
+	periodic periodic
+}
+
+// Counter registers a counter, whose value is returned by value. A later
+// registration under the same name replaces the earlier one.
+func (r *MetricsReporter) Counter(name string, value func() uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.counters == nil {
+		r.counters = map[string]func() uint64{}
+	}
+	r.counters[name] = value
+}
+
+// Gauge registers a gauge, whose value is returned by value. A later
+// registration under the same name replaces the earlier one.
+func (r *MetricsReporter) Gauge(name string, value func() float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.gauges == nil {
+		r.gauges = map[string]func() float64{}
+	}
+	r.gauges[name] = value
+}
+
+// values returns the current values of the metrics, by name
+func (r *MetricsReporter) values() map[string]string {
+	values := map[string]string{}
+	r.mu.Lock()
+	for name, value := range r.counters {
+		values[name] = strconv.FormatUint(value(), 10)
+	}
+	for name, value := range r.gauges {
+		values[name] = strconv.FormatFloat(value(), 'g', -1, 64)
+	}
+	r.mu.Unlock()
+
+	if r.Runtime {
+		samples := make([]metrics.Sample, 0, len(runtimeMetrics))
+		for _, metric := range runtimeMetrics {
+			samples = append(samples, metrics.Sample{Name: metric})
+		}
+		metrics.Read(samples)
+		for name, metric := range runtimeMetrics {
+			for _, sample := range samples {
+				if sample.Name == metric && sample.Value.Kind() == metrics.KindUint64 {
+					values[name] = strconv.FormatUint(sample.Value.Uint64(), 10)
+				}
+			}
+		}
+	}
+	return values
+}
+
+// Report writes the current values of the metrics now
+func (r *MetricsReporter) Report() error {
+	sdID := r.SDID
+	if sdID == "" {
+		sdID = defaultMetricsSDID
+	}
+	values := r.values()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := NewMessage().MessageID("metrics")
+	for _, name := range names {
+		b.SD(sdID, name, values[name])
+	}
+	m, err := b.Build()
+	if err != nil {
+		return err
+	}
+	return r.Writer.WriteMessage(m)
+}
+
+// Start starts reporting every Interval, until Close is called
+func (r *MetricsReporter) Start() {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = defaultMetricsInterval
+	}
+	r.periodic.start(interval, func() {
+		if err := r.Report(); err != nil && r.Error != nil {
+			r.Error(err)
+		}
+	})
+}
+
+// Close stops the periodic reports and closes Writer
+func (r *MetricsReporter) Close() error {
+	r.periodic.stopAndWait()
+	return r.Writer.Close()
+}
@@ -0,0 +1,66 @@
+package rfc5424
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&MetricsTest{})
+
+type MetricsTest struct {
+}
+
+func (s *MetricsTest) TestReport(c *C) {
+	w := &chanWriter{messages: make(chan Message, 1)}
+	r := &MetricsReporter{Writer: w}
+	requests := uint64(41)
+	r.Counter("requests", func() uint64 { return requests })
+	r.Gauge("load", func() float64 { return 0.25 })
+	requests++
+
+	c.Assert(r.Report(), IsNil)
+	m := <-w.messages
+	c.Assert(m.MessageID, Equals, "metrics")
+	c.Assert(m.Severity(), Equals, Severity(Info))
+	c.Assert(m.StructuredData, DeepEquals, []StructuredData{{ID: "metrics@32473", Parameters: []SDParam{
+		{Name: "load", Value: "0.25"},
+		{Name: "requests", Value: "42"},
+	}}})
+
+	r.Counter("bad name", func() uint64 { return 0 })
+	c.Assert(r.Report(), NotNil)
+}
+
+func (s *MetricsTest) TestRuntime(c *C) {
+	w := &chanWriter{messages: make(chan Message, 1)}
+	r := &MetricsReporter{Writer: w, SDID: "m@32473", Runtime: true}
+	c.Assert(r.Report(), IsNil)
+	m := <-w.messages
+	c.Assert(Params(m.StructuredData[0].Parameters).Names(), DeepEquals,
+		[]string{"gcCycles", "goroutines", "heapBytes", "totalBytes"})
+	goroutines, err := m.GetInt("m@32473", "goroutines")
+	c.Assert(err, IsNil)
+	c.Assert(goroutines > 0, Equals, true)
+}
+
+func (s *MetricsTest) TestStart(c *C) {
+	w := &chanWriter{messages: make(chan Message, 10)}
+	r := &MetricsReporter{Writer: w, Interval: 10 * time.Millisecond}
+	r.Gauge("x", func() float64 { return 1 })
+	r.Start()
+	for i := 0; i < 2; i++ {
+		select {
+		case m := <-w.messages:
+			c.Assert(m.StructuredData[0].Parameters, DeepEquals, []SDParam{{Name: "x", Value: "1"}})
+		case <-time.After(time.Second):
+			c.Fatal("no report")
+		}
+	}
+	c.Assert(r.Close(), IsNil)
+	for len(w.messages) > 0 {
+		<-w.messages
+	}
+	time.Sleep(30 * time.Millisecond)
+	c.Assert(w.messages, HasLen, 0)
+}
@@ -0,0 +1,191 @@
+package rfc5424
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ParseSeverity returns the severity with the given name, ignoring case.
+// Both the syslog(3) names, e.g. "err", and their common aliases, e.g.
+// "error" and "panic", are accepted.
+func ParseSeverity(name string) (Severity, error) {
+	if s, ok := severityNames[strings.ToLower(name)]; ok {
+		return s, nil
+	}
+	return DefaultSeverity, InvalidValue("Severity", name)
+}
+
+// AllSeverities returns the severities, from the most severe, Emergency, to
+// the least, Debug
+func AllSeverities() []Severity {
+	severities := make([]Severity, 0, numSeverities)
+	for s := Severity(Emergency); s <= Debug; s++ {
+		severities = append(severities, s)
+	}
+	return severities
+}
+
+// SeverityOfCode returns the severity with the numerical code used in the
+// PRI, from 0 for Emergency to 7 for Debug
+func SeverityOfCode(code int) (Severity, error) {
+	if code < 0 || code >= numSeverities {
+		return DefaultSeverity, InvalidValue("Severity", code)
+	}
+	return Emergency + Severity(code), nil
+}
+
+// Code returns the numerical code of the severity used in the PRI, from 0
+// for Emergency to 7 for Debug, or -1 for DefaultSeverity and unknown
+// severities
+func (s Severity) Code() int {
+	if s < Emergency || s > Debug {
+		return -1
+	}
+	return int(s - Emergency)
+}
+
+// Description returns the description of the severity given by RFC-5424,
+// e.g. "error conditions", or "" for unknown severities
+func (s Severity) Description() string {
+	return severityDescriptions[s]
+}
+
+// String returns the syslog(3) name of the severity, e.g. "err"
+func (s Severity) String() string {
+	if name, ok := severityKeywords[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// MarshalText returns the syslog(3) name of the severity
+func (s Severity) MarshalText() ([]byte, error) {
+	name, ok := severityKeywords[s]
+	if !ok {
+		return nil, InvalidValue("Severity", int(s))
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText parses any of the names accepted by ParseSeverity
+func (s *Severity) UnmarshalText(text []byte) error {
+	severity, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
+
+// UnmarshalJSON accepts a name, or the numeric value of the constant for
+// compatibility with configuration written before severities had names.
+func (s *Severity) UnmarshalJSON(b []byte) error {
+	var n int
+	if err := json.Unmarshal(b, &n); err == nil {
+		*s = Severity(n)
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+	return s.UnmarshalText([]byte(name))
+}
+
+// Set implements flag.Value, so that a severity can be a command line flag
+func (s *Severity) Set(name string) error {
+	return s.UnmarshalText([]byte(name))
+}
+
+// ParseFacility returns the facility with the given name, ignoring case.
+// The syslog(3) names, e.g. "kern", and aliases such as "kernel" and
+// "security" are accepted.
+func ParseFacility(name string) (Facility, error) {
+	if f, ok := facilityNames[strings.ToLower(name)]; ok {
+		return f, nil
+	}
+	return DefaultFacility, InvalidValue("Facility", name)
+}
+
+// AllFacilities returns the facilities in the order of their codes, from
+// Kernel to Local7
+func AllFacilities() []Facility {
+	facilities := make([]Facility, 0, numFacilities)
+	for f := Facility(Kernel); f <= Local7; f++ {
+		facilities = append(facilities, f)
+	}
+	return facilities
+}
+
+// FacilityOfCode returns the facility with the numerical code used in the
+// PRI, from 0 for Kernel to 23 for Local7
+func FacilityOfCode(code int) (Facility, error) {
+	if code < 0 || code >= numFacilities {
+		return DefaultFacility, InvalidValue("Facility", code)
+	}
+	return Kernel + Facility(code), nil
+}
+
+// Code returns the numerical code of the facility used in the PRI, from 0
+// for Kernel to 23 for Local7, or -1 for DefaultFacility and unknown
+// facilities
+func (f Facility) Code() int {
+	if f < Kernel || f > Local7 {
+		return -1
+	}
+	return int(f - Kernel)
+}
+
+// Description returns the description of the facility given by RFC-5424,
+// e.g. "mail system", or "" for unknown facilities
+func (f Facility) Description() string {
+	return facilityDescriptions[f]
+}
+
+// String returns the name of the facility, e.g. "kern"
+func (f Facility) String() string {
+	if name, ok := facilityKeywords[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Facility(%d)", int(f))
+}
+
+// MarshalText returns the name of the facility
+func (f Facility) MarshalText() ([]byte, error) {
+	name, ok := facilityKeywords[f]
+	if !ok {
+		return nil, InvalidValue("Facility", int(f))
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText parses any of the names accepted by ParseFacility
+func (f *Facility) UnmarshalText(text []byte) error {
+	facility, err := ParseFacility(string(text))
+	if err != nil {
+		return err
+	}
+	*f = facility
+	return nil
+}
+
+// UnmarshalJSON accepts a name, or the numeric value of the constant for
+// compatibility with configuration written before facilities had names.
+func (f *Facility) UnmarshalJSON(b []byte) error {
+	var n int
+	if err := json.Unmarshal(b, &n); err == nil {
+		*f = Facility(n)
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+	return f.UnmarshalText([]byte(name))
+}
+
+// Set implements flag.Value, so that a facility can be a command line flag
+func (f *Facility) Set(name string) error {
+	return f.UnmarshalText([]byte(name))
+}
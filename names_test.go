@@ -0,0 +1,100 @@
+package rfc5424
+
+import (
+	"encoding/json"
+	"flag"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&NamesTest{})
+
+type NamesTest struct {
+}
+
+func (s *NamesTest) TestSeverityNames(c *C) {
+	for name, expected := range map[string]Severity{"emerg": Emergency, "panic": Emergency, "ERR": Error,
+		"error": Error, "warn": Warning, "informational": Info} {
+		severity, err := ParseSeverity(name)
+		c.Assert(err, IsNil)
+		c.Assert(severity, Equals, expected)
+	}
+	_, err := ParseSeverity("loud")
+	c.Assert(err, NotNil)
+
+	for severity := Severity(Emergency); severity <= Debug; severity++ {
+		parsed, err := ParseSeverity(severity.String())
+		c.Assert(err, IsNil)
+		c.Assert(parsed, Equals, severity)
+	}
+	c.Assert(Severity(Error).String(), Equals, "err")
+	c.Assert(Severity(DefaultSeverity).String(), Equals, "Severity(0)")
+}
+
+func (s *NamesTest) TestFacilityNames(c *C) {
+	for name, expected := range map[string]Facility{"kern": Kernel, "kernel": Kernel, "security": Auth,
+		"Local7": Local7} {
+		facility, err := ParseFacility(name)
+		c.Assert(err, IsNil)
+		c.Assert(facility, Equals, expected)
+	}
+	for facility := Facility(Kernel); facility <= Local7; facility++ {
+		parsed, err := ParseFacility(facility.String())
+		c.Assert(err, IsNil)
+		c.Assert(parsed, Equals, facility)
+	}
+}
+
+func (s *NamesTest) TestText(c *C) {
+	var v struct {
+		Severity   Severity
+		Facilities []Facility
+	}
+	c.Assert(json.Unmarshal([]byte(`{"severity": "warn", "facilities": ["kern", 5]}`), &v), IsNil)
+	c.Assert(v.Severity, Equals, Severity(Warning))
+	c.Assert(v.Facilities, DeepEquals, []Facility{Kernel, Auth})
+	b, err := json.Marshal(v)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `{"Severity":"warning","Facilities":["kern","auth"]}`)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	severity := Severity(Info)
+	fs.Var(&severity, "severity", "")
+	c.Assert(fs.Parse([]string{"-severity", "crit"}), IsNil)
+	c.Assert(severity, Equals, Severity(Critical))
+}
+
+func (s *NamesTest) TestMetadata(c *C) {
+	severities := AllSeverities()
+	c.Assert(severities, HasLen, 8)
+	c.Assert(severities[0], Equals, Severity(Emergency))
+	c.Assert(severities[7], Equals, Severity(Debug))
+	for code, severity := range severities {
+		c.Assert(severity.Code(), Equals, code)
+		c.Assert(severity.Description(), Not(Equals), "")
+		fromCode, err := SeverityOfCode(code)
+		c.Assert(err, IsNil)
+		c.Assert(fromCode, Equals, severity)
+	}
+	c.Assert(Severity(Error).Description(), Equals, "error conditions")
+	c.Assert(Severity(DefaultSeverity).Code(), Equals, -1)
+	c.Assert(Severity(DefaultSeverity).Description(), Equals, "")
+	_, err := SeverityOfCode(8)
+	c.Assert(err, NotNil)
+
+	facilities := AllFacilities()
+	c.Assert(facilities, HasLen, 24)
+	for code, facility := range facilities {
+		c.Assert(facility.Code(), Equals, code)
+		c.Assert(facility.Description(), Not(Equals), "")
+		fromCode, err := FacilityOfCode(code)
+		c.Assert(err, IsNil)
+		c.Assert(fromCode, Equals, facility)
+		m := Message{Priority: code << 3}
+		c.Assert(m.Facility(), Equals, facility)
+	}
+	c.Assert(Facility(Local3).Description(), Equals, "local use 3")
+	c.Assert(Facility(Local7+1).Code(), Equals, -1)
+	_, err = FacilityOfCode(-1)
+	c.Assert(err, NotNil)
+}
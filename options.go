@@ -0,0 +1,73 @@
+package rfc5424
+
+import "time"
+
+// Option configures an Encoder, StreamWriter, Server or Logger when passed
+// to NewEncoder, NewStreamWriter, NewServer or NewLogger. Each option sets
+// the matching field, so that the settings which otherwise come from
+// package-level defaults (TimeNow, DefaultHostnameResolver, StrictSDIDs) can
+// be chosen per instance:
+//
+//	e := NewEncoder(conn, WithAppName("api"), WithFraming(NonTransparentFraming))
+//
+// Options that do not apply to a type are ignored, e.g. WithFraming by a
+// Server, which detects the framing of each connection.
+type Option func(o *options)
+
+type options struct {
+	hostname  string
+	appName   string
+	framing   Framing
+	maxLength int
+	clock     func() time.Time
+	strict    bool
+	formatter *Formatter
+	processID ProcessIDStrategy
+	encrypter *ParamEncrypter
+}
+
+func newOptions(opts []Option) options {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithHostname sets the HOSTNAME of messages encoded from structs without a
+// Hostname field, instead of the one chosen by DefaultHostnameResolver.
+func WithHostname(hostname string) Option {
+	return func(o *options) { o.hostname = hostname }
+}
+
+// WithAppName sets the APP-NAME of messages encoded from structs without an
+// AppName field, instead of the one chosen by DefaultAppNameResolver.
+func WithAppName(appName string) Option {
+	return func(o *options) { o.appName = appName }
+}
+
+// WithFraming sets how messages are delimited on the stream.
+func WithFraming(f Framing) Option {
+	return func(o *options) { o.framing = f }
+}
+
+// WithMaxLen sets the longest message written, longer ones being truncated
+// (see LengthLimiter), or for a Server the longest message accepted on
+// stream connections.
+func WithMaxLen(n int) Option {
+	return func(o *options) { o.maxLength = n }
+}
+
+// WithClock sets the function reporting the TIMESTAMP of messages encoded
+// from structs without a Timestamp field, instead of TimeNow.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) { o.clock = now }
+}
+
+// WithStrictness sets whether SD-IDs and parameter names are checked against
+// the full RFC-5424 grammar, as StrictSDIDs does for the whole package.
+// Encoders then refuse to send, and Servers drop, messages that do not
+// conform.
+func WithStrictness(strict bool) Option {
+	return func(o *options) { o.strict = strict }
+}
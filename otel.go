@@ -0,0 +1,187 @@
+package rfc5424
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// Attributes of a LogRecord holding the RFC-5424 header fields and
+// structured data, named as by the OpenTelemetry Collector's syslog receiver
+const (
+	AttributeHostname       = "hostname"
+	AttributeAppName        = "appname"
+	AttributeProcessID      = "proc_id"
+	AttributeMessageID      = "msg_id"
+	AttributeFacility       = "facility"
+	AttributePriority       = "priority"
+	AttributeStructuredData = "structured_data"
+)
+
+// LogRecord is a log record in the OpenTelemetry log data model, holding the
+// fields that RFC-5424 messages can carry. It can be copied field by field to
+// and from the record types of the OpenTelemetry SDKs and pdata.
+type LogRecord struct {
+	Timestamp         time.Time
+	ObservedTimestamp time.Time
+
+	// SeverityNumber is between 1 (TRACE) and 24 (FATAL4), or 0 if not
+	// specified
+	SeverityNumber int
+	SeverityText   string
+
+	// Body is a string, or []byte for a MSG that is not UTF-8
+	Body interface{}
+
+	// Attributes holds the header fields, keyed by the Attribute constants.
+	// The facility and priority are their numeric codes, and the structured
+	// data is a map[string]map[string]string from SD-ID to PARAM-NAME to
+	// PARAM-VALUE.
+	Attributes map[string]interface{}
+}
+
+// ToLogRecord converts m to the OpenTelemetry log data model. The
+// ObservedTimestamp is the current time. Repeated PARAM-NAMEs within an SD
+// element keep only their last value.
+func ToLogRecord(m Message) LogRecord {
+	r := LogRecord{
+		Timestamp:         m.Timestamp,
+		ObservedTimestamp: TimeNow().UTC(),
+		SeverityNumber:    OTelSeverityNumbers.Level(m.Severity()),
+		SeverityText:      m.Severity().String(),
+		Attributes: map[string]interface{}{
+			AttributeFacility: int(m.Facility() - Kernel),
+			AttributePriority: m.Priority,
+		},
+	}
+	if m.IsUTF8() || utf8.Valid(m.Message) {
+		r.Body = m.TextMessage()
+	} else {
+		r.Body = m.Message
+	}
+	for name, value := range map[string]string{
+		AttributeHostname:  m.Hostname,
+		AttributeAppName:   m.AppName,
+		AttributeProcessID: m.ProcessID,
+		AttributeMessageID: m.MessageID,
+	} {
+		if value != "" {
+			r.Attributes[name] = value
+		}
+	}
+	if len(m.StructuredData) > 0 {
+		sds := map[string]map[string]string{}
+		for _, sd := range m.StructuredData {
+			params := sds[sd.ID]
+			if params == nil {
+				params = map[string]string{}
+				sds[sd.ID] = params
+			}
+			for _, param := range sd.Parameters {
+				params[param.Name] = param.Value
+			}
+		}
+		r.Attributes[AttributeStructuredData] = sds
+	}
+	return r
+}
+
+// FromLogRecord converts an OpenTelemetry log record to a Message. The
+// severity comes from SeverityNumber, or if it is not specified from a
+// SeverityText naming a Severity. The facility may also be named, e.g.
+// "auth". A string Body is sent as UTF-8 text, with a BOM. Attributes other than the Attribute
+// constants become parameters of the DefaultSDID element, formatted with
+// fmt.Sprint. SD elements and parameters are sorted by name, as maps have no
+// order.
+func FromLogRecord(r LogRecord) Message {
+	severity := Severity(defaultSeverity)
+	if r.SeverityNumber > 0 {
+		severity = OTelSeverityNumbers.Severity(r.SeverityNumber)
+	} else if s, err := ParseSeverity(r.SeverityText); err == nil {
+		severity = s
+	}
+	facility := Facility(defaultFacility)
+	switch f := r.Attributes[AttributeFacility].(type) {
+	case string:
+		if parsed, err := ParseFacility(f); err == nil {
+			facility = parsed
+		}
+	case int:
+		facility = Kernel + Facility(f)
+	}
+
+	m := Message{
+		Priority:  int(severity-Emergency) | int(facility-Kernel)<<3,
+		Timestamp: r.Timestamp,
+	}
+	if m.Timestamp.IsZero() {
+		m.Timestamp = r.ObservedTimestamp
+	}
+	switch body := r.Body.(type) {
+	case string:
+		m.SetTextMessage(body)
+	case []byte:
+		m.SetBinaryMessage(body)
+	case nil:
+	default:
+		m.SetTextMessage(fmt.Sprint(body))
+	}
+
+	var others []string
+	for name, value := range r.Attributes {
+		switch name {
+		case AttributeHostname:
+			m.Hostname, _ = value.(string)
+		case AttributeAppName:
+			m.AppName, _ = value.(string)
+		case AttributeProcessID:
+			m.ProcessID = fmt.Sprint(value)
+		case AttributeMessageID:
+			m.MessageID, _ = value.(string)
+		case AttributeFacility, AttributePriority:
+		case AttributeStructuredData:
+			if sds, ok := value.(map[string]map[string]string); ok {
+				m.StructuredData = append(m.StructuredData, sortedStructuredData(sds)...)
+			}
+		default:
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	for _, name := range others {
+		m.AddDatum(DefaultSDID, name, attributeValue(r.Attributes[name]))
+	}
+	return m
+}
+
+// attributeValue formats an attribute as a PARAM-VALUE
+func attributeValue(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// sortedStructuredData returns the SD elements of sds, sorted by SD-ID and
+// PARAM-NAME
+func sortedStructuredData(sds map[string]map[string]string) []StructuredData {
+	ids := make([]string, 0, len(sds))
+	for id := range sds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	elements := make([]StructuredData, len(ids))
+	for i, id := range ids {
+		names := make([]string, 0, len(sds[id]))
+		for name := range sds[id] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		elements[i].ID = id
+		for _, name := range names {
+			elements[i].AddParam(name, sds[id][name])
+		}
+	}
+	return elements
+}
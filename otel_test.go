@@ -0,0 +1,86 @@
+package rfc5424
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&OTelTest{})
+
+type OTelTest struct {
+}
+
+func (s *OTelTest) TestToLogRecord(c *C) {
+	defer func() { TimeNow = time.Now }()
+	TimeNow = func() time.Time { return T("2003-10-11T22:14:16Z") }
+
+	m := Message{
+		Priority:  34,
+		Timestamp: T("2003-10-11T22:14:15.003Z"),
+		Hostname:  "mymachine.example.com",
+		AppName:   "su",
+		MessageID: "ID47",
+		StructuredData: []StructuredData{
+			{ID: "exampleSDID@32473", Parameters: []SDParam{{Name: "iut", Value: "3"}}},
+		},
+		Message: []byte("\ufeff'su root' failed for lonvick on /dev/pts/8"),
+	}
+	c.Assert(ToLogRecord(m), DeepEquals, LogRecord{
+		Timestamp:         T("2003-10-11T22:14:15.003Z"),
+		ObservedTimestamp: T("2003-10-11T22:14:16Z"),
+		SeverityNumber:    18,
+		SeverityText:      "crit",
+		Body:              "'su root' failed for lonvick on /dev/pts/8",
+		Attributes: map[string]interface{}{
+			AttributeFacility:       4,
+			AttributePriority:       34,
+			AttributeHostname:       "mymachine.example.com",
+			AttributeAppName:        "su",
+			AttributeMessageID:      "ID47",
+			AttributeStructuredData: map[string]map[string]string{"exampleSDID@32473": {"iut": "3"}},
+		},
+	})
+
+	m.Message = []byte{0xff, 0xfe}
+	c.Assert(ToLogRecord(m).Body, DeepEquals, []byte{0xff, 0xfe})
+}
+
+func (s *OTelTest) TestFromLogRecord(c *C) {
+	r := LogRecord{
+		ObservedTimestamp: T("2003-10-11T22:14:16Z"),
+		SeverityNumber:    14, // WARN2
+		Body:              "disk full",
+		Attributes: map[string]interface{}{
+			AttributeFacility:       "auth",
+			AttributeHostname:       "host",
+			AttributeProcessID:      1234,
+			AttributeStructuredData: map[string]map[string]string{"b@1": {"y": "2", "x": "1"}, "a@1": {"z": "3"}},
+			"http.status_code":      503,
+			"component":             "api",
+		},
+	}
+	m := FromLogRecord(r)
+	c.Assert(m, DeepEquals, Message{
+		Priority:  36,
+		Timestamp: T("2003-10-11T22:14:16Z"),
+		Hostname:  "host",
+		ProcessID: "1234",
+		StructuredData: []StructuredData{
+			{ID: "a@1", Parameters: []SDParam{{Name: "z", Value: "3"}}},
+			{ID: "b@1", Parameters: []SDParam{{Name: "x", Value: "1"}, {Name: "y", Value: "2"}}},
+			{ID: DefaultSDID, Parameters: []SDParam{{Name: "component", Value: "api"}, {Name: "http.status_code", Value: "503"}}},
+		},
+		Message: []byte("\ufeffdisk full"),
+	})
+
+	m = FromLogRecord(LogRecord{SeverityText: "err"})
+	c.Assert(m.Severity(), Equals, Severity(Error))
+	c.Assert(m.Facility(), Equals, Facility(Local0))
+}
+
+func (s *OTelTest) TestSeverityRoundTrip(c *C) {
+	for severity := Severity(Emergency); severity <= Debug; severity++ {
+		c.Assert(OTelSeverityNumbers.Severity(OTelSeverityNumbers.Level(severity)), Equals, severity)
+	}
+}
@@ -0,0 +1,108 @@
+package rfc5424
+
+import "sort"
+
+// Params is a list of SD parameters in which, as RFC-5424 allows, a name
+// may appear more than once. It converts to and from the
+// map[string][]string of http.Header and url.Values, and from the
+// Parameters of an SD element: Params(sd.Parameters). Ranging over it
+// visits the parameters in order.
+type Params []SDParam
+
+// Add appends a parameter, keeping any others with the same name
+func (p *Params) Add(name, value string) {
+	*p = append(*p, SDParam{Name: name, Value: value})
+}
+
+// Set replaces the parameters with the given name by one with value, where
+// the first of them was, or appends it if there were none
+func (p *Params) Set(name, value string) {
+	params := (*p)[:0]
+	found := false
+	for _, param := range *p {
+		if param.Name != name {
+			params = append(params, param)
+		} else if !found {
+			found = true
+			params = append(params, SDParam{Name: name, Value: value})
+		}
+	}
+	if !found {
+		params = append(params, SDParam{Name: name, Value: value})
+	}
+	*p = params
+}
+
+// Del removes the parameters with the given name
+func (p *Params) Del(name string) {
+	params := (*p)[:0]
+	for _, param := range *p {
+		if param.Name != name {
+			params = append(params, param)
+		}
+	}
+	*p = params
+}
+
+// Get returns the value of the first parameter with the given name, or ""
+func (p Params) Get(name string) string {
+	for _, param := range p {
+		if param.Name == name {
+			return param.Value
+		}
+	}
+	return ""
+}
+
+// Values returns the values of the parameters with the given name, in order
+func (p Params) Values(name string) []string {
+	var values []string
+	for _, param := range p {
+		if param.Name == name {
+			values = append(values, param.Value)
+		}
+	}
+	return values
+}
+
+// Names returns the names of the parameters, each once, in the order they
+// first appear
+func (p Params) Names() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, param := range p {
+		if !seen[param.Name] {
+			seen[param.Name] = true
+			names = append(names, param.Name)
+		}
+	}
+	return names
+}
+
+// Map returns the values of the parameters by name. The order of
+// parameters with different names is lost.
+func (p Params) Map() map[string][]string {
+	m := make(map[string][]string, len(p))
+	for _, param := range p {
+		m[param.Name] = append(m[param.Name], param.Value)
+	}
+	return m
+}
+
+// ParamsFromMap returns the parameters in m, sorted by name so that the
+// result does not depend on the map's order. The values of each name keep
+// their order.
+func ParamsFromMap(m map[string][]string) Params {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var p Params
+	for _, name := range names {
+		for _, value := range m[name] {
+			p.Add(name, value)
+		}
+	}
+	return p
+}
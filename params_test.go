@@ -0,0 +1,44 @@
+package rfc5424
+
+import (
+	"net/http"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&ParamsTest{})
+
+type ParamsTest struct {
+}
+
+func (s *ParamsTest) TestParams(c *C) {
+	var p Params
+	p.Add("a", "1")
+	p.Add("b", "2")
+	p.Add("a", "3")
+	c.Assert(p.Get("a"), Equals, "1")
+	c.Assert(p.Get("c"), Equals, "")
+	c.Assert(p.Values("a"), DeepEquals, []string{"1", "3"})
+	c.Assert(p.Names(), DeepEquals, []string{"a", "b"})
+	c.Assert(p.Map(), DeepEquals, map[string][]string{"a": {"1", "3"}, "b": {"2"}})
+
+	p.Set("a", "4")
+	c.Assert(p, DeepEquals, Params{{"a", "4"}, {"b", "2"}})
+	p.Set("c", "5")
+	p.Del("b")
+	c.Assert(p, DeepEquals, Params{{"a", "4"}, {"c", "5"}})
+}
+
+func (s *ParamsTest) TestParamsFromMap(c *C) {
+	h := http.Header{}
+	h.Add("X-B", "1")
+	h.Add("X-A", "2")
+	h.Add("X-A", "3")
+	p := ParamsFromMap(h)
+	c.Assert(p, DeepEquals, Params{{"X-A", "2"}, {"X-A", "3"}, {"X-B", "1"}})
+	c.Assert(http.Header(p.Map()), DeepEquals, h)
+
+	sd := StructuredData{ID: "x@32473", Parameters: p}
+	c.Assert(Params(sd.Parameters).Values("X-A"), DeepEquals, []string{"2", "3"})
+	c.Assert(ParamsFromMap(nil), IsNil)
+}
@@ -0,0 +1,50 @@
+package rfc5424
+
+import (
+	"sync"
+	"time"
+)
+
+// periodic calls a function at regular intervals on a goroutine of its own,
+// for the components reporting periodically. The zero value is stopped.
+type periodic struct {
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// start calls f every interval until stopped. It does nothing if already
+// started.
+func (p *periodic) start(interval time.Duration, f func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		return
+	}
+	p.stop, p.done = make(chan struct{}), make(chan struct{})
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				f()
+			}
+		}
+	}(p.stop, p.done)
+}
+
+// stopAndWait stops the calls, waiting for the one in progress if any
+func (p *periodic) stopAndWait() {
+	p.mu.Lock()
+	stop, done := p.stop, p.done
+	p.stop, p.done = nil, nil
+	p.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
@@ -0,0 +1,67 @@
+package rfc5424
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ProcessIDStrategy returns the PROCID of emitted messages. It is called for
+// each message encoded from a struct without a ProcessID field, and for each
+// message built by NewMessage or a Logger.
+type ProcessIDStrategy func() string
+
+// DefaultProcessID is the strategy used by Encoders and Loggers without
+// one, and by NewMessage. It is PIDProcessID unless changed.
+var DefaultProcessID ProcessIDStrategy = PIDProcessID
+
+var (
+	processPID = strconv.FormatInt(int64(os.Getpid()), 10)
+
+	// processStart is when the package was initialized, which is close
+	// enough to the start of the process to tell restarts apart
+	processStart = time.Now()
+
+	processInstanceID = func() string {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return processPID
+		}
+		b[6] = b[6]&0x0f | 0x40 // version 4
+		b[8] = b[8]&0x3f | 0x80 // variant 10
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	}()
+)
+
+// PIDProcessID returns the ID of the process, as is traditional
+func PIDProcessID() string {
+	return processPID
+}
+
+// PIDStartTimeProcessID returns the ID of the process and the Unix time it
+// started, e.g. "1-1760609700", so that the processes of a container, whose
+// ID is often 1, can be told apart across restarts.
+func PIDStartTimeProcessID() string {
+	return processPID + "-" + strconv.FormatInt(processStart.Unix(), 10)
+}
+
+// InstanceProcessID returns a random version 4 UUID chosen when the process
+// starts, identifying it among all instances of the application
+func InstanceProcessID() string {
+	return processInstanceID
+}
+
+// StaticProcessID returns a strategy always returning id, e.g. the name of
+// the pod or task running the application
+func StaticProcessID(id string) ProcessIDStrategy {
+	return func() string { return id }
+}
+
+// WithProcessID sets the strategy choosing the PROCID of messages encoded
+// from structs without a ProcessID field, or built by a Logger, instead of
+// DefaultProcessID.
+func WithProcessID(strategy ProcessIDStrategy) Option {
+	return func(o *options) { o.processID = strategy }
+}
@@ -0,0 +1,46 @@
+package rfc5424
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&ProcessIDTest{})
+
+type ProcessIDTest struct {
+}
+
+func (s *ProcessIDTest) TestStrategies(c *C) {
+	pid := strconv.Itoa(os.Getpid())
+	c.Assert(PIDProcessID(), Equals, pid)
+	c.Assert(PIDStartTimeProcessID(), Matches, pid+`-[0-9]+`)
+	c.Assert(InstanceProcessID(), Matches, `[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}`)
+	c.Assert(InstanceProcessID(), Equals, InstanceProcessID())
+	c.Assert(StaticProcessID("pod-7")(), Equals, "pod-7")
+}
+
+func (s *ProcessIDTest) TestEncoder(c *C) {
+	e := NewEncoder(&bytes.Buffer{}, WithProcessID(StaticProcessID("pod-7")))
+	m, err := e.message(struct2{})
+	c.Assert(err, IsNil)
+	c.Assert(m.ProcessID, Equals, "pod-7")
+
+	// a ProcessID field wins
+	m, err = e.message(typedEvent{ProcessID: 42})
+	c.Assert(err, IsNil)
+	c.Assert(m.ProcessID, Equals, "42")
+}
+
+func (s *ProcessIDTest) TestLogger(c *C) {
+	t := &recordingTransport{}
+	l := NewLogger(TransportWriter(t), WithProcessID(InstanceProcessID))
+	c.Assert(l.Log(Info, "started"), IsNil)
+	l.ProcessID = nil
+	c.Assert(l.Log(Info, "started"), IsNil)
+	c.Assert(t.sent, HasLen, 2)
+	c.Assert(t.sent[0].ProcessID, Equals, InstanceProcessID())
+	c.Assert(t.sent[1].ProcessID, Equals, PIDProcessID())
+}
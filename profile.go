@@ -0,0 +1,259 @@
+package rfc5424
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxSDNameLength is the RFC-5424 limit on the length of SD-IDs and
+// PARAM-NAMEs, which is not enforced unless allowLongSdNames is false
+const maxSDNameLength = 32
+
+// ParamValuePolicy is how a Profile treats PARAM-VALUEs containing octets
+// outside the printable US-ASCII range (%d32-126), i.e. control characters
+// and non-ASCII UTF-8.
+type ParamValuePolicy int
+
+const (
+	// ParamValuesUTF8 sends values unchanged, as UTF-8
+	ParamValuesUTF8 ParamValuePolicy = iota
+	// ParamValuesASCIIEscape replaces each octet outside %d32-126 with
+	// \xHH, its value in hexadecimal, for collectors that only accept
+	// ASCII.
+	ParamValuesASCIIEscape
+	// ParamValuesReject makes Apply return an error for such values
+	ParamValuesReject
+)
+
+// isPrintableASCIIValue reports whether s only holds octets %d32-126
+func isPrintableASCIIValue(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 32 || s[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+// asciiEscape replaces the octets of s outside %d32-126 with \xHH
+func asciiEscape(s string) string {
+	if isPrintableASCIIValue(s) {
+		return s
+	}
+	b := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 32 || c > 126 {
+			fmt.Fprintf(b, "\\x%02x", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Profile describes the quirks of a destination, so that the same process
+// can emit messages that suit each collector it sends to. The zero Profile
+// sends messages unchanged.
+type Profile struct {
+	// ShortenSDNames shortens SD-IDs and PARAM-NAMEs longer than the 32
+	// characters RFC-5424 allows, for collectors that enforce the limit.
+	// Names are shortened deterministically (see shortenSDName), so each
+	// long name always maps to the same short one.
+	ShortenSDNames bool
+
+	// ParamValues is how PARAM-VALUEs that are not printable US-ASCII are
+	// sent. By default they are sent unchanged.
+	ParamValues ParamValuePolicy
+
+	// Framing is how messages are delimited on stream transports created
+	// with StreamWriter, or by an Encoder using the profile. When it is
+	// NonTransparentFraming, line feeds in MSG are replaced with spaces.
+	Framing Framing
+
+	// MaxLength, if set, is the longest message the destination accepts.
+	// Longer messages are truncated or rejected according to LengthPolicy.
+	MaxLength    int
+	LengthPolicy LengthPolicy
+
+	// TimestampPrecision, if set, is the precision TIMESTAMPs are truncated
+	// to, for collectors that reject fractions they cannot store. Without it
+	// the microseconds of each TIMESTAMP are sent.
+	TimestampPrecision time.Duration
+}
+
+// Profiles for popular collectors, matching their default configuration.
+// Each can be adjusted, e.g. to match a raised length limit:
+//
+//	p := QRadarProfile
+//	p.MaxLength = 32000
+var (
+	// RsyslogProfile suits rsyslog's imtcp and imptcp inputs, which accept
+	// octet-counted messages of up to 8 KiB.
+	RsyslogProfile = Profile{
+		Framing:   OctetCounting,
+		MaxLength: 8192,
+	}
+
+	// SyslogNGProfile suits syslog-ng's syslog() source, which accepts
+	// octet-counted messages of up to 64 KiB.
+	SyslogNGProfile = Profile{
+		Framing:   OctetCounting,
+		MaxLength: 65536,
+	}
+
+	// SplunkProfile suits a Splunk TCP input, which splits the stream into
+	// lines, truncates events at 10000 octets and extracts timestamps to
+	// the millisecond.
+	SplunkProfile = Profile{
+		Framing:            NonTransparentFraming,
+		MaxLength:          10000,
+		TimestampPrecision: time.Millisecond,
+	}
+
+	// QRadarProfile suits a QRadar syslog log source, which splits the
+	// stream into lines, truncates payloads at 4096 octets, and handles
+	// neither long SD names nor non-ASCII PARAM-VALUEs.
+	QRadarProfile = Profile{
+		ShortenSDNames:     true,
+		ParamValues:        ParamValuesASCIIEscape,
+		Framing:            NonTransparentFraming,
+		MaxLength:          4096,
+		TimestampPrecision: time.Millisecond,
+	}
+
+	// GraylogProfile suits a Graylog syslog TCP input, which splits the
+	// stream into lines and stores timestamps to the millisecond.
+	GraylogProfile = Profile{
+		Framing:            NonTransparentFraming,
+		TimestampPrecision: time.Millisecond,
+	}
+)
+
+var namedProfiles = map[string]Profile{
+	"rsyslog":   RsyslogProfile,
+	"syslog-ng": SyslogNGProfile,
+	"splunk":    SplunkProfile,
+	"qradar":    QRadarProfile,
+	"graylog":   GraylogProfile,
+}
+
+// LookupProfile returns the profile for the named collector: "rsyslog",
+// "syslog-ng", "splunk", "qradar" or "graylog". The name is not case
+// sensitive. This allows the destination to be chosen in configuration.
+func LookupProfile(name string) (Profile, bool) {
+	p, ok := namedProfiles[strings.ToLower(name)]
+	return p, ok
+}
+
+// shortenSDName returns name if it is at most 32 characters long. Otherwise
+// it returns a 32 character name made of a prefix of name, "~", and 8 hex
+// digits of the FNV-1a hash of name. For SD-IDs of the form name@pen only
+// the name is shortened.
+func shortenSDName(name string) string {
+	if len(name) <= maxSDNameLength {
+		return name
+	}
+	suffix := ""
+	if i := strings.LastIndexByte(name, '@'); i > 0 && len(name)-i < maxSDNameLength-9 {
+		name, suffix = name[:i], name[i:]
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name + suffix))
+	prefix := name[:maxSDNameLength-len(suffix)-9]
+	return fmt.Sprintf("%s~%08x%s", prefix, h.Sum32(), suffix)
+}
+
+// Apply returns m adapted to the profile. m itself is not modified.
+func (p Profile) Apply(m Message) (Message, error) {
+	if p.TimestampPrecision > 0 {
+		m.Timestamp = m.Timestamp.Truncate(p.TimestampPrecision)
+	}
+	if p.Framing == NonTransparentFraming && bytes.IndexByte(m.Message, '\n') >= 0 {
+		m.Message = bytes.Replace(m.Message, []byte{'\n'}, []byte{' '}, -1)
+	}
+	if p.ShortenSDNames || p.ParamValues != ParamValuesUTF8 {
+		sds, err := p.applyStructuredData(m.StructuredData)
+		if err != nil {
+			return m, err
+		}
+		m.StructuredData = sds
+	}
+	if p.MaxLength > 0 {
+		limited, _, err := limitLength(m, p.MaxLength, p.LengthPolicy)
+		return limited, err
+	}
+	return m, nil
+}
+
+// applyStructuredData returns a copy of sds adapted to the profile
+func (p Profile) applyStructuredData(sds []StructuredData) ([]StructuredData, error) {
+	applied := make([]StructuredData, len(sds))
+	for i, sd := range sds {
+		params := make([]SDParam, len(sd.Parameters))
+		for j, param := range sd.Parameters {
+			switch p.ParamValues {
+			case ParamValuesASCIIEscape:
+				param.Value = asciiEscape(param.Value)
+			case ParamValuesReject:
+				if !isPrintableASCIIValue(param.Value) {
+					return nil, errorInvalidValue{Property: fmt.Sprintf("StructuredData[%s]/%s", sd.ID, param.Name),
+						Value: param.Value, reason: "the destination only accepts printable US-ASCII"}
+				}
+			}
+			if p.ShortenSDNames {
+				param.Name = shortenSDName(param.Name)
+			}
+			params[j] = param
+		}
+		if p.ShortenSDNames {
+			sd.ID = shortenSDName(sd.ID)
+		}
+		applied[i] = StructuredData{ID: sd.ID, Parameters: params}
+	}
+	return applied, nil
+}
+
+// profileWriter is the MessageWriter returned by Profile.Writer
+type profileWriter struct {
+	profile Profile
+	writer  MessageWriter
+}
+
+func (pw profileWriter) WriteMessage(m Message) error {
+	m, err := pw.profile.Apply(m)
+	if err != nil {
+		return err
+	}
+	return pw.writer.WriteMessage(m)
+}
+
+// writeMarshaled passes b on when the profile leaves m unchanged
+func (pw profileWriter) writeMarshaled(m Message, b []byte) error {
+	p := pw.profile
+	if p.TimestampPrecision > 0 || p.ShortenSDNames || p.ParamValues != ParamValuesUTF8 ||
+		(p.Framing == NonTransparentFraming && bytes.IndexByte(m.Message, '\n') >= 0) ||
+		(p.MaxLength > 0 && len(b) > p.MaxLength) {
+		return pw.WriteMessage(m)
+	}
+	return writeMarshaled(pw.writer, m, b)
+}
+
+func (pw profileWriter) Close() error {
+	return pw.writer.Close()
+}
+
+// Writer returns a MessageWriter that adapts each message to the profile
+// before writing it to w
+func (p Profile) Writer(w MessageWriter) MessageWriter {
+	return profileWriter{profile: p, writer: w}
+}
+
+// StreamWriter returns a MessageWriter that adapts each message to the
+// profile and writes it to w with the profile's Framing
+func (p Profile) StreamWriter(w io.Writer) MessageWriter {
+	return p.Writer(&StreamWriter{Writer: w, Framing: p.Framing})
+}
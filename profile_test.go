@@ -0,0 +1,128 @@
+package rfc5424
+
+import (
+	"bytes"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&ProfileTest{})
+
+type ProfileTest struct {
+}
+
+func (s *ProfileTest) TestShortenSDNames(c *C) {
+	long := strings.Repeat("a", 40)
+	m := Message{StructuredData: []StructuredData{
+		{ID: long + "@32473", Parameters: []SDParam{{Name: long, Value: "1"}, {Name: "short", Value: "2"}}},
+		{ID: "x@1"},
+	}}
+
+	p := Profile{ShortenSDNames: true}
+	shortened, err := p.Apply(m)
+	c.Assert(err, IsNil)
+	sd := shortened.StructuredData[0]
+	c.Assert(sd.ID, Matches, `a{17}~[0-9a-f]{8}@32473`)
+	c.Assert(sd.ID, HasLen, 32)
+	c.Assert(sd.Parameters[0].Name, Matches, `a{23}~[0-9a-f]{8}`)
+	c.Assert(sd.Parameters[1].Name, Equals, "short")
+	c.Assert(shortened.StructuredData[1].ID, Equals, "x@1")
+
+	// Deterministic, and distinct names stay distinct
+	again, _ := p.Apply(m)
+	c.Assert(again, DeepEquals, shortened)
+	c.Assert(shortenSDName(long+"b"), Not(Equals), shortenSDName(long+"c"))
+
+	// The message itself is unchanged, and the zero profile changes nothing
+	c.Assert(m.StructuredData[0].ID, Equals, long+"@32473")
+	unchanged, err := Profile{}.Apply(m)
+	c.Assert(err, IsNil)
+	c.Assert(unchanged, DeepEquals, m)
+}
+
+func (s *ProfileTest) TestParamValues(c *C) {
+	m := Message{StructuredData: []StructuredData{
+		{ID: "x@1", Parameters: []SDParam{{Name: "plain", Value: `a "quoted" value`}, {Name: "v", Value: "café\ttab"}}},
+	}}
+
+	escaped, err := Profile{ParamValues: ParamValuesASCIIEscape}.Apply(m)
+	c.Assert(err, IsNil)
+	c.Assert(escaped.StructuredData[0].Parameters, DeepEquals, []SDParam{
+		{Name: "plain", Value: `a "quoted" value`},
+		{Name: "v", Value: `caf\xc3\xa9\x09tab`},
+	})
+	c.Assert(m.StructuredData[0].Parameters[1].Value, Equals, "café\ttab")
+
+	_, err = Profile{ParamValues: ParamValuesReject}.Apply(m)
+	c.Assert(err, ErrorMatches, `.*StructuredData\[x@1\]/v is invalid.*printable US-ASCII.*`)
+
+	unchanged, err := Profile{ParamValues: ParamValuesUTF8}.Apply(m)
+	c.Assert(err, IsNil)
+	c.Assert(unchanged, DeepEquals, m)
+}
+
+func (s *ProfileTest) TestCollectorProfiles(c *C) {
+	m := Message{
+		Priority:  14,
+		Timestamp: T("2003-10-11T22:14:15.123456Z"),
+		Hostname:  "host",
+		StructuredData: []StructuredData{
+			{ID: "x@1", Parameters: []SDParam{{Name: "v", Value: "café"}}},
+		},
+		Message: []byte("first line\nsecond line"),
+	}
+
+	applied, err := QRadarProfile.Apply(m)
+	c.Assert(err, IsNil)
+	b, err := applied.MarshalBinary()
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `<14>1 2003-10-11T22:14:15.123Z host - - - [x@1 v="caf\\xc3\\xa9"] first line second line`)
+
+	applied, err = RsyslogProfile.Apply(m)
+	c.Assert(err, IsNil)
+	c.Assert(applied, DeepEquals, m)
+
+	p, ok := LookupProfile("Syslog-NG")
+	c.Assert(ok, Equals, true)
+	c.Assert(p, DeepEquals, SyslogNGProfile)
+	_, ok = LookupProfile("unknown")
+	c.Assert(ok, Equals, false)
+}
+
+func (s *ProfileTest) TestMaxLength(c *C) {
+	m := Message{Priority: 14, Message: []byte("0123456789")}
+
+	p := Profile{MaxLength: 20}
+	applied, err := p.Apply(m)
+	c.Assert(err, IsNil)
+	c.Assert(string(applied.Message), Equals, "01")
+
+	p.LengthPolicy = RejectLongMessages
+	_, err = p.Apply(m)
+	c.Assert(err, Equals, ErrMessageTooLong)
+}
+
+func (s *ProfileTest) TestStreamWriter(c *C) {
+	m := Message{Priority: 14, Timestamp: T("2003-10-11T22:14:15.123456Z"), Message: []byte("a\nb")}
+
+	buf := &bytes.Buffer{}
+	w := SplunkProfile.StreamWriter(buf)
+	c.Assert(w.WriteMessage(m), IsNil)
+	c.Assert(w.WriteMessage(m), IsNil)
+	c.Assert(buf.String(), Equals, "<14>1 2003-10-11T22:14:15.123Z - - - - - a b\n"+
+		"<14>1 2003-10-11T22:14:15.123Z - - - - - a b\n")
+
+	buf.Reset()
+	w = RsyslogProfile.StreamWriter(buf)
+	c.Assert(w.WriteMessage(m), IsNil)
+	c.Assert(buf.String(), Equals, "47 <14>1 2003-10-11T22:14:15.123456Z - - - - - a\nb")
+
+	// A line feed elsewhere cannot be replaced
+	buf.Reset()
+	sw := &StreamWriter{Writer: buf, Framing: NonTransparentFraming}
+	m.Message = []byte("a b")
+	m.StructuredData = []StructuredData{{ID: "x@1", Parameters: []SDParam{{Name: "v", Value: "\n"}}}}
+	c.Assert(sw.WriteMessage(m), ErrorMatches, `(?s).*StructuredData is invalid.*line feed.*`)
+	c.Assert(buf.Len(), Equals, 0)
+}
@@ -0,0 +1,136 @@
+package rfc5424
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// The Generate methods below implement testing/quick's Generator, so that
+// property-based tests can be run against arbitrary valid messages. The
+// messages they return pass the strict checks (see StrictSDIDs) and survive
+// being marshaled and unmarshaled, except that timestamps come back in a
+// fixed zone with the same offset.
+
+// quickValueRunes are the runes of generated PARAM-VALUEs and MSGs besides
+// printable ASCII, to exercise escaping and multi-byte UTF-8
+var quickValueRunes = []rune{' ', '"', '\\', ']', 'é', 'ß', '日', '本', '€', '😀'}
+
+// quickLength returns a random length from min to max, or to size if it is
+// smaller
+func quickLength(rand *rand.Rand, min, max, size int) int {
+	if size < max {
+		max = size
+	}
+	if max <= min {
+		return min
+	}
+	return min + rand.Intn(max-min+1)
+}
+
+// quickString returns a random string of n characters for which ok returns
+// true, from PRINTUSASCII
+func quickString(rand *rand.Rand, n int, ok func(byte) bool) string {
+	b := make([]byte, 0, n)
+	for len(b) < n {
+		if ch := byte(33 + rand.Intn(94)); ok == nil || ok(ch) {
+			b = append(b, ch)
+		}
+	}
+	return string(b)
+}
+
+// quickField returns a random header field of at most max characters. It
+// is empty (NILVALUE) a quarter of the time, and never "-".
+func quickField(rand *rand.Rand, max, size int) string {
+	if rand.Intn(4) == 0 {
+		return ""
+	}
+	s := quickString(rand, quickLength(rand, 1, max, size), nil)
+	if s == "-" {
+		s = "_"
+	}
+	return s
+}
+
+// quickText returns a random UTF-8 string of at most max runes
+func quickText(rand *rand.Rand, max, size int) string {
+	runes := make([]rune, quickLength(rand, 0, max, size))
+	for i := range runes {
+		if rand.Intn(8) == 0 {
+			runes[i] = quickValueRunes[rand.Intn(len(quickValueRunes))]
+		} else {
+			runes[i] = rune(33 + rand.Intn(94))
+		}
+	}
+	return string(runes)
+}
+
+// quickSdName returns a random SD-NAME, without '@' so that it can be the
+// name part of an SD-ID
+func quickSdName(rand *rand.Rand, max, size int) string {
+	return quickString(rand, quickLength(rand, 1, max, size), func(ch byte) bool {
+		return sdNameChars[ch] && ch != '@'
+	})
+}
+
+// quickTimestamp returns a random TIMESTAMP between 1970 and 2100 with
+// microsecond precision, or the zero time (NILVALUE) a tenth of the time
+func quickTimestamp(rand *rand.Rand) time.Time {
+	if rand.Intn(10) == 0 {
+		return time.Time{}
+	}
+	t := time.Unix(rand.Int63n(130*365*24*3600), int64(rand.Intn(1e6))*1e3)
+	if rand.Intn(2) == 0 {
+		return t.UTC()
+	}
+	offset := (rand.Intn(2*14*60+1) - 14*60) * 60
+	return t.In(time.FixedZone("", offset))
+}
+
+// Generate returns a random SDParam with a name of at most 32 characters
+func (SDParam) Generate(rand *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(SDParam{
+		Name:  quickSdName(rand, 32, size),
+		Value: quickText(rand, 64, size),
+	})
+}
+
+// Generate returns a random StructuredData with an SD-ID of the form
+// "name@pen" and up to four parameters
+func (StructuredData) Generate(rand *rand.Rand, size int) reflect.Value {
+	pen := strconv.Itoa(1 + rand.Intn(99999))
+	sd := StructuredData{ID: quickSdName(rand, 31-len(pen), size) + "@" + pen}
+	for i, n := 0, quickLength(rand, 0, 4, size); i < n; i++ {
+		sd.Parameters = append(sd.Parameters, SDParam{}.Generate(rand, size).Interface().(SDParam))
+	}
+	return reflect.ValueOf(sd)
+}
+
+// Generate returns a random Message. Its SD-IDs are unique, and its MSG is
+// either empty, UTF-8 text starting with a BOM, or printable ASCII.
+func (Message) Generate(rand *rand.Rand, size int) reflect.Value {
+	m := Message{
+		Priority:  rand.Intn(192),
+		Timestamp: quickTimestamp(rand),
+		Hostname:  quickField(rand, 255, size),
+		AppName:   quickField(rand, 48, size),
+		ProcessID: quickField(rand, 128, size),
+		MessageID: quickField(rand, 32, size),
+	}
+	for i, n := 0, quickLength(rand, 0, 3, size); i < n; i++ {
+		sd := StructuredData{}.Generate(rand, size).Interface().(StructuredData)
+		if m.SD(sd.ID) == nil {
+			m.StructuredData = append(m.StructuredData, sd)
+		}
+	}
+	switch rand.Intn(3) {
+	case 0:
+	case 1:
+		m.SetTextMessage(quickText(rand, 256, size))
+	case 2:
+		m.Message = []byte(quickString(rand, quickLength(rand, 1, 256, size), nil))
+	}
+	return reflect.ValueOf(m)
+}
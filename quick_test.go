@@ -0,0 +1,49 @@
+package rfc5424
+
+import (
+	"testing/quick"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&QuickTest{})
+
+type QuickTest struct{}
+
+func (s *QuickTest) TestGeneratedMessagesRoundTrip(c *C) {
+	defer func() { StrictSDIDs = false }()
+	StrictSDIDs = true
+
+	roundTrip := func(m Message) bool {
+		b, err := m.MarshalBinary()
+		if err != nil {
+			c.Logf("%#v: %s", m, err)
+			return false
+		}
+		var m2 Message
+		if err := m2.UnmarshalBinary(b); err != nil {
+			c.Logf("%q: %s", b, err)
+			return false
+		}
+		b2, err := m2.MarshalBinary()
+		if err != nil || string(b2) != string(b) {
+			c.Logf("%q became %q (%v)", b, b2, err)
+			return false
+		}
+		return m2.Timestamp.Equal(m.Timestamp) && m2.Hostname == m.Hostname &&
+			len(m2.StructuredData) == len(m.StructuredData) && string(m2.Message) == string(m.Message)
+	}
+	c.Assert(quick.Check(roundTrip, &quick.Config{MaxCount: 500}), IsNil)
+}
+
+func (s *QuickTest) TestGeneratedStructuredData(c *C) {
+	valid := func(sd StructuredData, param SDParam) bool {
+		if _, _, err := ParseSDID(sd.ID); err != nil {
+			return false
+		}
+		m := Message{StructuredData: []StructuredData{sd}}
+		m.AddDatum(sd.ID, param.Name, param.Value)
+		return m.validate(true) == nil
+	}
+	c.Assert(quick.Check(valid, nil), IsNil)
+}
@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package rfc5424
+
+// raceEnabled is true when the tests run under the race detector, which
+// makes sync.Pool drop items and so changes allocation counts
+const raceEnabled = true
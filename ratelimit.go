@@ -0,0 +1,170 @@
+package rfc5424
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const rateLimitSweepInterval = time.Minute
+
+// RateLimitOverflow is what a RateLimiter does with messages over the limit
+type RateLimitOverflow int
+
+const (
+	// RateLimitDrop drops messages over the limit, counting them in Dropped
+	RateLimitDrop RateLimitOverflow = iota
+	// RateLimitNotify drops messages over the limit and, once the source
+	// is allowed to send again, passes on a notice saying how many messages
+	// were dropped before the next message.
+	RateLimitNotify
+)
+
+// SourceIPKey is a RateLimiter key function that limits each sender IP address
+func SourceIPKey(m Message, src Source) string {
+	return sourceIP(src)
+}
+
+// HostnameKey is a RateLimiter key function that limits each HOSTNAME
+func HostnameKey(m Message, src Source) string {
+	return m.Hostname
+}
+
+// tokenBucket holds the state of a single RateLimiter key
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	dropped int
+}
+
+// RateLimiter limits the rate of messages passed to a Handler from each
+// source, protecting a collector from a single chatty host. Each source may
+// send a burst of Burst messages, after which it is limited to Rate messages
+// per second.
+//
+// A RateLimiter is used as Middleware via its Wrap method:
+//
+//	h = rfc5424.Chain(h, limiter.Wrap)
+type RateLimiter struct {
+	// Rate is the sustained number of messages per second allowed from each
+	// source. If zero or negative, messages are not limited, rather than
+	// each source being allowed a single burst for good.
+	Rate float64
+
+	// Burst is the number of messages a source may send at once. If zero,
+	// Rate rounded up (and at least one) is used.
+	Burst int
+
+	// Key returns the source of a message. If nil, SourceIPKey is used.
+	Key func(m Message, src Source) string
+
+	// Overflow is what to do with messages over the limit
+	Overflow RateLimitOverflow
+
+	dropped   uint64 // accessed atomically
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// Dropped returns the number of messages dropped by the limiter
+func (rl *RateLimiter) Dropped() uint64 {
+	return atomic.LoadUint64(&rl.dropped)
+}
+
+func (rl *RateLimiter) burst() float64 {
+	if rl.Burst > 0 {
+		return float64(rl.Burst)
+	}
+	if rl.Rate < 1 {
+		return 1
+	}
+	return float64(int(rl.Rate + 0.999))
+}
+
+// allow reports whether a message from key may be passed on and, if so, how
+// many messages from key were dropped since the last one that was.
+func (rl *RateLimiter) allow(key string) (bool, int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := TimeNow()
+	if rl.buckets == nil {
+		rl.buckets = map[string]*tokenBucket{}
+		rl.lastSweep = now
+	}
+	if now.Sub(rl.lastSweep) >= rateLimitSweepInterval {
+		rl.sweep(now)
+	}
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: rl.burst(), updated: now}
+		rl.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.updated).Seconds() * rl.Rate
+	if b.tokens > rl.burst() {
+		b.tokens = rl.burst()
+	}
+	b.updated = now
+
+	if b.tokens < 1 {
+		b.dropped++
+		return false, 0
+	}
+	b.tokens--
+	dropped := b.dropped
+	b.dropped = 0
+	return true, dropped
+}
+
+// sweep forgets sources whose buckets have refilled, so that the limiter
+// doesn't grow without bound. The caller must hold rl.mu.
+func (rl *RateLimiter) sweep(now time.Time) {
+	for key, b := range rl.buckets {
+		if b.dropped == 0 && b.tokens+now.Sub(b.updated).Seconds()*rl.Rate >= rl.burst() {
+			delete(rl.buckets, key)
+		}
+	}
+	rl.lastSweep = now
+}
+
+// Wrap returns a Handler that passes messages to next unless their source
+// is over the limit.
+func (rl *RateLimiter) Wrap(next Handler) Handler {
+	return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+		if rl.Rate <= 0 {
+			next.Handle(ctx, m, src)
+			return
+		}
+		key := SourceIPKey
+		if rl.Key != nil {
+			key = rl.Key
+		}
+		k := key(m, src)
+		ok, dropped := rl.allow(k)
+		if !ok {
+			atomic.AddUint64(&rl.dropped, 1)
+			return
+		}
+		if dropped > 0 && rl.Overflow == RateLimitNotify {
+			next.Handle(ctx, rateLimitNotice(m, k, dropped), src)
+		}
+		next.Handle(ctx, m, src)
+	})
+}
+
+// rateLimitNotice returns a message reporting that `dropped` messages from
+// key were dropped. It has the HOSTNAME of m, the next message from key.
+func rateLimitNotice(m Message, key string, dropped int) Message {
+	return Message{
+		Priority:  int(Warning-Emergency) | int(Syslog-Kernel)<<3,
+		Timestamp: TimeNow().UTC(),
+		Hostname:  m.Hostname,
+		AppName:   "rfc5424",
+		MessageID: "RATELIMIT",
+		Message:   []byte(fmt.Sprintf("rate limited: dropped %d messages from %s", dropped, key)),
+	}
+}
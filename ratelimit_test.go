@@ -0,0 +1,84 @@
+package rfc5424
+
+import (
+	"context"
+	"net"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&RateLimitTest{})
+
+type RateLimitTest struct {
+}
+
+func (s *RateLimitTest) TestPerSourceLimit(c *C) {
+	now := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	TimeNow = func() time.Time { return now }
+	defer func() { TimeNow = time.Now }()
+
+	h := make(chanHandler, 100)
+	rl := &RateLimiter{Rate: 1, Burst: 2, Overflow: RateLimitNotify}
+	limited := Chain(h, rl.Wrap)
+
+	chatty := Source{RemoteAddr: &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 514}}
+	quiet := Source{RemoteAddr: &net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 514}}
+	for i := 0; i < 5; i++ {
+		limited.Handle(context.Background(), Message{Hostname: "chatty"}, chatty)
+	}
+	limited.Handle(context.Background(), Message{Hostname: "quiet"}, quiet)
+
+	// The burst from the chatty host is passed on, the rest dropped, and
+	// the quiet host is unaffected.
+	c.Assert(rl.Dropped(), Equals, uint64(3))
+	c.Assert((<-h).Message.Hostname, Equals, "chatty")
+	c.Assert((<-h).Message.Hostname, Equals, "chatty")
+	c.Assert((<-h).Message.Hostname, Equals, "quiet")
+	c.Assert(len(h), Equals, 0)
+
+	// Once a token is available the next message is preceded by a notice
+	now = now.Add(time.Second)
+	limited.Handle(context.Background(), Message{Hostname: "chatty", MessageID: "next"}, chatty)
+	notice := (<-h).Message
+	c.Assert(notice.MessageID, Equals, "RATELIMIT")
+	c.Assert(notice.Hostname, Equals, "chatty")
+	c.Assert(notice.Severity(), Equals, Severity(Warning))
+	c.Assert(notice.Facility(), Equals, Facility(Syslog))
+	c.Assert(string(notice.Message), Equals, "rate limited: dropped 3 messages from 10.0.0.1")
+	c.Assert((<-h).Message.MessageID, Equals, "next")
+	c.Assert(len(h), Equals, 0)
+}
+
+func (s *RateLimitTest) TestDropByHostname(c *C) {
+	now := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	TimeNow = func() time.Time { return now }
+	defer func() { TimeNow = time.Now }()
+
+	h := make(chanHandler, 100)
+	rl := &RateLimiter{Rate: 1, Key: HostnameKey}
+	limited := rl.Wrap(h)
+
+	for i := 0; i < 3; i++ {
+		limited.Handle(context.Background(), Message{Hostname: "a"}, Source{})
+	}
+	now = now.Add(time.Second)
+	limited.Handle(context.Background(), Message{Hostname: "a"}, Source{})
+
+	// No notice is synthesized when dropping
+	c.Assert(rl.Dropped(), Equals, uint64(2))
+	c.Assert(len(h), Equals, 2)
+}
+
+func (s *RateLimitTest) TestZeroRate(c *C) {
+	h := make(chanHandler, 100)
+	rl := &RateLimiter{Burst: 1}
+	limited := rl.Wrap(h)
+
+	for i := 0; i < 5; i++ {
+		limited.Handle(context.Background(), Message{}, Source{})
+	}
+	c.Assert(rl.Dropped(), Equals, uint64(0))
+	c.Assert(len(h), Equals, 5)
+	c.Assert(rl.buckets, HasLen, 0)
+}
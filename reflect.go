@@ -1,37 +1,15 @@
 package rfc5424
 
 import (
+	"fmt"
 	"log"
-	"os"
-	"path"
 	"reflect"
-	"regexp"
-	"strconv"
 	"strings"
 )
 
 const (
-	defaultSeverity         = Info
-	defaultFacility         = Local0
-	defaultStructuredDataID = "0@local"
-)
-
-var (
-	defaultHostname = func() string {
-		h, err := os.Hostname()
-		if err != nil {
-			panic(err)
-		}
-		return h
-	}()
-
-	defaultAppName = func() string {
-		return path.Base(os.Args[0])
-	}()
-
-	defaultProcessID = func() string {
-		return strconv.FormatInt(int64(os.Getpid()), 10)
-	}()
+	defaultSeverity = Info
+	defaultFacility = Local0
 )
 
 type reflection struct {
@@ -45,6 +23,7 @@ type reflection struct {
 	AppNameFieldIndex              int
 	AppNameDefault                 string
 	ProcessIDFieldIndex            int
+	ProcessIDFormat                valueFormat
 	MessageIDFieldIndex            int
 	MessageIDDefault               string
 	MessageFieldIndex              int
@@ -55,8 +34,45 @@ type reflection struct {
 type structuredDataFieldReflection struct {
 	FieldIndex int
 	OmitEmpty  bool
+	Encrypt    bool
 	FieldName  string
 	SdID       string
+	Format     valueFormat
+}
+
+// valueFormat is how a field is converted to a PROCID or PARAM-VALUE. It is
+// chosen from the field's type when the struct is reflected, so that
+// encoding does not have to inspect each value.
+type valueFormat int
+
+const (
+	formatString valueFormat = iota
+	formatInt
+	formatUint
+	formatBool
+	formatFloat
+	formatOther // fmt.Sprint, e.g. for fmt.Stringers
+)
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+func formatOf(t reflect.Type) valueFormat {
+	if t.Implements(stringerType) {
+		return formatOther
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return formatString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return formatInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return formatUint
+	case reflect.Bool:
+		return formatBool
+	case reflect.Float32, reflect.Float64:
+		return formatFloat
+	}
+	return formatOther
 }
 
 func (r *reflection) GetStructuredDataFieldReflection(
@@ -89,7 +105,33 @@ func Reflect(t reflect.Type) *reflection {
 	return r
 }
 
-var sdRegexp = regexp.MustCompile("^(\\d+@\\S+)( (.*))?$")
+// isDigits reports whether s is a non-empty string of decimal digits
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// parseSDTag parses tags naming an SD-ID, either "name@pen" or the older
+// "pen@name", optionally followed by a space and the parameter name. ok is
+// false if the tag does not start with an SD-ID.
+func parseSDTag(tag string) (sdID, name string, ok bool) {
+	sdID = tag
+	if i := strings.IndexByte(tag, ' '); i >= 0 {
+		sdID, name = tag[:i], tag[i+1:]
+	}
+	at := strings.IndexByte(sdID, '@')
+	if at <= 0 || at == len(sdID)-1 {
+		return "", "", false
+	}
+	if !isDigits(sdID[at+1:]) && !isDigits(sdID[:at]) {
+		return "", "", false
+	}
+	return sdID, name, true
+}
 
 func reflectImpl(t reflect.Type) *reflection {
 	r := reflection{
@@ -101,7 +143,6 @@ func reflectImpl(t reflect.Type) *reflection {
 		TimestampFieldIndex:            -1,
 		HostnameFieldIndex:             -1,
 		AppNameFieldIndex:              -1,
-		AppNameDefault:                 defaultAppName,
 		ProcessIDFieldIndex:            -1,
 		MessageIDFieldIndex:            -1,
 		MessageIDDefault:               t.Name(),
@@ -116,8 +157,8 @@ func reflectImpl(t reflect.Type) *reflection {
 		case "Severity":
 			r.SeverityFieldIndex = fieldIndex
 			if fieldTag != "" {
-				severity, ok := severityNames[fieldTag]
-				if !ok {
+				severity, err := ParseSeverity(fieldTag)
+				if err != nil {
 					panic("invalid tag on Severity field")
 				}
 				r.SeverityDefault = severity
@@ -125,8 +166,8 @@ func reflectImpl(t reflect.Type) *reflection {
 		case "Facility":
 			r.FacilityFieldIndex = fieldIndex
 			if fieldTag != "" {
-				facility, ok := facilityNames[fieldTag]
-				if !ok {
+				facility, err := ParseFacility(fieldTag)
+				if err != nil {
 					panic("invalid tag on Facility field")
 				}
 				r.FacilityDefault = facility
@@ -142,6 +183,7 @@ func reflectImpl(t reflect.Type) *reflection {
 			}
 		case "ProcessID":
 			r.ProcessIDFieldIndex = fieldIndex
+			r.ProcessIDFormat = formatOf(field.Type)
 		case "MessageID":
 			r.MessageIDFieldIndex = fieldIndex
 			if fieldTag != "" {
@@ -173,16 +215,13 @@ func reflectImpl(t reflect.Type) *reflection {
 			fieldReflection := structuredDataFieldReflection{}
 			fieldReflection.FieldIndex = fieldIndex
 			fieldReflection.FieldName = tagParts[0]
-			if r.SDIDDefault != "" {
-				fieldReflection.SdID = r.SDIDDefault
-			} else {
-				fieldReflection.SdID = defaultStructuredDataID
-			}
+			fieldReflection.Format = formatOf(field.Type)
+			// If empty, DefaultSDID is used when encoding
+			fieldReflection.SdID = r.SDIDDefault
 
-			matches := sdRegexp.FindAllStringSubmatch(fieldReflection.FieldName, -1)
-			if matches != nil {
-				fieldReflection.SdID = matches[0][1]
-				fieldReflection.FieldName = matches[0][3]
+			if sdID, name, ok := parseSDTag(fieldReflection.FieldName); ok {
+				fieldReflection.SdID = sdID
+				fieldReflection.FieldName = name
 			}
 
 			if fieldReflection.FieldName == "" {
@@ -192,9 +231,11 @@ func reflectImpl(t reflect.Type) *reflection {
 
 			if len(tagParts) > 1 {
 				for _, tagAttr := range tagParts[1:] {
-					switch tagParts[1] {
+					switch tagAttr {
 					case "omitempty":
 						fieldReflection.OmitEmpty = true
+					case "encrypt":
+						fieldReflection.Encrypt = true
 					default:
 						log.Panicf("unknown tag %s on field %s of %s",
 							tagAttr, field.Name, t.Name())
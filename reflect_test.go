@@ -39,6 +39,7 @@ var expectedReflection1 = reflection{
 	AppNameFieldIndex:   4,
 	AppNameDefault:      "myAppName",
 	ProcessIDFieldIndex: 5,
+	ProcessIDFormat:     formatInt,
 	MessageIDFieldIndex: 6,
 	MessageIDDefault:    "struct1",
 	MessageFieldIndex:   7,
@@ -47,21 +48,20 @@ var expectedReflection1 = reflection{
 			FieldIndex: 8,
 			FieldName:  "myCustomInt",
 			SdID:       "9999@custom",
+			Format:     formatInt,
 		},
 		structuredDataFieldReflection{
 			FieldIndex: 9,
 			FieldName:  "myCustomString",
-			SdID:       "0@local",
 		},
 		structuredDataFieldReflection{
 			FieldIndex: 10,
 			FieldName:  "myCustomBool",
-			SdID:       "0@local",
+			Format:     formatBool,
 		},
 		structuredDataFieldReflection{
 			FieldIndex: 12,
 			FieldName:  "myUnexportedTaggedValue",
-			SdID:       "0@local",
 		},
 	},
 }
@@ -84,7 +84,6 @@ var expectedReflection2 = reflection{
 	TimestampFieldIndex: -1,
 	HostnameFieldIndex:  -1,
 	AppNameFieldIndex:   -1,
-	AppNameDefault:      "rfc5424.test",
 	ProcessIDFieldIndex: -1,
 	MessageIDFieldIndex: -1,
 	MessageIDDefault:    "struct2",
@@ -95,6 +94,7 @@ var expectedReflection2 = reflection{
 			FieldIndex: 1,
 			FieldName:  "myCustomInt",
 			SdID:       "1234@demo",
+			Format:     formatInt,
 		},
 		structuredDataFieldReflection{
 			FieldIndex: 2,
@@ -105,6 +105,7 @@ var expectedReflection2 = reflection{
 			FieldIndex: 3,
 			FieldName:  "myCustomBool",
 			SdID:       "1234@demo",
+			Format:     formatBool,
 		},
 		structuredDataFieldReflection{
 			FieldIndex: 5,
@@ -136,8 +137,8 @@ var expectedReflection3 = reflection{
 	TimestampFieldIndex: 2,
 	HostnameFieldIndex:  3,
 	AppNameFieldIndex:   4,
-	AppNameDefault:      "rfc5424.test",
 	ProcessIDFieldIndex: 5,
+	ProcessIDFormat:     formatInt,
 	MessageIDFieldIndex: 6,
 	MessageIDDefault:    "struct3",
 	MessageFieldIndex:   8,
@@ -145,7 +146,6 @@ var expectedReflection3 = reflection{
 		structuredDataFieldReflection{
 			FieldIndex: 9,
 			FieldName:  "myCustomString",
-			SdID:       "0@local",
 		},
 	},
 }
@@ -169,3 +169,27 @@ func (s *ReflectTest) TestCanReflect(c *C) {
 	}
 
 }
+
+func (s *ReflectTest) TestParseSDTag(c *C) {
+	for _, t := range []struct {
+		tag, sdID, name string
+		ok              bool
+	}{
+		{"xyz@5516", "xyz@5516", "", true},
+		{"xyz@5516 sessionID", "xyz@5516", "sessionID", true},
+		{"5516@xyz name with spaces", "5516@xyz", "name with spaces", true},
+		{"5516@a@b", "5516@a@b", "", true},
+		{"xyz@5516 ", "xyz@5516", "", true},
+		{"name", "", "", false},
+		{"a@b", "", "", false},
+		{"@5516", "", "", false},
+		{"5516@", "", "", false},
+		{"x@y@5516", "", "", false},
+		{"", "", "", false},
+	} {
+		sdID, name, ok := parseSDTag(t.tag)
+		c.Assert(ok, Equals, t.ok, Commentf("%q", t.tag))
+		c.Assert(sdID, Equals, t.sdID, Commentf("%q", t.tag))
+		c.Assert(name, Equals, t.name, Commentf("%q", t.tag))
+	}
+}
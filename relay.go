@@ -0,0 +1,86 @@
+package rfc5424
+
+import (
+	"context"
+	"net"
+)
+
+// Rewrite modifies a received message before a Relay forwards it
+type Rewrite func(m *Message, src Source)
+
+// Relay is a Handler that forwards received messages to Writer, which may be
+// any MessageWriter (a StreamWriter over TCP or TLS, a MultiMessageWriter,
+// ...). Combined with a Server it acts as a programmable syslog relay.
+// Messages that are not rewritten are forwarded exactly as received, unless
+// they use one of the optional forms listed by rfc5424test.Fidelity.
+type Relay struct {
+	Writer MessageWriter
+
+	// Accept, if set, is called for each message before it is rewritten.
+	// Messages for which it returns false are dropped.
+	Accept func(m Message, src Source) bool
+
+	// Rewrites are applied to each accepted message in order
+	Rewrites []Rewrite
+
+	// Error, if set, is called with errors returned by Writer
+	Error func(err error)
+}
+
+// Handle filters, rewrites and forwards a single message
+func (r *Relay) Handle(ctx context.Context, m Message, src Source) {
+	if r.Accept != nil && !r.Accept(m, src) {
+		return
+	}
+	for _, rewrite := range r.Rewrites {
+		rewrite(&m, src)
+	}
+	if err := r.Writer.WriteMessage(m); err != nil && r.Error != nil {
+		r.Error(err)
+	}
+}
+
+// Close closes Writer
+func (r *Relay) Close() error {
+	return r.Writer.Close()
+}
+
+// sourceIP returns the IP address of the sender, or "" if it isn't known
+func sourceIP(src Source) string {
+	if src.RemoteAddr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(src.RemoteAddr.String())
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// SetHostname returns a Rewrite that replaces the HOSTNAME of each message
+func SetHostname(hostname string) Rewrite {
+	return func(m *Message, src Source) {
+		m.Hostname = hostname
+	}
+}
+
+// DefaultHostnameToSource returns a Rewrite that sets the HOSTNAME of messages
+// that have none (NILVALUE) to the IP address they were received from.
+func DefaultHostnameToSource() Rewrite {
+	return func(m *Message, src Source) {
+		if m.Hostname == "" {
+			m.Hostname = sourceIP(src)
+		}
+	}
+}
+
+// AddOriginIP returns a Rewrite that records the IP address each message was
+// received from in the "ip" parameter of the "origin" SD element described in
+// RFC-5424 section 7.2.
+func AddOriginIP() Rewrite {
+	return func(m *Message, src Source) {
+		if ip := sourceIP(src); ip != "" {
+			m.AddDatum(OriginSDID, "ip", ip)
+		}
+	}
+}
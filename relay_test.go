@@ -0,0 +1,37 @@
+package rfc5424
+
+import (
+	"bytes"
+	"context"
+	"net"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&RelayTest{})
+
+type RelayTest struct {
+}
+
+func (s *RelayTest) TestRelay(c *C) {
+	out := bytes.Buffer{}
+	relay := Relay{
+		Writer: NewStreamWriter(&out),
+		Accept: func(m Message, src Source) bool { return m.AppName != "noisy" },
+		Rewrites: []Rewrite{
+			DefaultHostnameToSource(),
+			AddOriginIP(),
+		},
+	}
+	src := Source{
+		Network:    "udp",
+		RemoteAddr: &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 514},
+	}
+	relay.Handle(context.Background(),
+		Message{AppName: "noisy", Timestamp: T("0000-12-31T00:00:00Z")}, src)
+	relay.Handle(context.Background(),
+		Message{AppName: "su", Timestamp: T("0000-12-31T00:00:00Z")}, src)
+
+	c.Assert(out.String(), Equals,
+		`66 <0>1 0000-12-31T00:00:00Z 192.0.2.1 su - - [origin ip="192.0.2.1"]`)
+}
@@ -0,0 +1,272 @@
+package rfc5424
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// relpMaxNumberDigits bounds the TXNR and DATALEN fields of a RELP frame
+	relpMaxNumberDigits = 9
+
+	// relpMaxCommandLength is the longest command allowed by the RELP spec
+	relpMaxCommandLength = 32
+
+	// relpOfferOverhead allows for the offers sent with the open command,
+	// which may be longer than a short MaxMessageLength
+	relpOfferOverhead = 4096
+
+	relpSoftware = "rfc5424"
+)
+
+// relpFrame is a single command or response of the Reliable Event Logging
+// Protocol (RELP), as used by rsyslog's omrelp and imrelp:
+//
+//	TXNR SP COMMAND SP DATALEN [SP DATA] TRAILER
+type relpFrame struct {
+	txnr    int
+	command string
+	data    []byte
+}
+
+// relpToken reads a field of a RELP frame up to the next space or line feed,
+// which is returned as the delimiter.
+func relpToken(r *bufio.Reader, maxLength int) (string, byte, error) {
+	token := []byte{}
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(token) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", 0, err
+		}
+		if b == ' ' || b == '\n' {
+			return string(token), b, nil
+		}
+		if len(token) == maxLength {
+			return "", 0, fmt.Errorf("relp: field longer than %d bytes", maxLength)
+		}
+		token = append(token, b)
+	}
+}
+
+func relpNumber(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("relp: invalid number %q", s)
+	}
+	return n, nil
+}
+
+// readRELPFrame reads the next frame from r. Frames with more than maxLength
+// bytes of data are rejected before the data is read, except that open
+// frames may always have relpOfferOverhead bytes of offers.
+func readRELPFrame(r *bufio.Reader, maxLength int) (relpFrame, error) {
+	f := relpFrame{}
+	s, delim, err := relpToken(r, relpMaxNumberDigits)
+	if err != nil {
+		return f, err
+	}
+	if delim != ' ' {
+		return f, fmt.Errorf("relp: truncated frame")
+	}
+	if f.txnr, err = relpNumber(s); err != nil {
+		return f, err
+	}
+
+	if f.command, delim, err = relpToken(r, relpMaxCommandLength); err != nil {
+		return f, err
+	}
+	if delim != ' ' {
+		return f, fmt.Errorf("relp: truncated frame")
+	}
+
+	s, delim, err = relpToken(r, relpMaxNumberDigits)
+	if err != nil {
+		return f, err
+	}
+	length, err := relpNumber(s)
+	if err != nil {
+		return f, err
+	}
+	if f.command == "open" && maxLength < relpOfferOverhead {
+		maxLength = relpOfferOverhead
+	}
+	if length > maxLength {
+		return f, ErrMessageTooLong
+	}
+	if delim == '\n' {
+		if length != 0 {
+			return f, fmt.Errorf("relp: truncated frame")
+		}
+		return f, nil
+	}
+
+	f.data = make([]byte, length)
+	if _, err := io.ReadFull(r, f.data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return f, err
+	}
+	trailer, err := r.ReadByte()
+	if err != nil {
+		return f, err
+	}
+	if trailer != '\n' {
+		return f, fmt.Errorf("relp: missing trailer")
+	}
+	return f, nil
+}
+
+// relpOffers parses the offers sent with the open command, one
+// "name=value" per line.
+func relpOffers(data []byte) map[string]string {
+	offers := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 {
+			offers[parts[0]] = parts[1]
+		} else {
+			offers[parts[0]] = ""
+		}
+	}
+	return offers
+}
+
+// ListenAndServeRELP listens on the TCP address addr and then calls
+// ServeRELP.
+func (srv *Server) ListenAndServeRELP(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return srv.ServeRELP(l)
+}
+
+// ServeRELP accepts connections on l from senders using the Reliable Event
+// Logging Protocol, such as rsyslog's omrelp. Each message is acknowledged
+// once the Handler has returned, so the sender resends messages that were
+// not handled before a connection broke. Messages that cannot be parsed, or
+// are dropped because the queue is full, are answered with a 500 response. MaxInFlightPerConn limits the
+// number of unacknowledged messages from each connection. ServeRELP always
+// returns a non-nil error and closes l.
+func (srv *Server) ServeRELP(l net.Listener) error {
+	return srv.serveStream(l, "relp", srv.readRELP)
+}
+
+// readRELP runs a RELP session on conn
+func (srv *Server) readRELP(ctx context.Context, conn net.Conn, src Source) {
+	var mu sync.Mutex // serializes responses
+	respond := func(txnr int, data string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		var err error
+		if data == "" {
+			_, err = fmt.Fprintf(conn, "%d rsp 0\n", txnr)
+		} else {
+			_, err = fmt.Fprintf(conn, "%d rsp %d %s\n", txnr, len(data), data)
+		}
+		return err
+	}
+
+	// window holds a token for each message from this connection that has
+	// not yet been acknowledged
+	var window chan struct{}
+	if srv.MaxInFlightPerConn > 0 {
+		window = make(chan struct{}, srv.MaxInFlightPerConn)
+	}
+	var unacked sync.WaitGroup
+	defer unacked.Wait()
+
+	r := bufio.NewReader(conn)
+	maxLength := srv.maxMessageLength()
+	opened := false
+	for {
+		if window != nil {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if srv.ReadTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(srv.ReadTimeout))
+		}
+		f, err := readRELPFrame(r, maxLength)
+		if err != nil {
+			if err != io.EOF && !srv.isClosed() {
+				srv.connectionError(src, err)
+			}
+			return
+		}
+		if window != nil && f.command != "syslog" {
+			<-window
+		}
+
+		switch {
+		case f.command == "open" && !opened:
+			offers := relpOffers(f.data)
+			if commands, ok := offers["commands"]; ok && !containsString(strings.Split(commands, ","), "syslog") {
+				respond(f.txnr, "500 syslog command required")
+				srv.connectionError(src, fmt.Errorf("relp: client does not offer the syslog command"))
+				return
+			}
+			err = respond(f.txnr, "200 OK\nrelp_version=0\nrelp_software="+relpSoftware+"\ncommands=syslog")
+
+		case f.command == "syslog" && opened:
+			txnr := f.txnr
+			unacked.Add(1)
+			srv.dispatch(ctx, f.data, src, func(handled bool) {
+				// only handled messages are acknowledged, so that the
+				// sender resends dropped ones
+				if handled {
+					respond(txnr, "200 OK")
+				} else {
+					respond(txnr, "500 message not handled")
+				}
+				if window != nil {
+					<-window
+				}
+				unacked.Done()
+			})
+
+		case f.command == "close" && opened:
+			unacked.Wait()
+			respond(f.txnr, "")
+			return
+
+		default:
+			respond(f.txnr, "500 unexpected command "+f.command)
+			srv.connectionError(src, fmt.Errorf("relp: unexpected command %q", f.command))
+			return
+		}
+		if err != nil {
+			if !srv.isClosed() {
+				srv.connectionError(src, err)
+			}
+			return
+		}
+		opened = true
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package rfc5424
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&RELPTest{})
+
+type RELPTest struct {
+}
+
+// relpCommand formats a RELP frame as sent by a client
+func relpCommand(txnr int, command, data string) string {
+	if data == "" {
+		return fmt.Sprintf("%d %s 0\n", txnr, command)
+	}
+	return fmt.Sprintf("%d %s %d %s\n", txnr, command, len(data), data)
+}
+
+func (s *RELPTest) TestReadFrame(c *C) {
+	r := bufio.NewReader(strings.NewReader(
+		relpCommand(1, "open", "relp_version=0\ncommands=syslog") +
+			"2 close 0\n" +
+			"3 syslog 100 <34>1\n"))
+	f, err := readRELPFrame(r, 50)
+	c.Assert(err, IsNil)
+	c.Assert(f.txnr, Equals, 1)
+	c.Assert(f.command, Equals, "open")
+	c.Assert(relpOffers(f.data), DeepEquals, map[string]string{"relp_version": "0", "commands": "syslog"})
+
+	f, err = readRELPFrame(r, 50)
+	c.Assert(err, IsNil)
+	c.Assert(f, DeepEquals, relpFrame{txnr: 2, command: "close"})
+
+	_, err = readRELPFrame(r, 50)
+	c.Assert(err, Equals, ErrMessageTooLong)
+
+	// offers may exceed a short maxLength, messages may not
+	r = bufio.NewReader(strings.NewReader(
+		relpCommand(1, "open", "relp_version=0\ncommands=syslog") +
+			relpCommand(2, "syslog", "<34>1 - - - - - -")))
+	_, err = readRELPFrame(r, 10)
+	c.Assert(err, IsNil)
+	_, err = readRELPFrame(r, 10)
+	c.Assert(err, Equals, ErrMessageTooLong)
+
+	for _, bad := range []string{"x open 0\n", "1 open\n", "1 syslog 5 abc\n", "1 syslog 3 abcd\n", "1234567890 open 0\n"} {
+		_, err = readRELPFrame(bufio.NewReader(strings.NewReader(bad)), 50)
+		c.Check(err, NotNil, Commentf("%q", bad))
+	}
+}
+
+func (s *RELPTest) TestSession(c *C) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	h := make(chanHandler, 10)
+	srv := Server{Handler: h, Workers: 2, ErrorLog: log.New(ioutil.Discard, "", 0)}
+	done := make(chan error)
+	go func() { done <- srv.ServeRELP(l) }()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	defer client.Close()
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(client)
+	readLine := func() string {
+		f, err := readRELPFrame(r, 1024)
+		c.Assert(err, IsNil)
+		c.Assert(f.command, Equals, "rsp")
+		return fmt.Sprintf("%d %s", f.txnr, f.data)
+	}
+
+	_, err = client.Write([]byte(relpCommand(1, "open", "relp_version=0\nrelp_software=test\ncommands=syslog")))
+	c.Assert(err, IsNil)
+	c.Assert(readLine(), Equals, "1 200 OK\nrelp_version=0\nrelp_software=rfc5424\ncommands=syslog")
+
+	_, err = client.Write([]byte(
+		relpCommand(2, "syslog", "<34>1 0000-12-31T00:00:00Z - - - - - one") +
+			relpCommand(3, "syslog", "<34>1 0000-12-31T00:00:00Z - - - - - two")))
+	c.Assert(err, IsNil)
+	acks := []string{readLine(), readLine()}
+	if acks[0] > acks[1] {
+		acks[0], acks[1] = acks[1], acks[0]
+	}
+	c.Assert(acks, DeepEquals, []string{"2 200 OK", "3 200 OK"})
+	first, second := receive(c, h), receive(c, h)
+	bodies := string(first.Message.Message) + "," + string(second.Message.Message)
+	c.Assert(bodies, Matches, "one,two|two,one")
+	c.Assert(first.Source.Network, Equals, "relp")
+
+	// messages that cannot be parsed are not acknowledged
+	_, err = client.Write([]byte(relpCommand(4, "syslog", "garbage")))
+	c.Assert(err, IsNil)
+	c.Assert(readLine(), Equals, "4 500 message not handled")
+
+	_, err = client.Write([]byte(relpCommand(5, "close", "")))
+	c.Assert(err, IsNil)
+	c.Assert(readLine(), Equals, "5 ")
+	_, err = ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+
+	c.Assert(srv.Close(), IsNil)
+	c.Assert(<-done, Equals, ErrServerClosed)
+}
+
+func (s *RELPTest) TestRequiresOpen(c *C) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	connErrors := make(chan error, 1)
+	srv := Server{Handler: make(chanHandler, 1), ConnectionError: func(src Source, err error) { connErrors <- err }}
+	go srv.ServeRELP(l)
+	defer srv.Close()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	defer client.Close()
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	_, err = client.Write([]byte(relpCommand(1, "syslog", "<34>1 0000-12-31T00:00:00Z - - - - -")))
+	c.Assert(err, IsNil)
+	rsp, err := ioutil.ReadAll(client)
+	c.Assert(err, IsNil)
+	c.Assert(string(rsp), Equals, "1 rsp 29 500 unexpected command syslog\n")
+	c.Assert((<-connErrors).Error(), Equals, `relp: unexpected command "syslog"`)
+}
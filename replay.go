@@ -0,0 +1,138 @@
+package rfc5424
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// TimestampRewrite is how a Replayer changes the TIMESTAMP of the messages
+// it replays
+type TimestampRewrite int
+
+const (
+	// KeepTimestamps replays the messages unchanged
+	KeepTimestamps TimestampRewrite = iota
+	// NowTimestamps sets the TIMESTAMP to the time of the replay
+	NowTimestamps
+	// ShiftTimestamps moves the messages forward in time, so that the
+	// first is replayed at the time of the replay and the intervals
+	// between them are kept
+	ShiftTimestamps
+)
+
+var timestampRewriteNames = []string{"keep", "now", "shift"}
+
+func (tr TimestampRewrite) String() string {
+	if tr >= 0 && int(tr) < len(timestampRewriteNames) {
+		return timestampRewriteNames[tr]
+	}
+	return fmt.Sprintf("TimestampRewrite(%d)", int(tr))
+}
+
+// Set parses the name of a TimestampRewrite, implementing flag.Value
+func (tr *TimestampRewrite) Set(s string) error {
+	for i, name := range timestampRewriteNames {
+		if s == name {
+			*tr = TimestampRewrite(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("rfc5424: unknown timestamp rewrite %q", s)
+}
+
+// Replayer writes stored messages to Writer again, e.g. to a collector
+// after an outage, or to a staging environment for testing. Messages can be
+// read from a Store or from a file of framed messages, such as the ".log"
+// file of a FileStore segment or a stream saved by a StreamWriter.
+type Replayer struct {
+	Writer MessageWriter
+
+	// Rate, if set, is the most messages written per second, so that the
+	// destination is not overwhelmed
+	Rate float64
+
+	// Timestamps is how the TIMESTAMP of the messages is rewritten. By
+	// default it is kept.
+	Timestamps TimestampRewrite
+}
+
+// replay holds the state of a replay
+type replay struct {
+	r     *Replayer
+	ctx   context.Context
+	count int
+	next  time.Time
+	shift time.Duration
+}
+
+// write writes m, waiting for its turn if Rate is set
+func (rp *replay) write(m Message) error {
+	if err := rp.ctx.Err(); err != nil {
+		return err
+	}
+	now := TimeNow()
+	if rp.r.Rate > 0 {
+		if wait := rp.next.Sub(now); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-rp.ctx.Done():
+				timer.Stop()
+				return rp.ctx.Err()
+			case <-timer.C:
+			}
+			now = rp.next
+		}
+		rp.next = now.Add(time.Duration(float64(time.Second) / rp.r.Rate))
+	}
+
+	switch rp.r.Timestamps {
+	case NowTimestamps:
+		m.Timestamp = now.UTC()
+	case ShiftTimestamps:
+		if rp.count == 0 && !m.Timestamp.IsZero() {
+			rp.shift = now.Sub(m.Timestamp)
+		}
+		if !m.Timestamp.IsZero() {
+			m.Timestamp = m.Timestamp.Add(rp.shift).UTC()
+		}
+	}
+	if err := rp.r.Writer.WriteMessage(m); err != nil {
+		return err
+	}
+	rp.count++
+	return nil
+}
+
+// ReplayStore replays the messages of s with a Timestamp in the range
+// [start, end), in the order they were stored. It returns the number of
+// messages replayed, and stops at the first error or when ctx is done.
+func (r *Replayer) ReplayStore(ctx context.Context, s Store, start, end time.Time) (int, error) {
+	rp := &replay{r: r, ctx: ctx}
+	err := s.Query(start, end, rp.write)
+	return rp.count, err
+}
+
+// ReplayFrames replays the octet-counted or non-transparently framed
+// messages read from rd until the end of the stream. It returns the number
+// of messages replayed, and stops at the first error or when ctx is done.
+func (r *Replayer) ReplayFrames(ctx context.Context, rd io.Reader) (int, error) {
+	rp := &replay{r: r, ctx: ctx}
+	fr := newFrameReader(rd, defaultMaxMessageLength)
+	for {
+		frame, err := fr.ReadFrame()
+		if err == io.EOF {
+			return rp.count, nil
+		} else if err != nil {
+			return rp.count, err
+		}
+		m := Message{}
+		if err := m.UnmarshalBinary(frame); err != nil {
+			return rp.count, err
+		}
+		if err := rp.write(m); err != nil {
+			return rp.count, err
+		}
+	}
+}
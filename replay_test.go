@@ -0,0 +1,157 @@
+package rfc5424
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&ReplayTest{})
+
+type ReplayTest struct {
+}
+
+func replayMessages(c *C) []Message {
+	messages := []Message{}
+	for i, ts := range []string{"2003-10-11T22:14:15Z", "2003-10-11T22:14:25Z", "2003-10-11T22:15:15Z"} {
+		m, err := NewMessage().Timestamp(T(ts)).MessageID(string(rune('a' + i))).Build()
+		c.Assert(err, IsNil)
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+func (s *ReplayTest) TestReplayFrames(c *C) {
+	buf := &bytes.Buffer{}
+	sw := NewStreamWriter(buf)
+	for _, m := range replayMessages(c) {
+		c.Assert(sw.WriteMessage(m), IsNil)
+	}
+
+	w := &chanWriter{messages: make(chan Message, 3)}
+	n, err := (&Replayer{Writer: w}).ReplayFrames(context.Background(), buf)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 3)
+	for i, m := range replayMessages(c) {
+		replayed := <-w.messages
+		c.Assert(replayed.MessageID, Equals, m.MessageID, Commentf("message %d", i))
+		c.Assert(replayed.Timestamp.Equal(m.Timestamp), Equals, true)
+	}
+}
+
+func (s *ReplayTest) TestReplayFramesInvalid(c *C) {
+	w := &chanWriter{messages: make(chan Message, 3)}
+	n, err := (&Replayer{Writer: w}).ReplayFrames(context.Background(), bytes.NewBufferString("5 hello"))
+	c.Assert(err, NotNil)
+	c.Assert(n, Equals, 0)
+}
+
+func (s *ReplayTest) TestReplayStore(c *C) {
+	dir := c.MkDir()
+	fs, err := OpenFileStore(dir)
+	c.Assert(err, IsNil)
+	c.Assert(fs.AppendBatch(replayMessages(c)), IsNil)
+	c.Assert(fs.Close(), IsNil)
+
+	fs, err = OpenFileStore(dir)
+	c.Assert(err, IsNil)
+	defer fs.Close()
+	w := &chanWriter{messages: make(chan Message, 3)}
+	n, err := (&Replayer{Writer: w}).ReplayStore(context.Background(), fs,
+		T("2003-10-11T22:14:20Z"), T("2003-10-12T00:00:00Z"))
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+	c.Assert((<-w.messages).MessageID, Equals, "b")
+	c.Assert((<-w.messages).MessageID, Equals, "c")
+
+	// the segment files are framed messages too
+	logs, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	c.Assert(err, IsNil)
+	c.Assert(logs, HasLen, 1)
+	f, err := os.Open(logs[0])
+	c.Assert(err, IsNil)
+	defer f.Close()
+	w = &chanWriter{messages: make(chan Message, 3)}
+	n, err = (&Replayer{Writer: w}).ReplayFrames(context.Background(), f)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 3)
+}
+
+func (s *ReplayTest) TestTimestamps(c *C) {
+	now := T("2020-01-02T03:04:05Z")
+	TimeNow = func() time.Time { return now }
+	defer func() { TimeNow = time.Now }()
+
+	replayed := func(tr TimestampRewrite) []time.Time {
+		w := &chanWriter{messages: make(chan Message, 3)}
+		r := &Replayer{Writer: w, Timestamps: tr}
+		buf := &bytes.Buffer{}
+		sw := NewStreamWriter(buf)
+		for _, m := range replayMessages(c) {
+			c.Assert(sw.WriteMessage(m), IsNil)
+		}
+		_, err := r.ReplayFrames(context.Background(), buf)
+		c.Assert(err, IsNil)
+		times := []time.Time{}
+		for i := 0; i < 3; i++ {
+			times = append(times, (<-w.messages).Timestamp)
+		}
+		return times
+	}
+
+	times := replayed(KeepTimestamps)
+	c.Assert(times[0].Equal(T("2003-10-11T22:14:15Z")), Equals, true)
+	times = replayed(NowTimestamps)
+	for _, ts := range times {
+		c.Assert(ts.Equal(now), Equals, true)
+	}
+	times = replayed(ShiftTimestamps)
+	c.Assert(times[0].Equal(now), Equals, true)
+	c.Assert(times[1].Equal(now.Add(10*time.Second)), Equals, true)
+	c.Assert(times[2].Equal(now.Add(time.Minute)), Equals, true)
+}
+
+func (s *ReplayTest) TestTimestampRewriteSet(c *C) {
+	var tr TimestampRewrite
+	c.Assert(tr.Set("shift"), IsNil)
+	c.Assert(tr, Equals, ShiftTimestamps)
+	c.Assert(tr.String(), Equals, "shift")
+	c.Assert(tr.Set("later"), ErrorMatches, `rfc5424: unknown timestamp rewrite "later"`)
+}
+
+func (s *ReplayTest) TestRate(c *C) {
+	buf := &bytes.Buffer{}
+	sw := NewStreamWriter(buf)
+	for _, m := range replayMessages(c) {
+		c.Assert(sw.WriteMessage(m), IsNil)
+	}
+	w := &chanWriter{messages: make(chan Message, 3)}
+	start := time.Now()
+	n, err := (&Replayer{Writer: w, Rate: 20}).ReplayFrames(context.Background(), buf)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 3)
+	// the second and third messages wait 50ms each
+	c.Assert(time.Since(start) >= 100*time.Millisecond, Equals, true)
+}
+
+func (s *ReplayTest) TestCancel(c *C) {
+	buf := &bytes.Buffer{}
+	sw := NewStreamWriter(buf)
+	for _, m := range replayMessages(c) {
+		c.Assert(sw.WriteMessage(m), IsNil)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &chanWriter{messages: make(chan Message, 3)}
+	go func() {
+		<-w.messages
+		cancel()
+	}()
+	// the second message would wait 10s
+	n, err := (&Replayer{Writer: w, Rate: 0.1}).ReplayFrames(ctx, buf)
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(n, Equals, 1)
+}
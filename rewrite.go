@@ -0,0 +1,116 @@
+package rfc5424
+
+import (
+	"context"
+)
+
+// ParamRename renames the parameters called From in the SD element ID to To
+type ParamRename struct {
+	ID   string `json:"id" yaml:"id"`
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+}
+
+// FieldRewrite changes the fields of messages to suit one destination, as
+// downstream systems often expect their own names for the same data. It is
+// usually set for an output of a Config, or wraps one writer of a
+// MultiMessageWriter (see Writer).
+type FieldRewrite struct {
+	// Hostname and AppName, if set, replace the HOSTNAME and APP-NAME
+	Hostname string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	AppName  string `json:"appName,omitempty" yaml:"appName,omitempty"`
+
+	// DropSD lists the SD-IDs of the elements removed
+	DropSD []string `json:"dropSD,omitempty" yaml:"dropSD,omitempty"`
+
+	// RenameParams renames parameters. It is applied before RenameSD, so
+	// it uses the original SD-IDs.
+	RenameParams []ParamRename `json:"renameParams,omitempty" yaml:"renameParams,omitempty"`
+
+	// RenameSD maps SD-IDs to new ones. An element renamed to the SD-ID of
+	// another has its parameters added to that element, since an SD-ID
+	// may only appear once in a message.
+	RenameSD map[string]string `json:"renameSD,omitempty" yaml:"renameSD,omitempty"`
+}
+
+// Apply returns m with the fields rewritten. m itself is not modified.
+func (fr FieldRewrite) Apply(m Message) Message {
+	if fr.Hostname != "" {
+		m.Hostname = fr.Hostname
+	}
+	if fr.AppName != "" {
+		m.AppName = fr.AppName
+	}
+	if len(fr.DropSD) == 0 && len(fr.RenameParams) == 0 && len(fr.RenameSD) == 0 {
+		return m
+	}
+
+	sds := m.StructuredData
+	m.StructuredData = nil
+	for _, sd := range sds {
+		if fr.drops(sd.ID) {
+			continue
+		}
+		id := sd.ID
+		if to, ok := fr.RenameSD[id]; ok {
+			id = to
+		}
+		for _, param := range sd.Parameters {
+			m.AddDatum(id, fr.paramName(sd.ID, param.Name), param.Value)
+		}
+		if len(sd.Parameters) == 0 && m.SD(id) == nil {
+			m.StructuredData = append(m.StructuredData, StructuredData{ID: id})
+		}
+	}
+	return m
+}
+
+// drops reports whether the SD element id is dropped
+func (fr FieldRewrite) drops(id string) bool {
+	for _, drop := range fr.DropSD {
+		if drop == id {
+			return true
+		}
+	}
+	return false
+}
+
+// paramName returns the new name of the parameter name of the SD element id
+func (fr FieldRewrite) paramName(id, name string) string {
+	for _, rename := range fr.RenameParams {
+		if rename.ID == id && rename.From == name {
+			return rename.To
+		}
+	}
+	return name
+}
+
+// Writer returns a MessageWriter that rewrites each message before writing
+// it to w
+func (fr FieldRewrite) Writer(w MessageWriter) MessageWriter {
+	return rewriteWriter{rewrite: fr, writer: w}
+}
+
+// Middleware returns a Middleware that rewrites each message before
+// passing it on, e.g. to the handler of one route of a RuleSet
+func (fr FieldRewrite) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+			next.Handle(ctx, fr.Apply(m), src)
+		})
+	}
+}
+
+// rewriteWriter is the MessageWriter returned by FieldRewrite.Writer
+type rewriteWriter struct {
+	rewrite FieldRewrite
+	writer  MessageWriter
+}
+
+func (rw rewriteWriter) WriteMessage(m Message) error {
+	return rw.writer.WriteMessage(rw.rewrite.Apply(m))
+}
+
+func (rw rewriteWriter) Close() error {
+	return rw.writer.Close()
+}
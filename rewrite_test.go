@@ -0,0 +1,67 @@
+package rfc5424
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&RewriteTest{})
+
+type RewriteTest struct {
+}
+
+func (s *RewriteTest) TestApply(c *C) {
+	m := Message{Priority: 14, Hostname: "host", AppName: "app"}
+	m.AddDatum("http@32473", "status", "200")
+	m.AddDatum("http@32473", "path", "/")
+	m.AddDatum("debug@32473", "trace", "1")
+	m.AddDatum("req@32473", "id", "42")
+	m.AddDatum(OriginSDID, "ip", "192.0.2.1")
+	original := m.Clone()
+
+	fr := FieldRewrite{
+		AppName:      "api",
+		DropSD:       []string{"debug@32473"},
+		RenameParams: []ParamRename{{ID: "http@32473", From: "status", To: "status_code"}},
+		RenameSD:     map[string]string{"req@32473": "http@32473", OriginSDID: "source@32473"},
+	}
+	rewritten := fr.Apply(m)
+	c.Assert(rewritten.Hostname, Equals, "host")
+	c.Assert(rewritten.AppName, Equals, "api")
+	c.Assert(rewritten.StructuredData, DeepEquals, []StructuredData{
+		{ID: "http@32473", Parameters: []SDParam{{"status_code", "200"}, {"path", "/"}, {"id", "42"}}},
+		{ID: "source@32473", Parameters: []SDParam{{"ip", "192.0.2.1"}}},
+	})
+	c.Assert(m, DeepEquals, original)
+
+	c.Assert(FieldRewrite{Hostname: "h"}.Apply(m).StructuredData, DeepEquals, m.StructuredData)
+}
+
+func (s *RewriteTest) TestWriterAndMiddleware(c *C) {
+	buf := &bytes.Buffer{}
+	w := FieldRewrite{Hostname: "relay"}.Writer(&StreamWriter{Writer: buf, Framing: NonTransparentFraming})
+	c.Assert(w.WriteMessage(Message{Priority: 14, Hostname: "host"}), IsNil)
+	c.Assert(w.Close(), IsNil)
+	c.Assert(buf.String(), Equals, "<14>1 - relay - - - -\n")
+
+	var handled Message
+	h := Chain(HandlerFunc(func(ctx context.Context, m Message, src Source) { handled = m }),
+		FieldRewrite{AppName: "app"}.Middleware())
+	h.Handle(context.Background(), Message{}, Source{})
+	c.Assert(handled.AppName, Equals, "app")
+}
+
+func (s *RewriteTest) TestConfig(c *C) {
+	var config Config
+	c.Assert(json.Unmarshal([]byte(`{"outputs": [{"network": "udp", "address": "127.0.0.1:514",
+		"rewrite": {"hostname": "relay", "dropSD": ["debug@32473"],
+			"renameParams": [{"id": "http@32473", "from": "status", "to": "code"}]}}]}`), &config), IsNil)
+	c.Assert(config.Outputs[0].Rewrite, DeepEquals, &FieldRewrite{
+		Hostname:     "relay",
+		DropSD:       []string{"debug@32473"},
+		RenameParams: []ParamRename{{ID: "http@32473", From: "status", To: "code"}},
+	})
+}
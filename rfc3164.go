@@ -0,0 +1,71 @@
+package rfc5424
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+)
+
+// rfc3164TimestampLayout is the TIMESTAMP of RFC-3164, which has no year
+// or time zone
+const rfc3164TimestampLayout = "Jan _2 15:04:05"
+
+// parseRFC3164 parses a message in the BSD syslog format described by
+// RFC-3164:
+//
+//	<PRI>Mmm dd hh:mm:ss [HOSTNAME] TAG[PID]: MSG
+//
+// The HOSTNAME is optional because the C library omits it when writing to
+// /dev/log; the local hostname is used instead. The timestamp is assumed to be
+// in the local time zone and the current year.
+func parseRFC3164(buf []byte) (Message, error) {
+	m := Message{StructuredData: []StructuredData{}}
+	r := bytes.NewBuffer(buf)
+	if err := m.readPriority(r); err != nil {
+		return m, err
+	}
+
+	rest := r.Bytes()
+	if len(rest) < len(rfc3164TimestampLayout)+1 || rest[len(rfc3164TimestampLayout)] != ' ' {
+		return m, BadFormat("Timestamp")
+	}
+	now := TimeNow()
+	t, err := time.ParseInLocation(rfc3164TimestampLayout,
+		string(rest[:len(rfc3164TimestampLayout)]), now.Location())
+	if err != nil {
+		return m, BadFormat("Timestamp")
+	}
+	m.Timestamp = t.AddDate(now.Year(), 0, 0)
+	rest = rest[len(rfc3164TimestampLayout)+1:]
+
+	// The first word is the TAG if it ends with ':' or contains '[',
+	// otherwise it is the HOSTNAME.
+	m.Hostname = DefaultHostnameResolver.Resolve()
+	word := rest
+	if i := bytes.IndexByte(rest, ' '); i >= 0 {
+		word = rest[:i]
+	}
+	if !bytes.HasSuffix(word, []byte(":")) && bytes.IndexByte(word, '[') < 0 &&
+		len(word) < len(rest) {
+		m.Hostname = string(word)
+		rest = rest[len(word)+1:]
+	}
+
+	// TAG is terminated by ':', possibly preceded by "[PID]"
+	colon := bytes.IndexByte(rest, ':')
+	if colon < 0 {
+		m.Message = rest
+		return m, nil
+	}
+	tag := rest[:colon]
+	if open := bytes.IndexByte(tag, '['); open >= 0 && bytes.HasSuffix(tag, []byte("]")) {
+		pid := tag[open+1 : len(tag)-1]
+		if _, err := strconv.Atoi(string(pid)); err == nil {
+			m.ProcessID = string(pid)
+		}
+		tag = tag[:open]
+	}
+	m.AppName = string(tag)
+	m.Message = bytes.TrimPrefix(rest[colon+1:], []byte(" "))
+	return m, nil
+}
@@ -0,0 +1,42 @@
+package rfc5424
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&RFC3164Test{})
+
+type RFC3164Test struct {
+}
+
+func (s *RFC3164Test) TestParse(c *C) {
+	TimeNow = func() time.Time { return time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { TimeNow = time.Now }()
+
+	m, err := parseRFC3164([]byte("<13>Oct 11 22:14:15 su[8710]: 'su root' failed"))
+	c.Assert(err, IsNil)
+	c.Assert(m, DeepEquals, Message{
+		Priority:       13,
+		Timestamp:      time.Date(2015, 10, 11, 22, 14, 15, 0, time.UTC),
+		Hostname:       DefaultHostnameResolver.Resolve(),
+		AppName:        "su",
+		ProcessID:      "8710",
+		StructuredData: []StructuredData{},
+		Message:        []byte("'su root' failed"),
+	})
+
+	m, err = parseRFC3164([]byte("<34>Oct  1 22:14:15 mymachine su: hello"))
+	c.Assert(err, IsNil)
+	c.Assert(m.Timestamp, Equals, time.Date(2015, 10, 1, 22, 14, 15, 0, time.UTC))
+	c.Assert(m.Hostname, Equals, "mymachine")
+	c.Assert(m.AppName, Equals, "su")
+	c.Assert(m.ProcessID, Equals, "")
+	c.Assert(string(m.Message), Equals, "hello")
+
+	for _, invalid := range []string{"", "<34>", "<34>Oct 11", "<34>notatimestamp su: x"} {
+		_, err = parseRFC3164([]byte(invalid))
+		c.Assert(err, NotNil, Commentf("%q", invalid))
+	}
+}
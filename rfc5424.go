@@ -17,13 +17,42 @@ const (
 	Debug
 )
 
+// severityKeywords are the canonical names of the severities, as used by
+// syslog(3) and syslog.conf
+var severityKeywords = map[Severity]string{
+	Emergency: "emerg",
+	Alert:     "alert",
+	Critical:  "crit",
+	Error:     "err",
+	Warning:   "warning",
+	Notice:    "notice",
+	Info:      "info",
+	Debug:     "debug",
+}
+
+// severityDescriptions are the descriptions of the severities in Table 2 of
+// RFC-5424
+var severityDescriptions = map[Severity]string{
+	Emergency: "system is unusable",
+	Alert:     "action must be taken immediately",
+	Critical:  "critical conditions",
+	Error:     "error conditions",
+	Warning:   "warning conditions",
+	Notice:    "normal but significant condition",
+	Info:      "informational messages",
+	Debug:     "debug-level messages",
+}
+
+// severityNames maps the names and aliases of the severities to their values
 var severityNames = map[string]Severity{
 	"emergency":     Emergency,
 	"emerg":         Emergency,
+	"panic":         Emergency,
 	"alert":         Alert,
 	"critical":      Critical,
 	"crit":          Critical,
 	"error":         Error,
+	"err":           Error,
 	"warning":       Warning,
 	"warn":          Warning,
 	"notice":        Notice,
@@ -62,12 +91,74 @@ const (
 	Local7
 )
 
+// facilityKeywords are the canonical names of the facilities. They are the
+// syslog(3) names where it has one; the facilities it leaves unnamed use the
+// names of their constants.
+var facilityKeywords = map[Facility]string{
+	Kernel:   "kern",
+	User:     "user",
+	Mail:     "mail",
+	Daemon:   "daemon",
+	Auth:     "auth",
+	Syslog:   "syslog",
+	LPR:      "lpr",
+	News:     "news",
+	UUCP:     "uucp",
+	Clock:    "clock",
+	AuthPriv: "authpriv",
+	FTP:      "ftp",
+	NTP:      "ntp",
+	Audit:    "audit",
+	LogAlert: "logalert",
+	Cron:     "cron",
+	Local0:   "local0",
+	Local1:   "local1",
+	Local2:   "local2",
+	Local3:   "local3",
+	Local4:   "local4",
+	Local5:   "local5",
+	Local6:   "local6",
+	Local7:   "local7",
+}
+
+// facilityDescriptions are the descriptions of the facilities in Table 1 of
+// RFC-5424
+var facilityDescriptions = map[Facility]string{
+	Kernel:   "kernel messages",
+	User:     "user-level messages",
+	Mail:     "mail system",
+	Daemon:   "system daemons",
+	Auth:     "security/authorization messages",
+	Syslog:   "messages generated internally by syslogd",
+	LPR:      "line printer subsystem",
+	News:     "network news subsystem",
+	UUCP:     "UUCP subsystem",
+	Clock:    "clock daemon",
+	AuthPriv: "security/authorization messages",
+	FTP:      "FTP daemon",
+	NTP:      "NTP subsystem",
+	Audit:    "log audit",
+	LogAlert: "log alert",
+	Cron:     "clock daemon",
+	Local0:   "local use 0",
+	Local1:   "local use 1",
+	Local2:   "local use 2",
+	Local3:   "local use 3",
+	Local4:   "local use 4",
+	Local5:   "local use 5",
+	Local6:   "local use 6",
+	Local7:   "local use 7",
+}
+
+// facilityNames maps the names and aliases of the facilities to their values
 var facilityNames = map[string]Facility{
 	"kernel":   Kernel,
+	"kern":     Kernel,
 	"user":     User,
 	"mail":     Mail,
 	"daemon":   Daemon,
 	"auth":     Auth,
+	"security": Auth,
 	"syslog":   Syslog,
 	"lpr":      LPR,
 	"news":     News,
@@ -78,6 +169,7 @@ var facilityNames = map[string]Facility{
 	"ntp":      NTP,
 	"audit":    Audit,
 	"logalert": LogAlert,
+	"console":  LogAlert,
 	"cron":     Cron,
 	"local0":   Local0,
 	"local1":   Local1,
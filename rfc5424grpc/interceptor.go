@@ -0,0 +1,124 @@
+// Package rfc5424grpc logs the RPCs served by a gRPC server as RFC-5424
+// messages, like rfc5424.AccessLog does for HTTP requests. It is a package
+// of its own so that programs not using gRPC do not depend on it.
+package rfc5424grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/secureworks/rfc5424"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultSDID      = "grpc@32473"
+	defaultMessageID = "rpc"
+)
+
+// Interceptor provides gRPC server interceptors writing a message for each
+// RPC served to Writer, usually the application's Logger. The message has
+// the MSGID "rpc", text such as "/pkg.Service/Method NotFound", and an SD
+// element, SDID, with the "method", status "code", "duration" (as parsed by
+// time.ParseDuration) and "peer" address.
+//
+//	i := &rfc5424grpc.Interceptor{Writer: logger}
+//	srv := grpc.NewServer(grpc.UnaryInterceptor(i.Unary()), grpc.StreamInterceptor(i.Stream()))
+type Interceptor struct {
+	Writer rfc5424.MessageWriter
+
+	// SDID is the ID of the RPC's SD element. If empty, "grpc@32473" is
+	// used.
+	SDID string
+
+	// Severity, if set, returns the severity of the message for an RPC of
+	// the full method name, e.g. "/pkg.Service/Method", that ended with
+	// code, e.g. to log health checks as Debug. By default codes meaning a
+	// server error, such as Internal or Unavailable, are Error and the
+	// others Info.
+	Severity func(method string, code codes.Code) rfc5424.Severity
+
+	// Sample, if set, decides whether an RPC is logged. If nil, all RPCs
+	// are logged.
+	Sample func(method string, code codes.Code, duration time.Duration) bool
+
+	// Error, if set, is called with the errors returned by Writer
+	Error func(err error)
+}
+
+// Unary returns an interceptor logging unary RPCs
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		start := rfc5424.TimeNow()
+		resp, err := handler(ctx, req)
+		i.log(ctx, info.FullMethod, err, rfc5424.TimeNow().Sub(start))
+		return resp, err
+	}
+}
+
+// Stream returns an interceptor logging streaming RPCs, once they end
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		start := rfc5424.TimeNow()
+		err := handler(srv, ss)
+		i.log(ss.Context(), info.FullMethod, err, rfc5424.TimeNow().Sub(start))
+		return err
+	}
+}
+
+// log writes the message for an RPC that returned err
+func (i *Interceptor) log(ctx context.Context, method string, err error, duration time.Duration) {
+	code := status.Code(err)
+	if i.Sample != nil && !i.Sample(method, code, duration) {
+		return
+	}
+	m, err := i.message(ctx, method, code, duration)
+	if err == nil {
+		err = i.Writer.WriteMessage(m)
+	}
+	if err != nil && i.Error != nil {
+		i.Error(err)
+	}
+}
+
+// message returns the message for an RPC
+func (i *Interceptor) message(ctx context.Context, method string, code codes.Code,
+	duration time.Duration) (rfc5424.Message, error) {
+	sdID := i.SDID
+	if sdID == "" {
+		sdID = defaultSDID
+	}
+	severity := defaultSeverity(code)
+	if i.Severity != nil {
+		severity = i.Severity(method, code)
+	}
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	return rfc5424.NewMessage().
+		Severity(severity).
+		MessageID(defaultMessageID).
+		Text(method+" "+code.String()).
+		SD(sdID, "method", method).
+		SD(sdID, "code", code.String()).
+		SD(sdID, "duration", duration.String()).
+		SD(sdID, "peer", addr).
+		Build()
+}
+
+// defaultSeverity returns Error for the codes meaning a server error, and
+// Info for the others
+func defaultSeverity(code codes.Code) rfc5424.Severity {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal,
+		codes.Unavailable, codes.DataLoss:
+		return rfc5424.Error
+	}
+	return rfc5424.Info
+}
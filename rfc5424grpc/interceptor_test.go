@@ -0,0 +1,126 @@
+package rfc5424grpc
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/secureworks/rfc5424"
+	"github.com/secureworks/rfc5424/rfc5424test"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&InterceptorTest{})
+
+type InterceptorTest struct {
+}
+
+// messageWriter keeps the messages written
+type messageWriter struct {
+	messages []rfc5424.Message
+}
+
+func (w *messageWriter) WriteMessage(m rfc5424.Message) error {
+	w.messages = append(w.messages, m)
+	return nil
+}
+
+func (w *messageWriter) Close() error {
+	return nil
+}
+
+// serverStream is a grpc.ServerStream with a context
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ss serverStream) Context() context.Context {
+	return ss.ctx
+}
+
+func (s *InterceptorTest) TestUnary(c *C) {
+	w := &messageWriter{}
+	i := &Interceptor{Writer: w}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5555}})
+	info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Get"}
+
+	resp, err := i.Unary()(ctx, "req", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "resp", nil
+	})
+	c.Assert(resp, Equals, "resp")
+	c.Assert(err, IsNil)
+	_, err = i.Unary()(ctx, "req", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Unavailable, "down")
+	})
+	c.Assert(status.Code(err), Equals, codes.Unavailable)
+
+	c.Assert(w.messages, HasLen, 2)
+	m := w.messages[0]
+	c.Assert(m.Severity(), Equals, rfc5424.Severity(rfc5424.Info))
+	c.Assert(m.MessageID, Equals, "rpc")
+	c.Assert(m.TextMessage(), Equals, "/pkg.Service/Get OK")
+	params := rfc5424.Params(m.StructuredData[0].Parameters)
+	c.Assert(m.StructuredData[0].ID, Equals, "grpc@32473")
+	c.Assert(params.Names(), DeepEquals, []string{"method", "code", "duration", "peer"})
+	c.Assert(params.Get("method"), Equals, "/pkg.Service/Get")
+	c.Assert(params.Get("code"), Equals, "OK")
+	c.Assert(params.Get("peer"), Equals, "192.0.2.1:5555")
+	_, err = m.GetDuration("grpc@32473", "duration")
+	c.Assert(err, IsNil)
+
+	m = w.messages[1]
+	c.Assert(m.Severity(), Equals, rfc5424.Severity(rfc5424.Error))
+	code, _ := m.SDParam("grpc@32473", "code")
+	c.Assert(code, Equals, "Unavailable")
+}
+
+func (s *InterceptorTest) TestStream(c *C) {
+	w := &messageWriter{}
+	i := &Interceptor{
+		Writer: w,
+		SDID:   "rpc@32473",
+		Severity: func(method string, code codes.Code) rfc5424.Severity {
+			if method == "/grpc.health.v1.Health/Watch" {
+				return rfc5424.Debug
+			}
+			return rfc5424.Notice
+		},
+		Sample: func(method string, code codes.Code, duration time.Duration) bool {
+			return code != codes.Canceled
+		},
+	}
+	ss := serverStream{ctx: context.Background()}
+	info := &grpc.StreamServerInfo{FullMethod: "/grpc.health.v1.Health/Watch", IsServerStream: true}
+
+	err := i.Stream()(nil, ss, info, func(srv interface{}, stream grpc.ServerStream) error {
+		return status.Error(codes.NotFound, "unknown service")
+	})
+	c.Assert(status.Code(err), Equals, codes.NotFound)
+	c.Assert(i.Stream()(nil, ss, info, func(srv interface{}, stream grpc.ServerStream) error {
+		return status.Error(codes.Canceled, "gone")
+	}), NotNil)
+
+	c.Assert(w.messages, HasLen, 1)
+	m := w.messages[0]
+	c.Assert(m.Severity(), Equals, rfc5424.Severity(rfc5424.Debug))
+	c.Assert(m.TextMessage(), Equals, "/grpc.health.v1.Health/Watch NotFound")
+	peerAddr, ok := m.SDParam("rpc@32473", "peer")
+	c.Assert(ok, Equals, true)
+	c.Assert(peerAddr, Equals, "")
+}
+
+func (s *InterceptorTest) TestLogger(c *C) {
+	fw := rfc5424test.NewFakeWriter()
+	l := rfc5424.NewLogger(fw)
+	i := &Interceptor{Writer: l}
+	info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Get"}
+	i.Unary()(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	c.Assert(<-fw.Messages, Matches, `<134>1 .* rpc \[grpc@32473 .*\] .*/pkg.Service/Get OK`)
+}
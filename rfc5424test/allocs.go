@@ -0,0 +1,23 @@
+package rfc5424test
+
+import (
+	"testing"
+
+	"github.com/secureworks/rfc5424"
+)
+
+// MarshalAllocBudget is the number of heap allocations MarshalBinary makes
+// for a valid message: the returned slice. Only escaped PARAM-VALUEs longer
+// than 256 octets need more.
+const MarshalAllocBudget = 1
+
+// AllocsPerMarshal returns the average number of heap allocations made by
+// m.MarshalBinary, over runs calls, so that tests can check that it stays
+// within MarshalAllocBudget. Like testing.AllocsPerRun, it makes a warm-up
+// call first. The counts are not meaningful under the race detector, which
+// makes the package's buffer pool drop buffers at random.
+func AllocsPerMarshal(m rfc5424.Message, runs int) float64 {
+	return testing.AllocsPerRun(runs, func() {
+		m.MarshalBinary()
+	})
+}
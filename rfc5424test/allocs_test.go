@@ -0,0 +1,41 @@
+package rfc5424test
+
+import (
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/secureworks/rfc5424"
+)
+
+var _ = Suite(&AllocsTest{})
+
+type AllocsTest struct {
+}
+
+func (s *AllocsTest) SetUpTest(c *C) {
+	if raceEnabled {
+		c.Skip("allocations are not stable under the race detector")
+	}
+}
+
+func (s *AllocsTest) TestMarshalAllocBudget(c *C) {
+	timestamp := time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC)
+	withSD := rfc5424.Message{Priority: 165, Timestamp: timestamp, Hostname: "mymachine.example.com",
+		AppName: "evntslog", MessageID: "ID47", Message: []byte("An application event log entry...")}
+	withSD.AddDatum("exampleSDID@32473", "iut", "3")
+	withSD.AddDatum("exampleSDID@32473", "eventSource", `"Application"`)
+	withSD.AddDatum("examplePriority@32473", "class", "high")
+	utf8 := rfc5424.Message{Priority: 14, Timestamp: timestamp}
+	utf8.SetTextMessage("café")
+
+	for name, m := range map[string]rfc5424.Message{
+		"no SD":         {Priority: 34, Timestamp: timestamp, Hostname: "host", Message: []byte("hello")},
+		"SD":            withSD,
+		"UTF-8 MSG":     utf8,
+		"long hostname": {Hostname: strings.Repeat("h", 255), Timestamp: timestamp},
+	} {
+		c.Assert(AllocsPerMarshal(m, 100) <= MarshalAllocBudget, Equals, true, Commentf("%s", name))
+	}
+}
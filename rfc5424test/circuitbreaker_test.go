@@ -0,0 +1,100 @@
+package rfc5424test
+
+import (
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/secureworks/rfc5424"
+)
+
+var _ = Suite(&CircuitBreakerTest{})
+
+// blockingCall is a single call to blockingWriter.WriteMessage, which returns
+// whatever is sent on Result.
+type blockingCall struct {
+	Message rfc5424.Message
+	Result  chan error
+}
+
+// blockingWriter is a MessageWriter whose writes block until the test decides
+// their outcome, so that concurrent writes can be ordered deterministically.
+type blockingWriter struct {
+	Calls chan blockingCall
+}
+
+func (bw blockingWriter) WriteMessage(m rfc5424.Message) error {
+	call := blockingCall{Message: m, Result: make(chan error)}
+	bw.Calls <- call
+	return <-call.Result
+}
+
+func (bw blockingWriter) Close() error {
+	return nil
+}
+
+type CircuitBreakerTest struct {
+}
+
+func (testSuite *CircuitBreakerTest) TestOpensAndRecovers(c *C) {
+	now := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	rfc5424.TimeNow = func() time.Time { return now }
+	defer func() { rfc5424.TimeNow = time.Now }()
+
+	fw := NewFakeWriter()
+	fw.Error = errors.New("collector is down")
+	cb := &rfc5424.CircuitBreaker{Writer: fw, Threshold: 2, Cooldown: time.Minute}
+	msg := rfc5424.Message{MessageID: "one"}
+
+	// The first failures are passed through until the threshold is reached
+	c.Assert(cb.WriteMessage(msg), Equals, fw.Error)
+	c.Assert(cb.State(), Equals, rfc5424.CircuitClosed)
+	c.Assert(cb.WriteMessage(msg), Equals, fw.Error)
+	c.Assert(cb.State(), Equals, rfc5424.CircuitOpen)
+
+	// While open we fail fast, or use the fallback
+	c.Assert(cb.WriteMessage(msg), Equals, rfc5424.ErrCircuitOpen)
+	fallback := NewFakeWriter()
+	cb.Fallback = fallback
+	c.Assert(cb.WriteMessage(msg), IsNil)
+	c.Assert(<-fallback.Messages, Equals, "<0>1 - - - - one -")
+
+	// A failed probe re-opens the circuit
+	now = now.Add(time.Minute)
+	c.Assert(cb.State(), Equals, rfc5424.CircuitHalfOpen)
+	c.Assert(cb.WriteMessage(msg), Equals, fw.Error)
+	c.Assert(cb.State(), Equals, rfc5424.CircuitOpen)
+
+	// A successful probe closes it
+	now = now.Add(time.Minute)
+	fw.Error = nil
+	c.Assert(cb.WriteMessage(msg), IsNil)
+	c.Assert(<-fw.Messages, Equals, "<0>1 - - - - one -")
+	c.Assert(cb.State(), Equals, rfc5424.CircuitClosed)
+}
+
+func (testSuite *CircuitBreakerTest) TestLateSuccessDoesNotCloseCircuit(c *C) {
+	bw := blockingWriter{Calls: make(chan blockingCall)}
+	cb := &rfc5424.CircuitBreaker{Writer: bw, Threshold: 1, Cooldown: time.Hour}
+	msg := rfc5424.Message{MessageID: "one"}
+
+	// A slow write starts while the circuit is closed...
+	slowDone := make(chan error)
+	go func() { slowDone <- cb.WriteMessage(msg) }()
+	slow := <-bw.Calls
+
+	// ...a second write fails and opens the circuit...
+	fastDone := make(chan error)
+	go func() { fastDone <- cb.WriteMessage(msg) }()
+	fast := <-bw.Calls
+	fast.Result <- errors.New("collector is down")
+	c.Assert(<-fastDone, NotNil)
+	c.Assert(cb.State(), Equals, rfc5424.CircuitOpen)
+
+	// ...and the slow write finally succeeding must not close it.
+	slow.Result <- nil
+	c.Assert(<-slowDone, IsNil)
+	c.Assert(cb.State(), Equals, rfc5424.CircuitOpen)
+	c.Assert(cb.WriteMessage(msg), Equals, rfc5424.ErrCircuitOpen)
+}
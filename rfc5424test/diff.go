@@ -0,0 +1,154 @@
+package rfc5424test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/secureworks/rfc5424"
+)
+
+// Diff returns a description of the differences between want and got, one
+// per line, or "" if they are equal. SD elements are matched by SD-ID and
+// their parameters by name, so that the lines show which parameters
+// differ rather than where. Timestamps are compared with time.Time.Equal.
+//
+//	c.Assert(rfc5424test.Diff(want, got), Equals, "")
+func Diff(want, got rfc5424.Message) string {
+	var lines []string
+	field := func(name string, want, got interface{}) {
+		if want != got {
+			lines = append(lines, fmt.Sprintf("%s: want %#v, got %#v", name, want, got))
+		}
+	}
+	field("Priority", want.Priority, got.Priority)
+	field("Version", want.Version, got.Version)
+	if !want.Timestamp.Equal(got.Timestamp) {
+		lines = append(lines, fmt.Sprintf("Timestamp: want %s, got %s", formatTime(want), formatTime(got)))
+	}
+	field("Hostname", want.Hostname, got.Hostname)
+	field("AppName", want.AppName, got.AppName)
+	field("ProcessID", want.ProcessID, got.ProcessID)
+	field("MessageID", want.MessageID, got.MessageID)
+	lines = append(lines, diffStructuredData(want.StructuredData, got.StructuredData)...)
+	if string(want.Message) != string(got.Message) {
+		lines = append(lines, fmt.Sprintf("Message: want %q, got %q", want.Message, got.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatTime returns the TIMESTAMP of m, or "-" if it has none
+func formatTime(m rfc5424.Message) string {
+	if m.Timestamp.IsZero() {
+		return "-"
+	}
+	return m.Timestamp.Format("2006-01-02T15:04:05.999999Z07:00")
+}
+
+// diffStructuredData returns the differences between the SD elements of
+// two messages
+func diffStructuredData(want, got []rfc5424.StructuredData) []string {
+	var lines []string
+	for _, id := range sdIDs(want, got) {
+		wantSD, gotSD := findSD(want, id), findSD(got, id)
+		switch {
+		case gotSD == nil:
+			lines = append(lines, fmt.Sprintf("StructuredData[%s]: missing", id))
+			continue
+		case wantSD == nil:
+			lines = append(lines, fmt.Sprintf("StructuredData[%s]: unexpected", id))
+			continue
+		}
+		for _, name := range paramNames(wantSD.Parameters, gotSD.Parameters) {
+			wantValues, gotValues := paramValues(wantSD.Parameters, name), paramValues(gotSD.Parameters, name)
+			for i := 0; i < len(wantValues) || i < len(gotValues); i++ {
+				param := fmt.Sprintf("StructuredData[%s]/%s", id, name)
+				if len(wantValues) > 1 || len(gotValues) > 1 {
+					param += fmt.Sprintf("[%d]", i)
+				}
+				switch {
+				case i >= len(gotValues):
+					lines = append(lines, fmt.Sprintf("%s: missing, want %q", param, wantValues[i]))
+				case i >= len(wantValues):
+					lines = append(lines, fmt.Sprintf("%s: unexpected %q", param, gotValues[i]))
+				case wantValues[i] != gotValues[i]:
+					lines = append(lines, fmt.Sprintf("%s: want %q, got %q", param, wantValues[i], gotValues[i]))
+				}
+			}
+		}
+	}
+	if len(lines) == 0 && !sameOrder(want, got) {
+		lines = append(lines, fmt.Sprintf("StructuredData: want order %s, got %s", sdIDs(want, nil), sdIDs(got, nil)))
+	}
+	return lines
+}
+
+// sdIDs returns the SD-IDs of want, then those of got not in want
+func sdIDs(want, got []rfc5424.StructuredData) []string {
+	var ids []string
+	seen := map[string]bool{}
+	for _, sds := range [][]rfc5424.StructuredData{want, got} {
+		for _, sd := range sds {
+			if !seen[sd.ID] {
+				seen[sd.ID] = true
+				ids = append(ids, sd.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// findSD returns the first SD element with the given ID, or nil
+func findSD(sds []rfc5424.StructuredData, id string) *rfc5424.StructuredData {
+	for i := range sds {
+		if sds[i].ID == id {
+			return &sds[i]
+		}
+	}
+	return nil
+}
+
+// paramNames returns the parameter names of want, then those of got not
+// in want
+func paramNames(want, got []rfc5424.SDParam) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, params := range [][]rfc5424.SDParam{want, got} {
+		for _, param := range params {
+			if !seen[param.Name] {
+				seen[param.Name] = true
+				names = append(names, param.Name)
+			}
+		}
+	}
+	return names
+}
+
+// paramValues returns the values of the parameters with the given name
+func paramValues(params []rfc5424.SDParam, name string) []string {
+	var values []string
+	for _, param := range params {
+		if param.Name == name {
+			values = append(values, param.Value)
+		}
+	}
+	return values
+}
+
+// sameOrder reports whether want and got have their SD elements, and the
+// parameters of each, in the same order
+func sameOrder(want, got []rfc5424.StructuredData) bool {
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if want[i].ID != got[i].ID || len(want[i].Parameters) != len(got[i].Parameters) {
+			return false
+		}
+		for j := range want[i].Parameters {
+			if want[i].Parameters[j].Name != got[i].Parameters[j].Name {
+				return false
+			}
+		}
+	}
+	return true
+}
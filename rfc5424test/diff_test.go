@@ -0,0 +1,57 @@
+package rfc5424test
+
+import (
+	"time"
+
+	"github.com/secureworks/rfc5424"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&DiffTest{})
+
+type DiffTest struct {
+}
+
+func (testSuite *DiffTest) TestEqual(c *C) {
+	m := rfc5424.Message{Priority: 13, Timestamp: time.Unix(1e9, 0).UTC(), Hostname: "host"}
+	m.AddDatum("x@32473", "a", "1")
+	m2 := m.Clone()
+	m2.Timestamp = m.Timestamp.In(time.FixedZone("", 3600))
+	c.Assert(Diff(m, m2), Equals, "")
+}
+
+func (testSuite *DiffTest) TestDiff(c *C) {
+	want := rfc5424.Message{Priority: 13, Timestamp: time.Unix(1e9, 0).UTC(), Hostname: "host", AppName: "app"}
+	want.AddDatum("x@32473", "a", "1")
+	want.AddDatum("x@32473", "b", "2")
+	want.AddDatum("x@32473", "c", "3")
+	want.AddDatum("x@32473", "c", "4")
+	want.AddDatum("y@32473", "a", "1")
+	want.SetTextMessage("hello")
+
+	got := rfc5424.Message{Priority: 14, Hostname: "host", AppName: "app2"}
+	got.AddDatum("x@32473", "a", "1")
+	got.AddDatum("x@32473", "b", "two")
+	got.AddDatum("x@32473", "c", "3")
+	got.AddDatum("x@32473", "d", "")
+	got.AddDatum("z@32473", "a", "1")
+	got.SetTextMessage("hello")
+
+	c.Assert(Diff(want, got), Equals, `Priority: want 13, got 14
+Timestamp: want 2001-09-09T01:46:40Z, got -
+AppName: want "app", got "app2"
+StructuredData[x@32473]/b: want "2", got "two"
+StructuredData[x@32473]/c[1]: missing, want "4"
+StructuredData[x@32473]/d: unexpected ""
+StructuredData[y@32473]: missing
+StructuredData[z@32473]: unexpected`)
+}
+
+func (testSuite *DiffTest) TestOrder(c *C) {
+	want, got := rfc5424.Message{}, rfc5424.Message{}
+	want.AddDatum("x@32473", "a", "1")
+	want.AddDatum("y@32473", "a", "1")
+	got.AddDatum("y@32473", "a", "1")
+	got.AddDatum("x@32473", "a", "1")
+	c.Assert(Diff(want, got), Equals, "StructuredData: want order [x@32473 y@32473], got [y@32473 x@32473]")
+}
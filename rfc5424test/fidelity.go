@@ -0,0 +1,42 @@
+package rfc5424test
+
+import (
+	"fmt"
+
+	"github.com/secureworks/rfc5424"
+)
+
+// Fidelity checks that parsing raw and marshaling the result reproduces raw
+// byte-for-byte, as it must for a relay to forward the message unaltered.
+// It returns an error describing the first difference.
+//
+// Messages that use the optional forms RFC-5424 allows for values are
+// normalized, and so fail: trailing zeros in TIME-SECFRAC, a "+00:00"
+// TIME-OFFSET and unescaped backslashes in PARAM-VALUEs.
+func Fidelity(raw []byte) error {
+	m := rfc5424.Message{}
+	if err := m.UnmarshalBinary(raw); err != nil {
+		return err
+	}
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(raw) || i < len(b); i++ {
+		if i >= len(raw) || i >= len(b) || raw[i] != b[i] {
+			return fmt.Errorf("rfc5424test: round trip differs at offset %d: %q became %q", i, tail(raw, i), tail(b, i))
+		}
+	}
+	return nil
+}
+
+// tail returns up to 20 bytes of b from offset i
+func tail(b []byte, i int) []byte {
+	if i >= len(b) {
+		return nil
+	}
+	if len(b)-i > 20 {
+		return b[i : i+20]
+	}
+	return b[i:]
+}
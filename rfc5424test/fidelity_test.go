@@ -0,0 +1,27 @@
+package rfc5424test
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&FidelityTest{})
+
+type FidelityTest struct {
+}
+
+func (testSuite *FidelityTest) TestVectors(c *C) {
+	for _, v := range Vectors() {
+		if v.Valid {
+			c.Assert(Fidelity(v.Raw), IsNil, Commentf("%s", v.Name))
+		}
+	}
+	c.Assert(Fidelity([]byte("<13>1 - host app - - - ")), IsNil)
+	c.Assert(Fidelity([]byte(`<13>1 - host app - - [x@1 a="" b="\\"]`)), IsNil)
+}
+
+func (testSuite *FidelityTest) TestNormalized(c *C) {
+	c.Assert(Fidelity([]byte("<13>1 2003-10-11T22:14:15.300Z host app - - -")), ErrorMatches,
+		`rfc5424test: round trip differs at offset 27: "00Z host app - - -" became "Z host app - - -"`)
+	c.Assert(Fidelity([]byte("<13>1 2003-10-11T22:14:15+00:00 host app - - -")), NotNil)
+	c.Assert(Fidelity([]byte("not syslog")), NotNil)
+}
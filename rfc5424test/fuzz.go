@@ -0,0 +1,103 @@
+//go:build go1.18
+// +build go1.18
+
+package rfc5424test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/secureworks/rfc5424"
+)
+
+// FuzzUnmarshal is a fuzz target for the parser, seeded with the raw
+// messages of Vectors and with seeds, e.g. messages captured from devices
+// whose quirks should be covered. Any input may be rejected, but must not
+// crash the parser, and a message that parses must marshal to bytes that
+// parse to the same message. Call it from a fuzz test:
+//
+//	func FuzzUnmarshal(f *testing.F) { rfc5424test.FuzzUnmarshal(f, deviceCorpus...) }
+func FuzzUnmarshal(f *testing.F, seeds ...[]byte) {
+	for _, v := range Vectors() {
+		f.Add(v.Raw)
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		m := rfc5424.Message{}
+		if err := m.UnmarshalBinary(raw); err != nil {
+			return
+		}
+		b, err := m.MarshalBinary()
+		if err != nil {
+			return
+		}
+		m2 := rfc5424.Message{}
+		if err := m2.UnmarshalBinary(b); err != nil {
+			t.Fatalf("%q parsed, but its marshaled form %q did not: %s", raw, b, err)
+		}
+		if diff := Diff(m, m2); diff != "" {
+			t.Fatalf("%q changed when marshaled as %q:\n%s", raw, b, diff)
+		}
+	})
+}
+
+// FuzzRoundTrip is a fuzz target for marshaling, seeded with the fields of
+// the valid Vectors and with seeds. Every message that marshals must parse
+// back to bytes that marshal the same way. Call it from a fuzz test:
+//
+//	func FuzzRoundTrip(f *testing.F) { rfc5424test.FuzzRoundTrip(f) }
+func FuzzRoundTrip(f *testing.F, seeds ...rfc5424.Message) {
+	add := func(m rfc5424.Message) {
+		id, name, value := "", "", ""
+		if len(m.StructuredData) > 0 {
+			id = m.StructuredData[0].ID
+			if len(m.StructuredData[0].Parameters) > 0 {
+				name, value = m.StructuredData[0].Parameters[0].Name, m.StructuredData[0].Parameters[0].Value
+			}
+		}
+		f.Add(m.Priority, m.Timestamp.UnixNano(), m.Hostname, m.AppName, m.ProcessID, m.MessageID, id, name, value, m.Message)
+	}
+	for _, v := range Vectors() {
+		if v.Valid {
+			add(v.Message)
+		}
+	}
+	for _, seed := range seeds {
+		add(seed)
+	}
+	f.Fuzz(func(t *testing.T, priority int, unixNano int64, hostname, appName, processID, messageID,
+		id, name, value string, msg []byte) {
+		m := rfc5424.Message{
+			Priority:  priority,
+			Hostname:  hostname,
+			AppName:   appName,
+			ProcessID: processID,
+			MessageID: messageID,
+			Message:   msg,
+		}
+		if unixNano != 0 {
+			m.Timestamp = time.Unix(0, unixNano).UTC()
+		}
+		if id != "" {
+			m.AddDatum(id, name, value)
+		}
+		b, err := m.MarshalBinary()
+		if err != nil {
+			return
+		}
+		m2 := rfc5424.Message{}
+		if err := m2.UnmarshalBinary(b); err != nil {
+			t.Fatalf("%#v marshaled as %q, which did not parse: %s", m, b, err)
+		}
+		b2, err := m2.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%q parsed, but did not marshal again: %s", b, err)
+		}
+		if !bytes.Equal(b, b2) {
+			t.Fatalf("%q marshaled again as %q", b, b2)
+		}
+	})
+}
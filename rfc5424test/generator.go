@@ -0,0 +1,149 @@
+package rfc5424test
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/secureworks/rfc5424"
+)
+
+const (
+	defaultGeneratorMinSize = 16
+	defaultGeneratorMaxSize = 256
+)
+
+// printable is the alphabet used for generated message bodies
+const printable = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 .,:;-_/"
+
+// UniformSize returns a Generator.Size function choosing body sizes uniformly
+// from [min, max].
+func UniformSize(min, max int) func(r *rand.Rand) int {
+	return func(r *rand.Rand) int {
+		if max <= min {
+			return min
+		}
+		return min + r.Intn(max-min+1)
+	}
+}
+
+// NormalSize returns a Generator.Size function choosing body sizes from a
+// normal distribution, clamped to be at least zero.
+func NormalSize(mean, stddev float64) func(r *rand.Rand) int {
+	return func(r *rand.Rand) int {
+		size := int(r.NormFloat64()*stddev + mean)
+		if size < 0 {
+			return 0
+		}
+		return size
+	}
+}
+
+// GeneratorResult summarizes a run of a Generator
+type GeneratorResult struct {
+	Sent    int
+	Errors  int
+	Bytes   int64 // total length of the bodies of the messages sent
+	Elapsed time.Duration
+}
+
+// Generator produces valid, randomized messages and writes them to Writer at
+// a fixed rate, for load-testing collectors (including rfc5424.Server).
+// Writer may be any client transport.
+type Generator struct {
+	Writer rfc5424.MessageWriter
+
+	// Rate is the number of messages written per second. If zero, messages
+	// are written as fast as Writer accepts them.
+	Rate float64
+
+	// Size chooses the length of each message body. If nil, lengths are
+	// uniform between 16 and 256 bytes.
+	Size func(r *rand.Rand) int
+
+	// Hostnames and AppNames are chosen from at random. If empty, names of
+	// the form "host-N" and "app-N" are used.
+	Hostnames []string
+	AppNames  []string
+
+	// Rand is the source of randomness. If nil, one seeded from the clock is
+	// created, so set it for reproducible runs.
+	Rand *rand.Rand
+}
+
+func (g *Generator) pick(names []string, prefix string) string {
+	if len(names) > 0 {
+		return names[g.Rand.Intn(len(names))]
+	}
+	return prefix + strconv.Itoa(g.Rand.Intn(10))
+}
+
+// Message returns a random message
+func (g *Generator) Message() rfc5424.Message {
+	if g.Rand == nil {
+		g.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	size := g.Size
+	if size == nil {
+		size = UniformSize(defaultGeneratorMinSize, defaultGeneratorMaxSize)
+	}
+
+	body := make([]byte, size(g.Rand))
+	for i := range body {
+		body[i] = printable[g.Rand.Intn(len(printable))]
+	}
+	m := rfc5424.Message{
+		Priority:  g.Rand.Intn(24 * 8),
+		Timestamp: rfc5424.TimeNow().UTC(),
+		Hostname:  g.pick(g.Hostnames, "host-"),
+		AppName:   g.pick(g.AppNames, "app-"),
+		ProcessID: strconv.Itoa(1 + g.Rand.Intn(32767)),
+		MessageID: "ID" + strconv.Itoa(g.Rand.Intn(100)),
+		Message:   body,
+	}
+	if g.Rand.Intn(2) == 0 {
+		m.AddDatum("load@32473", "seq", strconv.Itoa(g.Rand.Int()))
+	}
+	return m
+}
+
+// Run writes n messages, or writes messages until ctx is done if n is zero.
+// Write errors are counted rather than stopping the run. Run returns the
+// context's error if it was cancelled before n messages were written.
+func (g *Generator) Run(ctx context.Context, n int) (GeneratorResult, error) {
+	result := GeneratorResult{}
+	start := time.Now()
+	var interval time.Duration
+	if g.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / g.Rate)
+	}
+
+	next := start
+	for n == 0 || result.Sent+result.Errors < n {
+		if interval > 0 {
+			next = next.Add(interval)
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				result.Elapsed = time.Since(start)
+				return result, ctx.Err()
+			case <-timer.C:
+			}
+		} else if ctx.Err() != nil {
+			result.Elapsed = time.Since(start)
+			return result, ctx.Err()
+		}
+
+		m := g.Message()
+		if err := g.Writer.WriteMessage(m); err != nil {
+			result.Errors++
+			continue
+		}
+		result.Sent++
+		result.Bytes += int64(len(m.Message))
+	}
+	result.Elapsed = time.Since(start)
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package rfc5424test
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/secureworks/rfc5424"
+)
+
+var _ = Suite(&GeneratorTest{})
+
+type GeneratorTest struct {
+}
+
+func (testSuite *GeneratorTest) TestGeneratesValidMessages(c *C) {
+	fw := NewFakeWriter()
+	g := &Generator{
+		Writer:    fw,
+		Size:      UniformSize(10, 20),
+		Hostnames: []string{"a", "b"},
+		Rand:      rand.New(rand.NewSource(1)),
+	}
+	result, err := g.Run(context.Background(), 100)
+	c.Assert(err, IsNil)
+	c.Assert(result.Sent, Equals, 100)
+	c.Assert(result.Errors, Equals, 0)
+
+	var bytes int64
+	for i := 0; i < 100; i++ {
+		m := rfc5424.Message{}
+		c.Assert(m.UnmarshalBinary([]byte(<-fw.Messages)), IsNil)
+		c.Assert(len(m.Message) >= 10 && len(m.Message) <= 20, Equals, true)
+		c.Assert(m.Hostname == "a" || m.Hostname == "b", Equals, true)
+		bytes += int64(len(m.Message))
+	}
+	c.Assert(result.Bytes, Equals, bytes)
+
+	// The same seed produces the same messages
+	g1 := &Generator{Rand: rand.New(rand.NewSource(2))}
+	g2 := &Generator{Rand: rand.New(rand.NewSource(2))}
+	m1, m2 := g1.Message(), g2.Message()
+	m2.Timestamp = m1.Timestamp
+	c.Assert(m1, DeepEquals, m2)
+}
+
+func (testSuite *GeneratorTest) TestRate(c *C) {
+	fw := NewFakeWriter()
+	g := &Generator{Writer: fw, Rate: 1000}
+	result, err := g.Run(context.Background(), 20)
+	c.Assert(err, IsNil)
+	c.Assert(result.Sent, Equals, 20)
+	c.Assert(result.Elapsed >= 20*time.Millisecond, Equals, true, Commentf("%s", result.Elapsed))
+
+	// Without n, the generator runs until cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	g.Rate = 100
+	result, err = g.Run(ctx, 0)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+	c.Assert(result.Sent <= 3, Equals, true, Commentf("%d", result.Sent))
+}
@@ -0,0 +1,56 @@
+package rfc5424test
+
+import (
+	"sync"
+	"time"
+
+	"github.com/secureworks/rfc5424"
+)
+
+// MemoryStore implements rfc5424.Store in memory, for testing.
+type MemoryStore struct {
+	mu       sync.Mutex
+	Messages []rfc5424.Message
+
+	// Error, if non-nil, is returned by Append and AppendBatch instead of
+	// storing the messages.
+	Error error
+}
+
+// Append stores m
+func (ms *MemoryStore) Append(m rfc5424.Message) error {
+	return ms.AppendBatch([]rfc5424.Message{m})
+}
+
+// AppendBatch stores messages
+func (ms *MemoryStore) AppendBatch(messages []rfc5424.Message) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.Error != nil {
+		return ms.Error
+	}
+	ms.Messages = append(ms.Messages, messages...)
+	return nil
+}
+
+// Query calls fn for each stored message with a timestamp in [start, end)
+func (ms *MemoryStore) Query(start, end time.Time, fn func(m rfc5424.Message) error) error {
+	ms.mu.Lock()
+	messages := append([]rfc5424.Message(nil), ms.Messages...)
+	ms.mu.Unlock()
+
+	for _, m := range messages {
+		if m.Timestamp.Before(start) || !m.Timestamp.Before(end) {
+			continue
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close does nothing
+func (ms *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,6 @@
+//go:build !race
+// +build !race
+
+package rfc5424test
+
+const raceEnabled = false
@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package rfc5424test
+
+// raceEnabled is true when the tests run under the race detector, which
+// changes allocation counts
+const raceEnabled = true
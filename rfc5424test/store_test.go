@@ -0,0 +1,42 @@
+package rfc5424test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/secureworks/rfc5424"
+)
+
+var _ = Suite(&StoreTest{})
+
+type StoreTest struct {
+}
+
+func (testSuite *StoreTest) TestStoreHandler(c *C) {
+	store := &MemoryStore{}
+	var _ rfc5424.Store = store
+
+	errs := []error{}
+	h := rfc5424.StoreHandler(store, func(err error) { errs = append(errs, err) })
+	t0 := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		h.Handle(context.Background(),
+			rfc5424.Message{Timestamp: t0.Add(time.Duration(i) * time.Hour)},
+			rfc5424.Source{})
+	}
+
+	store.Error = errors.New("disk full")
+	h.Handle(context.Background(), rfc5424.Message{}, rfc5424.Source{})
+	c.Assert(errs, DeepEquals, []error{store.Error})
+
+	found := []time.Time{}
+	err := store.Query(t0.Add(time.Hour), t0.Add(3*time.Hour), func(m rfc5424.Message) error {
+		found = append(found, m.Timestamp)
+		return nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(found, DeepEquals, []time.Time{t0.Add(time.Hour), t0.Add(2 * time.Hour)})
+}
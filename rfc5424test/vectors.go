@@ -0,0 +1,141 @@
+package rfc5424test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/secureworks/rfc5424"
+)
+
+// Vector is a conformance test case: a message as sent on the wire, whether
+// RFC-5424 allows it, and for valid messages the expected parse.
+type Vector struct {
+	Name  string
+	Raw   []byte
+	Valid bool
+
+	// Message is the expected result of parsing Raw. It is only set for
+	// valid vectors. Timestamps should be compared with time.Time.Equal.
+	Message rfc5424.Message
+}
+
+func vectorTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+const bom = "\xef\xbb\xbf"
+
+// Vectors returns a corpus of valid and invalid RFC-5424 messages, drawn
+// from the examples of the RFC and from quirks seen in interoperability
+// testing, to check parsers and transports against.
+func Vectors() []Vector {
+	exampleSD := rfc5424.StructuredData{ID: "exampleSDID@32473", Parameters: []rfc5424.SDParam{
+		{Name: "iut", Value: "3"},
+		{Name: "eventSource", Value: "Application"},
+		{Name: "eventID", Value: "1011"},
+	}}
+	valid := []Vector{
+		{
+			Name: "RFC-5424 example 1",
+			Raw:  []byte("<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - " + bom + "'su root' failed for lonvick on /dev/pts/8"),
+			Message: rfc5424.Message{Priority: 34, Timestamp: vectorTime("2003-10-11T22:14:15.003Z"),
+				Hostname: "mymachine.example.com", AppName: "su", MessageID: "ID47",
+				Message: []byte(bom + "'su root' failed for lonvick on /dev/pts/8")},
+		},
+		{
+			Name: "RFC-5424 example 2",
+			Raw:  []byte("<165>1 2003-08-24T05:14:15.000003-07:00 192.0.2.1 myproc 8710 - - %% It's time to make the do-nuts."),
+			Message: rfc5424.Message{Priority: 165, Timestamp: vectorTime("2003-08-24T05:14:15.000003-07:00"),
+				Hostname: "192.0.2.1", AppName: "myproc", ProcessID: "8710",
+				Message: []byte("%% It's time to make the do-nuts.")},
+		},
+		{
+			Name: "RFC-5424 example 3",
+			Raw: []byte(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 ` +
+				`[exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"] ` + bom + `An application event log entry...`),
+			Message: rfc5424.Message{Priority: 165, Timestamp: vectorTime("2003-10-11T22:14:15.003Z"),
+				Hostname: "mymachine.example.com", AppName: "evntslog", MessageID: "ID47",
+				StructuredData: []rfc5424.StructuredData{exampleSD},
+				Message:        []byte(bom + "An application event log entry...")},
+		},
+		{
+			Name: "RFC-5424 example 4",
+			Raw: []byte(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 ` +
+				`[exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"][examplePriority@32473 class="high"]`),
+			Message: rfc5424.Message{Priority: 165, Timestamp: vectorTime("2003-10-11T22:14:15.003Z"),
+				Hostname: "mymachine.example.com", AppName: "evntslog", MessageID: "ID47",
+				StructuredData: []rfc5424.StructuredData{exampleSD, {ID: "examplePriority@32473",
+					Parameters: []rfc5424.SDParam{{Name: "class", Value: "high"}}}}},
+		},
+		{
+			Name:    "every field NILVALUE",
+			Raw:     []byte("<0>1 - - - - - -"),
+			Message: rfc5424.Message{},
+		},
+		{
+			Name:    "largest PRIVAL",
+			Raw:     []byte("<191>1 2003-10-11T22:14:15Z host app - - -"),
+			Message: rfc5424.Message{Priority: 191, Timestamp: vectorTime("2003-10-11T22:14:15Z"), Hostname: "host", AppName: "app"},
+		},
+		{
+			Name: "escaped PARAM-VALUE",
+			Raw:  []byte(`<13>1 2003-10-11T22:14:15Z host app - - [x@32473 v="a\"b\\c\]d"]`),
+			Message: rfc5424.Message{Priority: 13, Timestamp: vectorTime("2003-10-11T22:14:15Z"), Hostname: "host", AppName: "app",
+				StructuredData: []rfc5424.StructuredData{{ID: "x@32473", Parameters: []rfc5424.SDParam{{Name: "v", Value: `a"b\c]d`}}}}},
+		},
+		{
+			Name: "repeated PARAM-NAME",
+			Raw:  []byte(`<13>1 2003-10-11T22:14:15Z host app - - [x@32473 ip="192.0.2.1" ip="192.0.2.2"]`),
+			Message: rfc5424.Message{Priority: 13, Timestamp: vectorTime("2003-10-11T22:14:15Z"), Hostname: "host", AppName: "app",
+				StructuredData: []rfc5424.StructuredData{{ID: "x@32473", Parameters: []rfc5424.SDParam{
+					{Name: "ip", Value: "192.0.2.1"}, {Name: "ip", Value: "192.0.2.2"}}}}},
+		},
+		{
+			Name: "MSG-ANY that is not UTF-8",
+			Raw:  []byte("<13>1 2003-10-11T22:14:15Z host app - - - \xff\xfe"),
+			Message: rfc5424.Message{Priority: 13, Timestamp: vectorTime("2003-10-11T22:14:15Z"), Hostname: "host", AppName: "app",
+				Message: []byte("\xff\xfe")},
+		},
+		{
+			Name: "longest header fields",
+			Raw: []byte("<13>1 2003-10-11T22:14:15.123456+14:00 " + strings.Repeat("h", 255) + " " + strings.Repeat("a", 48) +
+				" " + strings.Repeat("p", 128) + " " + strings.Repeat("m", 32) + " -"),
+			Message: rfc5424.Message{Priority: 13, Timestamp: vectorTime("2003-10-11T22:14:15.123456+14:00"),
+				Hostname: strings.Repeat("h", 255), AppName: strings.Repeat("a", 48),
+				ProcessID: strings.Repeat("p", 128), MessageID: strings.Repeat("m", 32)},
+		},
+	}
+	for i := range valid {
+		valid[i].Valid = true
+		if valid[i].Message.StructuredData == nil {
+			valid[i].Message.StructuredData = []rfc5424.StructuredData{}
+		}
+	}
+
+	invalid := []Vector{
+		{Name: "empty", Raw: []byte("")},
+		{Name: "RFC-3164 message", Raw: []byte("<34>Oct 11 22:14:15 mymachine su: 'su root' failed")},
+		{Name: "PRIVAL above 191", Raw: []byte("<192>1 2003-10-11T22:14:15Z host app - - -")},
+		{Name: "PRIVAL with four digits", Raw: []byte("<0013>1 2003-10-11T22:14:15Z host app - - -")},
+		{Name: "unknown VERSION", Raw: []byte("<13>2 2003-10-11T22:14:15Z host app - - -")},
+		{Name: "missing VERSION", Raw: []byte("<13> 2003-10-11T22:14:15Z host app - - -")},
+		{Name: "lowercase T in TIMESTAMP", Raw: []byte("<13>1 2003-10-11t22:14:15Z host app - - -")},
+		{Name: "TIMESTAMP without offset", Raw: []byte("<13>1 2003-10-11T22:14:15 host app - - -")},
+		{Name: "seven digits of TIME-SECFRAC", Raw: []byte("<13>1 2003-10-11T22:14:15.1234567Z host app - - -")},
+		{Name: "leap second", Raw: []byte("<13>1 2016-12-31T23:59:60Z host app - - -")},
+		{Name: "impossible date", Raw: []byte("<13>1 2003-02-30T22:14:15Z host app - - -")},
+		{Name: "HOSTNAME too long", Raw: []byte("<13>1 2003-10-11T22:14:15Z " + strings.Repeat("h", 256) + " app - - -")},
+		{Name: "APP-NAME too long", Raw: []byte("<13>1 2003-10-11T22:14:15Z host " + strings.Repeat("a", 49) + " - - -")},
+		{Name: "MSGID too long", Raw: []byte("<13>1 2003-10-11T22:14:15Z host app - " + strings.Repeat("m", 33) + " -")},
+		{Name: "non-ASCII HOSTNAME", Raw: []byte("<13>1 2003-10-11T22:14:15Z hôst app - - -")},
+		{Name: "missing STRUCTURED-DATA", Raw: []byte("<13>1 2003-10-11T22:14:15Z host app - -")},
+		{Name: "unterminated SD-ELEMENT", Raw: []byte(`<13>1 2003-10-11T22:14:15Z host app - - [x@32473 v="1"`)},
+		{Name: "unquoted PARAM-VALUE", Raw: []byte(`<13>1 2003-10-11T22:14:15Z host app - - [x@32473 v=1]`)},
+		{Name: "MSG-UTF8 that is not UTF-8", Raw: []byte("<13>1 2003-10-11T22:14:15Z host app - - - " + bom + "\xff\xfe")},
+	}
+	return append(valid, invalid...)
+}
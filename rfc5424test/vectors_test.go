@@ -0,0 +1,27 @@
+package rfc5424test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/secureworks/rfc5424"
+)
+
+var _ = Suite(&VectorsTest{})
+
+type VectorsTest struct {
+}
+
+func (testSuite *VectorsTest) TestParser(c *C) {
+	for _, v := range Vectors() {
+		m := rfc5424.Message{}
+		err := m.UnmarshalBinary(v.Raw)
+		if !v.Valid {
+			c.Assert(err, NotNil, Commentf("%s: %q", v.Name, v.Raw))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf("%s", v.Name))
+		c.Assert(m.Timestamp.Equal(v.Message.Timestamp), Equals, true, Commentf("%s", v.Name))
+		m.Timestamp = v.Message.Timestamp
+		c.Assert(m, DeepEquals, v.Message, Commentf("%s", v.Name))
+	}
+}
@@ -30,16 +30,16 @@ func (testSuite *MultiMessageWriterTest) TestStuff(c *C) {
 	err = mmw.WriteMessage(msg)
 	c.Assert(err, IsNil)
 	m := <-fw1.Messages
-	c.Assert(m, Equals, "<0>1 0001-01-01T00:00:00Z - - - one -")
+	c.Assert(m, Equals, "<0>1 - - - - one -")
 	m = <-fw2.Messages
-	c.Assert(m, Equals, "<0>1 0001-01-01T00:00:00Z - - - one -")
+	c.Assert(m, Equals, "<0>1 - - - - one -")
 
 	// Writes to one and the other fails
 	fw1.Error = errors.New("Couldn't frob the grob")
 	err = mmw.WriteMessage(msg)
 	c.Assert(err, ErrorMatches, fw1.Error.Error())
 	m = <-fw2.Messages
-	c.Assert(m, Equals, "<0>1 0001-01-01T00:00:00Z - - - one -")
+	c.Assert(m, Equals, "<0>1 - - - - one -")
 
 	// Closes upstream on close
 	mmw.Close()
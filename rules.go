@@ -0,0 +1,203 @@
+package rfc5424
+
+import (
+	"context"
+	"fmt"
+	"path"
+)
+
+// RuleAction is what happens to a message matched by a Rule
+type RuleAction int
+
+const (
+	// ActionAccept passes the message on. Evaluation stops.
+	ActionAccept RuleAction = iota
+	// ActionDrop discards the message. Evaluation stops.
+	ActionDrop
+	// ActionRoute sends the message to the handler named by Rule.Route.
+	// Evaluation stops.
+	ActionRoute
+	// ActionTag adds Rule.Tag to the message's structured data. Evaluation
+	// continues with the next rule.
+	ActionTag
+)
+
+var ruleActionNames = []string{"accept", "drop", "route", "tag"}
+
+func (a RuleAction) String() string {
+	if a >= 0 && int(a) < len(ruleActionNames) {
+		return ruleActionNames[a]
+	}
+	return fmt.Sprintf("RuleAction(%d)", int(a))
+}
+
+// MarshalText returns the name of the action, e.g. "drop"
+func (a RuleAction) MarshalText() ([]byte, error) {
+	if a < 0 || int(a) >= len(ruleActionNames) {
+		return nil, InvalidValue("RuleAction", int(a))
+	}
+	return []byte(ruleActionNames[a]), nil
+}
+
+// UnmarshalText parses the name of an action, so that rules can be loaded
+// from configuration files.
+func (a *RuleAction) UnmarshalText(text []byte) error {
+	for i, name := range ruleActionNames {
+		if name == string(text) {
+			*a = RuleAction(i)
+			return nil
+		}
+	}
+	return InvalidValue("RuleAction", string(text))
+}
+
+// SDMatch matches a structured data parameter. Value is a glob pattern as
+// used by path.Match; an empty Value matches any value.
+type SDMatch struct {
+	ID    string `json:"id" yaml:"id"`
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// SDTag is a structured data parameter added to messages by ActionTag
+type SDTag struct {
+	ID    string `json:"id" yaml:"id"`
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// Rule matches messages and says what to do with them. Empty conditions
+// match every message; a message matches the rule if it matches all of the
+// non-empty conditions.
+type Rule struct {
+	// Facilities lists the facilities to match
+	Facilities []Facility `json:"facilities,omitempty" yaml:"facilities,omitempty"`
+
+	// MinSeverity matches messages at least as severe as it, so Warning
+	// matches Warning, Error, ... Emergency. MaxSeverity matches messages at
+	// most as severe as it, so Error matches Error, Warning, ... Debug.
+	MinSeverity Severity `json:"minSeverity,omitempty" yaml:"minSeverity,omitempty"`
+	MaxSeverity Severity `json:"maxSeverity,omitempty" yaml:"maxSeverity,omitempty"`
+
+	// Hostname and AppName are glob patterns as used by path.Match
+	Hostname string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	AppName  string `json:"appName,omitempty" yaml:"appName,omitempty"`
+
+	// SD lists structured data parameters that must all be present
+	SD []SDMatch `json:"sd,omitempty" yaml:"sd,omitempty"`
+
+	Action RuleAction `json:"action" yaml:"action"`
+	Route  string     `json:"route,omitempty" yaml:"route,omitempty"`
+	Tag    *SDTag     `json:"tag,omitempty" yaml:"tag,omitempty"`
+}
+
+// glob reports whether value matches pattern, treating an empty pattern as
+// matching anything. Malformed patterns match nothing.
+func glob(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+// Matches reports whether m satisfies all of the rule's conditions
+func (r Rule) Matches(m Message) bool {
+	if len(r.Facilities) > 0 {
+		found := false
+		for _, facility := range r.Facilities {
+			if m.Facility() == facility {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Severities are numbered from most to least severe
+	severity := m.Severity()
+	if r.MinSeverity != DefaultSeverity && severity > r.MinSeverity {
+		return false
+	}
+	if r.MaxSeverity != DefaultSeverity && severity < r.MaxSeverity {
+		return false
+	}
+
+	if !glob(r.Hostname, m.Hostname) || !glob(r.AppName, m.AppName) {
+		return false
+	}
+
+	for _, sdMatch := range r.SD {
+		if !matchesSD(m, sdMatch) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesSD(m Message, sdMatch SDMatch) bool {
+	for _, sd := range m.StructuredData {
+		if sd.ID != sdMatch.ID {
+			continue
+		}
+		for _, param := range sd.Parameters {
+			if param.Name == sdMatch.Name && glob(sdMatch.Value, param.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RuleSet is an ordered list of rules, evaluated against each message until
+// one with a terminal action (accept, drop or route) matches.
+type RuleSet struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+
+	// Default is the action taken when no terminal rule matches. The zero
+	// value accepts the message.
+	Default RuleAction `json:"default" yaml:"default"`
+}
+
+// Evaluate applies the rule set to m, adding any tags, and returns the final
+// action. For ActionRoute the name of the route is also returned.
+func (rs RuleSet) Evaluate(m *Message) (RuleAction, string) {
+	for _, rule := range rs.Rules {
+		if !rule.Matches(*m) {
+			continue
+		}
+		switch rule.Action {
+		case ActionTag:
+			if rule.Tag != nil {
+				m.AddDatum(rule.Tag.ID, rule.Tag.Name, rule.Tag.Value)
+			}
+		case ActionRoute:
+			return ActionRoute, rule.Route
+		default:
+			return rule.Action, ""
+		}
+	}
+	return rs.Default, ""
+}
+
+// Handler returns a Handler that evaluates the rule set for each message.
+// Accepted messages are passed to next, routed messages to the matching
+// handler in routes (or next, if the route is not known) and dropped messages
+// are discarded.
+func (rs RuleSet) Handler(next Handler, routes map[string]Handler) Handler {
+	return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+		action, route := rs.Evaluate(&m)
+		switch action {
+		case ActionDrop:
+			return
+		case ActionRoute:
+			if h, ok := routes[route]; ok {
+				h.Handle(ctx, m, src)
+				return
+			}
+		}
+		next.Handle(ctx, m, src)
+	})
+}
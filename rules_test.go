@@ -0,0 +1,85 @@
+package rfc5424
+
+import (
+	"context"
+	"encoding/json"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&RulesTest{})
+
+type RulesTest struct {
+}
+
+func priority(facility Facility, severity Severity) int {
+	return int(severity-Emergency) | int(facility-Kernel)<<3
+}
+
+func (s *RulesTest) TestEvaluate(c *C) {
+	rs := RuleSet{}
+	// 8 is Debug, 5 is Auth and 4 is Error
+	err := json.Unmarshal([]byte(`{
+		"rules": [
+			{"appName": "noisy*", "maxSeverity": 8, "action": "drop"},
+			{"sd": [{"id": "x@1", "name": "user", "value": "root"}],
+			 "action": "tag", "tag": {"id": "x@1", "name": "privileged", "value": "true"}},
+			{"facilities": [5], "action": "route", "route": "security"},
+			{"hostname": "db-*", "minSeverity": 4, "action": "route", "route": "dba"}
+		],
+		"default": "accept"
+	}`), &rs)
+	c.Assert(err, IsNil)
+
+	m := Message{AppName: "noisyd", Priority: priority(User, Debug)}
+	action, _ := rs.Evaluate(&m)
+	c.Assert(action, Equals, ActionDrop)
+
+	m = Message{AppName: "noisyd", Priority: priority(User, Info)}
+	action, _ = rs.Evaluate(&m)
+	c.Assert(action, Equals, ActionAccept)
+
+	m = Message{Priority: priority(Auth, Notice)}
+	m.AddDatum("x@1", "user", "root")
+	action, route := rs.Evaluate(&m)
+	c.Assert(action, Equals, ActionRoute)
+	c.Assert(route, Equals, "security")
+	c.Assert(m.StructuredData[0].Parameters[1], DeepEquals, SDParam{Name: "privileged", Value: "true"})
+
+	m = Message{Hostname: "db-1", Priority: priority(Daemon, Error)}
+	action, route = rs.Evaluate(&m)
+	c.Assert(action, Equals, ActionRoute)
+	c.Assert(route, Equals, "dba")
+
+	m = Message{Hostname: "db-1", Priority: priority(Daemon, Warning)}
+	action, _ = rs.Evaluate(&m)
+	c.Assert(action, Equals, ActionAccept)
+}
+
+func (s *RulesTest) TestHandler(c *C) {
+	rs := RuleSet{
+		Rules: []Rule{
+			{AppName: "sshd", Action: ActionRoute, Route: "security"},
+			{AppName: "cron", Action: ActionDrop},
+		},
+	}
+	general := make(chanHandler, 10)
+	security := make(chanHandler, 10)
+	h := rs.Handler(general, map[string]Handler{"security": security})
+	for _, appName := range []string{"sshd", "cron", "nginx"} {
+		h.Handle(context.Background(), Message{AppName: appName}, Source{})
+	}
+	c.Assert((<-security).Message.AppName, Equals, "sshd")
+	c.Assert((<-general).Message.AppName, Equals, "nginx")
+	c.Assert(len(general)+len(security), Equals, 0)
+}
+
+func (s *RulesTest) TestRuleActionText(c *C) {
+	var a RuleAction
+	c.Assert(a.UnmarshalText([]byte("frob")), NotNil)
+	c.Assert(a.UnmarshalText([]byte("tag")), IsNil)
+	c.Assert(a, Equals, ActionTag)
+	text, err := a.MarshalText()
+	c.Assert(err, IsNil)
+	c.Assert(string(text), Equals, "tag")
+}
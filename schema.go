@@ -0,0 +1,173 @@
+package rfc5424
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ParamType is the type of the values of a declared SD parameter
+type ParamType int
+
+const (
+	// ParamString allows any value
+	ParamString ParamType = iota
+	// ParamInt allows decimal integers
+	ParamInt
+	// ParamBool allows the values accepted by strconv.ParseBool
+	ParamBool
+	// ParamFloat allows the values accepted by strconv.ParseFloat
+	ParamFloat
+	// ParamTime allows RFC-3339 timestamps
+	ParamTime
+	// ParamIP allows IPv4 and IPv6 addresses
+	ParamIP
+)
+
+var paramTypeNames = []string{"string", "int", "bool", "float", "time", "ip"}
+
+func (t ParamType) String() string {
+	if t >= 0 && int(t) < len(paramTypeNames) {
+		return paramTypeNames[t]
+	}
+	return fmt.Sprintf("ParamType(%d)", int(t))
+}
+
+// valid reports whether value is of type t
+func (t ParamType) valid(value string) bool {
+	var err error
+	switch t {
+	case ParamInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case ParamBool:
+		_, err = strconv.ParseBool(value)
+	case ParamFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case ParamTime:
+		_, err = time.Parse(time.RFC3339Nano, value)
+	case ParamIP:
+		return net.ParseIP(value) != nil
+	}
+	return err == nil
+}
+
+// SDSchema declares an SD element: its SD-ID and the names and types of its
+// parameters
+type SDSchema struct {
+	ID     string
+	Params map[string]ParamType
+
+	// Required lists the parameters that every element must have
+	Required []string
+}
+
+// SchemaPolicy says what happens to messages that do not follow their
+// schemas
+type SchemaPolicy int
+
+const (
+	// SchemaWarn reports the problem and lets the message through
+	SchemaWarn SchemaPolicy = iota
+	// SchemaError refuses the message
+	SchemaError
+)
+
+// SchemaRegistry holds the SD elements declared by an application, so that
+// the parameters it logs stay consistent across a large code base. Set it
+// as the Schemas of a Logger to check every message; the zero value is
+// ready to use.
+type SchemaRegistry struct {
+	// Policy says what happens to messages with undeclared parameters or
+	// values of the wrong type
+	Policy SchemaPolicy
+
+	// Closed makes SD elements whose SD-ID is not declared problems too.
+	// By default they are not checked, so that the elements of libraries
+	// and of RFC-5424 itself, such as origin, need not be declared.
+	Closed bool
+
+	// Warn, if set, is called with the problems found under SchemaWarn. If
+	// nil, they are logged by the log package's standard logger.
+	Warn func(err error)
+
+	mu      sync.RWMutex
+	schemas map[string]SDSchema
+}
+
+// Declare adds schema to the registry. It returns an error if its SD-ID is
+// invalid or already declared.
+func (r *SchemaRegistry) Declare(schema SDSchema) error {
+	if _, _, err := ParseSDID(schema.ID); err != nil {
+		return err
+	}
+	for _, name := range schema.Required {
+		if _, ok := schema.Params[name]; !ok {
+			return fmt.Errorf("rfc5424: required parameter %q of %s is not declared", name, schema.ID)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.schemas[schema.ID]; ok {
+		return fmt.Errorf("rfc5424: SD-ID %q is already declared", schema.ID)
+	}
+	if r.schemas == nil {
+		r.schemas = map[string]SDSchema{}
+	}
+	r.schemas[schema.ID] = schema
+	return nil
+}
+
+// Schema returns the schema declared for the SD-ID id
+func (r *SchemaRegistry) Schema(id string) (SDSchema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schema, ok := r.schemas[id]
+	return schema, ok
+}
+
+// Validate returns an error describing the first problem with the SD
+// elements of m, or nil if they follow their schemas. It ignores Policy.
+func (r *SchemaRegistry) Validate(m Message) error {
+	for _, sd := range m.StructuredData {
+		schema, ok := r.Schema(sd.ID)
+		if !ok {
+			if r.Closed {
+				return fmt.Errorf("rfc5424: SD-ID %q is not declared", sd.ID)
+			}
+			continue
+		}
+		for _, param := range sd.Parameters {
+			t, ok := schema.Params[param.Name]
+			if !ok {
+				return fmt.Errorf("rfc5424: parameter %q of %s is not declared", param.Name, sd.ID)
+			}
+			if !t.valid(param.Value) {
+				return fmt.Errorf("rfc5424: parameter %q of %s must be of type %s: %q", param.Name, sd.ID, t, param.Value)
+			}
+		}
+		for _, name := range schema.Required {
+			if Params(sd.Parameters).Values(name) == nil {
+				return fmt.Errorf("rfc5424: %s is missing required parameter %q", sd.ID, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Check validates m and applies Policy: it returns the problem under
+// SchemaError, and reports it and returns nil under SchemaWarn.
+func (r *SchemaRegistry) Check(m Message) error {
+	err := r.Validate(m)
+	if err == nil || r.Policy == SchemaError {
+		return err
+	}
+	if r.Warn != nil {
+		r.Warn(err)
+	} else {
+		log.Print(err)
+	}
+	return nil
+}
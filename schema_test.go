@@ -0,0 +1,80 @@
+package rfc5424
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&SchemaTest{})
+
+type SchemaTest struct {
+}
+
+var testSchema = SDSchema{
+	ID: "request@32473",
+	Params: map[string]ParamType{
+		"method": ParamString,
+		"status": ParamInt,
+		"cached": ParamBool,
+		"client": ParamIP,
+		"start":  ParamTime,
+		"ratio":  ParamFloat,
+	},
+	Required: []string{"method"},
+}
+
+func (s *SchemaTest) TestDeclare(c *C) {
+	r := &SchemaRegistry{}
+	c.Assert(r.Declare(testSchema), IsNil)
+	c.Assert(r.Declare(testSchema), ErrorMatches, `rfc5424: SD-ID "request@32473" is already declared`)
+	c.Assert(r.Declare(SDSchema{ID: "request"}), NotNil)
+	c.Assert(r.Declare(SDSchema{ID: "other@32473", Required: []string{"a"}}), ErrorMatches,
+		`rfc5424: required parameter "a" of other@32473 is not declared`)
+	schema, ok := r.Schema("request@32473")
+	c.Assert(ok, Equals, true)
+	c.Assert(schema.Params["status"], Equals, ParamInt)
+}
+
+func (s *SchemaTest) TestValidate(c *C) {
+	r := &SchemaRegistry{}
+	c.Assert(r.Declare(testSchema), IsNil)
+
+	tests := []struct {
+		params []SDParam
+		err    string
+	}{
+		{[]SDParam{{"method", "GET"}, {"status", "200"}, {"cached", "true"}, {"client", "::1"},
+			{"start", "2003-10-11T22:14:15.003Z"}, {"ratio", "0.5"}}, ""},
+		{[]SDParam{{"method", "GET"}, {"path", "/"}}, `rfc5424: parameter "path" of request@32473 is not declared`},
+		{[]SDParam{{"method", "GET"}, {"status", "ok"}}, `rfc5424: parameter "status" of request@32473 must be of type int: "ok"`},
+		{[]SDParam{{"method", "GET"}, {"client", "localhost"}}, `rfc5424: parameter "client" of request@32473 must be of type ip: "localhost"`},
+		{[]SDParam{{"status", "200"}}, `rfc5424: request@32473 is missing required parameter "method"`},
+	}
+	for _, test := range tests {
+		m := Message{StructuredData: []StructuredData{{ID: "request@32473", Parameters: test.params}}}
+		m.AddDatum(OriginSDID, "ip", "192.0.2.1")
+		if test.err == "" {
+			c.Assert(r.Validate(m), IsNil)
+		} else {
+			c.Assert(r.Validate(m), ErrorMatches, test.err)
+		}
+	}
+
+	m := Message{}
+	m.AddDatum(OriginSDID, "ip", "192.0.2.1")
+	r.Closed = true
+	c.Assert(r.Validate(m), ErrorMatches, `rfc5424: SD-ID "origin" is not declared`)
+}
+
+func (s *SchemaTest) TestCheck(c *C) {
+	var warnings []error
+	r := &SchemaRegistry{Warn: func(err error) { warnings = append(warnings, err) }}
+	c.Assert(r.Declare(testSchema), IsNil)
+	m := Message{}
+	m.AddDatum("request@32473", "path", "/")
+
+	c.Assert(r.Check(m), IsNil)
+	c.Assert(warnings, HasLen, 1)
+	r.Policy = SchemaError
+	c.Assert(r.Check(m), ErrorMatches, `rfc5424: parameter "path" of request@32473 is not declared`)
+	c.Assert(warnings, HasLen, 1)
+}
@@ -0,0 +1,125 @@
+package rfc5424
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The IDs of the SD elements registered by RFC-5424 section 7
+const (
+	TimeQualitySDID = "timeQuality"
+	OriginSDID      = "origin"
+	MetaSDID        = "meta"
+)
+
+// maxSequenceID is the largest meta sequenceId, after which it wraps to 1
+const maxSequenceID = 2147483647
+
+// TimeQuality describes the sender's clock, as reported by the timeQuality
+// SD element.
+type TimeQuality struct {
+	// TZKnown is true if the sender knows its time zone
+	TZKnown bool
+
+	// IsSynced is true if the clock is synchronized to a reliable source,
+	// e.g. by NTP
+	IsSynced bool
+
+	// SyncAccuracy is how accurate the synchronized clock is believed to
+	// be, in microseconds. It is omitted if zero or if IsSynced is false.
+	SyncAccuracy int
+}
+
+func boolParam(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// StructuredData returns the timeQuality SD element
+func (tq TimeQuality) StructuredData() StructuredData {
+	sd := StructuredData{ID: TimeQualitySDID}
+	sd.AddParam("tzKnown", boolParam(tq.TZKnown))
+	sd.AddParam("isSynced", boolParam(tq.IsSynced))
+	if tq.IsSynced && tq.SyncAccuracy > 0 {
+		sd.AddParam("syncAccuracy", strconv.Itoa(tq.SyncAccuracy))
+	}
+	return sd
+}
+
+// Origin identifies the software that emitted a message, as reported by the
+// origin SD element.
+type Origin struct {
+	// IP holds the addresses of the sender. Each is sent as a separate
+	// "ip" parameter.
+	IP []string
+
+	// EnterpriseID is the sender's IANA Private Enterprise Number,
+	// optionally followed by a dotted sub-identifier, e.g. "32473.1"
+	EnterpriseID string
+
+	// Software and SWVersion name the emitting software and its version.
+	// RFC-5424 limits them to 48 and 32 characters.
+	Software  string
+	SWVersion string
+}
+
+// StructuredData returns the origin SD element. Empty fields are omitted.
+func (o Origin) StructuredData() StructuredData {
+	sd := StructuredData{ID: OriginSDID}
+	for _, ip := range o.IP {
+		sd.AddParam("ip", ip)
+	}
+	if o.EnterpriseID != "" {
+		sd.AddParam("enterpriseId", o.EnterpriseID)
+	}
+	if o.Software != "" {
+		sd.AddParam("software", o.Software)
+	}
+	if o.SWVersion != "" {
+		sd.AddParam("swVersion", o.SWVersion)
+	}
+	return sd
+}
+
+// Meta numbers messages and reports the sender's uptime in the meta SD
+// element, so that receivers can detect lost and reordered messages. Each
+// sender should use its own Meta.
+type Meta struct {
+	// Start is the time sysUpTime is measured from. If zero, the time the
+	// first message is numbered is used.
+	Start time.Time
+
+	// Language, if set, is the language of the messages' free-form text
+	Language string
+
+	mu       sync.Mutex
+	sequence int
+}
+
+// Next returns the meta SD element for the next message, with the next
+// sequenceId and the current sysUpTime in hundredths of a second.
+func (meta *Meta) Next() StructuredData {
+	now := TimeNow()
+	meta.mu.Lock()
+	if meta.Start.IsZero() {
+		meta.Start = now
+	}
+	meta.sequence++
+	if meta.sequence > maxSequenceID {
+		meta.sequence = 1
+	}
+	sequence := meta.sequence
+	uptime := now.Sub(meta.Start) / (10 * time.Millisecond)
+	meta.mu.Unlock()
+
+	sd := StructuredData{ID: MetaSDID}
+	sd.AddParam("sequenceId", strconv.Itoa(sequence))
+	sd.AddParam("sysUpTime", strconv.FormatInt(int64(uptime), 10))
+	if meta.Language != "" {
+		sd.AddParam("language", meta.Language)
+	}
+	return sd
+}
@@ -0,0 +1,72 @@
+package rfc5424
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DocumentationEnterpriseNumber is the IANA Private Enterprise Number
+// reserved for use in documentation (RFC-5612). The SD-IDs this package uses
+// by default for its own SD elements, such as DefaultSDID and the defaults
+// of the SDID fields, are under this number, which must not appear in
+// production traffic: deployments should set them, using NewSDID, to IDs
+// under their own enterprise number.
+const DocumentationEnterpriseNumber = 32473
+
+// DefaultSDID is the ID of the SD element holding the fields of logged
+// structs that are not tagged with one. It should be set, using NewSDID, to
+// an ID under the organization's own enterprise number.
+var DefaultSDID = "local@32473"
+
+// registeredSDIDs are the SD-IDs registered with IANA, which are the only
+// ones allowed without an enterprise number
+var registeredSDIDs = map[string]bool{
+	TimeQualitySDID: true,
+	OriginSDID:      true,
+	MetaSDID:        true,
+	SignatureSDID:   true,
+	CertificateSDID: true,
+}
+
+// isSDName reports whether s is a valid SD-NAME that is not an enterprise
+// SD-ID, i.e. has no '@'
+func isSDName(s string) bool {
+	return s != "" && len(s) <= 32 && isValidSdName(s) && !strings.Contains(s, "@")
+}
+
+// NewSDID returns the SD-ID for the SD element name defined by the
+// organization with IANA Private Enterprise Number pen, of the form
+// "name@pen". If pen is zero, name must be one of the SD-IDs registered with
+// IANA, e.g. "origin", and is returned unchanged.
+func NewSDID(name string, pen uint32) (string, error) {
+	if !isSDName(name) {
+		return "", fmt.Errorf("rfc5424: invalid SD-ID name %q", name)
+	}
+	if pen == 0 {
+		if !registeredSDIDs[name] {
+			return "", fmt.Errorf("rfc5424: SD-ID %q is not registered and needs an enterprise number", name)
+		}
+		return name, nil
+	}
+	return name + "@" + strconv.FormatUint(uint64(pen), 10), nil
+}
+
+// ParseSDID splits id into its name and enterprise number. The enterprise
+// number is zero for the SD-IDs registered with IANA. It returns an error if
+// id is neither a registered SD-ID nor of the form "name@pen".
+func ParseSDID(id string) (name string, pen uint32, err error) {
+	i := strings.IndexByte(id, '@')
+	if i < 0 {
+		if !registeredSDIDs[id] {
+			return "", 0, fmt.Errorf("rfc5424: SD-ID %q is neither registered nor of the form name@enterprise-number", id)
+		}
+		return id, 0, nil
+	}
+	name = id[:i]
+	n, err := strconv.ParseUint(id[i+1:], 10, 32)
+	if !isSDName(name) || len(id) > 32 || err != nil || n == 0 {
+		return "", 0, fmt.Errorf("rfc5424: invalid SD-ID %q", id)
+	}
+	return name, uint32(n), nil
+}
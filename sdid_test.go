@@ -0,0 +1,61 @@
+package rfc5424
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&SDIDTest{})
+
+type SDIDTest struct {
+}
+
+func (s *SDIDTest) TestNewSDID(c *C) {
+	id, err := NewSDID("app", 32473)
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, "app@32473")
+
+	id, err = NewSDID(OriginSDID, 0)
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, "origin")
+
+	_, err = NewSDID("app", 0)
+	c.Assert(err, ErrorMatches, `.*"app" is not registered.*`)
+	for _, name := range []string{"", "a@b", "a b", `a"b`, "a=b", "a]b", "abcdefghijklmnopqrstuvwxyz0123456789"} {
+		_, err = NewSDID(name, 1)
+		c.Assert(err, ErrorMatches, "rfc5424: invalid SD-ID name.*", Commentf("%q", name))
+	}
+}
+
+func (s *SDIDTest) TestParseSDID(c *C) {
+	name, pen, err := ParseSDID("app@32473")
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, "app")
+	c.Assert(pen, Equals, uint32(32473))
+
+	name, pen, err = ParseSDID("timeQuality")
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, "timeQuality")
+	c.Assert(pen, Equals, uint32(0))
+
+	for _, id := range []string{"app", "app@", "@1", "app@0", "app@x", "app@1@2", "app@99999999999"} {
+		_, _, err = ParseSDID(id)
+		c.Assert(err, NotNil, Commentf("%q", id))
+	}
+}
+
+func (s *SDIDTest) TestDefaultSDID(c *C) {
+	type event struct {
+		Value string
+		Other string `log:"app@32473 other"`
+	}
+	m := Encode(event{Value: "x", Other: "y"})
+	c.Assert(m.StructuredData, DeepEquals, []StructuredData{
+		{ID: "local@32473", Parameters: []SDParam{{Name: "value", Value: "x"}}},
+		{ID: "app@32473", Parameters: []SDParam{{Name: "other", Value: "y"}}},
+	})
+
+	defer func(id string) { DefaultSDID = id }(DefaultSDID)
+	DefaultSDID = "app@1"
+	m = Encode(event{Value: "x"})
+	c.Assert(m.StructuredData[0].ID, Equals, "app@1")
+}
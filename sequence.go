@@ -0,0 +1,157 @@
+package rfc5424
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// SequenceEventKind classifies an irregularity in the meta sequenceIds
+// received from a sender
+type SequenceEventKind int
+
+const (
+	// SequenceGap means messages were skipped: Received is greater than
+	// Expected, and Received-Expected messages are missing (or delayed).
+	SequenceGap SequenceEventKind = iota
+	// SequenceDuplicate means the last message was received again
+	SequenceDuplicate
+	// SequenceOutOfOrder means a message older than the last one arrived,
+	// e.g. one counted as missing by an earlier SequenceGap.
+	SequenceOutOfOrder
+	// SequenceRestart means the sender started counting again: it sent
+	// sequenceId 1, or a later sequenceId with a lower sysUpTime.
+	SequenceRestart
+)
+
+func (k SequenceEventKind) String() string {
+	switch k {
+	case SequenceGap:
+		return "gap"
+	case SequenceDuplicate:
+		return "duplicate"
+	case SequenceOutOfOrder:
+		return "out-of-order"
+	case SequenceRestart:
+		return "restart"
+	}
+	return fmt.Sprintf("SequenceEventKind(%d)", int(k))
+}
+
+// SequenceEvent reports an irregularity in the messages from one sender
+type SequenceEvent struct {
+	Kind     SequenceEventKind
+	Hostname string
+	AppName  string
+
+	// Expected is the sequenceId that should have come next, and Received
+	// the one that did.
+	Expected int
+	Received int
+}
+
+type sequenceKey struct {
+	hostname, appName string
+}
+
+type sequenceState struct {
+	last   int
+	uptime int64
+}
+
+// SequenceTracker follows the sequenceId of the meta SD element (see Meta)
+// in the messages from each HOSTNAME and APP-NAME, and reports lost,
+// duplicated and reordered messages to Event. Messages without a sequenceId
+// are ignored. State is kept for every sender seen.
+//
+// A SequenceTracker is used as Middleware via its Wrap method. Messages are
+// always passed on to the next Handler.
+type SequenceTracker struct {
+	Event func(e SequenceEvent)
+
+	mu      sync.Mutex
+	senders map[sequenceKey]*sequenceState
+}
+
+// metaSequence returns the sequenceId and sysUpTime of m's meta SD element.
+// The uptime is -1 if it is not known.
+func metaSequence(m Message) (sequence int, uptime int64, ok bool) {
+	uptime = -1
+	for _, sd := range m.StructuredData {
+		if sd.ID != MetaSDID {
+			continue
+		}
+		for _, p := range sd.Parameters {
+			switch p.Name {
+			case "sequenceId":
+				n, err := strconv.Atoi(p.Value)
+				if err == nil && n >= 1 && n <= maxSequenceID {
+					sequence, ok = n, true
+				}
+			case "sysUpTime":
+				if n, err := strconv.ParseInt(p.Value, 10, 64); err == nil {
+					uptime = n
+				}
+			}
+		}
+	}
+	return sequence, uptime, ok
+}
+
+// Track records the sequenceId of m, reporting any irregularity
+func (st *SequenceTracker) Track(m Message) {
+	sequence, uptime, ok := metaSequence(m)
+	if !ok {
+		return
+	}
+	key := sequenceKey{hostname: m.Hostname, appName: m.AppName}
+
+	st.mu.Lock()
+	if st.senders == nil {
+		st.senders = map[sequenceKey]*sequenceState{}
+	}
+	state, seen := st.senders[key]
+	if !seen {
+		st.senders[key] = &sequenceState{last: sequence, uptime: uptime}
+		st.mu.Unlock()
+		return
+	}
+
+	expected := state.last + 1
+	if expected > maxSequenceID {
+		expected = 1
+	}
+	event := SequenceEvent{Hostname: m.Hostname, AppName: m.AppName, Expected: expected, Received: sequence}
+	report := true
+	switch {
+	case sequence == expected:
+		report = false
+	case sequence == 1,
+		sequence > state.last && uptime >= 0 && state.uptime >= 0 && uptime < state.uptime:
+		event.Kind = SequenceRestart
+	case sequence == state.last:
+		event.Kind = SequenceDuplicate
+	case sequence < state.last:
+		event.Kind = SequenceOutOfOrder
+	default:
+		event.Kind = SequenceGap
+	}
+	if !report || event.Kind != SequenceOutOfOrder {
+		state.last = sequence
+		state.uptime = uptime
+	}
+	st.mu.Unlock()
+
+	if report && st.Event != nil {
+		st.Event(event)
+	}
+}
+
+// Wrap returns a Handler that tracks each message before passing it to next
+func (st *SequenceTracker) Wrap(next Handler) Handler {
+	return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+		st.Track(m)
+		next.Handle(ctx, m, src)
+	})
+}
@@ -0,0 +1,58 @@
+package rfc5424
+
+import (
+	"context"
+	"strconv"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&SequenceTest{})
+
+type SequenceTest struct {
+}
+
+func sequenced(hostname string, sequence, uptime int) Message {
+	m := Message{Hostname: hostname, AppName: "app"}
+	m.AddDatum(MetaSDID, "sequenceId", strconv.Itoa(sequence))
+	m.AddDatum(MetaSDID, "sysUpTime", strconv.Itoa(uptime))
+	return m
+}
+
+func (s *SequenceTest) TestEvents(c *C) {
+	events := []SequenceEvent{}
+	st := &SequenceTracker{Event: func(e SequenceEvent) { events = append(events, e) }}
+	received := 0
+	h := st.Wrap(HandlerFunc(func(ctx context.Context, m Message, src Source) { received++ }))
+
+	for _, m := range []Message{
+		sequenced("a", 1, 0),
+		sequenced("a", 2, 10),
+		sequenced("b", 7, 10), // senders are tracked separately
+		sequenced("a", 5, 20),
+		sequenced("a", 3, 15),
+		sequenced("a", 6, 30),
+		sequenced("a", 6, 30),
+		sequenced("a", 4, 35),
+		sequenced("a", 1, 5),
+		{Hostname: "a", AppName: "app"},
+	} {
+		h.Handle(context.Background(), m, Source{})
+	}
+	c.Assert(received, Equals, 10)
+	c.Assert(events, DeepEquals, []SequenceEvent{
+		{Kind: SequenceGap, Hostname: "a", AppName: "app", Expected: 3, Received: 5},
+		{Kind: SequenceOutOfOrder, Hostname: "a", AppName: "app", Expected: 6, Received: 3},
+		{Kind: SequenceDuplicate, Hostname: "a", AppName: "app", Expected: 7, Received: 6},
+		{Kind: SequenceOutOfOrder, Hostname: "a", AppName: "app", Expected: 7, Received: 4},
+		{Kind: SequenceRestart, Hostname: "a", AppName: "app", Expected: 7, Received: 1},
+	})
+}
+
+func (s *SequenceTest) TestWrap(c *C) {
+	events := []SequenceEvent{}
+	st := &SequenceTracker{Event: func(e SequenceEvent) { events = append(events, e) }}
+	st.Track(sequenced("a", maxSequenceID, 0))
+	st.Track(sequenced("a", 1, 1))
+	c.Assert(events, HasLen, 0)
+}
@@ -0,0 +1,521 @@
+package rfc5424
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxDatagramSize is the largest UDP payload we can receive
+	maxDatagramSize = 65535
+
+	// shutdownPollInterval is how often Shutdown checks whether all messages
+	// have been handled
+	shutdownPollInterval = 10 * time.Millisecond
+
+	// defaultMaxMessageLength is the default limit on the length of messages
+	// received over streams. RFC-5425 requires receivers to accept at least
+	// 2048 octets and says they SHOULD accept 8192.
+	defaultMaxMessageLength = 8192
+)
+
+// ErrServerClosed is returned by the Server's Serve methods after the server
+// has been closed.
+var ErrServerClosed = errors.New("rfc5424: Server closed")
+
+// ErrNoHandler is returned by the Server's Serve methods when Handler is nil.
+var ErrNoHandler = errors.New("rfc5424: Server has no Handler")
+
+// Source describes where a received message came from
+type Source struct {
+	// Network is the network the message was received on, e.g. "udp"
+	Network    string
+	RemoteAddr net.Addr
+	LocalAddr  net.Addr
+
+	// TLS holds the state of the TLS connection the message was received on,
+	// or nil if the connection was not encrypted.
+	TLS *tls.ConnectionState
+}
+
+// Handler processes messages received by a Server. Handle is called once for
+// each message that could be parsed. The context is cancelled when the Server
+// is closed.
+type Handler interface {
+	Handle(ctx context.Context, m Message, src Source)
+}
+
+// Server receives RFC-5424 messages from the network and passes each one to
+// Handler. It is the counterpart to the MessageWriters, allowing a Go program
+// to act as a collector.
+type Server struct {
+	// active counts the goroutines reading from connections and packet
+	// sockets, and pending the messages read but not yet handled. They are
+	// used by Shutdown, and come first to be 64-bit aligned for atomic access.
+	active  int64
+	pending int64
+
+	Handler Handler
+
+	// TLSConfig optionally provides the TLS configuration used by ServeTLS
+	// and ListenAndServeTLS.
+	TLSConfig *tls.Config
+
+	// ClientCertPolicy controls how TLS clients are authenticated
+	ClientCertPolicy ClientCertPolicy
+
+	// MaxMessageLength is the largest message accepted on stream connections.
+	// Connections sending longer frames are closed. If zero, 8192 is used.
+	MaxMessageLength int
+
+	// StrictSDIDs makes the server drop messages whose SD-IDs or parameter
+	// names do not follow the full RFC-5424 grammar (see StrictSDIDs), as if
+	// they could not be parsed.
+	StrictSDIDs bool
+
+	// ReadTimeout is the longest a stream connection may take to send each
+	// message. Idle connections are closed when it expires. If zero, there
+	// is no timeout.
+	ReadTimeout time.Duration
+
+	// ConnectionError, if set, is called when a stream connection is closed
+	// because of an error, e.g. a read timeout, a message longer than
+	// MaxMessageLength (ErrMessageTooLong) or bad framing. If nil the error
+	// is logged to ErrorLog.
+	ConnectionError func(src Source, err error)
+
+	// AcceptRFC3164 makes the server also accept messages in the traditional
+	// BSD syslog format described in RFC-3164, as sent to /dev/log by the C
+	// library's syslog(3). Such messages are converted to RFC-5424 messages.
+	AcceptRFC3164 bool
+
+	// Workers is the number of goroutines that parse and handle messages. If
+	// zero, each message is handled on the goroutine that read it, so a slow
+	// Handler delays reading the next message.
+	Workers int
+
+	// QueueSize is the number of messages that may wait for a free worker.
+	// If zero, messages are passed straight to a worker. It has no effect
+	// unless Workers is set.
+	QueueSize int
+
+	// QueuePolicy is what happens to messages read while the queue is full.
+	// The default, QueueBlock, applies back-pressure.
+	QueuePolicy QueuePolicy
+
+	// MaxConnections limits the number of stream connections served at once.
+	// While at the limit, no more connections are accepted. If zero, there is
+	// no limit.
+	MaxConnections int
+
+	// MaxInFlightPerConn limits the number of messages from a single stream
+	// connection that may be waiting for or being handled by the workers.
+	// While at the limit, no more is read from the connection. If zero, there
+	// is no limit.
+	MaxInFlightPerConn int
+
+	// AccessList, if set, restricts the addresses messages are accepted
+	// from. Stream connections from other addresses are closed as soon as
+	// they are accepted, and datagrams from them are discarded.
+	AccessList *AccessList
+
+	// Rejected, if set, is called for each connection or datagram refused
+	// because of AccessList.
+	Rejected func(src Source)
+
+	// Stats, if set, counts the messages received by the server
+	Stats *ServerStats
+
+	// MessagePool, if set, provides the messages that received messages are
+	// parsed into, and each is returned to it once Handle returns. Handlers
+	// must then not keep the StructuredData of the messages they are passed
+	// after returning; they should copy whatever they need.
+	MessagePool *MessagePool
+
+	// Interner, if set, interns the header fields and SD names of received
+	// messages (see Interner.InternMessage), which saves memory when
+	// Handlers keep many messages from the same senders.
+	Interner *Interner
+
+	// ErrorLog specifies an optional logger for messages that cannot be
+	// parsed and other errors. If nil, the log package's standard logger is
+	// used.
+	ErrorLog *log.Logger
+
+	mu        sync.Mutex
+	listeners map[io.Closer]struct{}
+	closed    bool
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	workersOnce sync.Once
+	jobs        chan job
+	connSlots   chan struct{}
+}
+
+// NewServer returns a Server passing messages to h. WithMaxLen and
+// WithStrictness set its MaxMessageLength and StrictSDIDs.
+func NewServer(h Handler, opts ...Option) *Server {
+	o := newOptions(opts)
+	return &Server{Handler: h, MaxMessageLength: o.maxLength, StrictSDIDs: o.strict}
+}
+
+func (srv *Server) maxMessageLength() int {
+	if srv.MaxMessageLength <= 0 {
+		return defaultMaxMessageLength
+	}
+	return srv.MaxMessageLength
+}
+
+// connectionError reports that the connection from src is being closed
+// because of err
+func (srv *Server) connectionError(src Source, err error) {
+	if srv.ConnectionError != nil {
+		srv.ConnectionError(src, err)
+		return
+	}
+	srv.logf("rfc5424: closing connection from %s: %s", src.RemoteAddr, err)
+}
+
+// allowed reports whether messages from src are accepted by AccessList,
+// calling Rejected if not.
+func (srv *Server) allowed(src Source) bool {
+	// unix senders that are not bound to a path have no address
+	unix := src.Network == "unix" || src.Network == "unixgram"
+	if srv.AccessList == nil || unix || srv.AccessList.Allows(src.RemoteAddr) {
+		return true
+	}
+	srv.Stats.droppedSource()
+	if srv.Rejected != nil {
+		srv.Rejected(src)
+	}
+	return false
+}
+
+func (srv *Server) logf(format string, args ...interface{}) {
+	if srv.ErrorLog != nil {
+		srv.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+// baseContext returns the context passed to the Handler, which is cancelled
+// by Close.
+func (srv *Server) baseContext() context.Context {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.ctx == nil {
+		srv.ctx, srv.cancel = context.WithCancel(context.Background())
+		if srv.closed {
+			srv.cancel()
+		}
+	}
+	return srv.ctx
+}
+
+// track adds or removes l from the set of listeners and connections that are
+// closed by Close. It returns false if the server is already closed.
+func (srv *Server) track(l io.Closer, add bool) bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.listeners == nil {
+		srv.listeners = map[io.Closer]struct{}{}
+	}
+	if add {
+		if srv.closed {
+			return false
+		}
+		srv.listeners[l] = struct{}{}
+	} else {
+		delete(srv.listeners, l)
+	}
+	return true
+}
+
+func (srv *Server) isClosed() bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.closed
+}
+
+// Close immediately closes all listeners and connections, and cancels the
+// context passed to the Handler. See Shutdown for a graceful alternative.
+func (srv *Server) Close() error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.cancel != nil {
+		srv.cancel()
+	}
+	return srv.closeListenersLocked()
+}
+
+// closeListenersLocked marks the server closed and closes all listeners and
+// connections. The caller must hold srv.mu.
+func (srv *Server) closeListenersLocked() error {
+	srv.closed = true
+	var err error
+	for l := range srv.listeners {
+		if closeErr := l.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		delete(srv.listeners, l)
+	}
+	return err
+}
+
+// Pending returns the number of messages read but not yet handled, which
+// includes those waiting in the worker queue
+func (srv *Server) Pending() int {
+	return int(atomic.LoadInt64(&srv.pending))
+}
+
+// Shutdown gracefully shuts down the server. It stops accepting messages by
+// closing all listeners and connections, waits for the messages that have
+// already been read to be handled and then cancels the context passed to the
+// Handler. If ctx expires first, Shutdown calls Close and returns the
+// context's error, otherwise it returns any error from closing the listeners.
+func (srv *Server) Shutdown(ctx context.Context) error {
+	srv.mu.Lock()
+	err := srv.closeListenersLocked()
+	srv.mu.Unlock()
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for {
+		// Readers hold a message as pending before they stop being active,
+		// so when both are zero nothing is left to handle.
+		if atomic.LoadInt64(&srv.active) == 0 && atomic.LoadInt64(&srv.pending) == 0 {
+			srv.mu.Lock()
+			if srv.cancel != nil {
+				srv.cancel()
+			}
+			srv.mu.Unlock()
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			srv.Close()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// handle parses a single message and hands it to the Handler. It returns
+// false if the message could not be parsed.
+func (srv *Server) handle(ctx context.Context, buf []byte, src Source) bool {
+	m := srv.MessagePool.Get()
+	defer srv.MessagePool.Put(m)
+	err := m.UnmarshalBinary(buf)
+	if err != nil && srv.AcceptRFC3164 {
+		*m, err = parseRFC3164(buf)
+	}
+	if err == nil && srv.StrictSDIDs {
+		err = m.validate(true)
+	}
+	if err != nil {
+		srv.Stats.parseError(len(buf))
+		srv.logf("rfc5424: cannot parse message from %s: %s", src.RemoteAddr, err)
+		return false
+	}
+	if srv.Interner != nil {
+		srv.Interner.InternMessage(m)
+	}
+	srv.Stats.messageReceived(*m, len(buf))
+	srv.Handler.Handle(ctx, *m, src)
+	return true
+}
+
+// ListenAndServeUDP listens on the UDP address addr and then calls ServeUDP.
+func (srv *Server) ListenAndServeUDP(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return srv.ServeUDP(conn)
+}
+
+// ServeUDP reads datagrams from conn, each of which holds a single message as
+// described in RFC-5426, and passes them to the Handler. ServeUDP always
+// returns a non-nil error and closes conn.
+func (srv *Server) ServeUDP(conn net.PacketConn) error {
+	return srv.servePacket(conn, "udp")
+}
+
+func (srv *Server) servePacket(conn net.PacketConn, network string) error {
+	defer conn.Close()
+	if srv.Handler == nil {
+		return ErrNoHandler
+	}
+	if !srv.track(conn, true) {
+		return ErrServerClosed
+	}
+	defer srv.track(conn, false)
+	atomic.AddInt64(&srv.active, 1)
+	defer atomic.AddInt64(&srv.active, -1)
+
+	ctx := srv.baseContext()
+	done := func(handled bool) {}
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if srv.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		src := Source{
+			Network:    network,
+			RemoteAddr: addr,
+			LocalAddr:  conn.LocalAddr(),
+		}
+		if !srv.allowed(src) {
+			continue
+		}
+
+		// The parsed message refers to the bytes it was parsed from, so
+		// each datagram needs its own copy.
+		datagram := make([]byte, n)
+		copy(datagram, buf[:n])
+		srv.dispatch(ctx, datagram, src, done)
+	}
+}
+
+// ListenAndServeTCP listens on the TCP address addr and then calls ServeTCP.
+func (srv *Server) ListenAndServeTCP(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return srv.ServeTCP(l)
+}
+
+// ServeTCP accepts connections on l and reads messages from each of them,
+// passing them to the Handler. The framing (octet-counting or
+// non-transparent, see RFC-6587) is detected separately for each connection.
+// ServeTCP always returns a non-nil error and closes l.
+func (srv *Server) ServeTCP(l net.Listener) error {
+	return srv.serveStream(l, "tcp", srv.readFrames)
+}
+
+// connReader reads messages from a stream connection until it is closed or
+// fails, dispatching each of them.
+type connReader func(ctx context.Context, conn net.Conn, src Source)
+
+func (srv *Server) serveStream(l net.Listener, network string, read connReader) error {
+	defer l.Close()
+	if srv.Handler == nil {
+		return ErrNoHandler
+	}
+	if !srv.track(l, true) {
+		return ErrServerClosed
+	}
+	defer srv.track(l, false)
+
+	ctx := srv.baseContext()
+	var tempDelay time.Duration // how long to sleep on accept failure
+	for {
+		if !srv.acquireConn(ctx) {
+			return ErrServerClosed
+		}
+		conn, err := l.Accept()
+		if err != nil {
+			srv.releaseConn()
+			if srv.isClosed() {
+				return ErrServerClosed
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if tempDelay == 0 {
+					tempDelay = 5 * time.Millisecond
+				} else if tempDelay *= 2; tempDelay > time.Second {
+					tempDelay = time.Second
+				}
+				srv.logf("rfc5424: accept error: %s; retrying in %s", err, tempDelay)
+				time.Sleep(tempDelay)
+				continue
+			}
+			return err
+		}
+		tempDelay = 0
+		go srv.serveConn(conn, network, read)
+	}
+}
+
+// serveConn sets up a single stream connection and then calls read
+func (srv *Server) serveConn(conn net.Conn, network string, read connReader) {
+	defer srv.releaseConn()
+	defer conn.Close()
+	src := Source{
+		Network:    network,
+		RemoteAddr: conn.RemoteAddr(),
+		LocalAddr:  conn.LocalAddr(),
+	}
+	if !srv.allowed(src) {
+		return
+	}
+	if !srv.track(conn, true) {
+		return
+	}
+	defer srv.track(conn, false)
+	atomic.AddInt64(&srv.active, 1)
+	defer atomic.AddInt64(&srv.active, -1)
+
+	ctx := srv.baseContext()
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		state, err := srv.handshake(tlsConn)
+		if err != nil {
+			srv.connectionError(src, fmt.Errorf("TLS handshake failed: %s", err))
+			return
+		}
+		src.TLS = state
+	}
+	read(ctx, conn, src)
+}
+
+// readFrames reads messages framed as described in RFC-6587
+func (srv *Server) readFrames(ctx context.Context, conn net.Conn, src Source) {
+	// inFlight holds a token for each message from this connection that
+	// has been read but not yet handled
+	var inFlight chan struct{}
+	if srv.MaxInFlightPerConn > 0 {
+		inFlight = make(chan struct{}, srv.MaxInFlightPerConn)
+	}
+	done := func(handled bool) {
+		if inFlight != nil {
+			<-inFlight
+		}
+	}
+
+	fr := newFrameReader(conn, srv.maxMessageLength())
+	for {
+		if inFlight != nil {
+			select {
+			case inFlight <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if srv.ReadTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(srv.ReadTimeout))
+		}
+		frame, err := fr.ReadFrame()
+		if err != nil {
+			if err != io.EOF && !srv.isClosed() {
+				srv.connectionError(src, err)
+			}
+			return
+		}
+		srv.dispatch(ctx, frame, src, done)
+	}
+}
@@ -0,0 +1,301 @@
+package rfc5424
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&ServerTest{})
+
+type ServerTest struct {
+}
+
+type receivedMessage struct {
+	Message Message
+	Source  Source
+}
+
+// chanHandler is a Handler that sends each message it receives to a channel
+type chanHandler chan receivedMessage
+
+func (h chanHandler) Handle(ctx context.Context, m Message, src Source) {
+	h <- receivedMessage{Message: m, Source: src}
+}
+
+func (s *ServerTest) TestUDP(c *C) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+
+	h := make(chanHandler, 10)
+	srv := Server{Handler: h, ErrorLog: log.New(ioutil.Discard, "", 0)}
+	done := make(chan error)
+	go func() { done <- srv.ServeUDP(conn) }()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	c.Assert(err, IsNil)
+	defer client.Close()
+	_, err = client.Write([]byte("not a syslog message"))
+	c.Assert(err, IsNil)
+	_, err = client.Write([]byte(`<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - hello`))
+	c.Assert(err, IsNil)
+
+	rm := <-h
+	c.Assert(rm.Message.Hostname, Equals, "mymachine.example.com")
+	c.Assert(string(rm.Message.Message), Equals, "hello")
+	c.Assert(rm.Source.Network, Equals, "udp")
+	c.Assert(rm.Source.RemoteAddr.String(), Equals, client.LocalAddr().String())
+
+	c.Assert(srv.Close(), IsNil)
+	c.Assert(<-done, Equals, ErrServerClosed)
+}
+
+func (s *ServerTest) TestMessagePool(c *C) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+
+	ids := make(chan string, 10)
+	h := HandlerFunc(func(ctx context.Context, m Message, src Source) {
+		ids <- m.StructuredData[0].ID
+	})
+	srv := Server{Handler: h, MessagePool: &MessagePool{}, ErrorLog: log.New(ioutil.Discard, "", 0)}
+	done := make(chan error)
+	go func() { done <- srv.ServeUDP(conn) }()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	c.Assert(err, IsNil)
+	defer client.Close()
+	for _, id := range []string{"a@1", "b@1", "c@1"} {
+		_, err = client.Write([]byte(`<34>1 - - - - - [` + id + ` x="1"]`))
+		c.Assert(err, IsNil)
+		select {
+		case received := <-ids:
+			c.Assert(received, Equals, id)
+		case <-time.After(5 * time.Second):
+			c.Fatal("timed out waiting for a message")
+		}
+	}
+
+	c.Assert(srv.Close(), IsNil)
+	c.Assert(<-done, Equals, ErrServerClosed)
+}
+
+func (s *ServerTest) TestRequiresHandler(c *C) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	srv := Server{}
+	c.Assert(srv.ServeUDP(conn), Equals, ErrNoHandler)
+}
+
+func (s *ServerTest) TestCloseCancelsHandlerContext(c *C) {
+	srv := Server{Handler: make(chanHandler)}
+	ctx := srv.baseContext()
+	c.Assert(ctx.Err(), IsNil)
+	c.Assert(srv.Close(), IsNil)
+	<-ctx.Done()
+	c.Assert(ctx.Err(), Equals, context.Canceled)
+}
+
+// receive returns the next message passed to h, failing the test rather than
+// hanging if none arrives.
+func receive(c *C, h chanHandler) receivedMessage {
+	select {
+	case rm := <-h:
+		return rm
+	case <-time.After(5 * time.Second):
+		c.Fatal("timed out waiting for a message")
+	}
+	return receivedMessage{}
+}
+
+// octetCounted frames each message with its length, as in RFC-5425
+func octetCounted(messages ...string) []byte {
+	b := []byte{}
+	for _, m := range messages {
+		b = append(b, fmt.Sprintf("%d %s", len(m), m)...)
+	}
+	return b
+}
+
+func (s *ServerTest) TestTCPDetectsFraming(c *C) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+
+	h := make(chanHandler, 10)
+	srv := Server{Handler: h, ErrorLog: log.New(ioutil.Discard, "", 0)}
+	done := make(chan error)
+	go func() { done <- srv.ServeTCP(l) }()
+
+	// octet-counting
+	client, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	_, err = client.Write(octetCounted(
+		"<34>1 0000-12-31T00:00:00Z - - - - -",
+		"<34>1 0000-12-31T00:00:00Z - - - - - two"))
+	c.Assert(err, IsNil)
+	c.Assert(receive(c, h).Message.Message, IsNil)
+	rm := receive(c, h)
+	c.Assert(string(rm.Message.Message), Equals, "two")
+	c.Assert(rm.Source.Network, Equals, "tcp")
+	c.Assert(rm.Source.RemoteAddr.String(), Equals, client.LocalAddr().String())
+	client.Close()
+
+	// non-transparent
+	client, err = net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	_, err = client.Write([]byte("<34>1 0000-12-31T00:00:00Z - - - - - one\n" +
+		"<34>1 0000-12-31T00:00:00Z - - - - - two\r\n"))
+	c.Assert(err, IsNil)
+	c.Assert(string(receive(c, h).Message.Message), Equals, "one")
+	c.Assert(string(receive(c, h).Message.Message), Equals, "two")
+	client.Close()
+
+	c.Assert(srv.Close(), IsNil)
+	c.Assert(<-done, Equals, ErrServerClosed)
+}
+
+// safeBuffer is a bytes.Buffer that can be written to by the server while
+// the test reads it
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (s *ServerTest) TestUnixgramAcceptsRFC3164(c *C) {
+	path := c.MkDir() + "/log"
+	h := make(chanHandler, 10)
+	srv := Server{Handler: h, AcceptRFC3164: true, ErrorLog: log.New(ioutil.Discard, "", 0)}
+	done := make(chan error)
+	go func() { done <- srv.ListenAndServeUnixgram(path) }()
+
+	var client net.Conn
+	var err error
+	for i := 0; i < 100; i++ {
+		if client, err = net.Dial("unixgram", path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(err, IsNil)
+	defer client.Close()
+
+	_, err = client.Write([]byte("<13>Oct 11 22:14:15 su[8710]: hello"))
+	c.Assert(err, IsNil)
+	_, err = client.Write([]byte("<34>1 0000-12-31T00:00:00Z - - - - - world"))
+	c.Assert(err, IsNil)
+
+	rm := receive(c, h)
+	c.Assert(rm.Message.AppName, Equals, "su")
+	c.Assert(string(rm.Message.Message), Equals, "hello")
+	c.Assert(rm.Source.Network, Equals, "unixgram")
+	c.Assert(string(receive(c, h).Message.Message), Equals, "world")
+
+	c.Assert(srv.Close(), IsNil)
+	c.Assert(<-done, Equals, ErrServerClosed)
+}
+
+func (s *ServerTest) TestShutdownDrainsMessages(c *C) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	h := newGatedHandler()
+	srv := Server{Handler: h, Workers: 2, ErrorLog: log.New(ioutil.Discard, "", 0)}
+	done := make(chan error)
+	go func() { done <- srv.ServeTCP(l) }()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	defer client.Close()
+	_, err = client.Write(octetCounted("<34>1 0000-12-31T00:00:00Z - - - - -"))
+	c.Assert(err, IsNil)
+	waitFor(c, func() bool { return atomic.LoadInt32(&h.active) == 1 })
+	ctx := srv.baseContext()
+
+	shutdown := make(chan error)
+	go func() { shutdown <- srv.Shutdown(context.Background()) }()
+	c.Assert(<-done, Equals, ErrServerClosed)
+
+	// Shutdown waits for the handler, which still has a live context
+	select {
+	case <-shutdown:
+		c.Fatal("Shutdown returned before the message was handled")
+	case <-time.After(50 * time.Millisecond):
+	}
+	c.Assert(ctx.Err(), IsNil)
+	h.release <- struct{}{}
+	c.Assert(<-shutdown, IsNil)
+	c.Assert(ctx.Err(), Equals, context.Canceled)
+}
+
+func (s *ServerTest) TestShutdownTimeout(c *C) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	h := newGatedHandler()
+	srv := Server{Handler: h, ErrorLog: log.New(ioutil.Discard, "", 0)}
+	go srv.ServeTCP(l)
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	defer client.Close()
+	_, err = client.Write(octetCounted("<34>1 0000-12-31T00:00:00Z - - - - -"))
+	c.Assert(err, IsNil)
+	waitFor(c, func() bool { return atomic.LoadInt32(&h.active) == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c.Assert(srv.Shutdown(ctx), Equals, context.DeadlineExceeded)
+	c.Assert(srv.baseContext().Err(), Equals, context.Canceled)
+	h.release <- struct{}{}
+}
+
+func (s *ServerTest) TestConnectionLimits(c *C) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	connErrors := make(chan error, 10)
+	srv := Server{
+		Handler:          make(chanHandler, 10),
+		ReadTimeout:      50 * time.Millisecond,
+		MaxMessageLength: 100,
+		ConnectionError:  func(src Source, err error) { connErrors <- err },
+	}
+	go srv.ServeTCP(l)
+	defer srv.Close()
+
+	// An idle connection is timed out and closed
+	client, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	err = <-connErrors
+	ne, ok := err.(net.Error)
+	c.Assert(ok && ne.Timeout(), Equals, true, Commentf("%v", err))
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = ioutil.ReadAll(client)
+	c.Assert(err, IsNil)
+	client.Close()
+
+	// So is one sending too much
+	client, err = net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	defer client.Close()
+	_, err = client.Write([]byte("101 <34>1"))
+	c.Assert(err, IsNil)
+	c.Assert(<-connErrors, Equals, ErrMessageTooLong)
+}
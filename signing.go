@@ -0,0 +1,337 @@
+package rfc5424
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	_ "crypto/sha1" // register the hashes used by RFC-5848
+	_ "crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The SD-IDs of the Signature Block and Certificate Block defined by RFC-5848
+const (
+	SignatureSDID   = "ssign"
+	CertificateSDID = "ssign-cert"
+)
+
+const (
+	// signatureVersion is the protocol version part of the VER parameter
+	signatureVersion = "01"
+
+	// signatureSchemePrivate is the signature scheme part of the VER
+	// parameter. RFC-5848 only registers OpenPGP DSA, "1", which these
+	// signatures are not, so an unassigned value is used for them to be
+	// rejected, rather than misverified, by other implementations.
+	signatureSchemePrivate = "9"
+
+	// keyBlobPKIX is the Key Blob Type of a DER encoded X.509 certificate
+	keyBlobPKIX = "C"
+
+	defaultSignatureMaxHashes      = 25
+	defaultCertificateFragmentSize = 1024
+)
+
+// SignatureGroup selects how signed messages are divided into signature
+// groups, as described in RFC-5848 section 4.2.3.
+type SignatureGroup int
+
+const (
+	// SignatureGroupSingle (SG=0) signs all messages in one group
+	SignatureGroupSingle SignatureGroup = iota
+	// SignatureGroupPerPRI (SG=1) signs the messages of each PRI value in
+	// a separate group, and sends its Signature Blocks with that PRI.
+	SignatureGroupPerPRI
+)
+
+// signatureHashCode returns the hash algorithm digit of the VER parameter
+func signatureHashCode(h crypto.Hash) (string, error) {
+	switch h {
+	case crypto.SHA1:
+		return "1", nil
+	case crypto.SHA256:
+		return "2", nil
+	}
+	return "", fmt.Errorf("rfc5424: hash %v is not supported by RFC-5848", h)
+}
+
+// signedBytes returns the bytes covered by the SIGN parameter of a Signature
+// or Certificate Block: the whole message, serialized without SIGN.
+func signedBytes(m Message) ([]byte, error) {
+	unsigned := m
+	unsigned.StructuredData = make([]StructuredData, len(m.StructuredData))
+	for i, sd := range m.StructuredData {
+		params := []SDParam{}
+		for _, p := range sd.Parameters {
+			if p.Name != "SIGN" {
+				params = append(params, p)
+			}
+		}
+		unsigned.StructuredData[i] = StructuredData{ID: sd.ID, Parameters: params}
+	}
+	return unsigned.MarshalBinary()
+}
+
+// signatureGroup is the state of a single signature group
+type signatureGroup struct {
+	spri     int
+	first    uint64 // the number of the first hash in hashes
+	hashes   []string
+	template Message // supplies HOSTNAME, APP-NAME and PROCID of blocks
+}
+
+// Signer is a MessageWriter that adds the tamper-evidence described in
+// RFC-5848 to the messages written to Writer. It hashes each message and
+// periodically writes Signature Blocks (the "ssign" SD element) holding the
+// hashes and a signature over them. The Certificate is first sent in
+// Certificate Blocks ("ssign-cert") so that receivers can verify the
+// signatures.
+//
+// The signature is made by Key with the hash algorithm Hash (for
+// Ed25519 keys, over the whole block) and encoded in the key's native
+// format, ASN.1 for ECDSA and PKCS #1 v1.5 for RSA. RFC-5848 only registers
+// OpenPGP DSA signatures, which this is not, so the signature scheme is
+// private to this package: VER advertises the unassigned scheme 9, and the
+// blocks can only be checked by a Verifier, which takes the key type from
+// the certificate. Only signature groups 0 and 1 are supported.
+type Signer struct {
+	Writer      MessageWriter
+	Key         crypto.Signer
+	Certificate *x509.Certificate
+
+	// Hash is the hash algorithm, crypto.SHA1 or crypto.SHA256. If zero,
+	// crypto.SHA256 is used.
+	Hash crypto.Hash
+
+	// RebootSessionID identifies this signing session. It should be
+	// persisted and incremented each time the sender restarts. If zero, 1
+	// is used.
+	RebootSessionID uint64
+
+	Group SignatureGroup
+
+	// Priority is the PRI of Signature and Certificate Blocks when Group is
+	// SignatureGroupSingle.
+	Priority int
+
+	// MaxHashes is the number of message hashes in each Signature Block.
+	// If zero, 25 is used.
+	MaxHashes int
+
+	// CertificateFragmentSize is the largest fragment of the certificate
+	// payload sent in each Certificate Block. If zero, 1024 is used.
+	CertificateFragmentSize int
+
+	mu        sync.Mutex
+	started   bool
+	gbc       uint64 // global block counter, of Signature Blocks sent
+	groups    map[int]*signatureGroup
+	groupList []*signatureGroup // in order of creation, for Flush
+}
+
+func (s *Signer) hash() crypto.Hash {
+	if s.Hash == 0 {
+		return crypto.SHA256
+	}
+	return s.Hash
+}
+
+func (s *Signer) rsid() uint64 {
+	if s.RebootSessionID == 0 {
+		return 1
+	}
+	return s.RebootSessionID
+}
+
+func (s *Signer) ver() (string, error) {
+	code, err := signatureHashCode(s.hash())
+	if err != nil {
+		return "", err
+	}
+	return signatureVersion + code + signatureSchemePrivate, nil
+}
+
+// sign sets the SIGN parameter of the last SD element of block
+func (s *Signer) sign(block *Message) error {
+	b, err := signedBytes(*block)
+	if err != nil {
+		return err
+	}
+	var sig []byte
+	if _, ok := s.Key.Public().(ed25519.PublicKey); ok {
+		sig, err = s.Key.Sign(rand.Reader, b, crypto.Hash(0))
+	} else {
+		h := s.hash().New()
+		h.Write(b)
+		sig, err = s.Key.Sign(rand.Reader, h.Sum(nil), s.hash())
+	}
+	if err != nil {
+		return err
+	}
+	sd := &block.StructuredData[len(block.StructuredData)-1]
+	sd.AddParam("SIGN", base64.StdEncoding.EncodeToString(sig))
+	return nil
+}
+
+// writeCertificate sends the Certificate Blocks. The caller must hold s.mu.
+func (s *Signer) writeCertificate(template Message) error {
+	if s.Certificate == nil {
+		return errors.New("rfc5424: Signer has no Certificate")
+	}
+	ver, err := s.ver()
+	if err != nil {
+		return err
+	}
+	payload := TimeNow().UTC().Format(time.RFC3339Nano) + " " + keyBlobPKIX + " " +
+		base64.StdEncoding.EncodeToString(s.Certificate.Raw)
+
+	spri := s.Priority
+	if s.Group == SignatureGroupPerPRI {
+		spri = template.Priority
+	}
+	fragmentSize := s.CertificateFragmentSize
+	if fragmentSize <= 0 {
+		fragmentSize = defaultCertificateFragmentSize
+	}
+	for index := 0; index < len(payload); index += fragmentSize {
+		end := index + fragmentSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		block := s.blockMessage(template, spri)
+		sd := StructuredData{ID: CertificateSDID}
+		sd.AddParam("VER", ver)
+		sd.AddParam("RSID", strconv.FormatUint(s.rsid(), 10))
+		sd.AddParam("SG", strconv.Itoa(int(s.Group)))
+		sd.AddParam("SPRI", strconv.Itoa(spri))
+		sd.AddParam("TPBL", strconv.Itoa(len(payload)))
+		sd.AddParam("INDEX", strconv.Itoa(index+1))
+		sd.AddParam("FLEN", strconv.Itoa(end-index))
+		sd.AddParam("FRAG", payload[index:end])
+		block.StructuredData = []StructuredData{sd}
+		if err := s.sign(&block); err != nil {
+			return err
+		}
+		if err := s.Writer.WriteMessage(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Signer) blockMessage(template Message, pri int) Message {
+	return Message{
+		Priority:  pri,
+		Timestamp: TimeNow().UTC(),
+		Hostname:  template.Hostname,
+		AppName:   template.AppName,
+		ProcessID: template.ProcessID,
+	}
+}
+
+// writeSignature sends a Signature Block for the pending hashes of g. The
+// caller must hold s.mu.
+func (s *Signer) writeSignature(g *signatureGroup) error {
+	if len(g.hashes) == 0 {
+		return nil
+	}
+	ver, err := s.ver()
+	if err != nil {
+		return err
+	}
+	s.gbc++
+	block := s.blockMessage(g.template, g.spri)
+	sd := StructuredData{ID: SignatureSDID}
+	sd.AddParam("VER", ver)
+	sd.AddParam("RSID", strconv.FormatUint(s.rsid(), 10))
+	sd.AddParam("SG", strconv.Itoa(int(s.Group)))
+	sd.AddParam("SPRI", strconv.Itoa(g.spri))
+	sd.AddParam("GBC", strconv.FormatUint(s.gbc, 10))
+	sd.AddParam("FMN", strconv.FormatUint(g.first, 10))
+	sd.AddParam("CNT", strconv.Itoa(len(g.hashes)))
+	sd.AddParam("HB", strings.Join(g.hashes, " "))
+	block.StructuredData = []StructuredData{sd}
+	if err := s.sign(&block); err != nil {
+		return err
+	}
+	g.first += uint64(len(g.hashes))
+	g.hashes = g.hashes[:0]
+	return s.Writer.WriteMessage(block)
+}
+
+// WriteMessage writes m to Writer and adds its hash to the next Signature
+// Block of its group, writing the block once it is full.
+func (s *Signer) WriteMessage(m Message) error {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started {
+		if err := s.writeCertificate(m); err != nil {
+			return err
+		}
+		s.started = true
+	}
+	if err := s.Writer.WriteMessage(m); err != nil {
+		return err
+	}
+
+	key, spri := 0, s.Priority
+	if s.Group == SignatureGroupPerPRI {
+		key, spri = m.Priority, m.Priority
+	}
+	if s.groups == nil {
+		s.groups = map[int]*signatureGroup{}
+	}
+	g, ok := s.groups[key]
+	if !ok {
+		g = &signatureGroup{spri: spri, first: 1}
+		s.groups[key] = g
+		s.groupList = append(s.groupList, g)
+	}
+	h := s.hash().New()
+	h.Write(b)
+	g.hashes = append(g.hashes, base64.StdEncoding.EncodeToString(h.Sum(nil)))
+	g.template = m
+
+	maxHashes := s.MaxHashes
+	if maxHashes <= 0 {
+		maxHashes = defaultSignatureMaxHashes
+	}
+	if len(g.hashes) >= maxHashes {
+		return s.writeSignature(g)
+	}
+	return nil
+}
+
+// Flush writes Signature Blocks for all messages that have not yet been
+// signed.
+func (s *Signer) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, g := range s.groupList {
+		if err := s.writeSignature(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes the pending signatures and closes Writer
+func (s *Signer) Close() error {
+	err := s.Flush()
+	if closeErr := s.Writer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
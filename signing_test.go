@@ -0,0 +1,260 @@
+package rfc5424
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&SigningTest{})
+
+type SigningTest struct {
+}
+
+// signingKey returns a new ECDSA key and a self-signed certificate for it
+func signingKey(c *C) (*ecdsa.PrivateKey, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	c.Assert(err, IsNil)
+	cert, err := x509.ParseCertificate(der)
+	c.Assert(err, IsNil)
+	return key, cert
+}
+
+func sdParams(m Message, id string) map[string]string {
+	params := map[string]string{}
+	for _, sd := range m.StructuredData {
+		if sd.ID == id {
+			for _, p := range sd.Parameters {
+				params[p.Name] = p.Value
+			}
+		}
+	}
+	return params
+}
+
+func (testSuite *SigningTest) TestSignatureBlocks(c *C) {
+	key, cert := signingKey(c)
+	fw := &chanWriter{messages: make(chan Message, 64)}
+	signer := &Signer{Writer: fw, Key: key, Certificate: cert, MaxHashes: 2, Priority: 110,
+		CertificateFragmentSize: 200}
+
+	sent := []string{}
+	for _, body := range []string{"one", "two", "three"} {
+		m := Message{Priority: 14, Hostname: "host", AppName: "app", Message: []byte(body)}
+		c.Assert(signer.WriteMessage(m), IsNil)
+		b, _ := m.MarshalBinary()
+		sent = append(sent, string(b))
+	}
+	c.Assert(signer.Flush(), IsNil)
+
+	received := []Message{}
+	for len(fw.messages) > 0 {
+		received = append(received, <-fw.messages)
+	}
+
+	// The certificate comes first, split into fragments
+	payload := ""
+	i := 0
+	for ; len(sdParams(received[i], CertificateSDID)) > 0; i++ {
+		params := sdParams(received[i], CertificateSDID)
+		c.Assert(params["VER"], Equals, "0129")
+		c.Assert(params["INDEX"], Equals, strconv.Itoa(len(payload)+1))
+		c.Assert(received[i].Priority, Equals, 110)
+		payload += params["FRAG"]
+	}
+	c.Assert(i > 1, Equals, true)
+	fields := strings.Split(payload, " ")
+	c.Assert(fields, HasLen, 3)
+	c.Assert(fields[1], Equals, "C")
+	c.Assert(fields[2], Equals, base64.StdEncoding.EncodeToString(cert.Raw))
+
+	// Then the messages, with a Signature Block after every two
+	c.Assert(string(received[i].Message), Equals, "one")
+	c.Assert(string(received[i+1].Message), Equals, "two")
+	checkSignatureBlock(c, key, received[i+2], "1", "1", sent[0:2])
+	c.Assert(string(received[i+3].Message), Equals, "three")
+	checkSignatureBlock(c, key, received[i+4], "2", "3", sent[2:3])
+	c.Assert(received, HasLen, i+5)
+}
+
+func checkSignatureBlock(c *C, key *ecdsa.PrivateKey, block Message, gbc, fmn string, sent []string) {
+	params := sdParams(block, SignatureSDID)
+	c.Assert(params["GBC"], Equals, gbc)
+	c.Assert(params["FMN"], Equals, fmn)
+	c.Assert(params["CNT"], Equals, strconv.Itoa(len(sent)))
+	c.Assert(params["SG"], Equals, "0")
+	c.Assert(params["SPRI"], Equals, "110")
+	c.Assert(block.Hostname, Equals, "host")
+
+	hashes := []string{}
+	for _, s := range sent {
+		sum := sha256.Sum256([]byte(s))
+		hashes = append(hashes, base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	c.Assert(params["HB"], Equals, strings.Join(hashes, " "))
+
+	// The signature covers the block without its SIGN parameter
+	sig, err := base64.StdEncoding.DecodeString(params["SIGN"])
+	c.Assert(err, IsNil)
+	unsigned := block
+	unsigned.StructuredData = []StructuredData{{ID: SignatureSDID}}
+	for _, p := range block.StructuredData[0].Parameters {
+		if p.Name != "SIGN" {
+			unsigned.StructuredData[0].AddParam(p.Name, p.Value)
+		}
+	}
+	b, err := unsigned.MarshalBinary()
+	c.Assert(err, IsNil)
+	h := crypto.SHA256.New()
+	h.Write(b)
+	c.Assert(ecdsa.VerifyASN1(&key.PublicKey, h.Sum(nil), sig), Equals, true)
+}
+
+func (testSuite *SigningTest) TestGroupPerPRI(c *C) {
+	key, cert := signingKey(c)
+	fw := &chanWriter{messages: make(chan Message, 64)}
+	signer := &Signer{Writer: fw, Key: key, Certificate: cert, Group: SignatureGroupPerPRI}
+	for _, pri := range []int{14, 11, 14} {
+		c.Assert(signer.WriteMessage(Message{Priority: pri}), IsNil)
+	}
+	c.Assert(signer.Close(), IsNil)
+
+	blocks := map[string]string{}
+	for len(fw.messages) > 0 {
+		m := <-fw.messages
+		if params := sdParams(m, SignatureSDID); len(params) > 0 {
+			c.Assert(params["SPRI"], Equals, strconv.Itoa(m.Priority))
+			blocks[params["SPRI"]] = params["CNT"]
+		}
+	}
+	c.Assert(blocks, DeepEquals, map[string]string{"14": "2", "11": "1"})
+}
+
+// signedStream returns the messages written by a Signer for bodies
+func signedStream(c *C, key crypto.Signer, cert *x509.Certificate, bodies ...string) []string {
+	fw := &chanWriter{messages: make(chan Message, 64)}
+	signer := &Signer{Writer: fw, Key: key, Certificate: cert, MaxHashes: 2,
+		CertificateFragmentSize: 200}
+	for _, body := range bodies {
+		c.Assert(signer.WriteMessage(Message{Hostname: "host", Message: []byte(body)}), IsNil)
+	}
+	c.Assert(signer.Flush(), IsNil)
+	stream := []string{}
+	for len(fw.messages) > 0 {
+		stream = append(stream, marshalString(c, <-fw.messages))
+	}
+	return stream
+}
+
+func (testSuite *SigningTest) TestVerify(c *C) {
+	key, cert := signingKey(c)
+	stream := signedStream(c, key, cert, "one", "two", "three")
+
+	// Tamper with "two" and drop "three"
+	results := map[string]VerificationStatus{}
+	missing := []uint64{}
+	v := &Verifier{
+		Certificates: []*x509.Certificate{cert},
+		Report: func(r VerificationResult) {
+			if r.Status == Missing {
+				missing = append(missing, r.Number)
+				return
+			}
+			m := Message{}
+			c.Assert(m.UnmarshalBinary(r.Message), IsNil)
+			results[string(m.Message)] = r.Status
+		},
+	}
+	for _, s := range stream {
+		switch {
+		case strings.HasSuffix(s, " two"):
+			s = strings.TrimSuffix(s, "two") + "TWO"
+		case strings.HasSuffix(s, " three"):
+			continue
+		}
+		c.Assert(v.Add([]byte(s)), IsNil)
+	}
+	c.Assert(v.Close(), IsNil)
+
+	c.Assert(results, DeepEquals, map[string]VerificationStatus{
+		"one": Verified,
+		"TWO": Unsigned,
+	})
+	c.Assert(missing, DeepEquals, []uint64{2, 3})
+}
+
+func (testSuite *SigningTest) TestVerifyMaxPending(c *C) {
+	key, cert := signingKey(c)
+	stream := signedStream(c, key, cert, "one", "two", "three")
+
+	results := []string{}
+	v := &Verifier{
+		Certificates: []*x509.Certificate{cert},
+		MaxPending:   1,
+		Report: func(r VerificationResult) {
+			if r.Status == Missing {
+				results = append(results, fmt.Sprintf("%d %s", r.Number, r.Status))
+				return
+			}
+			m := Message{}
+			c.Assert(m.UnmarshalBinary(r.Message), IsNil)
+			results = append(results, fmt.Sprintf("%s %s", m.Message, r.Status))
+		},
+	}
+	for _, s := range stream {
+		c.Assert(v.Add([]byte(s)), IsNil)
+	}
+	c.Assert(v.Close(), IsNil)
+
+	// "one" was reported unsigned when "two" was added, before the
+	// Signature Block covering both
+	c.Assert(results, DeepEquals, []string{"one unsigned", "1 missing", "two verified", "three verified"})
+}
+
+func (testSuite *SigningTest) TestVerifyRejectsBadBlocks(c *C) {
+	key, cert := signingKey(c)
+	_, otherCert := signingKey(c)
+	stream := signedStream(c, key, cert, "one")
+
+	// A certificate that isn't trusted
+	v := &Verifier{Certificates: []*x509.Certificate{otherCert}}
+	var err error
+	for _, s := range stream {
+		if err = v.Add([]byte(s)); err != nil {
+			break
+		}
+	}
+	c.Assert(err, ErrorMatches, ".*untrusted certificate")
+
+	// A Signature Block that has been modified
+	v = &Verifier{Certificates: []*x509.Certificate{cert}}
+	for _, s := range stream[:len(stream)-1] {
+		c.Assert(v.Add([]byte(s)), IsNil)
+	}
+	block := strings.Replace(stream[len(stream)-1], `CNT="1"`, `CNT="2"`, 1)
+	c.Assert(v.Add([]byte(block)), Equals, ErrBadSignature)
+
+	// OpenPGP DSA signatures are not supported
+	block = strings.Replace(stream[len(stream)-1], `VER="0129"`, `VER="0121"`, 1)
+	c.Assert(v.Add([]byte(block)), ErrorMatches, `rfc5424: unsupported signature version "0121"`)
+}
@@ -0,0 +1,86 @@
+package rfc5424
+
+import (
+	"strings"
+	"time"
+)
+
+const spanMessageID = "span"
+
+// SpanSDID is the ID of the SD element of the messages logged by Spans (see
+// DocumentationEnterpriseNumber)
+var SpanSDID = "span@32473"
+
+// Span times an operation, such as a database query, and logs it when it
+// ends, for latency visibility without a tracing system:
+//
+//	span := logger.Span("db.query").Param("table", "users")
+//	defer span.End()
+//	if err := query(); err != nil {
+//		span.Fail(err)
+//	}
+//
+// The message has the MSGID "span", text such as "db.query took 12ms", and
+// a SpanSDID SD element with the "name", "duration" (as parsed by
+// time.ParseDuration), "outcome", "success" or "failure", the "error" of a
+// failure, and the parameters added with Param. It is Info, or Error for a
+// failure. A Span is not safe for concurrent use.
+type Span struct {
+	logger *Logger
+	name   string
+	start  time.Time
+	params []SDParam
+	err    error
+	ended  bool
+}
+
+// Span starts timing the operation name
+func (l *Logger) Span(name string) *Span {
+	return &Span{logger: l, name: name, start: l.now()}
+}
+
+// Param adds a parameter to the span's SD element
+func (s *Span) Param(name, value string) *Span {
+	s.params = append(s.params, SDParam{Name: name, Value: value})
+	return s
+}
+
+// Fail records that the operation failed with err. A nil err is ignored.
+func (s *Span) Fail(err error) *Span {
+	if err != nil {
+		s.err = err
+	}
+	return s
+}
+
+// End logs the span, once; later calls do nothing. It returns the error of
+// the Logger.
+func (s *Span) End() error {
+	if s.ended {
+		return nil
+	}
+	s.ended = true
+	duration := s.logger.now().Sub(s.start)
+
+	severity, outcome := Severity(Info), "success"
+	if s.err != nil {
+		severity, outcome = Error, "failure"
+	}
+	b := s.logger.builder(severity).
+		MessageID(spanMessageID).
+		Text(s.name+" took "+duration.String()).
+		SD(SpanSDID, "name", s.name).
+		SD(SpanSDID, "duration", duration.String()).
+		SD(SpanSDID, "outcome", outcome)
+	if s.err != nil {
+		b.SD(SpanSDID, "error", strings.ToValidUTF8(s.err.Error(), "\ufffd"))
+	}
+	for _, param := range s.params {
+		b.SD(SpanSDID, param.Name, param.Value)
+	}
+	m, err := b.Build()
+	if err != nil {
+		return err
+	}
+	return s.logger.WriteMessage(m)
+}
@@ -0,0 +1,59 @@
+package rfc5424
+
+import (
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&SpanTest{})
+
+type SpanTest struct {
+}
+
+func (s *SpanTest) TestEnd(c *C) {
+	t := &recordingTransport{}
+	now := T("2003-10-11T22:14:15.003Z")
+	l := &Logger{Writer: TransportWriter(t), AppName: "api", Clock: func() time.Time { return now }}
+
+	span := l.Span("db.query").Param("table", "users")
+	now = now.Add(12 * time.Millisecond)
+	c.Assert(span.End(), IsNil)
+	c.Assert(span.End(), IsNil)
+	c.Assert(t.sent, HasLen, 1)
+
+	m := t.sent[0]
+	c.Assert(m.Severity(), Equals, Severity(Info))
+	c.Assert(m.AppName, Equals, "api")
+	c.Assert(m.MessageID, Equals, "span")
+	c.Assert(m.Timestamp.Equal(now), Equals, true)
+	c.Assert(m.TextMessage(), Equals, "db.query took 12ms")
+	c.Assert(m.StructuredData, DeepEquals, []StructuredData{{ID: "span@32473", Parameters: []SDParam{
+		{Name: "name", Value: "db.query"},
+		{Name: "duration", Value: "12ms"},
+		{Name: "outcome", Value: "success"},
+		{Name: "table", Value: "users"},
+	}}})
+}
+
+func (s *SpanTest) TestFail(c *C) {
+	t := &recordingTransport{}
+	l := &Logger{Writer: TransportWriter(t)}
+
+	span := l.Span("db.query")
+	span.Fail(nil)
+	span.Fail(errors.New("connection refused"))
+	c.Assert(span.End(), IsNil)
+
+	m := t.sent[0]
+	c.Assert(m.Severity(), Equals, Severity(Error))
+	outcome, _ := m.SDParam("span@32473", "outcome")
+	c.Assert(outcome, Equals, "failure")
+	msg, _ := m.SDParam("span@32473", "error")
+	c.Assert(msg, Equals, "connection refused")
+	_, err := m.GetDuration("span@32473", "duration")
+	c.Assert(err, IsNil)
+
+	c.Assert(l.Span("x").Param("bad name", "v").End(), NotNil)
+}
@@ -0,0 +1,331 @@
+package rfc5424
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSQLTable     = "syslog_messages"
+	defaultSQLBatchSize = 100
+
+	// sqliteTimeFormat is fixed width so that timestamps stored as TEXT
+	// sort (and compare) in time order.
+	sqliteTimeFormat = "2006-01-02T15:04:05.000000000Z"
+)
+
+// SQLDialect selects the SQL syntax and column types used by SQLStore
+type SQLDialect int
+
+const (
+	// PostgresDialect uses $n placeholders, TIMESTAMPTZ and JSONB columns
+	PostgresDialect SQLDialect = iota
+	// SQLiteDialect uses ? placeholders and stores timestamps and
+	// structured data as TEXT
+	SQLiteDialect
+)
+
+func (d SQLDialect) String() string {
+	switch d {
+	case PostgresDialect:
+		return "postgres"
+	case SQLiteDialect:
+		return "sqlite"
+	}
+	return fmt.Sprintf("SQLDialect(%d)", int(d))
+}
+
+func (d SQLDialect) placeholder(n int) string {
+	if d == PostgresDialect {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// maxPlaceholders is the most placeholders the dialect allows in a single
+// statement
+func (d SQLDialect) maxPlaceholders() int {
+	if d == SQLiteDialect {
+		return 999
+	}
+	return 65535
+}
+
+// quote returns name as a quoted identifier. Both dialects quote with double
+// quotes, doubling any within the name.
+func (d SQLDialect) quote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// sqlColumns are the columns written by SQLStore, in order
+var sqlColumns = []string{
+	"priority", "timestamp", "hostname", "app_name", "proc_id", "msg_id",
+	"structured_data", "message",
+}
+
+// sqlParam and sqlStructuredData are the JSON representation of structured
+// data in the structured_data column. A list is used rather than an object
+// since both SD-IDs and parameter names may repeat.
+type sqlParam struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type sqlStructuredData struct {
+	ID     string     `json:"id"`
+	Params []sqlParam `json:"params"`
+}
+
+// SQLStore is a Store that inserts messages into a SQL table, one column per
+// header field and the structured data as JSON. It works with any
+// database/sql driver for the chosen Dialect; the driver must be imported by
+// the caller.
+type SQLStore struct {
+	DB      *sql.DB
+	Dialect SQLDialect
+
+	// Table is the name of the table. It is quoted, so it is used as is
+	// rather than folded to lower case, and cannot be qualified with a
+	// schema. If empty, "syslog_messages" is used.
+	Table string
+
+	// BatchSize is the maximum number of rows inserted by a single
+	// statement. If zero, 100 is used. It is capped at the number of rows
+	// whose values fit in the dialect's limit on placeholders, 124 for
+	// SQLite.
+	BatchSize int
+
+	mu    sync.Mutex
+	stmts map[int]*sql.Stmt // prepared INSERTs, by number of rows
+}
+
+// NewSQLStore returns a new SQLStore writing to db
+func NewSQLStore(db *sql.DB, dialect SQLDialect) *SQLStore {
+	return &SQLStore{DB: db, Dialect: dialect}
+}
+
+func (s *SQLStore) tableName() string {
+	if s.Table == "" {
+		return defaultSQLTable
+	}
+	return s.Table
+}
+
+// table returns the quoted name of the table
+func (s *SQLStore) table() string {
+	return s.Dialect.quote(s.tableName())
+}
+
+func (s *SQLStore) batchSize() int {
+	n := s.BatchSize
+	if n <= 0 {
+		n = defaultSQLBatchSize
+	}
+	if max := s.Dialect.maxPlaceholders() / len(sqlColumns); n > max {
+		n = max
+	}
+	return n
+}
+
+// CreateTable creates the table and its timestamp index if they do not
+// already exist.
+func (s *SQLStore) CreateTable() error {
+	timestampType, jsonType, blobType, idType := "TIMESTAMPTZ", "JSONB", "BYTEA", "BIGSERIAL PRIMARY KEY"
+	if s.Dialect == SQLiteDialect {
+		timestampType, jsonType, blobType, idType = "TEXT", "TEXT", "BLOB", "INTEGER PRIMARY KEY AUTOINCREMENT"
+	}
+	stmts := []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ("+
+			"id %s, priority INTEGER NOT NULL, timestamp %s NOT NULL, "+
+			"hostname TEXT NOT NULL, app_name TEXT NOT NULL, proc_id TEXT NOT NULL, "+
+			"msg_id TEXT NOT NULL, structured_data %s NOT NULL, message %s NOT NULL)",
+			s.table(), idType, timestampType, jsonType, blobType),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (timestamp)",
+			s.Dialect.quote(s.tableName()+"_timestamp"), s.table()),
+	}
+	for _, stmt := range stmts {
+		if _, err := s.DB.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertStmt returns the prepared statement inserting n rows. The caller
+// must hold s.mu.
+func (s *SQLStore) insertStmt(n int) (*sql.Stmt, error) {
+	if stmt, ok := s.stmts[n]; ok {
+		return stmt, nil
+	}
+	rows := make([]string, n)
+	for i := range rows {
+		placeholders := make([]string, len(sqlColumns))
+		for j := range placeholders {
+			placeholders[j] = s.Dialect.placeholder(i*len(sqlColumns) + j + 1)
+		}
+		rows[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+	stmt, err := s.DB.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		s.table(), strings.Join(sqlColumns, ", "), strings.Join(rows, ", ")))
+	if err != nil {
+		return nil, err
+	}
+	if s.stmts == nil {
+		s.stmts = map[int]*sql.Stmt{}
+	}
+	s.stmts[n] = stmt
+	return stmt, nil
+}
+
+func (s *SQLStore) timeValue(t time.Time) interface{} {
+	if s.Dialect == SQLiteDialect {
+		return t.UTC().Format(sqliteTimeFormat)
+	}
+	return t
+}
+
+func (s *SQLStore) args(m Message) ([]interface{}, error) {
+	sd := make([]sqlStructuredData, len(m.StructuredData))
+	for i, d := range m.StructuredData {
+		sd[i] = sqlStructuredData{ID: d.ID, Params: make([]sqlParam, len(d.Parameters))}
+		for j, p := range d.Parameters {
+			sd[i].Params[j] = sqlParam(p)
+		}
+	}
+	sdJSON, err := json.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	body := m.Message
+	if body == nil {
+		body = []byte{}
+	}
+	return []interface{}{
+		m.Priority, s.timeValue(m.Timestamp), m.Hostname, m.AppName,
+		m.ProcessID, m.MessageID, string(sdJSON), body,
+	}, nil
+}
+
+// Append inserts a single message
+func (s *SQLStore) Append(m Message) error {
+	return s.AppendBatch([]Message{m})
+}
+
+// AppendBatch inserts messages in a single transaction, using multi-row
+// INSERT statements of up to BatchSize rows.
+func (s *SQLStore) AppendBatch(messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	for len(messages) > 0 {
+		n := len(messages)
+		if n > s.batchSize() {
+			n = s.batchSize()
+		}
+		stmt, err := s.insertStmt(n)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		args := make([]interface{}, 0, n*len(sqlColumns))
+		for _, m := range messages[:n] {
+			a, err := s.args(m)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			args = append(args, a...)
+		}
+		if _, err := tx.Stmt(stmt).Exec(args...); err != nil {
+			tx.Rollback()
+			return err
+		}
+		messages = messages[n:]
+	}
+	return tx.Commit()
+}
+
+// Query calls fn for each message with a Timestamp in [start, end), in the
+// order they were inserted.
+func (s *SQLStore) Query(start, end time.Time, fn func(m Message) error) error {
+	rows, err := s.DB.Query(fmt.Sprintf(
+		"SELECT %s FROM %s WHERE timestamp >= %s AND timestamp < %s ORDER BY id",
+		strings.Join(sqlColumns, ", "), s.table(),
+		s.Dialect.placeholder(1), s.Dialect.placeholder(2)),
+		s.timeValue(start), s.timeValue(end))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m := Message{}
+		var timestamp interface{}
+		var sdJSON string
+		err := rows.Scan(&m.Priority, &timestamp, &m.Hostname, &m.AppName,
+			&m.ProcessID, &m.MessageID, &sdJSON, &m.Message)
+		if err != nil {
+			return err
+		}
+		if m.Timestamp, err = s.scanTime(timestamp); err != nil {
+			return err
+		}
+
+		sd := []sqlStructuredData{}
+		if err := json.Unmarshal([]byte(sdJSON), &sd); err != nil {
+			return err
+		}
+		for _, d := range sd {
+			data := StructuredData{ID: d.ID}
+			for _, p := range d.Params {
+				data.AddParam(p.Name, p.Value)
+			}
+			m.StructuredData = append(m.StructuredData, data)
+		}
+
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// scanTime converts a timestamp column to a time.Time. Drivers return either
+// a time.Time or, for SQLite TEXT columns, the formatted string.
+func (s *SQLStore) scanTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		return time.Parse(sqliteTimeFormat, t)
+	case []byte:
+		return time.Parse(sqliteTimeFormat, string(t))
+	}
+	return time.Time{}, fmt.Errorf("rfc5424: cannot convert %T to a timestamp", v)
+}
+
+// Close closes the prepared statements. The DB is not closed since it is
+// owned by the caller.
+func (s *SQLStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	for n, stmt := range s.stmts {
+		if closeErr := stmt.Close(); err == nil {
+			err = closeErr
+		}
+		delete(s.stmts, n)
+	}
+	return err
+}
@@ -0,0 +1,221 @@
+package rfc5424
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&SQLStoreTest{})
+
+// fakeDB is a database/sql driver that understands just enough of the
+// statements issued by SQLStore to store and return rows. It records the
+// distinct statements prepared so tests can check the SQL.
+type fakeDB struct {
+	mu       sync.Mutex
+	prepared []string
+	rows     [][]driver.Value
+}
+
+func (db *fakeDB) prepare(query string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, q := range db.prepared {
+		if q == query {
+			return
+		}
+	}
+	db.prepared = append(db.prepared, query)
+}
+
+var (
+	fakeDBsMu sync.Mutex
+	fakeDBs   = map[string]*fakeDB{}
+)
+
+func init() {
+	sql.Register("fakesql", fakeDriver{})
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+	if fakeDBs[name] == nil {
+		fakeDBs[name] = &fakeDB{}
+	}
+	return fakeConn{fakeDBs[name]}, nil
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (fc fakeConn) Prepare(query string) (driver.Stmt, error) {
+	fc.db.prepare(query)
+	return fakeStmt{db: fc.db, query: query}, nil
+}
+
+func (fc fakeConn) Close() error              { return nil }
+func (fc fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (fs fakeStmt) Close() error  { return nil }
+func (fs fakeStmt) NumInput() int { return -1 }
+
+func (fs fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !strings.HasPrefix(fs.query, "INSERT") {
+		return driver.RowsAffected(0), nil
+	}
+	fs.db.mu.Lock()
+	defer fs.db.mu.Unlock()
+	n := len(args) / 8
+	for len(args) > 0 {
+		fs.db.rows = append(fs.db.rows, args[:8])
+		args = args[8:]
+	}
+	return driver.RowsAffected(n), nil
+}
+
+// Query returns the rows whose timestamp (the second column) is in the range
+// given by the two arguments.
+func (fs fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if !strings.HasPrefix(fs.query, "SELECT") || len(args) != 2 {
+		return nil, errors.New("unsupported query: " + fs.query)
+	}
+	start, end := args[0].(string), args[1].(string)
+	fs.db.mu.Lock()
+	defer fs.db.mu.Unlock()
+	rows := &fakeRows{}
+	for _, row := range fs.db.rows {
+		if t := row[1].(string); t >= start && t < end {
+			rows.rows = append(rows.rows, row)
+		}
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (fr *fakeRows) Columns() []string {
+	return []string{"priority", "timestamp", "hostname", "app_name", "proc_id",
+		"msg_id", "structured_data", "message"}
+}
+
+func (fr *fakeRows) Close() error { return nil }
+
+func (fr *fakeRows) Next(dest []driver.Value) error {
+	if len(fr.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, fr.rows[0])
+	fr.rows = fr.rows[1:]
+	return nil
+}
+
+type SQLStoreTest struct {
+}
+
+func (testSuite *SQLStoreTest) TestAppendAndQuery(c *C) {
+	db, err := sql.Open("fakesql", c.TestName())
+	c.Assert(err, IsNil)
+	defer db.Close()
+	c.Assert(db.Ping(), IsNil)
+	fake := fakeDBs[c.TestName()]
+
+	store := NewSQLStore(db, SQLiteDialect)
+	var _ Store = store
+	store.BatchSize = 2
+	c.Assert(store.CreateTable(), IsNil)
+	c.Assert(fake.prepared[0], Matches, `CREATE TABLE IF NOT EXISTS "syslog_messages" \(.*`)
+
+	t0 := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	msgs := []Message{}
+	for i := 0; i < 5; i++ {
+		m := Message{
+			Priority:  14,
+			Timestamp: t0.Add(time.Duration(i) * time.Hour),
+			Hostname:  "host",
+			AppName:   "app",
+			Message:   []byte("hello"),
+		}
+		m.AddDatum("a@1", "n", "1")
+		m.AddDatum("a@1", "n", "2")
+		msgs = append(msgs, m)
+	}
+	c.Assert(store.AppendBatch(msgs), IsNil)
+	c.Assert(store.Append(msgs[0]), IsNil)
+	c.Assert(fake.rows, HasLen, 6)
+
+	// There is one statement per batch size: two rows, then one row
+	inserts := []string{}
+	for _, q := range fake.prepared {
+		if strings.HasPrefix(q, "INSERT") {
+			inserts = append(inserts, q)
+		}
+	}
+	c.Assert(inserts, HasLen, 2)
+	c.Assert(inserts[1], Equals, "INSERT INTO \"syslog_messages\" (priority, timestamp, hostname, "+
+		"app_name, proc_id, msg_id, structured_data, message) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	c.Assert(fake.rows[0][6], Equals, `[{"id":"a@1","params":[{"name":"n","value":"1"},{"name":"n","value":"2"}]}]`)
+
+	found := []Message{}
+	err = store.Query(t0.Add(time.Hour), t0.Add(3*time.Hour), func(m Message) error {
+		found = append(found, m)
+		return nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(found, DeepEquals, msgs[1:3])
+	c.Assert(store.Close(), IsNil)
+}
+
+func (testSuite *SQLStoreTest) TestPostgresPlaceholders(c *C) {
+	db, err := sql.Open("fakesql", c.TestName())
+	c.Assert(err, IsNil)
+	defer db.Close()
+	c.Assert(db.Ping(), IsNil)
+	fake := fakeDBs[c.TestName()]
+
+	store := NewSQLStore(db, PostgresDialect)
+	store.Table = `Logs"`
+	c.Assert(store.AppendBatch([]Message{{}, {}}), IsNil)
+	c.Assert(fake.prepared, HasLen, 1)
+	c.Assert(fake.prepared[0], Equals, "INSERT INTO \"Logs\"\"\" (priority, timestamp, hostname, "+
+		"app_name, proc_id, msg_id, structured_data, message) VALUES "+
+		"($1, $2, $3, $4, $5, $6, $7, $8), ($9, $10, $11, $12, $13, $14, $15, $16)")
+	c.Assert(store.Close(), IsNil)
+}
+
+func (testSuite *SQLStoreTest) TestBatchSizeLimit(c *C) {
+	db, err := sql.Open("fakesql", c.TestName())
+	c.Assert(err, IsNil)
+	defer db.Close()
+	c.Assert(db.Ping(), IsNil)
+	fake := fakeDBs[c.TestName()]
+
+	// SQLite allows 999 placeholders, enough for 124 rows of 8 columns
+	store := NewSQLStore(db, SQLiteDialect)
+	store.BatchSize = 200
+	c.Assert(store.AppendBatch(make([]Message, 130)), IsNil)
+	c.Assert(fake.rows, HasLen, 130)
+	c.Assert(fake.prepared, HasLen, 2)
+	c.Assert(strings.Count(fake.prepared[0], "?"), Equals, 124*8)
+	c.Assert(strings.Count(fake.prepared[1], "?"), Equals, 6*8)
+	c.Assert(store.Close(), IsNil)
+}
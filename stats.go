@@ -0,0 +1,308 @@
+package rfc5424
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// numFacilities is the number of facilities that can be encoded in a PRI
+const numFacilities = 24
+
+// ServerStats counts the messages received by a Server, for capacity
+// monitoring. All methods are safe for concurrent use.
+//
+// A *ServerStats is an expvar.Var, so the counters can be published with
+//
+//	expvar.Publish("syslog", srv.Stats)
+type ServerStats struct {
+	// The counters are accessed atomically, and come first to be 64-bit
+	// aligned.
+	received    uint64
+	parseErrors uint64
+	dropped     uint64
+	overflows   uint64
+	bytes       uint64
+	facilities  [numFacilities]uint64
+}
+
+// ServerStatsSnapshot holds the values of the ServerStats counters at one
+// point in time.
+type ServerStatsSnapshot struct {
+	// Received is the number of messages parsed and passed to the Handler
+	Received uint64
+
+	// ParseErrors is the number of messages that could not be parsed
+	ParseErrors uint64
+
+	// Dropped is the number of datagrams and connections refused by the
+	// Server's AccessList.
+	Dropped uint64
+
+	// Overflows is the number of messages discarded because the worker
+	// queue was full (see QueueDrop).
+	Overflows uint64
+
+	// Bytes is the total length of the messages received, including those
+	// that could not be parsed.
+	Bytes uint64
+
+	// Facilities is the number of messages received with each facility.
+	// Facilities with no messages are omitted.
+	Facilities map[Facility]uint64
+}
+
+func (s *ServerStats) messageReceived(m Message, length int) {
+	if s == nil {
+		return
+	}
+	atomic.AddUint64(&s.received, 1)
+	atomic.AddUint64(&s.bytes, uint64(length))
+	if f := m.Facility() - Kernel; f >= 0 && f < numFacilities {
+		atomic.AddUint64(&s.facilities[f], 1)
+	}
+}
+
+func (s *ServerStats) parseError(length int) {
+	if s == nil {
+		return
+	}
+	atomic.AddUint64(&s.parseErrors, 1)
+	atomic.AddUint64(&s.bytes, uint64(length))
+}
+
+func (s *ServerStats) droppedSource() {
+	if s == nil {
+		return
+	}
+	atomic.AddUint64(&s.dropped, 1)
+}
+
+func (s *ServerStats) overflow() {
+	if s == nil {
+		return
+	}
+	atomic.AddUint64(&s.overflows, 1)
+}
+
+// Snapshot returns the current values of the counters
+func (s *ServerStats) Snapshot() ServerStatsSnapshot {
+	snap := ServerStatsSnapshot{
+		Received:    atomic.LoadUint64(&s.received),
+		ParseErrors: atomic.LoadUint64(&s.parseErrors),
+		Dropped:     atomic.LoadUint64(&s.dropped),
+		Overflows:   atomic.LoadUint64(&s.overflows),
+		Bytes:       atomic.LoadUint64(&s.bytes),
+		Facilities:  map[Facility]uint64{},
+	}
+	for i := range s.facilities {
+		if n := atomic.LoadUint64(&s.facilities[i]); n > 0 {
+			snap.Facilities[Kernel+Facility(i)] = n
+		}
+	}
+	return snap
+}
+
+// String returns the counters as a JSON object, implementing expvar.Var.
+// Facilities are keyed by name.
+func (s *ServerStats) String() string {
+	snap := s.Snapshot()
+	facilities := map[string]uint64{}
+	for f, n := range snap.Facilities {
+		facilities[f.String()] = n
+	}
+	b, _ := json.Marshal(map[string]interface{}{
+		"received":     snap.Received,
+		"parse_errors": snap.ParseErrors,
+		"dropped":      snap.Dropped,
+		"overflows":    snap.Overflows,
+		"bytes":        snap.Bytes,
+		"facilities":   facilities,
+	})
+	return string(b)
+}
+
+// numSeverities is the number of severities that can be encoded in a PRI
+const numSeverities = 8
+
+// LoggerStats describes the state of a Logger's pipeline, so that a live
+// process can be inspected: the messages written, dropped and failed by
+// severity, the last error, and the queues and connections registered with
+// Queue and Connection. All methods are safe for concurrent use.
+//
+// Like ServerStats, a *LoggerStats is an expvar.Var. It is also an
+// http.Handler serving the same JSON, e.g. on a debug listener:
+//
+//	http.Handle("/debug/logger", logger.Stats)
+type LoggerStats struct {
+	// The counters are accessed atomically, and come first to be 64-bit
+	// aligned.
+	written     [numSeverities]uint64
+	dropped     [numSeverities]uint64
+	failed      [numSeverities]uint64
+	mu          sync.Mutex
+	lastError   string
+	lastErrorAt time.Time
+	queues      map[string]func() int
+	connections map[string]func() string
+}
+
+// LoggerStatsSnapshot holds the state described by a LoggerStats at one
+// point in time. Severities with no messages are omitted from the maps.
+type LoggerStatsSnapshot struct {
+	// Written is the number of messages written, by severity
+	Written map[Severity]uint64
+
+	// Dropped is the number of messages dropped by the Logger's Rules
+	Dropped map[Severity]uint64
+
+	// Failed is the number of messages that could not be written
+	Failed map[Severity]uint64
+
+	// LastError is the last error returned by the Logger, and LastErrorAt
+	// when it was returned. They are empty if there was none.
+	LastError   string
+	LastErrorAt time.Time
+
+	// Queues holds the depth of each registered queue, and Connections the
+	// state of each registered connection.
+	Queues      map[string]int
+	Connections map[string]string
+}
+
+// count adds a message of severity s to counters
+func (s *LoggerStats) count(counters *[numSeverities]uint64, severity Severity) {
+	if s == nil {
+		return
+	}
+	if i := severity - Emergency; i >= 0 && i < numSeverities {
+		atomic.AddUint64(&counters[i], 1)
+	}
+}
+
+func (s *LoggerStats) messageWritten(m Message) {
+	if s != nil {
+		s.count(&s.written, m.Severity())
+	}
+}
+
+func (s *LoggerStats) messageDropped(m Message) {
+	if s != nil {
+		s.count(&s.dropped, m.Severity())
+	}
+}
+
+func (s *LoggerStats) writeFailed(m Message, err error) {
+	if s == nil {
+		return
+	}
+	s.count(&s.failed, m.Severity())
+	s.mu.Lock()
+	s.lastError, s.lastErrorAt = err.Error(), TimeNow()
+	s.mu.Unlock()
+}
+
+// Queue registers a queue whose depth, as returned by depth, is reported
+// under name, e.g. the Pending messages of a Server. A later registration
+// under the same name replaces the earlier one.
+func (s *LoggerStats) Queue(name string, depth func() int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queues == nil {
+		s.queues = map[string]func() int{}
+	}
+	s.queues[name] = depth
+}
+
+// Connection registers a connection whose state, as returned by state, is
+// reported under name, e.g. the State of a CircuitBreaker. A later
+// registration under the same name replaces the earlier one.
+func (s *LoggerStats) Connection(name string, state func() string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.connections == nil {
+		s.connections = map[string]func() string{}
+	}
+	s.connections[name] = state
+}
+
+// Snapshot returns the current state
+func (s *LoggerStats) Snapshot() LoggerStatsSnapshot {
+	snap := LoggerStatsSnapshot{
+		Written:     map[Severity]uint64{},
+		Dropped:     map[Severity]uint64{},
+		Failed:      map[Severity]uint64{},
+		Queues:      map[string]int{},
+		Connections: map[string]string{},
+	}
+	for i := 0; i < numSeverities; i++ {
+		severity := Emergency + Severity(i)
+		if n := atomic.LoadUint64(&s.written[i]); n > 0 {
+			snap.Written[severity] = n
+		}
+		if n := atomic.LoadUint64(&s.dropped[i]); n > 0 {
+			snap.Dropped[severity] = n
+		}
+		if n := atomic.LoadUint64(&s.failed[i]); n > 0 {
+			snap.Failed[severity] = n
+		}
+	}
+
+	s.mu.Lock()
+	snap.LastError, snap.LastErrorAt = s.lastError, s.lastErrorAt
+	queues := make(map[string]func() int, len(s.queues))
+	for name, depth := range s.queues {
+		queues[name] = depth
+	}
+	connections := make(map[string]func() string, len(s.connections))
+	for name, state := range s.connections {
+		connections[name] = state
+	}
+	s.mu.Unlock()
+
+	// The functions are called without holding the lock, as they may take
+	// locks of their own
+	for name, depth := range queues {
+		snap.Queues[name] = depth()
+	}
+	for name, state := range connections {
+		snap.Connections[name] = state()
+	}
+	return snap
+}
+
+// severityCounts returns counts keyed by severity name
+func severityCounts(counts map[Severity]uint64) map[string]uint64 {
+	named := make(map[string]uint64, len(counts))
+	for severity, n := range counts {
+		named[severity.String()] = n
+	}
+	return named
+}
+
+// String returns the state as a JSON object, implementing expvar.Var.
+// Severities are keyed by name.
+func (s *LoggerStats) String() string {
+	snap := s.Snapshot()
+	v := map[string]interface{}{
+		"written":     severityCounts(snap.Written),
+		"dropped":     severityCounts(snap.Dropped),
+		"failed":      severityCounts(snap.Failed),
+		"queues":      snap.Queues,
+		"connections": snap.Connections,
+	}
+	if snap.LastError != "" {
+		v["last_error"] = snap.LastError
+		v["last_error_at"] = snap.LastErrorAt.UTC().Format(time.RFC3339Nano)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// ServeHTTP serves the state as JSON
+func (s *LoggerStats) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(s.String()))
+}
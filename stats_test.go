@@ -0,0 +1,98 @@
+package rfc5424
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&StatsTest{})
+
+type StatsTest struct {
+}
+
+func (s *StatsTest) TestServerCountsMessages(c *C) {
+	stats := &ServerStats{}
+	h := make(chanHandler, 10)
+	srv := Server{Handler: h, Stats: stats, ErrorLog: log.New(ioutil.Discard, "", 0)}
+	ctx := context.Background()
+
+	length := 0
+	for _, msg := range []string{
+		"<34>1 0000-12-31T00:00:00Z - - - - -",
+		"<35>1 0000-12-31T00:00:00Z - - - - -",
+		"<165>1 0000-12-31T00:00:00Z - - - - -",
+		"garbage",
+	} {
+		srv.handle(ctx, []byte(msg), Source{})
+		length += len(msg)
+	}
+	al, err := ParseAccessList(nil, []string{"127.0.0.0/8"})
+	c.Assert(err, IsNil)
+	srv.AccessList = al
+	c.Assert(srv.allowed(Source{RemoteAddr: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}}), Equals, false)
+
+	c.Assert(stats.Snapshot(), DeepEquals, ServerStatsSnapshot{
+		Received:    3,
+		ParseErrors: 1,
+		Dropped:     1,
+		Bytes:       uint64(length),
+		Facilities:  map[Facility]uint64{Auth: 2, Local4: 1},
+	})
+
+	v := map[string]interface{}{}
+	c.Assert(json.Unmarshal([]byte(stats.String()), &v), IsNil)
+	c.Assert(v["received"], Equals, float64(3))
+	c.Assert(v["facilities"], DeepEquals, map[string]interface{}{"auth": float64(2), "local4": float64(1)})
+}
+
+func (s *StatsTest) TestNilStats(c *C) {
+	srv := Server{Handler: make(chanHandler, 1)}
+	srv.handle(context.Background(), []byte("<34>1 0000-12-31T00:00:00Z - - - - -"), Source{})
+	c.Assert(srv.Stats, IsNil)
+}
+
+func (s *StatsTest) TestLoggerStats(c *C) {
+	defer func() { TimeNow = time.Now }()
+	TimeNow = func() time.Time { return T("2003-10-11T22:14:15.003Z") }
+
+	stats := &LoggerStats{}
+	cb := &CircuitBreaker{Writer: &chanWriter{messages: make(chan Message, 10)}}
+	l := &Logger{Writer: cb, Stats: stats, StrictSDIDs: true,
+		Rules: RuleSet{Rules: []Rule{{MaxSeverity: Debug, Action: ActionDrop}}}}
+	srv := &Server{}
+	stats.Queue("server", srv.Pending)
+	stats.Connection("collector", func() string { return cb.State().String() })
+
+	c.Assert(l.Log(Error, "a"), IsNil)
+	c.Assert(l.Log(Error, "b"), IsNil)
+	c.Assert(l.Log(Info, "c"), IsNil)
+	c.Assert(l.Log(Debug, "d"), IsNil)
+	bad := Message{Priority: 12}
+	bad.AddDatum("nopen", "a", "1")
+	c.Assert(l.WriteMessage(bad), NotNil)
+
+	snap := stats.Snapshot()
+	c.Assert(snap.Written, DeepEquals, map[Severity]uint64{Error: 2, Info: 1})
+	c.Assert(snap.Dropped, DeepEquals, map[Severity]uint64{Debug: 1})
+	c.Assert(snap.Failed, DeepEquals, map[Severity]uint64{Warning: 1})
+	c.Assert(snap.LastError, Matches, ".*nopen.*")
+	c.Assert(snap.LastErrorAt, Equals, T("2003-10-11T22:14:15.003Z"))
+	c.Assert(snap.Queues, DeepEquals, map[string]int{"server": 0})
+	c.Assert(snap.Connections, DeepEquals, map[string]string{"collector": "closed"})
+
+	rec := httptest.NewRecorder()
+	stats.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/logger", nil))
+	c.Assert(rec.Header().Get("Content-Type"), Equals, "application/json")
+	v := map[string]interface{}{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &v), IsNil)
+	c.Assert(v["written"], DeepEquals, map[string]interface{}{"err": float64(2), "info": float64(1)})
+	c.Assert(v["last_error_at"], Equals, "2003-10-11T22:14:15.003Z")
+	c.Assert(v["connections"], DeepEquals, map[string]interface{}{"collector": "closed"})
+}
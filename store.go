@@ -0,0 +1,34 @@
+package rfc5424
+
+import (
+	"context"
+	"time"
+)
+
+// Store persists received messages. Implementations must be safe for
+// concurrent use, since a Server may handle messages concurrently.
+type Store interface {
+	// Append stores a single message
+	Append(m Message) error
+
+	// AppendBatch stores several messages, which may be more efficient than
+	// appending them one at a time.
+	AppendBatch(messages []Message) error
+
+	// Query calls fn for each stored message with a Timestamp in the range
+	// [start, end), in the order they were stored. If fn returns an error
+	// the query stops and Query returns that error.
+	Query(start, end time.Time, fn func(m Message) error) error
+
+	Close() error
+}
+
+// StoreHandler returns a Handler that appends each received message to s.
+// Errors returned by s are passed to onError, if it is not nil.
+func StoreHandler(s Store, onError func(error)) Handler {
+	return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+		if err := s.Append(m); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"sync"
+	"time"
 )
 
 // WriteTo writes the message to a stream of messages in the style defined
@@ -39,3 +41,193 @@ func (m *Message) ReadFrom(r io.Reader) (int64, error) {
 	}
 	return int64(n1 + len(buf)), err
 }
+
+const (
+	defaultCompressFlushSize     = 64 * 1024
+	defaultCompressFlushInterval = time.Second
+)
+
+// StreamWriter is a MessageWriter that writes messages to Writer using the
+// RFC-5425 length-delimited framing implemented by WriteTo, or the framing
+// chosen by Framing. Writer is typically a TCP or TLS connection.
+type StreamWriter struct {
+	// counters is accessed atomically, and comes first to be 64-bit aligned
+	counters transportCounters
+
+	Writer io.Writer
+
+	// Framing is how messages are delimited. By default they are
+	// octet-counted.
+	Framing Framing
+
+	// MaxLength, if set, is the longest message written. Longer messages
+	// are truncated, as by LengthLimiter.
+	MaxLength int
+
+	// Compression, if set, compresses the stream. The receiver must be
+	// configured to expect the same compression (see NewStreamReader).
+	Compression Compression
+
+	// FlushSize is the number of uncompressed bytes that may be buffered by
+	// the compressor before it is flushed to Writer. Flushing too often
+	// defeats the compression. If zero, 64 KiB is used.
+	FlushSize int
+
+	// FlushInterval is the longest a message may stay buffered by the
+	// compressor before it is flushed to Writer. If zero, one second is used.
+	FlushInterval time.Duration
+
+	mu      sync.Mutex
+	cw      compressWriter
+	pending int
+	timer   *time.Timer
+	err     error
+}
+
+// NewStreamWriter returns a new StreamWriter writing to w. WithFraming and
+// WithMaxLen set its Framing and MaxLength.
+func NewStreamWriter(w io.Writer, opts ...Option) *StreamWriter {
+	o := newOptions(opts)
+	return &StreamWriter{Writer: w, Framing: o.framing, MaxLength: o.maxLength}
+}
+
+// WriteMessage writes a single framed message to the stream. When compression
+// is enabled the message may be buffered until FlushSize bytes are pending,
+// FlushInterval has passed, or Flush or Close is called.
+func (sw *StreamWriter) WriteMessage(m Message) error {
+	n, err := sw.writeMessage(m, nil)
+	sw.counters.record(n, err)
+	return err
+}
+
+func (sw *StreamWriter) writeMarshaled(m Message, b []byte) error {
+	n, err := sw.writeMessage(m, b)
+	sw.counters.record(n, err)
+	return err
+}
+
+// Send writes m, implementing Transport
+func (sw *StreamWriter) Send(m Message) error {
+	return sw.WriteMessage(m)
+}
+
+// Stats returns the counts of the messages written. The octets are counted
+// before compression.
+func (sw *StreamWriter) Stats() TransportStats {
+	return sw.counters.stats()
+}
+
+// writeMessage writes m and returns the length of its frame. b is the
+// marshaled form of m, or nil if it is not known yet.
+func (sw *StreamWriter) writeMessage(m Message, b []byte) (int64, error) {
+	if sw.MaxLength > 0 && (b == nil || len(b) > sw.MaxLength) {
+		var err error
+		var truncated bool
+		if m, truncated, err = limitLength(m, sw.MaxLength, TruncateLongMessages); err != nil {
+			return 0, err
+		}
+		if truncated {
+			b = nil
+		}
+	}
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.Compression == NoCompression {
+		return sw.Framing.writeMessage(sw.Writer, m, b)
+	}
+	if sw.err != nil {
+		return 0, sw.err
+	}
+	if sw.cw == nil {
+		cw, err := newCompressWriter(sw.Writer, sw.Compression)
+		if err != nil {
+			return 0, err
+		}
+		sw.cw = cw
+	}
+	n, err := sw.Framing.writeMessage(sw.cw, m, b)
+	if err != nil {
+		return n, err
+	}
+
+	sw.pending += int(n)
+	flushSize := sw.FlushSize
+	if flushSize <= 0 {
+		flushSize = defaultCompressFlushSize
+	}
+	if sw.pending >= flushSize {
+		return n, sw.flush()
+	}
+	if sw.timer == nil {
+		interval := sw.FlushInterval
+		if interval <= 0 {
+			interval = defaultCompressFlushInterval
+		}
+		var timer *time.Timer
+		timer = time.AfterFunc(interval, func() {
+			sw.mu.Lock()
+			defer sw.mu.Unlock()
+			if sw.timer != timer {
+				return // stopped, and maybe replaced, while waiting for sw.mu
+			}
+			sw.timer = nil
+			if err := sw.flush(); err != nil {
+				sw.err = err // reported by the next write, Flush or Close
+			}
+		})
+		sw.timer = timer
+	}
+	return n, nil
+}
+
+// flush writes any buffered compressed data to Writer. The caller must hold
+// sw.mu.
+func (sw *StreamWriter) flush() error {
+	if sw.timer != nil {
+		sw.timer.Stop()
+		sw.timer = nil
+	}
+	if sw.cw == nil || sw.pending == 0 {
+		return nil
+	}
+	sw.pending = 0
+	return sw.cw.Flush()
+}
+
+// Flush writes any messages buffered by the compressor to Writer.
+func (sw *StreamWriter) Flush() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.err != nil {
+		return sw.err
+	}
+	return sw.flush()
+}
+
+// Close finishes the compressed stream, if any, and closes Writer if it
+// implements io.Closer. It returns the error from an earlier background
+// flush, if any.
+func (sw *StreamWriter) Close() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	err := sw.err
+	if sw.timer != nil {
+		sw.timer.Stop()
+		sw.timer = nil
+	}
+	if sw.cw != nil {
+		if closeErr := sw.cw.Close(); err == nil {
+			err = closeErr
+		}
+		sw.cw = nil
+	}
+	if c, ok := sw.Writer.(io.Closer); ok {
+		if closeErr := c.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
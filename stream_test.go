@@ -2,6 +2,10 @@ package rfc5424
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
 
 	. "gopkg.in/check.v1"
 )
@@ -54,3 +58,107 @@ func (s *StreamTest) TestRejectsInvalidStream2(c *C) {
 		c.Assert(err, Not(IsNil))
 	}
 }
+
+func (s *StreamTest) TestCanReadAndWriteCompressed(c *C) {
+	stream := bytes.Buffer{}
+	sw := StreamWriter{Writer: &stream, Compression: GzipCompression}
+	for i := 0; i < 4; i++ {
+		m := Message{Priority: i, Timestamp: T("0000-12-31T00:00:00Z")}
+		c.Assert(sw.WriteMessage(m), IsNil)
+	}
+	c.Assert(sw.Close(), IsNil)
+
+	r, err := NewStreamReader(&stream, GzipCompression)
+	c.Assert(err, IsNil)
+	for i := 0; i < 4; i++ {
+		m := Message{}
+		_, err := m.ReadFrom(r)
+		c.Assert(err, IsNil)
+		c.Assert(m, DeepEquals, Message{Priority: i,
+			Timestamp:      T("0000-12-31T00:00:00Z"),
+			StructuredData: []StructuredData{}})
+	}
+}
+
+func (s *StreamTest) TestCompressionSavesBandwidth(c *C) {
+	raw := bytes.Buffer{}
+	compressed := bytes.Buffer{}
+	rawWriter := StreamWriter{Writer: &raw}
+	compressedWriter := StreamWriter{Writer: &compressed, Compression: GzipCompression}
+	for i := 0; i < 1000; i++ {
+		m := Message{
+			Priority:  165,
+			Timestamp: T("2003-10-11T22:14:15.003Z"),
+			Hostname:  "mymachine.example.com",
+			AppName:   "evntslog",
+			MessageID: "ID47",
+			Message:   []byte(fmt.Sprintf("request %d served in %dms", i, i%100)),
+		}
+		m.AddDatum("exampleSDID@32473", "iut", "3")
+		m.AddDatum("exampleSDID@32473", "eventSource", "Application")
+		c.Assert(rawWriter.WriteMessage(m), IsNil)
+		c.Assert(compressedWriter.WriteMessage(m), IsNil)
+	}
+	c.Assert(compressedWriter.Close(), IsNil)
+	c.Assert(compressed.Len() < raw.Len()/5, Equals, true,
+		Commentf("raw %d bytes, compressed %d bytes", raw.Len(), compressed.Len()))
+}
+
+func (s *StreamTest) TestFlushSendsBufferedMessages(c *C) {
+	stream := bytes.Buffer{}
+	sw := StreamWriter{Writer: &stream, Compression: GzipCompression}
+	m := Message{Timestamp: T("0000-12-31T00:00:00Z")}
+	c.Assert(sw.WriteMessage(m), IsNil)
+	c.Assert(sw.Flush(), IsNil)
+
+	// The message can be read before the stream is closed
+	r, err := NewStreamReader(bytes.NewReader(stream.Bytes()), GzipCompression)
+	c.Assert(err, IsNil)
+	_, err = (&Message{}).ReadFrom(r)
+	c.Assert(err, IsNil)
+	c.Assert(sw.Close(), IsNil)
+}
+
+// failingWriter fails every write once fail is set
+type failingWriter struct {
+	bytes.Buffer
+	fail int32 // accessed atomically
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(&w.fail) != 0 {
+		return 0, errors.New("write failed")
+	}
+	return w.Buffer.Write(p)
+}
+
+func (s *StreamTest) TestCloseReportsFlushError(c *C) {
+	w := &failingWriter{}
+	sw := StreamWriter{Writer: w, Compression: GzipCompression, FlushInterval: time.Millisecond}
+	c.Assert(sw.WriteMessage(Message{}), IsNil)
+	atomic.StoreInt32(&w.fail, 1)
+	time.Sleep(50 * time.Millisecond)
+	c.Assert(sw.Close(), ErrorMatches, "write failed")
+}
+
+func (s *StreamTest) TestNoCompression(c *C) {
+	stream := bytes.Buffer{}
+	sw := NewStreamWriter(&stream)
+	c.Assert(sw.WriteMessage(Message{Timestamp: T("0000-12-31T00:00:00Z")}), IsNil)
+	c.Assert(sw.Flush(), IsNil)
+	c.Assert(stream.String(), Equals, `35 <0>1 0000-12-31T00:00:00Z - - - - -`)
+
+	r, err := NewStreamReader(&stream, NoCompression)
+	c.Assert(err, IsNil)
+	_, err = (&Message{}).ReadFrom(r)
+	c.Assert(err, IsNil)
+}
+
+func (s *StreamTest) TestUnsupportedCompression(c *C) {
+	sw := StreamWriter{Writer: &bytes.Buffer{}, Compression: Compression(99)}
+	err := sw.WriteMessage(Message{})
+	c.Assert(err, ErrorMatches, "unsupported compression Compression\\(99\\)")
+
+	_, err = NewStreamReader(&bytes.Buffer{}, Compression(99))
+	c.Assert(err, ErrorMatches, "unsupported compression Compression\\(99\\)")
+}
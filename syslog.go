@@ -0,0 +1,94 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package rfc5424
+
+import (
+	"log/syslog"
+	"strings"
+)
+
+// SyslogPriority returns the log/syslog Priority for severity and facility.
+// DefaultSeverity and DefaultFacility are taken to be Info and Local0, as in
+// Encode.
+func SyslogPriority(severity Severity, facility Facility) syslog.Priority {
+	if severity == DefaultSeverity {
+		severity = defaultSeverity
+	}
+	if facility == DefaultFacility {
+		facility = defaultFacility
+	}
+	return syslog.Priority(int(facility-Kernel)<<3 | int(severity-Emergency))
+}
+
+// FromSyslogPriority returns the severity and facility of a log/syslog
+// Priority
+func FromSyslogPriority(p syslog.Priority) (Severity, Facility) {
+	return Emergency + Severity(int(p)&severityMask), Kernel + Facility((int(p)&facilityMask)>>3)
+}
+
+// SyslogWriter has the methods of log/syslog's Writer, so that code written
+// for the standard library can send RFC-5424 messages to a MessageWriter
+// instead. Like syslog.Writer it can be the output of a log.Logger:
+//
+//	w := rfc5424.NewSyslogWriter(sw, syslog.LOG_WARNING|syslog.LOG_DAEMON, "api")
+//	log.SetOutput(w)
+type SyslogWriter struct {
+	logger   *Logger
+	severity Severity
+}
+
+// NewSyslogWriter returns a SyslogWriter writing to w, like syslog.New. The
+// severity of priority is used by Write, and its facility by every method.
+// tag is the APP-NAME; if empty DefaultAppNameResolver chooses it.
+func NewSyslogWriter(w MessageWriter, priority syslog.Priority, tag string) *SyslogWriter {
+	severity, facility := FromSyslogPriority(priority)
+	return &SyslogWriter{
+		logger:   &Logger{Writer: w, Facility: facility, AppName: tag},
+		severity: severity,
+	}
+}
+
+// log writes a message, removing the trailing newline added by log.Logger
+func (sw *SyslogWriter) log(severity Severity, m string) error {
+	return sw.logger.Log(severity, strings.TrimSuffix(m, "\n"))
+}
+
+// Write sends a message with the severity and facility passed to
+// NewSyslogWriter. It implements io.Writer.
+func (sw *SyslogWriter) Write(b []byte) (int, error) {
+	if err := sw.log(sw.severity, string(b)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Emerg sends a message with severity Emergency, ignoring the severity
+// passed to NewSyslogWriter
+func (sw *SyslogWriter) Emerg(m string) error { return sw.log(Emergency, m) }
+
+// Alert sends a message with severity Alert
+func (sw *SyslogWriter) Alert(m string) error { return sw.log(Alert, m) }
+
+// Crit sends a message with severity Critical
+func (sw *SyslogWriter) Crit(m string) error { return sw.log(Critical, m) }
+
+// Err sends a message with severity Error
+func (sw *SyslogWriter) Err(m string) error { return sw.log(Error, m) }
+
+// Warning sends a message with severity Warning
+func (sw *SyslogWriter) Warning(m string) error { return sw.log(Warning, m) }
+
+// Notice sends a message with severity Notice
+func (sw *SyslogWriter) Notice(m string) error { return sw.log(Notice, m) }
+
+// Info sends a message with severity Info
+func (sw *SyslogWriter) Info(m string) error { return sw.log(Info, m) }
+
+// Debug sends a message with severity Debug
+func (sw *SyslogWriter) Debug(m string) error { return sw.log(Debug, m) }
+
+// Close closes the underlying MessageWriter
+func (sw *SyslogWriter) Close() error {
+	return sw.logger.Close()
+}
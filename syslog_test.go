@@ -0,0 +1,54 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package rfc5424
+
+import (
+	"log"
+	"log/syslog"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&SyslogTest{})
+
+type SyslogTest struct {
+}
+
+func (s *SyslogTest) TestPriority(c *C) {
+	c.Assert(SyslogPriority(Error, Auth), Equals, syslog.LOG_ERR|syslog.LOG_AUTH)
+	c.Assert(SyslogPriority(Debug, Local7), Equals, syslog.LOG_DEBUG|syslog.LOG_LOCAL7)
+	c.Assert(SyslogPriority(DefaultSeverity, DefaultFacility), Equals, syslog.LOG_INFO|syslog.LOG_LOCAL0)
+
+	severity, facility := FromSyslogPriority(syslog.LOG_WARNING | syslog.LOG_DAEMON)
+	c.Assert(severity, Equals, Severity(Warning))
+	c.Assert(facility, Equals, Facility(Daemon))
+	severity, facility = FromSyslogPriority(syslog.LOG_EMERG | syslog.LOG_KERN)
+	c.Assert(severity, Equals, Severity(Emergency))
+	c.Assert(facility, Equals, Facility(Kernel))
+}
+
+func (s *SyslogTest) TestSyslogWriter(c *C) {
+	cw := &chanWriter{messages: make(chan Message, 4)}
+	w := NewSyslogWriter(cw, syslog.LOG_WARNING|syslog.LOG_DAEMON, "api")
+
+	log.New(w, "", 0).Print("from log")
+	c.Assert(w.Crit("from Crit"), IsNil)
+
+	m := marshalString(c, <-cw.messages)
+	c.Assert(strings.HasPrefix(m, "<28>1 "), Equals, true, Commentf("%s", m))
+	c.Assert(strings.HasSuffix(m, " api "+pidOf(c)+" - - \ufefffrom log"), Equals, true, Commentf("%s", m))
+	m = marshalString(c, <-cw.messages)
+	c.Assert(strings.HasPrefix(m, "<26>1 "), Equals, true, Commentf("%s", m))
+	c.Assert(strings.HasSuffix(m, "\ufefffrom Crit"), Equals, true, Commentf("%s", m))
+
+	c.Assert(w.Close(), IsNil)
+}
+
+// pidOf returns the PROCID given to messages by default
+func pidOf(c *C) string {
+	m, err := NewMessage().Build()
+	c.Assert(err, IsNil)
+	return m.ProcessID
+}
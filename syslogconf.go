@@ -0,0 +1,218 @@
+package rfc5424
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SyslogConfEntry is a line of a syslog.conf file: messages matched by
+// Rules are sent to Action, e.g. "/var/log/messages" or "@loghost".
+type SyslogConfEntry struct {
+	// Selector is the selector as written, e.g. "mail.*;mail.!=info"
+	Selector string
+
+	// Rules accepts the messages matched by the selector and drops the
+	// others
+	Rules RuleSet
+
+	Action string
+
+	// Line is the line of the file the entry starts on
+	Line int
+}
+
+// SyslogConf is a list of syslog.conf entries. Unlike a RuleSet, every
+// entry whose selector matches a message receives it.
+type SyslogConf []SyslogConfEntry
+
+// ParseSyslogConf parses the selectors and actions of a syslog.conf file,
+// so that an existing configuration can route the messages of a Server.
+// Blank lines, comments starting with '#' and lines continued with a
+// trailing '\' are handled.
+//
+// Selectors are ';' separated lists of "facility.severity", where the
+// facility may be a ',' separated list of names or "*", and the severity is
+// a name for it and anything more severe, "=name" for it alone, "*" or
+// "none". A '!' before the severity excludes instead of including, as in
+// sysklogd and rsyslog. Other syntax, such as rsyslog's property filters and
+// '$' directives, is rejected.
+func ParseSyslogConf(r io.Reader) (SyslogConf, error) {
+	var conf SyslogConf
+	scanner := bufio.NewScanner(r)
+	var line string
+	start, n := 0, 0
+	for scanner.Scan() {
+		n++
+		if line == "" {
+			start = n
+		}
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(text, `\`) {
+			line += strings.TrimSuffix(text, `\`) + " "
+			continue
+		}
+		line += text
+		if line == "" || strings.HasPrefix(line, "#") {
+			line = ""
+			continue
+		}
+		entry, err := parseSyslogConfLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("rfc5424: syslog.conf line %d: %s", start, err)
+		}
+		entry.Line = start
+		conf = append(conf, entry)
+		line = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if line != "" {
+		return nil, fmt.Errorf("rfc5424: syslog.conf line %d: continued past the end of the file", start)
+	}
+	return conf, nil
+}
+
+// parseSyslogConfLine parses a selector and its action
+func parseSyslogConfLine(line string) (SyslogConfEntry, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return SyslogConfEntry{}, fmt.Errorf("%q has no action", line)
+	}
+	selector, action := fields[0], strings.Join(fields[1:], " ")
+	masks, err := parseSelector(selector)
+	if err != nil {
+		return SyslogConfEntry{}, err
+	}
+	return SyslogConfEntry{Selector: selector, Rules: masks.rules(), Action: action}, nil
+}
+
+// severityMasks holds, for each facility, the severities selected, bit
+// s-Emergency standing for severity s
+type severityMasks [Local7 - Kernel + 1]uint8
+
+// parseSelector parses a selector into the severities it selects for each
+// facility. As in sysklogd, selectors are applied left to right: including
+// severities adds them to those of the facilities, and excluding them
+// removes them.
+func parseSelector(selector string) (masks severityMasks, err error) {
+	for _, part := range strings.Split(selector, ";") {
+		i := strings.LastIndexByte(part, '.')
+		if i < 0 {
+			return masks, fmt.Errorf("selector %q has no severity", part)
+		}
+		var facilities []Facility
+		for _, name := range strings.Split(part[:i], ",") {
+			if name == "*" {
+				for f := Facility(Kernel); f <= Local7; f++ {
+					facilities = append(facilities, f)
+				}
+				continue
+			}
+			f, err := ParseFacility(name)
+			if err != nil {
+				return masks, fmt.Errorf("unknown facility %q", name)
+			}
+			facilities = append(facilities, f)
+		}
+
+		level := part[i+1:]
+		exclude := strings.HasPrefix(level, "!")
+		level = strings.TrimPrefix(level, "!")
+		exact := strings.HasPrefix(level, "=")
+		level = strings.TrimPrefix(level, "=")
+		var mask uint8
+		switch level {
+		case "*":
+			mask = 0xff
+		case "none":
+			mask, exclude = 0xff, true
+		default:
+			s, err := ParseSeverity(level)
+			if err != nil {
+				return masks, fmt.Errorf("unknown severity %q", level)
+			}
+			if exact {
+				mask = 1 << uint(s-Emergency)
+			} else {
+				mask = 1<<uint(s-Emergency+1) - 1
+			}
+		}
+		for _, f := range facilities {
+			if exclude {
+				masks[f-Kernel] &^= mask
+			} else {
+				masks[f-Kernel] |= mask
+			}
+		}
+	}
+	return masks, nil
+}
+
+// rules returns a RuleSet accepting the selected messages and dropping the
+// others. Facilities with the same severities share rules, and each run of
+// consecutive severities is one rule.
+func (masks severityMasks) rules() RuleSet {
+	rs := RuleSet{Default: ActionDrop}
+	done := map[uint8]bool{0: true}
+	for _, mask := range masks {
+		if done[mask] {
+			continue
+		}
+		done[mask] = true
+		var facilities []Facility
+		for i, m := range masks {
+			if m == mask {
+				facilities = append(facilities, Kernel+Facility(i))
+			}
+		}
+		if len(facilities) == len(masks) {
+			facilities = nil
+		}
+		for s := Severity(Emergency); s <= Debug; s++ {
+			if mask&(1<<uint(s-Emergency)) == 0 {
+				continue
+			}
+			rule := Rule{Facilities: facilities, Action: ActionAccept}
+			if s != Emergency {
+				rule.MaxSeverity = s
+			}
+			for s < Debug && mask&(1<<uint(s+1-Emergency)) != 0 {
+				s++
+			}
+			if s != Debug {
+				rule.MinSeverity = s
+			}
+			rs.Rules = append(rs.Rules, rule)
+		}
+	}
+	return rs
+}
+
+// Handler returns a Handler passing each message to the handler of every
+// entry whose selector matches it. output is called once for each distinct
+// action to get its handler, e.g. a WriterHandler for a file or a
+// StreamWriter to "@host".
+func (conf SyslogConf) Handler(output func(action string) (Handler, error)) (Handler, error) {
+	outputs := map[string]Handler{}
+	handlers := make([]Handler, 0, len(conf))
+	for _, entry := range conf {
+		h, ok := outputs[entry.Action]
+		if !ok {
+			var err error
+			if h, err = output(entry.Action); err != nil {
+				return nil, fmt.Errorf("rfc5424: syslog.conf line %d: %s", entry.Line, err)
+			}
+			outputs[entry.Action] = h
+		}
+		handlers = append(handlers, entry.Rules.Handler(h, nil))
+	}
+	return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+		for _, h := range handlers {
+			h.Handle(ctx, m, src)
+		}
+	}), nil
+}
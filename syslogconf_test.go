@@ -0,0 +1,92 @@
+package rfc5424
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&SyslogConfTest{})
+
+type SyslogConfTest struct {
+}
+
+const testSyslogConf = `# comment
+*.info;mail.none;authpriv.none    /var/log/messages
+authpriv.*                        /var/log/secure
+
+mail.*;mail.!=info                -/var/log/maillog
+local3.err                        @loghost
+*.emerg \
+                                  :omusrmsg:*
+*.=debug;local3.!*                /var/log/debug
+`
+
+func (s *SyslogConfTest) TestParse(c *C) {
+	conf, err := ParseSyslogConf(strings.NewReader(testSyslogConf))
+	c.Assert(err, IsNil)
+	c.Assert(conf, HasLen, 6)
+	c.Assert(conf[4].Line, Equals, 7)
+	c.Assert(conf[4].Action, Equals, ":omusrmsg:*")
+	c.Assert(conf[3].Rules, DeepEquals, RuleSet{
+		Rules:   []Rule{{Facilities: []Facility{Local3}, MinSeverity: Error, Action: ActionAccept}},
+		Default: ActionDrop,
+	})
+
+	tests := []struct {
+		facility Facility
+		severity Severity
+		actions  []string
+	}{
+		{User, Info, []string{"/var/log/messages"}},
+		{User, Debug, []string{"/var/log/debug"}},
+		{Mail, Info, nil},
+		{Mail, Warning, []string{"-/var/log/maillog"}},
+		{Mail, Debug, []string{"-/var/log/maillog", "/var/log/debug"}},
+		{AuthPriv, Notice, []string{"/var/log/secure"}},
+		{Local3, Error, []string{"/var/log/messages", "@loghost"}},
+		{Local3, Debug, nil},
+		{Kernel, Emergency, []string{"/var/log/messages", ":omusrmsg:*"}},
+	}
+	for _, test := range tests {
+		var actions []string
+		h, err := conf.Handler(func(action string) (Handler, error) {
+			return HandlerFunc(func(ctx context.Context, m Message, src Source) {
+				actions = append(actions, action)
+			}), nil
+		})
+		c.Assert(err, IsNil)
+		m := Message{Priority: int(test.severity-Emergency) | int(test.facility-Kernel)<<3}
+		h.Handle(context.Background(), m, Source{})
+		c.Assert(actions, DeepEquals, test.actions, Commentf("%s.%s", test.facility, test.severity))
+	}
+}
+
+func (s *SyslogConfTest) TestErrors(c *C) {
+	tests := []struct {
+		conf string
+		err  string
+	}{
+		{"*.info", `rfc5424: syslog.conf line 1: "\*.info" has no action`},
+		{"\n$ModLoad imuxsock", `rfc5424: syslog.conf line 2: selector "\$ModLoad" has no severity`},
+		{"mark.info /x", `rfc5424: syslog.conf line 1: unknown facility "mark"`},
+		{"*.loud /x", `rfc5424: syslog.conf line 1: unknown severity "loud"`},
+		{"*.info \\", `rfc5424: syslog.conf line 1: continued past the end of the file`},
+	}
+	for _, test := range tests {
+		_, err := ParseSyslogConf(strings.NewReader(test.conf))
+		c.Assert(err, ErrorMatches, test.err)
+	}
+
+	conf, err := ParseSyslogConf(strings.NewReader("*.* /x\n*.* /y"))
+	c.Assert(err, IsNil)
+	_, err = conf.Handler(func(action string) (Handler, error) {
+		if action == "/y" {
+			return nil, errors.New("cannot open /y")
+		}
+		return HandlerFunc(func(context.Context, Message, Source) {}), nil
+	})
+	c.Assert(err, ErrorMatches, "rfc5424: syslog.conf line 2: cannot open /y")
+}
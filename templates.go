@@ -0,0 +1,106 @@
+package rfc5424
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"text/template"
+)
+
+// MessageTemplates renders the MSG of structured events from their fields,
+// so that viewers which only show the MSG still get a readable text. Each
+// template is chosen by the MSGID of the message, e.g.
+//
+//	t.Add("LOGINFAIL", "user {{.user}} failed login from {{.ip}}")
+//
+// Templates are executed with the SD parameters of the message by name,
+// the first element having a parameter providing its value, and with the
+// header fields under Header, as in {{.Header.Hostname}}: Hostname, AppName,
+// ProcessID, MessageID, Timestamp, Severity and Facility. A parameter named
+// Header is hidden by them. A template that uses a parameter the message
+// does not have fails to render. Set it as the Templates of a Logger, or use
+// Writer. The zero value is ready to use.
+type MessageTemplates struct {
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+}
+
+// Add parses text as the template of messages with the given MSGID,
+// replacing any previous one
+func (mt *MessageTemplates) Add(messageID, text string) error {
+	t, err := template.New(messageID).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("rfc5424: template for %s: %s", messageID, err)
+	}
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	if mt.templates == nil {
+		mt.templates = map[string]*template.Template{}
+	}
+	mt.templates[messageID] = t
+	return nil
+}
+
+// templateData returns the fields of m passed to templates
+func templateData(m Message) map[string]interface{} {
+	data := map[string]interface{}{}
+	for i := len(m.StructuredData) - 1; i >= 0; i-- {
+		params := m.StructuredData[i].Parameters
+		for j := len(params) - 1; j >= 0; j-- {
+			data[params[j].Name] = params[j].Value
+		}
+	}
+	data["Header"] = map[string]interface{}{
+		"Hostname":  m.Hostname,
+		"AppName":   m.AppName,
+		"ProcessID": m.ProcessID,
+		"MessageID": m.MessageID,
+		"Timestamp": m.Timestamp,
+		"Severity":  m.Severity(),
+		"Facility":  m.Facility(),
+	}
+	return data
+}
+
+// Render sets the MSG of m to the text of its template, if m has no MSG and
+// there is a template for its MSGID. The MSG is marked as UTF-8 text.
+func (mt *MessageTemplates) Render(m *Message) error {
+	if len(m.Message) > 0 {
+		return nil
+	}
+	mt.mu.RLock()
+	t, ok := mt.templates[m.MessageID]
+	mt.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	b := bytes.Buffer{}
+	if err := t.Execute(&b, templateData(*m)); err != nil {
+		return fmt.Errorf("rfc5424: template for %s: %s", m.MessageID, err)
+	}
+	m.SetTextMessage(b.String())
+	return nil
+}
+
+// Writer returns a MessageWriter that renders the MSG of each message
+// before writing it to w
+func (mt *MessageTemplates) Writer(w MessageWriter) MessageWriter {
+	return templateWriter{templates: mt, writer: w}
+}
+
+// templateWriter is the MessageWriter returned by MessageTemplates.Writer
+type templateWriter struct {
+	templates *MessageTemplates
+	writer    MessageWriter
+}
+
+func (tw templateWriter) WriteMessage(m Message) error {
+	if err := tw.templates.Render(&m); err != nil {
+		return err
+	}
+	return tw.writer.WriteMessage(m)
+}
+
+func (tw templateWriter) Close() error {
+	return tw.writer.Close()
+}
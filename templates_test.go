@@ -0,0 +1,69 @@
+package rfc5424
+
+import (
+	"bytes"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&TemplatesTest{})
+
+type TemplatesTest struct {
+}
+
+func (s *TemplatesTest) TestRender(c *C) {
+	mt := &MessageTemplates{}
+	c.Assert(mt.Add("LOGINFAIL", "user {{.user}} failed login from {{.ip}} on {{.Header.Hostname}} ({{.Header.Severity}})"), IsNil)
+	c.Assert(mt.Add("BAD", "{{.user"), ErrorMatches, "rfc5424: template for BAD: .*")
+
+	m := Message{Priority: 84, Hostname: "host", MessageID: "LOGINFAIL"}
+	m.AddDatum("auth@32473", "user", "root")
+	m.AddDatum(OriginSDID, "ip", "192.0.2.1")
+	m.AddDatum("other@32473", "user", "ignored")
+	c.Assert(mt.Render(&m), IsNil)
+	c.Assert(m.TextMessage(), Equals, "user root failed login from 192.0.2.1 on host (warning)")
+	c.Assert(m.IsUTF8(), Equals, true)
+
+	// Messages with a MSG, or without a template, are left alone
+	m.SetTextMessage("custom")
+	c.Assert(mt.Render(&m), IsNil)
+	c.Assert(m.TextMessage(), Equals, "custom")
+	other := Message{MessageID: "OTHER"}
+	c.Assert(mt.Render(&other), IsNil)
+	c.Assert(other.Message, IsNil)
+}
+
+func (s *TemplatesTest) TestParamsAndHeader(c *C) {
+	mt := &MessageTemplates{}
+	c.Assert(mt.Add("EVENT", "{{.Hostname}} on {{.Header.Hostname}}"), IsNil)
+
+	// SD parameters are not hidden by header fields of the same name
+	m := Message{Hostname: "host", MessageID: "EVENT"}
+	m.AddDatum("event@32473", "Hostname", "target")
+	c.Assert(mt.Render(&m), IsNil)
+	c.Assert(m.TextMessage(), Equals, "target on host")
+
+	// A missing parameter is an error, not "<no value>"
+	m = Message{Hostname: "host", MessageID: "EVENT"}
+	c.Assert(mt.Render(&m), ErrorMatches, `rfc5424: template for EVENT: .*map has no entry for key "Hostname"`)
+	c.Assert(m.Message, IsNil)
+}
+
+func (s *TemplatesTest) TestLoggerAndWriter(c *C) {
+	mt := &MessageTemplates{}
+	c.Assert(mt.Add("START", "{{.Header.AppName}} started with {{.workers}} workers"), IsNil)
+	m := Message{Priority: 14, AppName: "api", MessageID: "START"}
+	m.AddDatum("cfg@32473", "workers", "4")
+
+	buf := &bytes.Buffer{}
+	l := &Logger{Writer: &StreamWriter{Writer: buf, Framing: NonTransparentFraming}, Templates: mt}
+	c.Assert(l.WriteMessage(m), IsNil)
+	c.Assert(m.Message, IsNil)
+	c.Assert(buf.String(), Equals, "<14>1 - - api - START [cfg@32473 workers=\"4\"] \ufeffapi started with 4 workers\n")
+
+	buf.Reset()
+	w := mt.Writer(&StreamWriter{Writer: buf, Framing: NonTransparentFraming})
+	c.Assert(w.WriteMessage(m), IsNil)
+	c.Assert(buf.String(), Equals, "<14>1 - - api - START [cfg@32473 workers=\"4\"] \ufeffapi started with 4 workers\n")
+	c.Assert(w.Close(), IsNil)
+}
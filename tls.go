@@ -0,0 +1,138 @@
+package rfc5424
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// tlsHandshakeTimeout bounds how long a client may take to complete the TLS
+// handshake
+const tlsHandshakeTimeout = 10 * time.Second
+
+// ClientCertPolicy controls how a Server authenticates TLS clients, as
+// described in RFC-5425 section 5.
+type ClientCertPolicy struct {
+	// RequireClientCert rejects clients that do not present a certificate
+	// signed by one of the ClientCAs in the TLS config. When false, a
+	// certificate is verified if one is presented.
+	RequireClientCert bool
+
+	// AllowedNames, if not empty, lists the subject common names and DNS
+	// subject alternative names that are allowed to connect. Clients without
+	// a certificate matching one of them are rejected.
+	AllowedNames []string
+
+	// LogFingerprints logs the SHA-256 fingerprint of each client
+	// certificate when a connection is accepted.
+	LogFingerprints bool
+}
+
+// errClientNotAllowed is returned when a client certificate does not match
+// ClientCertPolicy.AllowedNames
+var errClientNotAllowed = errors.New("client certificate does not match any allowed name")
+
+// CertificateFingerprint returns the SHA-256 fingerprint of cert in the
+// colon-separated hexadecimal form used by RFC-5425, e.g. "SHA-256:E1:A2:...".
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+	return "SHA-256:" + strings.Join(hex, ":")
+}
+
+// allows reports whether the verified client certificate cert is permitted
+// by the AllowedNames of the policy.
+func (p ClientCertPolicy) allows(cert *x509.Certificate) bool {
+	if len(p.AllowedNames) == 0 {
+		return true
+	}
+	if cert == nil {
+		return false
+	}
+	for _, name := range p.AllowedNames {
+		if cert.Subject.CommonName == name {
+			return true
+		}
+		for _, dnsName := range cert.DNSNames {
+			if dnsName == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tlsConfig returns a copy of config with ClientAuth set according to the
+// policy.
+func (p ClientCertPolicy) tlsConfig(config *tls.Config) *tls.Config {
+	config = config.Clone()
+	if p.RequireClientCert || len(p.AllowedNames) > 0 {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	} else if config.ClientCAs != nil {
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config
+}
+
+// handshake completes the TLS handshake on conn and applies the client
+// certificate policy.
+func (srv *Server) handshake(conn *tls.Conn) (*tls.ConnectionState, error) {
+	conn.SetDeadline(time.Now().Add(tlsHandshakeTimeout))
+	if err := conn.Handshake(); err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	state := conn.ConnectionState()
+	var cert *x509.Certificate
+	if len(state.PeerCertificates) > 0 {
+		cert = state.PeerCertificates[0]
+	}
+	if !srv.ClientCertPolicy.allows(cert) {
+		return nil, errClientNotAllowed
+	}
+	if srv.ClientCertPolicy.LogFingerprints && cert != nil {
+		srv.logf("rfc5424: accepted TLS client %s (CN=%s, %s)",
+			conn.RemoteAddr(), cert.Subject.CommonName, CertificateFingerprint(cert))
+	}
+	return &state, nil
+}
+
+// ListenAndServeTLS listens on the TCP address addr and then calls ServeTLS.
+func (srv *Server) ListenAndServeTLS(addr, certFile, keyFile string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return srv.ServeTLS(l, certFile, keyFile)
+}
+
+// ServeTLS accepts connections on l, performs the TLS handshake described in
+// RFC-5425 and then reads messages as ServeTCP does. The server certificate
+// is loaded from certFile and keyFile unless TLSConfig already has one.
+// Clients are authenticated according to ClientCertPolicy. ServeTLS always
+// returns a non-nil error and closes l.
+func (srv *Server) ServeTLS(l net.Listener, certFile, keyFile string) error {
+	config := &tls.Config{}
+	if srv.TLSConfig != nil {
+		config = srv.TLSConfig
+	}
+	config = srv.ClientCertPolicy.tlsConfig(config)
+	if len(config.Certificates) == 0 && config.GetCertificate == nil {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			l.Close()
+			return err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return srv.serveStream(tls.NewListener(l, config), "tls", srv.readFrames)
+}
@@ -0,0 +1,119 @@
+package rfc5424
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&TLSTest{})
+
+type TLSTest struct {
+}
+
+// issue creates a certificate for commonName signed by parent (or
+// self-signed, if parent is nil).
+func issue(c *C, commonName string, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth,
+			x509.ExtKeyUsageClientAuth},
+		KeyUsage: x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	parentCert, parentKey := template, interface{}(key)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		parentCert = parent.Leaf
+		parentKey = parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	c.Assert(err, IsNil)
+	leaf, err := x509.ParseCertificate(der)
+	c.Assert(err, IsNil)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func (s *TLSTest) TestClientCertPolicy(c *C) {
+	ca := issue(c, "ca", nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	logBuf := &safeBuffer{}
+	h := make(chanHandler, 10)
+	srv := Server{
+		Handler: h,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{issue(c, "server", &ca)},
+			ClientCAs:    pool,
+		},
+		ClientCertPolicy: ClientCertPolicy{
+			AllowedNames:    []string{"good-client"},
+			LogFingerprints: true,
+		},
+		ErrorLog: log.New(logBuf, "", 0),
+	}
+	done := make(chan error)
+	go func() { done <- srv.ServeTLS(l, "", "") }()
+
+	dial := func(client tls.Certificate) (*tls.Conn, error) {
+		conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{
+			RootCAs:      pool,
+			Certificates: []tls.Certificate{client},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return conn, conn.Handshake()
+	}
+
+	good := issue(c, "good-client", &ca)
+	conn, err := dial(good)
+	c.Assert(err, IsNil)
+	_, err = conn.Write(octetCounted("<34>1 0000-12-31T00:00:00Z - - - - - hi"))
+	c.Assert(err, IsNil)
+	rm := receive(c, h)
+	c.Assert(string(rm.Message.Message), Equals, "hi")
+	c.Assert(rm.Source.Network, Equals, "tls")
+	c.Assert(rm.Source.TLS.PeerCertificates[0].Subject.CommonName, Equals, "good-client")
+	c.Assert(strings.Contains(logBuf.String(), CertificateFingerprint(good.Leaf)), Equals, true)
+	conn.Close()
+
+	// A valid certificate with the wrong name is disconnected
+	conn, err = dial(issue(c, "bad-client", &ca))
+	if err == nil {
+		conn.Write(octetCounted("<34>1 0000-12-31T00:00:00Z - - - - - hi"))
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, err = ioutil.ReadAll(conn)
+		c.Assert(err, IsNil) // closed by the server
+		conn.Close()
+	}
+	select {
+	case <-h:
+		c.Fatal("message from disallowed client was handled")
+	default:
+	}
+
+	c.Assert(srv.Close(), IsNil)
+	c.Assert(<-done, Equals, ErrServerClosed)
+}
@@ -0,0 +1,75 @@
+package rfc5424
+
+import "sync/atomic"
+
+// Transport sends messages to a destination. StreamWriter implements it for
+// TCP and TLS connections and any other stream, as do the udp outputs of a
+// Config. Other destinations, such as message queues, can be reached by
+// implementing it and using TransportWriter as the Writer of a Logger or of
+// any of the package's MessageWriters.
+type Transport interface {
+	// Send sends m, or buffers it to be sent
+	Send(m Message) error
+
+	// Flush sends the messages buffered, if any
+	Flush() error
+
+	// Close flushes the messages buffered and releases the destination
+	Close() error
+
+	// Stats returns the counts of the messages sent so far
+	Stats() TransportStats
+}
+
+// TransportStats counts the messages sent by a Transport
+type TransportStats struct {
+	// Sent is the number of messages sent or buffered, and Bytes the
+	// number of octets they were marshaled and framed to
+	Sent  uint64
+	Bytes uint64
+
+	// Failed is the number of messages that could not be sent
+	Failed uint64
+}
+
+// transportCounters keeps the TransportStats of a Transport. It is accessed
+// atomically, and should come first in the Transport to be 64-bit aligned.
+type transportCounters struct {
+	sent, bytes, failed uint64
+}
+
+// record counts a message of n octets, or a failure if err is not nil
+func (c *transportCounters) record(n int64, err error) {
+	if err != nil {
+		atomic.AddUint64(&c.failed, 1)
+		return
+	}
+	atomic.AddUint64(&c.sent, 1)
+	atomic.AddUint64(&c.bytes, uint64(n))
+}
+
+func (c *transportCounters) stats() TransportStats {
+	return TransportStats{
+		Sent:   atomic.LoadUint64(&c.sent),
+		Bytes:  atomic.LoadUint64(&c.bytes),
+		Failed: atomic.LoadUint64(&c.failed),
+	}
+}
+
+// TransportWriter returns a MessageWriter sending the messages written to
+// it with t
+func TransportWriter(t Transport) MessageWriter {
+	return transportWriter{t}
+}
+
+type transportWriter struct {
+	t Transport
+}
+
+func (tw transportWriter) WriteMessage(m Message) error {
+	return tw.t.Send(m)
+}
+
+func (tw transportWriter) Close() error {
+	return tw.t.Close()
+}
@@ -0,0 +1,59 @@
+package rfc5424
+
+import (
+	"bytes"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&TransportTest{})
+
+type TransportTest struct {
+}
+
+func (s *TransportTest) TestStreamWriterStats(c *C) {
+	buf := &bytes.Buffer{}
+	var t Transport = &StreamWriter{Writer: buf}
+	m := Message{Priority: 1, Timestamp: T("0000-12-31T00:00:00Z")}
+	c.Assert(t.Send(m), IsNil)
+	c.Assert(t.Send(m), IsNil)
+	c.Assert(t.Send(Message{Priority: 200}), NotNil)
+	c.Assert(t.Flush(), IsNil)
+	c.Assert(t.Stats(), Equals, TransportStats{Sent: 2, Bytes: 76, Failed: 1})
+	c.Assert(buf.Len(), Equals, 76)
+}
+
+// recordingTransport is a Transport keeping the messages sent
+type recordingTransport struct {
+	sent   []Message
+	closed bool
+}
+
+func (t *recordingTransport) Send(m Message) error {
+	t.sent = append(t.sent, m)
+	return nil
+}
+
+func (t *recordingTransport) Flush() error {
+	return nil
+}
+
+func (t *recordingTransport) Close() error {
+	t.closed = true
+	return nil
+}
+
+func (t *recordingTransport) Stats() TransportStats {
+	return TransportStats{Sent: uint64(len(t.sent))}
+}
+
+func (s *TransportTest) TestTransportWriter(c *C) {
+	t := &recordingTransport{}
+	l := NewLogger(TransportWriter(t), WithHostname("host"), WithAppName("app"))
+	c.Assert(l.Log(Warning, "hello"), IsNil)
+	c.Assert(t.sent, HasLen, 1)
+	c.Assert(t.sent[0].AppName, Equals, "app")
+	c.Assert(t.sent[0].TextMessage(), Equals, "hello")
+	c.Assert(l.Close(), IsNil)
+	c.Assert(t.closed, Equals, true)
+}
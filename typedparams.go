@@ -0,0 +1,112 @@
+package rfc5424
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrNoSDParam is returned by the typed getters of StructuredData and
+// Message when the parameter is not present, so that callers can tell a
+// missing parameter, for which they may use a default, from a malformed one
+var ErrNoSDParam = errors.New("rfc5424: no such SD parameter")
+
+// Get returns the value of the first parameter with the given name
+func (sd StructuredData) Get(name string) (string, bool) {
+	for _, param := range sd.Parameters {
+		if param.Name == name {
+			return param.Value, true
+		}
+	}
+	return "", false
+}
+
+// getTyped parses the value of the parameter name with parse
+func (sd StructuredData) getTyped(name, typ string, parse func(string) error) error {
+	value, ok := sd.Get(name)
+	if !ok {
+		return ErrNoSDParam
+	}
+	if err := parse(value); err != nil {
+		return fmt.Errorf("rfc5424: SD parameter %s of %s is not a valid %s: %q", name, sd.ID, typ, value)
+	}
+	return nil
+}
+
+// GetInt returns the value of the parameter name as a decimal integer
+func (sd StructuredData) GetInt(name string) (n int64, err error) {
+	err = sd.getTyped(name, "integer", func(s string) (err error) {
+		n, err = strconv.ParseInt(s, 10, 64)
+		return err
+	})
+	return n, err
+}
+
+// GetBool returns the value of the parameter name as a boolean. The values
+// accepted by strconv.ParseBool are allowed, including the "1" and "0" of
+// the timeQuality element.
+func (sd StructuredData) GetBool(name string) (b bool, err error) {
+	err = sd.getTyped(name, "boolean", func(s string) (err error) {
+		b, err = strconv.ParseBool(s)
+		return err
+	})
+	return b, err
+}
+
+// GetTime returns the value of the parameter name as a time in the given
+// layout, as used by time.Parse. If layout is empty, time.RFC3339Nano is
+// used.
+func (sd StructuredData) GetTime(name, layout string) (t time.Time, err error) {
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	err = sd.getTyped(name, "time", func(s string) (err error) {
+		t, err = time.Parse(layout, s)
+		return err
+	})
+	return t, err
+}
+
+// GetDuration returns the value of the parameter name as a duration in the
+// format of time.ParseDuration, e.g. "1.5s"
+func (sd StructuredData) GetDuration(name string) (d time.Duration, err error) {
+	err = sd.getTyped(name, "duration", func(s string) (err error) {
+		d, err = time.ParseDuration(s)
+		return err
+	})
+	return d, err
+}
+
+// sdOrEmpty returns the SD element of m with the given ID, or an empty one
+// with that ID
+func (m Message) sdOrEmpty(id string) StructuredData {
+	if sd := m.SD(id); sd != nil {
+		return *sd
+	}
+	return StructuredData{ID: id}
+}
+
+// GetInt returns the value of a parameter of the SD element id as a
+// decimal integer (see StructuredData.GetInt)
+func (m Message) GetInt(id, name string) (int64, error) {
+	return m.sdOrEmpty(id).GetInt(name)
+}
+
+// GetBool returns the value of a parameter of the SD element id as a
+// boolean (see StructuredData.GetBool)
+func (m Message) GetBool(id, name string) (bool, error) {
+	return m.sdOrEmpty(id).GetBool(name)
+}
+
+// GetTime returns the value of a parameter of the SD element id as a time
+// in the given layout (see StructuredData.GetTime)
+func (m Message) GetTime(id, name, layout string) (time.Time, error) {
+	return m.sdOrEmpty(id).GetTime(name, layout)
+}
+
+// GetDuration returns the value of a parameter of the SD element id as a
+// duration (see StructuredData.GetDuration)
+func (m Message) GetDuration(id, name string) (time.Duration, error) {
+	return m.sdOrEmpty(id).GetDuration(name)
+}
@@ -0,0 +1,57 @@
+package rfc5424
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&TypedParamsTest{})
+
+type TypedParamsTest struct {
+}
+
+func (s *TypedParamsTest) TestGetters(c *C) {
+	m := Message{}
+	m.AddDatum("req@32473", "status", "404")
+	m.AddDatum("req@32473", "cached", "1")
+	m.AddDatum("req@32473", "start", "2003-10-11T22:14:15.003Z")
+	m.AddDatum("req@32473", "date", "11/10/2003")
+	m.AddDatum("req@32473", "took", "1.5s")
+	m.AddDatum("req@32473", "bad", "x")
+
+	n, err := m.GetInt("req@32473", "status")
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(404))
+	b, err := m.GetBool("req@32473", "cached")
+	c.Assert(err, IsNil)
+	c.Assert(b, Equals, true)
+	t, err := m.GetTime("req@32473", "start", "")
+	c.Assert(err, IsNil)
+	c.Assert(t.Equal(T("2003-10-11T22:14:15.003Z")), Equals, true)
+	t, err = m.GetTime("req@32473", "date", "02/01/2006")
+	c.Assert(err, IsNil)
+	c.Assert(t, Equals, time.Date(2003, 10, 11, 0, 0, 0, 0, time.UTC))
+	d, err := m.GetDuration("req@32473", "took")
+	c.Assert(err, IsNil)
+	c.Assert(d, Equals, 1500*time.Millisecond)
+
+	_, err = m.GetInt("req@32473", "bad")
+	c.Assert(err, ErrorMatches, `rfc5424: SD parameter bad of req@32473 is not a valid integer: "x"`)
+	_, err = m.GetBool("req@32473", "bad")
+	c.Assert(err, ErrorMatches, `rfc5424: SD parameter bad of req@32473 is not a valid boolean: "x"`)
+	_, err = m.GetTime("req@32473", "bad", "")
+	c.Assert(err, ErrorMatches, `rfc5424: SD parameter bad of req@32473 is not a valid time: "x"`)
+	_, err = m.GetDuration("req@32473", "bad")
+	c.Assert(err, ErrorMatches, `rfc5424: SD parameter bad of req@32473 is not a valid duration: "x"`)
+
+	_, err = m.GetInt("req@32473", "missing")
+	c.Assert(err, Equals, ErrNoSDParam)
+	_, err = m.GetDuration("other@32473", "took")
+	c.Assert(err, Equals, ErrNoSDParam)
+
+	sd := TimeQuality{TZKnown: true, SyncAccuracy: 1}.StructuredData()
+	b, err = sd.GetBool("tzKnown")
+	c.Assert(err, IsNil)
+	c.Assert(b, Equals, true)
+}
@@ -0,0 +1,65 @@
+package rfc5424
+
+import (
+	"net"
+	"os"
+)
+
+// removeStaleSocket removes an existing unix socket at path, left behind by a
+// previous process, so that it can be listened on again. Other kinds of files
+// are left alone.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// ListenAndServeUnix listens on the unix stream socket at path and then calls
+// ServeUnix. An existing socket at path is replaced.
+func (srv *Server) ListenAndServeUnix(path string) error {
+	if err := removeStaleSocket(path); err != nil {
+		return err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	return srv.ServeUnix(l)
+}
+
+// ServeUnix accepts connections on the unix stream socket l and reads messages
+// from them as ServeTCP does. ServeUnix always returns a non-nil error and
+// closes l.
+func (srv *Server) ServeUnix(l net.Listener) error {
+	return srv.serveStream(l, "unix", srv.readFrames)
+}
+
+// ListenAndServeUnixgram listens on the unix datagram socket at path and then
+// calls ServeUnixgram. An existing socket at path is replaced.
+//
+// To stand in for the local syslog daemon, listen on /dev/log with
+// AcceptRFC3164 set, since that is the format the C library sends.
+func (srv *Server) ListenAndServeUnixgram(path string) error {
+	if err := removeStaleSocket(path); err != nil {
+		return err
+	}
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		return err
+	}
+	return srv.ServeUnixgram(conn)
+}
+
+// ServeUnixgram reads datagrams from the unix datagram socket conn, each of
+// which holds a single message, and passes them to the Handler. ServeUnixgram
+// always returns a non-nil error and closes conn.
+func (srv *Server) ServeUnixgram(conn net.PacketConn) error {
+	return srv.servePacket(conn, "unixgram")
+}
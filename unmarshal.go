@@ -7,8 +7,64 @@ import (
 	"strconv"
 	"time"
 	"unicode"
+	"unicode/utf8"
 )
 
+// PermissiveTimestamps, if true, makes UnmarshalBinary accept timestamps
+// that RFC-5424 does not allow but some senders emit: more than six digits
+// of fractional seconds, and leap seconds.
+var PermissiveTimestamps = false
+
+// isTimestampSyntax reports whether s matches the TIMESTAMP grammar; the
+// ranges of the fields are checked by time.Parse
+//
+// FULL-DATE       = DATE-FULLYEAR "-" DATE-MONTH "-" DATE-MDAY
+// FULL-TIME       = PARTIAL-TIME TIME-OFFSET
+// PARTIAL-TIME    = TIME-HOUR ":" TIME-MINUTE ":" TIME-SECOND
+// [TIME-SECFRAC]
+// TIME-SECFRAC    = "." 1*6DIGIT
+// TIME-OFFSET     = "Z" / TIME-NUMOFFSET
+// TIME-NUMOFFSET  = ("+" / "-") TIME-HOUR ":" TIME-MINUTE
+func isTimestampSyntax(s string) bool {
+	const layout = "dddd-dd-ddTdd:dd:dd"
+	if !matchesDigitLayout(s, layout) {
+		return false
+	}
+	s = s[len(layout):]
+	if s != "" && s[0] == '.' {
+		n := 1
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		if n == 1 || n > 7 {
+			return false
+		}
+		s = s[n:]
+	}
+	if s == "Z" {
+		return true
+	}
+	return len(s) == 6 && (s[0] == '+' || s[0] == '-') && matchesDigitLayout(s[1:], "dd:dd")
+}
+
+// matchesDigitLayout reports whether s starts with layout, where each 'd' in
+// layout stands for a decimal digit
+func matchesDigitLayout(s, layout string) bool {
+	if len(s) < len(layout) {
+		return false
+	}
+	for i := 0; i < len(layout); i++ {
+		if layout[i] == 'd' {
+			if s[i] < '0' || s[i] > '9' {
+				return false
+			}
+		} else if s[i] != layout[i] {
+			return false
+		}
+	}
+	return true
+}
+
 type errorBadFormat struct {
 	Property string
 }
@@ -23,10 +79,31 @@ func BadFormat(property string) error {
 	return errorBadFormat{Property: property}
 }
 
-// UnmarshalBinary unmarshals a byte slice into a message
+// UnmarshalBinary unmarshals a byte slice into a message. The fields that
+// follow the VERSION are read by the VersionCodec registered for it.
 func (m *Message) UnmarshalBinary(inputBuffer []byte) error {
 	r := bytes.NewBuffer(inputBuffer)
+	if err := m.readPriority(r); err != nil {
+		return err
+	}
+	version, err := readVersion(r)
+	if err != nil {
+		return err
+	}
+	codec, ok := lookupVersion(version)
+	if !ok {
+		return BadFormat("Version")
+	}
+	m.Version = 0
+	if version != 1 {
+		m.Version = version
+	}
+	return codec.Unmarshal(m, r)
+}
 
+// unmarshalFields reads the fields of an RFC-5424 message that follow the
+// VERSION
+func unmarshalFields(m *Message, r *bytes.Buffer) error {
 	// RFC-5424
 	// SYSLOG-MSG      = HEADER SP STRUCTURED-DATA [SP MSG]
 	if err := m.readHeader(r); err != nil {
@@ -48,13 +125,14 @@ func (m *Message) UnmarshalBinary(inputBuffer []byte) error {
 		return BadFormat("MSG") // unreachable
 	}
 
-	// TODO(ross): detect and handle UTF-8 BOM (\xef\xbb\xbf)
-	//
 	// MSG             = MSG-ANY / MSG-UTF8
 	// MSG-ANY         = *OCTET ; not starting with BOM
 	// MSG-UTF8        = BOM UTF-8-STRING
 	// BOM             = %xEF.BB.BF
 	m.Message = r.Bytes()
+	if m.IsUTF8() && !utf8.Valid(m.Message) {
+		return BadFormat("MSG")
+	}
 	return nil
 }
 
@@ -87,13 +165,9 @@ func (m *Message) UnmarshalBinary(inputBuffer []byte) error {
 // TIME-OFFSET     = "Z" / TIME-NUMOFFSET
 // TIME-NUMOFFSET  = ("+" / "-") TIME-HOUR ":" TIME-MINUTE
 //
+//
+// The PRI and VERSION have already been read by UnmarshalBinary.
 func (m *Message) readHeader(r io.RuneScanner) error {
-	if err := m.readPriority(r); err != nil {
-		return err
-	}
-	if err := m.readVersion(r); err != nil {
-		return err
-	}
 	if err := readSpace(r); err != nil {
 		return err // unreachable
 	}
@@ -153,8 +227,9 @@ func (m *Message) readPriority(r io.RuneScanner) error {
 		}
 
 		// We have a complete integer expression
+		// PRIVAL          = 1*3DIGIT ; range 0 .. 191
 		priority, err := strconv.ParseInt(string(rv.Bytes()), 10, 32)
-		if err != nil {
+		if err != nil || rv.Len() > 3 || priority > 191 {
 			return BadFormat("Priority")
 		}
 		m.Priority = int(priority)
@@ -163,16 +238,28 @@ func (m *Message) readPriority(r io.RuneScanner) error {
 	}
 }
 
-// readVersion reads the version string fails if it isn't `1`
-func (m *Message) readVersion(r io.RuneScanner) error {
-	ch, _, err := r.ReadRune()
-	if err != nil {
-		return err
-	}
-	if ch != '1' {
-		return BadFormat("Version")
+// readVersion reads the VERSION
+//
+// VERSION         = NONZERO-DIGIT 0*2DIGIT
+func readVersion(r io.RuneScanner) (int, error) {
+	version := 0
+	for digits := 0; ; digits++ {
+		ch, _, err := r.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if ch < '0' || ch > '9' || digits == 3 {
+			r.UnreadRune()
+			if digits == 0 {
+				return 0, BadFormat("Version")
+			}
+			return version, nil
+		}
+		if digits == 0 && ch == '0' {
+			return 0, BadFormat("Version")
+		}
+		version = version*10 + int(ch-'0')
 	}
-	return nil
 }
 
 // readTimestamp reads a TIMESTAMP as defined in RFC-5424 and assigns
@@ -193,35 +280,68 @@ func (m *Message) readVersion(r io.RuneScanner) error {
 // TIME-SECFRAC    = "." 1*6DIGIT
 // TIME-OFFSET     = "Z" / TIME-NUMOFFSET
 // TIME-NUMOFFSET  = ("+" / "-") TIME-HOUR ":" TIME-MINUTE
+//
+// Unless PermissiveTimestamps is set, timestamps with more than six digits
+// of fractional seconds are rejected. Leap seconds (TIME-SECOND 60) are
+// never valid; in permissive mode they are read as the last instant of the
+// previous second.
 func (m *Message) readTimestamp(r io.RuneScanner) error {
 	timestampString, err := readWord(r)
 	if err != nil {
 		return err
 	}
+	if timestampString == "" {
+		m.Timestamp = time.Time{} // NILVALUE
+		return nil
+	}
+	if !PermissiveTimestamps && !isTimestampSyntax(timestampString) {
+		return BadFormat("Timestamp")
+	}
+	leapSecond := false
+	if PermissiveTimestamps && len(timestampString) > 19 && timestampString[16:19] == ":60" {
+		timestampString = timestampString[:17] + "59" + timestampString[19:]
+		leapSecond = true
+	}
 	m.Timestamp, err = time.Parse(time.RFC3339, timestampString)
 	if err != nil {
 		return err
 	}
+	if leapSecond {
+		m.Timestamp = m.Timestamp.Truncate(time.Second).Add(time.Second - time.Nanosecond)
+	}
 	return nil
 }
 
+// readHeaderField reads a HOSTNAME, APP-NAME, PROCID or MSGID, which are
+// NILVALUE or 1 to maxLength PRINTUSASCII characters
+func readHeaderField(r io.RuneScanner, property string, maxLength int) (string, error) {
+	s, err := readWord(r)
+	if err != nil {
+		return "", err
+	}
+	if len(s) > maxLength || !isPrintableUsASCII(s) {
+		return "", BadFormat(property)
+	}
+	return s, nil
+}
+
 func (m *Message) readHostname(r io.RuneScanner) (err error) {
-	m.Hostname, err = readWord(r)
+	m.Hostname, err = readHeaderField(r, "Hostname", 255)
 	return err
 }
 
 func (m *Message) readAppName(r io.RuneScanner) (err error) {
-	m.AppName, err = readWord(r)
+	m.AppName, err = readHeaderField(r, "AppName", 48)
 	return err
 }
 
 func (m *Message) readProcID(r io.RuneScanner) (err error) {
-	m.ProcessID, err = readWord(r)
+	m.ProcessID, err = readHeaderField(r, "ProcessID", 128)
 	return err
 }
 
 func (m *Message) readMsgID(r io.RuneScanner) (err error) {
-	m.MessageID, err = readWord(r)
+	m.MessageID, err = readHeaderField(r, "MessageID", 32)
 	return err
 }
 
@@ -235,8 +355,12 @@ func (m *Message) readMsgID(r io.RuneScanner) (err error) {
 // PARAM-NAME      = SD-NAME
 // PARAM-VALUE     = UTF-8-STRING ; characters '"', '\' and ']' MUST be escaped.
 // SD-NAME         = 1*32PRINTUSASCII except '=', SP, ']', %d34 (")
+//
+// The elements and parameters of a message that was Reset are reused.
 func (m *Message) readStructuredData(r io.RuneScanner) (err error) {
-	m.StructuredData = []StructuredData{}
+	if len(m.StructuredData) != 0 || cap(m.StructuredData) == 0 {
+		m.StructuredData = []StructuredData{}
+	}
 
 	ch, _, err := r.ReadRune()
 	if err != nil {
@@ -258,7 +382,11 @@ func (m *Message) readStructuredData(r io.RuneScanner) (err error) {
 			return nil
 		} else if ch == '[' {
 			r.UnreadRune()
-			sde, err := readSDElement(r)
+			var params []SDParam
+			if n := len(m.StructuredData); n < cap(m.StructuredData) {
+				params = m.StructuredData[:n+1][n].Parameters[:0]
+			}
+			sde, err := readSDElement(r, params)
 			if err != nil {
 				return err
 			}
@@ -277,7 +405,10 @@ func (m *Message) readStructuredData(r io.RuneScanner) (err error) {
 // PARAM-NAME      = SD-NAME
 // PARAM-VALUE     = UTF-8-STRING ; characters '"', '\' and ']' MUST be escaped.
 // SD-NAME         = 1*32PRINTUSASCII except '=', SP, ']', %d34 (")
-func readSDElement(r io.RuneScanner) (element StructuredData, err error) {
+//
+// The parameters are appended to params.
+func readSDElement(r io.RuneScanner, params []SDParam) (element StructuredData, err error) {
+	element.Parameters = params
 	ch, _, err := r.ReadRune()
 	if err != nil {
 		return element, err // hard to reach without underlying IO error
@@ -0,0 +1,357 @@
+package rfc5424
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultMaxPending is the number of messages a Verifier keeps waiting for
+// a Signature Block by default
+const defaultMaxPending = 10000
+
+// ErrBadSignature is returned by Verifier.Add for a Signature or Certificate
+// Block whose signature is not valid for any trusted certificate.
+var ErrBadSignature = errors.New("rfc5424: invalid RFC-5848 signature")
+
+// VerificationStatus is the outcome of verifying a single message
+type VerificationStatus int
+
+const (
+	// Verified means the message was covered by a valid Signature Block
+	Verified VerificationStatus = iota
+	// Missing means a valid Signature Block covered a message that was not
+	// received, either because it was lost or because it was modified.
+	Missing
+	// Unsigned means the message was received but no Signature Block
+	// covering it was received before the Verifier was closed. Modified
+	// messages end up here.
+	Unsigned
+)
+
+func (s VerificationStatus) String() string {
+	switch s {
+	case Verified:
+		return "verified"
+	case Missing:
+		return "missing"
+	case Unsigned:
+		return "unsigned"
+	}
+	return fmt.Sprintf("VerificationStatus(%d)", int(s))
+}
+
+// VerificationResult reports the status of a single message
+type VerificationResult struct {
+	Status VerificationStatus
+
+	// Message is the message as received. It is nil if Status is Missing.
+	Message []byte
+
+	// RSID, GBC and Number identify the Signature Block and the message's
+	// number within its signature group. They are zero if Status is
+	// Unsigned.
+	RSID   uint64
+	GBC    uint64
+	Number uint64
+}
+
+// signatureHash returns the hash algorithm of a VER parameter
+func signatureHash(ver string) (crypto.Hash, error) {
+	if len(ver) != 4 || ver[:2] != signatureVersion || ver[3:] != signatureSchemePrivate {
+		return 0, fmt.Errorf("rfc5424: unsupported signature version %q", ver)
+	}
+	switch ver[2] {
+	case '1':
+		return crypto.SHA1, nil
+	case '2':
+		return crypto.SHA256, nil
+	}
+	return 0, fmt.Errorf("rfc5424: unsupported signature version %q", ver)
+}
+
+// verifySignature checks sig over the signed bytes b of a block
+func verifySignature(cert *x509.Certificate, h crypto.Hash, b, sig []byte) bool {
+	if pub, ok := cert.PublicKey.(ed25519.PublicKey); ok {
+		return ed25519.Verify(pub, b, sig)
+	}
+	hash := h.New()
+	hash.Write(b)
+	digest := hash.Sum(nil)
+	switch pub := cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(pub, digest, sig)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(pub, h, digest, sig) == nil
+	}
+	return false
+}
+
+// pendingMessage is a message waiting to be covered by a Signature Block
+type pendingMessage struct {
+	raw    []byte
+	hashes map[crypto.Hash]string
+}
+
+func (p *pendingMessage) hash(h crypto.Hash) string {
+	if s, ok := p.hashes[h]; ok {
+		return s
+	}
+	hash := h.New()
+	hash.Write(p.raw)
+	s := base64.StdEncoding.EncodeToString(hash.Sum(nil))
+	p.hashes[h] = s
+	return s
+}
+
+// certificatePayload collects the fragments of a Certificate Block payload
+type certificatePayload struct {
+	payload []byte
+	have    int
+	blocks  []Message // checked once the certificate is known
+}
+
+// Verifier checks received messages against the RFC-5848 Signature Blocks
+// sent with them by a Signer, whose signature scheme is private to this
+// package (see Signer). Each received message, including the blocks,
+// must be passed to Add exactly as it was received. Report is called, from
+// Add or Close, with the result for each message once it is known; messages
+// never covered by a Signature Block are reported as Unsigned by Close, or
+// by Add once more than MaxPending messages are waiting, oldest first, so
+// that a sender that never signs cannot exhaust memory. A Signature Block
+// received after its messages were reported reports them as Missing.
+//
+// Signatures are checked with the certificate sent in the Certificate
+// Blocks, which must be one of Certificates. If no Certificate Blocks have
+// been received, each of Certificates is tried.
+type Verifier struct {
+	Certificates []*x509.Certificate
+	Report       func(r VerificationResult)
+
+	// MaxPending is the most messages kept waiting for a Signature Block.
+	// If zero, 10000 is used, several hundred times the hashes of a
+	// Signature Block sent by a Signer by default.
+	MaxPending int
+
+	mu       sync.Mutex
+	pending  []*pendingMessage
+	payloads map[uint64]*certificatePayload
+	certs    map[uint64]*x509.Certificate // by RSID
+	lastGBC  map[uint64]uint64
+}
+
+// Add processes a single received message. It returns an error if the
+// message is a Signature or Certificate Block that cannot be verified, or if
+// Signature Blocks were lost. Other messages that cannot be parsed are
+// treated as unsigned.
+func (v *Verifier) Add(raw []byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	m := Message{}
+	if err := m.UnmarshalBinary(raw); err == nil {
+		for _, sd := range m.StructuredData {
+			switch sd.ID {
+			case SignatureSDID:
+				return v.addSignature(m, sd)
+			case CertificateSDID:
+				return v.addCertificate(m, sd)
+			}
+		}
+	}
+
+	v.pending = append(v.pending, &pendingMessage{
+		raw:    append([]byte(nil), raw...),
+		hashes: map[crypto.Hash]string{},
+	})
+	maxPending := v.MaxPending
+	if maxPending <= 0 {
+		maxPending = defaultMaxPending
+	}
+	for len(v.pending) > maxPending {
+		v.report(VerificationResult{Status: Unsigned, Message: v.pending[0].raw})
+		v.pending[0] = nil
+		v.pending = v.pending[1:]
+	}
+	return nil
+}
+
+// Handle implements Handler, re-serializing m and passing it to Add. This is
+// only correct when senders serialize messages exactly as MarshalBinary
+// does, as Signer does; otherwise use Add with the bytes received.
+func (v *Verifier) Handle(ctx context.Context, m Message, src Source) {
+	if b, err := m.MarshalBinary(); err == nil {
+		v.Add(b)
+	}
+}
+
+func sdParamMap(sd StructuredData) map[string]string {
+	params := map[string]string{}
+	for _, p := range sd.Parameters {
+		params[p.Name] = p.Value
+	}
+	return params
+}
+
+// checkBlock verifies the SIGN parameter of a block, returning its RSID and
+// hash algorithm. The caller must hold v.mu.
+func (v *Verifier) checkBlock(m Message, params map[string]string, certs []*x509.Certificate) (uint64, crypto.Hash, error) {
+	h, err := signatureHash(params["VER"])
+	if err != nil {
+		return 0, 0, err
+	}
+	rsid, err := strconv.ParseUint(params["RSID"], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("rfc5424: invalid RSID %q", params["RSID"])
+	}
+	sig, err := base64.StdEncoding.DecodeString(params["SIGN"])
+	if err != nil {
+		return 0, 0, ErrBadSignature
+	}
+	b, err := signedBytes(m)
+	if err != nil {
+		return 0, 0, err
+	}
+	if cert, ok := v.certs[rsid]; ok {
+		certs = []*x509.Certificate{cert}
+	}
+	for _, cert := range certs {
+		if verifySignature(cert, h, b, sig) {
+			return rsid, h, nil
+		}
+	}
+	return 0, 0, ErrBadSignature
+}
+
+// addCertificate collects a Certificate Block. Once the payload is complete
+// the certificate is checked against Certificates. The caller must hold
+// v.mu.
+func (v *Verifier) addCertificate(m Message, sd StructuredData) error {
+	params := sdParamMap(sd)
+	rsid, err := strconv.ParseUint(params["RSID"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("rfc5424: invalid RSID %q", params["RSID"])
+	}
+	total, err1 := strconv.Atoi(params["TPBL"])
+	index, err2 := strconv.Atoi(params["INDEX"])
+	length, err3 := strconv.Atoi(params["FLEN"])
+	frag := params["FRAG"]
+	if err1 != nil || err2 != nil || err3 != nil || index < 1 || length != len(frag) ||
+		index-1+length > total {
+		return errors.New("rfc5424: invalid Certificate Block")
+	}
+
+	if v.payloads == nil {
+		v.payloads = map[uint64]*certificatePayload{}
+	}
+	p, ok := v.payloads[rsid]
+	if !ok || len(p.payload) != total {
+		p = &certificatePayload{payload: make([]byte, total)}
+		v.payloads[rsid] = p
+	}
+	copy(p.payload[index-1:], frag)
+	p.have += length
+	p.blocks = append(p.blocks, m)
+	if p.have < total {
+		return nil
+	}
+	delete(v.payloads, rsid)
+
+	fields := strings.SplitN(string(p.payload), " ", 3)
+	if len(fields) != 3 || fields[1] != keyBlobPKIX {
+		return errors.New("rfc5424: unsupported Certificate Block payload")
+	}
+	der, err := base64.StdEncoding.DecodeString(fields[2])
+	if err != nil {
+		return err
+	}
+	for _, cert := range v.Certificates {
+		if bytes.Equal(cert.Raw, der) {
+			for _, block := range p.blocks {
+				for _, sd := range block.StructuredData {
+					if sd.ID != CertificateSDID {
+						continue
+					}
+					_, _, err := v.checkBlock(block, sdParamMap(sd), []*x509.Certificate{cert})
+					if err != nil {
+						return err
+					}
+				}
+			}
+			if v.certs == nil {
+				v.certs = map[uint64]*x509.Certificate{}
+			}
+			v.certs[rsid] = cert
+			return nil
+		}
+	}
+	return errors.New("rfc5424: Certificate Block holds an untrusted certificate")
+}
+
+// addSignature matches the hashes of a Signature Block with the pending
+// messages. The caller must hold v.mu.
+func (v *Verifier) addSignature(m Message, sd StructuredData) error {
+	params := sdParamMap(sd)
+	rsid, h, err := v.checkBlock(m, params, v.Certificates)
+	if err != nil {
+		return err
+	}
+	gbc, err1 := strconv.ParseUint(params["GBC"], 10, 64)
+	fmn, err2 := strconv.ParseUint(params["FMN"], 10, 64)
+	cnt, err3 := strconv.Atoi(params["CNT"])
+	hashes := strings.Fields(params["HB"])
+	if err1 != nil || err2 != nil || err3 != nil || cnt != len(hashes) {
+		return errors.New("rfc5424: invalid Signature Block")
+	}
+
+	for i, hash := range hashes {
+		result := VerificationResult{Status: Missing, RSID: rsid, GBC: gbc, Number: fmn + uint64(i)}
+		for j, p := range v.pending {
+			if p.hash(h) == hash {
+				result.Status = Verified
+				result.Message = p.raw
+				v.pending = append(v.pending[:j], v.pending[j+1:]...)
+				break
+			}
+		}
+		v.report(result)
+	}
+
+	if v.lastGBC == nil {
+		v.lastGBC = map[uint64]uint64{}
+	}
+	last, seen := v.lastGBC[rsid]
+	v.lastGBC[rsid] = gbc
+	if seen && gbc > last+1 {
+		return fmt.Errorf("rfc5424: Signature Blocks %d to %d of session %d are missing", last+1, gbc-1, rsid)
+	}
+	return nil
+}
+
+func (v *Verifier) report(r VerificationResult) {
+	if v.Report != nil {
+		v.Report(r)
+	}
+}
+
+// Close reports the messages that have not been covered by a Signature
+// Block as Unsigned.
+func (v *Verifier) Close() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, p := range v.pending {
+		v.report(VerificationResult{Status: Unsigned, Message: p.raw})
+	}
+	v.pending = nil
+	return nil
+}
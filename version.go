@@ -0,0 +1,63 @@
+package rfc5424
+
+import (
+	"bytes"
+	"sync"
+)
+
+// VersionCodec reads and writes the fields of a message that follow its
+// VERSION, so that future versions of the protocol, or private variants,
+// can be supported without changing how the PRI and VERSION are handled.
+// The codec for VERSION 1 is registered by this package.
+type VersionCodec interface {
+	// Marshal writes the fields of m that follow the VERSION, starting
+	// with the SP that separates them from it.
+	Marshal(b *bytes.Buffer, m Message) error
+
+	// Unmarshal reads the fields that follow the VERSION from r into m.
+	// m.Priority and m.Version have already been set.
+	Unmarshal(m *Message, r *bytes.Buffer) error
+}
+
+// rfc5424Codec is the VersionCodec of VERSION 1, as defined by RFC-5424
+type rfc5424Codec struct{}
+
+func (rfc5424Codec) Marshal(b *bytes.Buffer, m Message) error {
+	return marshalFields(b, m)
+}
+
+func (rfc5424Codec) Unmarshal(m *Message, r *bytes.Buffer) error {
+	return unmarshalFields(m, r)
+}
+
+var (
+	versionsMu sync.RWMutex
+	versions   = map[int]VersionCodec{1: rfc5424Codec{}}
+)
+
+// RegisterVersion makes codec handle messages with the given VERSION, which
+// must be from 1 to 999. Registering a codec for VERSION 1 replaces the
+// RFC-5424 one.
+func RegisterVersion(version int, codec VersionCodec) {
+	if version < 1 || version > 999 {
+		panic("rfc5424: VERSION must be from 1 to 999")
+	}
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	versions[version] = codec
+}
+
+func lookupVersion(version int) (VersionCodec, bool) {
+	versionsMu.RLock()
+	defer versionsMu.RUnlock()
+	codec, ok := versions[version]
+	return codec, ok
+}
+
+// version returns the VERSION of m
+func (m Message) version() int {
+	if m.Version == 0 {
+		return 1
+	}
+	return m.Version
+}
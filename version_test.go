@@ -0,0 +1,59 @@
+package rfc5424
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&VersionTest{})
+
+type VersionTest struct {
+}
+
+// rawCodec is a made up VERSION whose only field is the MSG
+type rawCodec struct{}
+
+func (rawCodec) Marshal(b *bytes.Buffer, m Message) error {
+	b.WriteByte(' ')
+	b.Write(m.Message)
+	return nil
+}
+
+func (rawCodec) Unmarshal(m *Message, r *bytes.Buffer) error {
+	if err := readSpace(r); err != nil {
+		return err
+	}
+	m.Message, _ = ioutil.ReadAll(r)
+	return nil
+}
+
+func (s *VersionTest) TestRegisterVersion(c *C) {
+	m := Message{}
+	c.Assert(m.UnmarshalBinary([]byte("<13>42 hello")), NotNil)
+	_, err := Message{Version: 42}.MarshalBinary()
+	c.Assert(err, NotNil)
+
+	RegisterVersion(42, rawCodec{})
+	defer func() {
+		versionsMu.Lock()
+		delete(versions, 42)
+		versionsMu.Unlock()
+	}()
+
+	c.Assert(m.UnmarshalBinary([]byte("<13>42 hello")), IsNil)
+	c.Assert(m.Priority, Equals, 13)
+	c.Assert(m.Version, Equals, 42)
+	c.Assert(string(m.Message), Equals, "hello")
+	b, err := m.MarshalBinary()
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, "<13>42 hello")
+
+	// VERSION 1 is unaffected and is reported as zero
+	c.Assert(m.UnmarshalBinary([]byte("<13>1 - - - - - -")), IsNil)
+	c.Assert(m.Version, Equals, 0)
+	for _, bad := range []string{"<13>01 - - - - - -", "<13>1000 - - - - - -"} {
+		c.Assert(m.UnmarshalBinary([]byte(bad)), NotNil, Commentf("%s", bad))
+	}
+}
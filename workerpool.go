@@ -0,0 +1,130 @@
+package rfc5424
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// QueuePolicy is what a Server does with a message when all of its workers
+// are busy and its queue is full.
+type QueuePolicy int
+
+const (
+	// QueueBlock stops reading from the connection or socket until a worker
+	// is free. On stream connections this lets TCP flow control slow down
+	// the sender instead of buffering without bound; datagrams arriving in
+	// the meantime may be dropped by the operating system.
+	QueueBlock QueuePolicy = iota
+	// QueueDrop discards the message and carries on reading, counting it in
+	// ServerStats.Overflows. Senders are never slowed down.
+	QueueDrop
+)
+
+func (p QueuePolicy) String() string {
+	switch p {
+	case QueueBlock:
+		return "block"
+	case QueueDrop:
+		return "drop"
+	}
+	return fmt.Sprintf("QueuePolicy(%d)", int(p))
+}
+
+// job is a received message waiting for a worker
+type job struct {
+	ctx  context.Context
+	buf  []byte
+	src  Source
+	done func(handled bool)
+}
+
+// startWorkers starts the worker pool the first time it is needed
+func (srv *Server) startWorkers() {
+	srv.workersOnce.Do(func() {
+		ctx := srv.baseContext()
+		queueSize := srv.QueueSize
+		if queueSize < 0 {
+			queueSize = 0
+		}
+		srv.jobs = make(chan job, queueSize)
+		for i := 0; i < srv.Workers; i++ {
+			go srv.worker(ctx)
+		}
+	})
+}
+
+// worker parses and handles messages until the server is closed
+func (srv *Server) worker(ctx context.Context) {
+	for {
+		select {
+		case j := <-srv.jobs:
+			j.done(srv.handle(j.ctx, j.buf, j.src))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatch arranges for buf to be parsed and handled, and for done to be
+// called afterwards, with whether the Handler was called. Without a worker pool the message is handled before
+// dispatch returns. With one, the message is queued for a worker. When the
+// queue is full dispatch either blocks, which in turn stops the caller from
+// reading more messages, or drops the message, depending on QueuePolicy.
+func (srv *Server) dispatch(ctx context.Context, buf []byte, src Source, done func(handled bool)) {
+	atomic.AddInt64(&srv.pending, 1)
+	finished := done
+	done = func(handled bool) {
+		finished(handled)
+		atomic.AddInt64(&srv.pending, -1)
+	}
+
+	if srv.Workers <= 0 {
+		done(srv.handle(ctx, buf, src))
+		return
+	}
+	srv.startWorkers()
+	j := job{ctx: ctx, buf: buf, src: src, done: done}
+	if srv.QueuePolicy == QueueDrop {
+		select {
+		case srv.jobs <- j:
+		default:
+			srv.Stats.overflow()
+			done(false)
+		}
+		return
+	}
+	select {
+	case srv.jobs <- j:
+	case <-ctx.Done():
+		done(false)
+	}
+}
+
+// acquireConn waits for a free connection slot when MaxConnections is set. It
+// returns false if the server is closed while waiting.
+func (srv *Server) acquireConn(ctx context.Context) bool {
+	if srv.MaxConnections <= 0 {
+		return true
+	}
+	srv.mu.Lock()
+	if srv.connSlots == nil {
+		srv.connSlots = make(chan struct{}, srv.MaxConnections)
+	}
+	slots := srv.connSlots
+	srv.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// releaseConn frees a slot taken by acquireConn
+func (srv *Server) releaseConn() {
+	if srv.MaxConnections > 0 {
+		<-srv.connSlots
+	}
+}
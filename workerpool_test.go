@@ -0,0 +1,186 @@
+package rfc5424
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&WorkerPoolTest{})
+
+type WorkerPoolTest struct {
+}
+
+// gatedHandler blocks each call to Handle until a value is sent on release,
+// and records the largest number of concurrent calls.
+type gatedHandler struct {
+	release chan struct{}
+	handled chan Message
+	active  int32
+	peak    int32
+}
+
+func newGatedHandler() *gatedHandler {
+	return &gatedHandler{release: make(chan struct{}), handled: make(chan Message, 100)}
+}
+
+func (h *gatedHandler) Handle(ctx context.Context, m Message, src Source) {
+	n := atomic.AddInt32(&h.active, 1)
+	for {
+		peak := atomic.LoadInt32(&h.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&h.peak, peak, n) {
+			break
+		}
+	}
+	<-h.release
+	atomic.AddInt32(&h.active, -1)
+	h.handled <- m
+}
+
+func (s *WorkerPoolTest) serve(c *C, srv *Server) (net.Listener, chan error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	srv.ErrorLog = log.New(ioutil.Discard, "", 0)
+	done := make(chan error)
+	go func() { done <- srv.ServeTCP(l) }()
+	return l, done
+}
+
+// waitFor polls cond until it is true or a timeout expires
+func waitFor(c *C, cond func() bool) {
+	for i := 0; i < 500; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Fatal("timed out")
+}
+
+func (s *WorkerPoolTest) TestWorkersHandleConcurrently(c *C) {
+	h := newGatedHandler()
+	srv := &Server{Handler: h, Workers: 3}
+	l, done := s.serve(c, srv)
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	defer client.Close()
+	for i := 0; i < 5; i++ {
+		_, err = client.Write(octetCounted("<34>1 0000-12-31T00:00:00Z - - - - -"))
+		c.Assert(err, IsNil)
+	}
+
+	waitFor(c, func() bool { return atomic.LoadInt32(&h.active) == 3 })
+	for i := 0; i < 5; i++ {
+		h.release <- struct{}{}
+		<-h.handled
+	}
+	c.Assert(atomic.LoadInt32(&h.peak), Equals, int32(3))
+
+	c.Assert(srv.Close(), IsNil)
+	c.Assert(<-done, Equals, ErrServerClosed)
+}
+
+func (s *WorkerPoolTest) TestMaxInFlightPerConn(c *C) {
+	h := newGatedHandler()
+	srv := &Server{Handler: h, Workers: 4, MaxInFlightPerConn: 1}
+	l, done := s.serve(c, srv)
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	defer client.Close()
+	for i := 0; i < 3; i++ {
+		_, err = client.Write(octetCounted("<34>1 0000-12-31T00:00:00Z - - - - -"))
+		c.Assert(err, IsNil)
+	}
+	for i := 0; i < 3; i++ {
+		waitFor(c, func() bool { return atomic.LoadInt32(&h.active) == 1 })
+		h.release <- struct{}{}
+		<-h.handled
+	}
+	c.Assert(atomic.LoadInt32(&h.peak), Equals, int32(1))
+
+	c.Assert(srv.Close(), IsNil)
+	c.Assert(<-done, Equals, ErrServerClosed)
+}
+
+func (s *WorkerPoolTest) TestMaxConnections(c *C) {
+	h := make(chanHandler, 10)
+	srv := &Server{Handler: h, MaxConnections: 1}
+	l, done := s.serve(c, srv)
+
+	first, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	_, err = first.Write(octetCounted("<34>1 0000-12-31T00:00:00Z - - - - - first"))
+	c.Assert(err, IsNil)
+	c.Assert(string(receive(c, h).Message.Message), Equals, "first")
+
+	// The second connection is not served until the first one closes
+	second, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	defer second.Close()
+	_, err = second.Write(octetCounted("<34>1 0000-12-31T00:00:00Z - - - - - second"))
+	c.Assert(err, IsNil)
+	select {
+	case <-h:
+		c.Fatal("second connection was served while at the limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+	first.Close()
+	c.Assert(string(receive(c, h).Message.Message), Equals, "second")
+
+	c.Assert(srv.Close(), IsNil)
+	c.Assert(<-done, Equals, ErrServerClosed)
+}
+
+func (s *WorkerPoolTest) TestQueuePolicy(c *C) {
+	h := newGatedHandler()
+	stats := &ServerStats{}
+	srv := &Server{Handler: h, Workers: 1, QueueSize: 1, QueuePolicy: QueueDrop, Stats: stats}
+	defer srv.Close()
+	ctx := srv.baseContext()
+	msg := []byte("<34>1 0000-12-31T00:00:00Z - - - - -")
+
+	// The first message occupies the only worker, the second waits in the
+	// queue and the rest are dropped without blocking.
+	srv.dispatch(ctx, msg, Source{}, func(bool) {})
+	waitFor(c, func() bool { return atomic.LoadInt32(&h.active) == 1 })
+	for i := 0; i < 3; i++ {
+		srv.dispatch(ctx, msg, Source{}, func(bool) {})
+	}
+	c.Assert(stats.Snapshot().Overflows, Equals, uint64(2))
+
+	for i := 0; i < 2; i++ {
+		h.release <- struct{}{}
+		<-h.handled
+	}
+	waitFor(c, func() bool { return atomic.LoadInt64(&srv.pending) == 0 })
+	c.Assert(stats.Snapshot().Received, Equals, uint64(2))
+
+	// By default dispatch blocks instead
+	srv.QueuePolicy = QueueBlock
+	srv.dispatch(ctx, msg, Source{}, func(bool) {})
+	waitFor(c, func() bool { return atomic.LoadInt32(&h.active) == 1 })
+	srv.dispatch(ctx, msg, Source{}, func(bool) {})
+	blocked := make(chan struct{})
+	go func() {
+		srv.dispatch(ctx, msg, Source{}, func(bool) {})
+		close(blocked)
+	}()
+	select {
+	case <-blocked:
+		c.Fatal("dispatch did not block with a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+	h.release <- struct{}{}
+	<-blocked
+	for i := 0; i < 2; i++ {
+		h.release <- struct{}{}
+	}
+	c.Assert(stats.Snapshot().Overflows, Equals, uint64(2))
+}
@@ -18,13 +18,27 @@ type MultiMessageWriter struct {
 
 // WriteMessage writes the message `m` to each of the fanout MessageWriters. If
 // any of the writers fail, the others are still tried. Returns a non-nil error
-// if any of the writers fail.
+// if any of the writers fail. The message is only marshaled once for the
+// writers of this package that write it as marshaled, such as StreamWriter.
 func (mmw MultiMessageWriter) WriteMessage(m Message) error {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		// each writer reports the error in its own way
+		b = nil
+	}
+	return mmw.writeMarshaled(m, b)
+}
+
+func (mmw MultiMessageWriter) writeMarshaled(m Message, b []byte) error {
 	errCh := make(chan error, len(mmw.Writers))
 
 	for _, w := range mmw.Writers {
 		go func(w MessageWriter) {
-			errCh <- w.WriteMessage(m)
+			if b == nil {
+				errCh <- w.WriteMessage(m)
+				return
+			}
+			errCh <- writeMarshaled(w, m, b)
 		}(w)
 	}
 